@@ -0,0 +1,287 @@
+// Package LogIndex narrows eth_getLogs queries down to the blocks that may hold
+// matching logs, using the layout of geth's bloombits.
+//
+// Blocks are grouped into sections. For each section the index keeps one bit
+// vector per bloom bit, with a bit per block set when that block's logs bloom
+// has the bloom bit set. An address or topic sets three bloom bits, so the
+// blocks that may contain it are the AND of three vectors; alternatives are
+// ORed and query positions ANDed. Blooms give false positives but never false
+// negatives, so callers still check each log against the query.
+package LogIndex
+
+import (
+	"fmt"
+	"math/bits"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+const (
+	// BloomBits is the number of bits in a logs bloom
+	BloomBits = 2048
+	// BloomLength is the size of a logs bloom in bytes
+	BloomLength = BloomBits / 8
+	// DefaultSectionSize is the number of blocks per section, as in geth
+	DefaultSectionSize = 4096
+)
+
+// Index is an in-memory bloom-bits index over a contiguous run of blocks. It
+// is safe for concurrent use.
+//
+// A section's vectors are allocated only once one of its blocks sets that
+// bloom bit, so chains with few logs stay small. A section of fully populated
+// vectors takes sectionSize*256 bytes.
+type Index struct {
+	mu       sync.RWMutex
+	size     uint64
+	sections map[uint64]*section
+	first    uint64 // first indexed block
+	next     uint64 // block after the last indexed one
+	empty    bool
+}
+
+// section holds the bit vectors of one run of sectionSize blocks
+type section struct {
+	vectors [BloomBits][]uint64
+}
+
+// New returns an empty index with sections of sectionSize blocks; zero uses
+// DefaultSectionSize.
+func New(sectionSize uint64) *Index {
+	if sectionSize == 0 {
+		sectionSize = DefaultSectionSize
+	}
+	// Vectors are whole words
+	sectionSize = (sectionSize + 63) &^ 63
+	return &Index{size: sectionSize, sections: map[uint64]*section{}, empty: true}
+}
+
+// Range returns the first and latest indexed block numbers, and false if the
+// index is empty.
+func (ix *Index) Range() (first, head uint64, ok bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if ix.empty {
+		return 0, 0, false
+	}
+	return ix.first, ix.next - 1, true
+}
+
+// Add indexes a block's logs bloom. Blocks must be added in order without
+// gaps; the first one added may have any number. An empty bloom is a block
+// without logs.
+func (ix *Index) Add(number uint64, bloom []byte) error {
+	if len(bloom) != 0 && len(bloom) != BloomLength {
+		return fmt.Errorf("logindex: bloom of block %d is %d bytes, want %d", number, len(bloom), BloomLength)
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !ix.empty && number != ix.next {
+		return fmt.Errorf("logindex: block %d does not follow %d", number, ix.next-1)
+	}
+	if ix.empty {
+		ix.first, ix.empty = number, false
+	}
+	ix.next = number + 1
+
+	sec, pos := number/ix.size, number%ix.size
+	s := ix.sections[sec]
+	if s == nil {
+		s = new(section)
+		ix.sections[sec] = s
+	}
+	for i, b := range bloom {
+		for ; b != 0; b &= b - 1 {
+			// Bloom bytes run from the highest bits down
+			bit := (BloomLength-1-i)*8 + bits.TrailingZeros8(b)
+			if s.vectors[bit] == nil {
+				s.vectors[bit] = make([]uint64, ix.size/64)
+			}
+			s.vectors[bit][pos/64] |= 1 << (pos % 64)
+		}
+	}
+	return nil
+}
+
+// AddLogs indexes a block from its logs, for sources whose headers carry no
+// bloom.
+func (ix *Index) AddLogs(number uint64, logs []*Types.Log) error {
+	return ix.Add(number, Bloom(logs))
+}
+
+// Truncate drops every block above head, for reorgs. Truncating below the
+// first indexed block empties the index.
+func (ix *Index) Truncate(head uint64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.empty || head+1 >= ix.next {
+		return
+	}
+	if head < ix.first {
+		ix.resetLocked()
+		return
+	}
+	ix.next = head + 1
+	for n, s := range ix.sections {
+		start := n * ix.size
+		switch {
+		case start > head:
+			delete(ix.sections, n)
+		case start+ix.size > head+1:
+			s.clearFrom(head + 1 - start)
+		}
+	}
+}
+
+// Reset empties the index.
+func (ix *Index) Reset() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.resetLocked()
+}
+
+func (ix *Index) resetLocked() {
+	ix.sections = map[uint64]*section{}
+	ix.first, ix.next, ix.empty = 0, 0, true
+}
+
+// clearFrom clears every block bit at or after pos
+func (s *section) clearFrom(pos uint64) {
+	for _, v := range s.vectors {
+		if v == nil {
+			continue
+		}
+		v[pos/64] &= 1<<(pos%64) - 1
+		clear(v[pos/64+1:])
+	}
+}
+
+// Match returns, in order, the blocks in from..to whose logs may satisfy the
+// query's addresses and topics. Blocks outside the indexed range are always
+// returned, so to should already be capped at the chain head. A query with
+// no criteria returns every block.
+func (ix *Index) Match(q *Types.FilterQuery, from, to uint64) []uint64 {
+	if from > to {
+		return nil
+	}
+	groups := queryGroups(q)
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if ix.empty || len(groups) == 0 {
+		return span(nil, from, to)
+	}
+	var out []uint64
+	if from < ix.first {
+		out = span(out, from, min(to, ix.first-1))
+	}
+	lo, hi := max(from, ix.first), min(to, ix.next-1)
+	for sec := lo / ix.size; lo <= hi && sec <= hi/ix.size; sec++ {
+		start := sec * ix.size
+		s := ix.sections[sec]
+		if s == nil {
+			continue
+		}
+		matches := s.match(groups, ix.size)
+		for w, word := range matches {
+			for ; word != 0; word &= word - 1 {
+				n := start + uint64(w)*64 + uint64(bits.TrailingZeros64(word))
+				if n >= lo && n <= hi {
+					out = append(out, n)
+				}
+			}
+		}
+	}
+	if to >= ix.next {
+		out = span(out, max(from, ix.next), to)
+	}
+	return out
+}
+
+// match ANDs the groups, each being the OR of its keys' bloom bits
+func (s *section) match(groups [][][3]uint, size uint64) []uint64 {
+	var result []uint64
+	for _, group := range groups {
+		either := make([]uint64, size/64)
+		for _, key := range group {
+			a, b, c := s.vectors[key[0]], s.vectors[key[1]], s.vectors[key[2]]
+			if a == nil || b == nil || c == nil {
+				continue
+			}
+			for w := range either {
+				either[w] |= a[w] & b[w] & c[w]
+			}
+		}
+		if result == nil {
+			result = either
+			continue
+		}
+		for w := range result {
+			result[w] &= either[w]
+		}
+	}
+	return result
+}
+
+// span appends from..to to out
+func span(out []uint64, from, to uint64) []uint64 {
+	for n := from; n <= to; n++ {
+		out = append(out, n)
+		if n == to {
+			// to may be the largest uint64
+			break
+		}
+	}
+	return out
+}
+
+// queryGroups turns a query into groups of bloom bit triples: one group for
+// the addresses and one per non-wildcard topic position.
+func queryGroups(q *Types.FilterQuery) [][][3]uint {
+	if q == nil {
+		return nil
+	}
+	var groups [][][3]uint
+	if len(q.Addresses) > 0 {
+		group := make([][3]uint, 0, len(q.Addresses))
+		for _, a := range q.Addresses {
+			group = append(group, bloomBits(a))
+		}
+		groups = append(groups, group)
+	}
+	for _, t := range q.Topics {
+		if len(t) > 0 {
+			groups = append(groups, [][3]uint{bloomBits(t)})
+		}
+	}
+	return groups
+}
+
+// bloomBits returns the three bloom bits set by data, as in geth's bloom9
+func bloomBits(data []byte) [3]uint {
+	h := crypto.Keccak256(data)
+	var out [3]uint
+	for i := range out {
+		out[i] = (uint(h[2*i])<<8 | uint(h[2*i+1])) & (BloomBits - 1)
+	}
+	return out
+}
+
+// Bloom computes the logs bloom of a block from its logs.
+func Bloom(logs []*Types.Log) []byte {
+	bloom := make([]byte, BloomLength)
+	set := func(data []byte) {
+		for _, bit := range bloomBits(data) {
+			bloom[BloomLength-1-bit/8] |= 1 << (bit % 8)
+		}
+	}
+	for _, l := range logs {
+		set(l.Address)
+		for _, t := range l.Topics {
+			set(t)
+		}
+	}
+	return bloom
+}
@@ -0,0 +1,290 @@
+package LogIndex
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+func addr(i int) []byte  { return common.BigToAddress(big.NewInt(int64(i) + 1)).Bytes() }
+func topic(i int) []byte { return common.BigToHash(big.NewInt(int64(i) + 0x1000)).Bytes() }
+
+func gethBloom(logs []*Types.Log) types.Bloom {
+	var b types.Bloom
+	for _, l := range logs {
+		b.Add(l.Address)
+		for _, t := range l.Topics {
+			b.Add(t)
+		}
+	}
+	return b
+}
+
+func TestBloomMatchesGeth(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		data := make([]byte, 1+rng.Intn(64))
+		rng.Read(data)
+
+		// The three bits land where geth's bloom sets them
+		var want types.Bloom
+		want.Add(data)
+		var got [BloomLength]byte
+		for _, bit := range bloomBits(data) {
+			got[BloomLength-1-bit/8] |= 1 << (bit % 8)
+		}
+		if !bytes.Equal(got[:], want.Bytes()) {
+			t.Fatalf("bloomBits(%x) = %x, geth sets %x", data, got, want.Bytes())
+		}
+	}
+
+	logs := []*Types.Log{
+		{Address: addr(1), Topics: [][]byte{topic(1), topic(2)}},
+		{Address: addr(2)},
+		{Address: addr(3), Topics: [][]byte{topic(3)}},
+	}
+	if got, want := Bloom(logs), gethBloom(logs); !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("Bloom = %x, geth %x", got, want.Bytes())
+	}
+	if got := Bloom(nil); !bytes.Equal(got, make([]byte, BloomLength)) {
+		t.Errorf("Bloom(nil) = %x", got)
+	}
+}
+
+// blocksWithLogs indexes blocks from..to, giving each block in logged one log
+// from addr(0)
+func blocksWithLogs(t *testing.T, ix *Index, from, to uint64, logged ...uint64) {
+	t.Helper()
+	has := map[uint64]bool{}
+	for _, n := range logged {
+		has[n] = true
+	}
+	for n := from; n <= to; n++ {
+		var logs []*Types.Log
+		if has[n] {
+			logs = []*Types.Log{{Address: addr(0)}}
+		}
+		if err := ix.AddLogs(n, logs); err != nil {
+			t.Fatalf("AddLogs(%d): %v", n, err)
+		}
+	}
+}
+
+func TestSectionBoundaries(t *testing.T) {
+	ix := New(64)
+	blocksWithLogs(t, ix, 0, 199, 0, 63, 64, 127, 128, 191, 192, 199)
+	q := &Types.FilterQuery{Addresses: [][]byte{addr(0)}}
+
+	tests := []struct {
+		from, to uint64
+		want     []uint64
+	}{
+		{0, 199, []uint64{0, 63, 64, 127, 128, 191, 192, 199}},
+		{63, 64, []uint64{63, 64}},
+		{64, 127, []uint64{64, 127}},
+		{65, 126, nil},
+		{100, 150, []uint64{127, 128}},
+		{192, 192, []uint64{192}},
+		{193, 198, nil},
+	}
+	for _, tt := range tests {
+		if got := ix.Match(q, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Match(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+	if got := ix.Match(&Types.FilterQuery{Addresses: [][]byte{addr(1)}}, 0, 199); got != nil {
+		t.Errorf("Match of an address never logged = %v", got)
+	}
+
+	// Section sizes round up to whole words
+	if ix := New(100); ix.size != 128 {
+		t.Errorf("New(100) sections of %d blocks", ix.size)
+	}
+	if err := New(0).Add(0, make([]byte, 10)); err == nil {
+		t.Error("Add accepted a short bloom")
+	}
+	if err := ix.Add(201, nil); err == nil {
+		t.Error("Add accepted a gap")
+	}
+}
+
+func TestTruncateInsideSection(t *testing.T) {
+	ix := New(64)
+	blocksWithLogs(t, ix, 10, 199, 20, 100, 129, 130, 140, 199)
+	q := &Types.FilterQuery{Addresses: [][]byte{addr(0)}}
+
+	ix.Truncate(129)
+	if first, head, ok := ix.Range(); !ok || first != 10 || head != 129 {
+		t.Fatalf("Range after Truncate(129) = %d, %d, %v", first, head, ok)
+	}
+	// Blocks past the head are unindexed, so they are returned as candidates
+	if got, want := ix.Match(q, 10, 132), []uint64{20, 100, 129, 130, 131, 132}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match after truncate = %v, want %v", got, want)
+	}
+
+	// Re-adding the dropped heights must not resurrect their old bits
+	blocksWithLogs(t, ix, 130, 199, 150)
+	if got, want := ix.Match(q, 10, 199), []uint64{20, 100, 129, 150}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match after re-adding = %v, want %v", got, want)
+	}
+
+	// Truncating at a section's last block keeps that section whole
+	ix.Truncate(127)
+	if got, want := ix.Match(q, 10, 127), []uint64{20, 100}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match after Truncate(127) = %v, want %v", got, want)
+	}
+
+	ix.Truncate(200) // above the head: nothing to do
+	if _, head, _ := ix.Range(); head != 127 {
+		t.Errorf("Truncate above the head moved it to %d", head)
+	}
+	ix.Truncate(9)
+	if _, _, ok := ix.Range(); ok {
+		t.Error("Truncate below the first block left the index non-empty")
+	}
+	if err := ix.Add(500, nil); err != nil {
+		t.Errorf("Add after emptying: %v", err)
+	}
+}
+
+func TestMatchOutsideIndexedRange(t *testing.T) {
+	ix := New(64)
+	q := &Types.FilterQuery{Addresses: [][]byte{addr(0)}}
+	if got, want := ix.Match(q, 5, 8), []uint64{5, 6, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match on an empty index = %v, want %v", got, want)
+	}
+
+	blocksWithLogs(t, ix, 100, 149, 120)
+	tests := []struct {
+		from, to uint64
+		want     []uint64
+	}{
+		{95, 105, []uint64{95, 96, 97, 98, 99}},
+		{145, 152, []uint64{150, 151, 152}},
+		{98, 151, []uint64{98, 99, 120, 150, 151}},
+		{10, 5, nil},
+		{200, 201, []uint64{200, 201}},
+	}
+	for _, tt := range tests {
+		if got := ix.Match(q, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Match(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+	// A query without criteria matches every block
+	if got := ix.Match(&Types.FilterQuery{}, 118, 121); !reflect.DeepEqual(got, []uint64{118, 119, 120, 121}) {
+		t.Errorf("Match without criteria = %v", got)
+	}
+	// The span stops at the largest block number instead of wrapping
+	if got := ix.Match(q, math.MaxUint64-1, math.MaxUint64); len(got) != 2 {
+		t.Errorf("Match at the top of the range = %v", got)
+	}
+}
+
+// TestMatchAgainstGeth indexes random logs and checks Match against geth's
+// bloom lookups block by block, and that no block with a matching log is missed.
+func TestMatchAgainstGeth(t *testing.T) {
+	const blocks = 500
+	rng := rand.New(rand.NewSource(2))
+	ix := New(128)
+	blooms := make([]types.Bloom, blocks)
+	logs := make([][]*Types.Log, blocks)
+	for n := range blocks {
+		for range rng.Intn(4) {
+			l := &Types.Log{Address: addr(rng.Intn(20))}
+			for range rng.Intn(4) {
+				l.Topics = append(l.Topics, topic(rng.Intn(30)))
+			}
+			logs[n] = append(logs[n], l)
+		}
+		blooms[n] = gethBloom(logs[n])
+		if err := ix.Add(uint64(n), blooms[n].Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 200; i++ {
+		q := &Types.FilterQuery{}
+		for range rng.Intn(3) {
+			q.Addresses = append(q.Addresses, addr(rng.Intn(20)))
+		}
+		for range rng.Intn(3) {
+			if rng.Intn(3) == 0 {
+				q.Topics = append(q.Topics, nil) // wildcard position
+			} else {
+				q.Topics = append(q.Topics, topic(rng.Intn(30)))
+			}
+		}
+		from := uint64(rng.Intn(blocks))
+		to := from + uint64(rng.Intn(blocks-int(from)))
+
+		var want []uint64
+		for n := from; n <= to; n++ {
+			if bloomAccepts(blooms[n], q) {
+				want = append(want, n)
+			}
+		}
+		got := ix.Match(q, from, to)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("query %d over %d..%d: Match = %v, geth blooms accept %v", i, from, to, got, want)
+		}
+		matched := map[uint64]bool{}
+		for _, n := range got {
+			matched[n] = true
+		}
+		for n := from; n <= to; n++ {
+			for _, l := range logs[n] {
+				if logMatches(l, q) && !matched[n] {
+					t.Fatalf("query %d: block %d holds a matching log but was not returned", i, n)
+				}
+			}
+		}
+	}
+}
+
+// bloomAccepts applies a query to a bloom the way geth's filters do
+func bloomAccepts(b types.Bloom, q *Types.FilterQuery) bool {
+	if len(q.Addresses) > 0 {
+		found := false
+		for _, a := range q.Addresses {
+			found = found || types.BloomLookup(b, common.BytesToAddress(a))
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, topic := range q.Topics {
+		if len(topic) > 0 && !types.BloomLookup(b, common.BytesToHash(topic)) {
+			return false
+		}
+	}
+	return true
+}
+
+// logMatches reports whether a log satisfies the query exactly
+func logMatches(l *Types.Log, q *Types.FilterQuery) bool {
+	if len(q.Addresses) > 0 {
+		found := false
+		for _, a := range q.Addresses {
+			found = found || bytes.Equal(a, l.Address)
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, topic := range q.Topics {
+		if len(topic) == 0 {
+			continue
+		}
+		if i >= len(l.Topics) || !bytes.Equal(topic, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
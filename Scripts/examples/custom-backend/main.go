@@ -5,13 +5,16 @@ import (
 	"log"
 	"math/big"
 
-	"github.com/jupitermetalabs/geth-facade/backend"
-	jmdtgethfacade "github.com/jupitermetalabs/geth-facade/pkg/jmdtgethfacade"
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
 )
 
-// CustomBackend implements the backend.Backend interface
+// CustomBackend serves a handful of Types.Backend methods and leaves the rest
+// to the embedded Types.BaseBackend, which answers them with
+// Types.ErrNotSupported (JSON-RPC -32601).
 // This is where you would integrate with your actual blockchain node
 type CustomBackend struct {
+	Types.BaseBackend
 	chainID *big.Int
 }
 
@@ -19,7 +22,6 @@ func NewCustomBackend(chainID *big.Int) *CustomBackend {
 	return &CustomBackend{chainID: chainID}
 }
 
-// Implement all required backend.Backend methods
 func (c *CustomBackend) ChainID(ctx context.Context) (*big.Int, error) {
 	return c.chainID, nil
 }
@@ -34,92 +36,31 @@ func (c *CustomBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
 	return big.NewInt(18000000), nil
 }
 
-func (c *CustomBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*backend.Block, error) {
+func (c *CustomBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
 	// In a real implementation, you would fetch the block from your node
-	return &backend.Block{
-		Number:     num,
-		Hash:       "0x1234567890abcdef1234567890abcdef12345678",
-		ParentHash: "0xabcdef1234567890abcdef1234567890abcdef12",
-		Timestamp:  1640995200,
+	if num == nil {
+		num = big.NewInt(18000000)
+	}
+	return &Types.Block{
+		Header: &Types.BlockHeader{
+			Number:    num.Uint64(),
+			GasLimit:  30000000,
+			Timestamp: 1640995200,
+		},
 	}, nil
 }
 
-func (c *CustomBackend) Balance(ctx context.Context, addr string, block *big.Int) (*big.Int, error) {
+func (c *CustomBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
 	// In a real implementation, you would query the balance from your node
-	// For example: return c.ethClient.BalanceAt(ctx, common.HexToAddress(addr), block)
+	// For example: return c.ethClient.BalanceAt(ctx, common.BytesToAddress(addr), block)
 	return big.NewInt(5000000000000000000), nil // 5 ETH in wei
 }
 
-func (c *CustomBackend) Call(ctx context.Context, msg backend.CallMsg, block *big.Int) ([]byte, error) {
-	// In a real implementation, you would execute the call on your node
-	return []byte("0x1234567890abcdef"), nil
-}
-
-func (c *CustomBackend) EstimateGas(ctx context.Context, msg backend.CallMsg) (uint64, error) {
-	// In a real implementation, you would estimate gas on your node
-	return 21000, nil
-}
-
 func (c *CustomBackend) GasPrice(ctx context.Context) (*big.Int, error) {
 	// In a real implementation, you would get gas price from your node
 	return big.NewInt(20000000000), nil // 20 gwei
 }
 
-func (c *CustomBackend) SendRawTx(ctx context.Context, rawHex string) (string, error) {
-	// In a real implementation, you would broadcast the transaction to your node
-	return "0xdeadbeef1234567890abcdef", nil
-}
-
-func (c *CustomBackend) TxByHash(ctx context.Context, hash string) (*backend.Tx, error) {
-	// In a real implementation, you would fetch the transaction from your node
-	return &backend.Tx{
-		Hash:     hash,
-		From:     "0x1234567890123456789012345678901234567890",
-		To:       "0x0987654321098765432109876543210987654321",
-		Value:    big.NewInt(1000000000000000000),
-		Nonce:    1,
-		Gas:      big.NewInt(21000),
-		GasPrice: big.NewInt(20000000000),
-	}, nil
-}
-
-func (c *CustomBackend) ReceiptByHash(ctx context.Context, hash string) (map[string]any, error) {
-	// In a real implementation, you would fetch the receipt from your node
-	return map[string]any{
-		"transactionHash": hash,
-		"status":          "0x1",
-		"gasUsed":         "0x5208",
-		"blockNumber":     "0x112a880",
-		"blockHash":       "0x1234567890abcdef1234567890abcdef12345678",
-	}, nil
-}
-
-func (c *CustomBackend) GetLogs(ctx context.Context, q backend.FilterQuery) ([]backend.Log, error) {
-	// In a real implementation, you would fetch logs from your node
-	return []backend.Log{}, nil
-}
-
-func (c *CustomBackend) SubscribeNewHeads(ctx context.Context) (<-chan *backend.Block, func(), error) {
-	// In a real implementation, you would subscribe to new blocks from your node
-	ch := make(chan *backend.Block)
-	cancel := func() { close(ch) }
-	return ch, cancel, nil
-}
-
-func (c *CustomBackend) SubscribeLogs(ctx context.Context, q *backend.FilterQuery) (<-chan backend.Log, func(), error) {
-	// In a real implementation, you would subscribe to logs from your node
-	ch := make(chan backend.Log)
-	cancel := func() { close(ch) }
-	return ch, cancel, nil
-}
-
-func (c *CustomBackend) SubscribePendingTxs(ctx context.Context) (<-chan string, func(), error) {
-	// In a real implementation, you would subscribe to pending transactions from your node
-	ch := make(chan string)
-	cancel := func() { close(ch) }
-	return ch, cancel, nil
-}
-
 func main() {
 	// Create custom backend for Ethereum mainnet
 	chainID := big.NewInt(1) // Ethereum mainnet
@@ -129,17 +70,12 @@ func main() {
 	log.Println("HTTP endpoint: http://localhost:8545")
 	log.Println("WebSocket endpoint: ws://localhost:8546")
 
-	// Create custom backend
-	customBackend := NewCustomBackend(chainID)
-
 	// Create server with custom backend
-	config := jmdtgethfacade.Config{
-		Backend:  customBackend,
+	server := Services.NewServer(Services.Config{
+		Backend:  NewCustomBackend(chainID),
 		HTTPAddr: ":8545",
 		WSAddr:   ":8546",
-	}
-
-	server := jmdtgethfacade.NewServer(config)
+	})
 
 	// Start the server
 	if err := server.Start(); err != nil {
@@ -0,0 +1,220 @@
+package Services
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// ContractBackend exposes a facade backend as a go-ethereum
+// bind.ContractBackend and bind.DeployBackend, so abigen bindings, bind.Deploy
+// and bind.WaitMined run in-process against any Types.Backend.
+//
+// The backend has no pending state: pending code and nonces are read from the
+// latest block. Log subscriptions need a backend serving
+// Types.CapSubscriptions.
+type ContractBackend struct {
+	be Types.Backend
+}
+
+var (
+	_ bind.ContractBackend = (*ContractBackend)(nil)
+	_ bind.DeployBackend   = (*ContractBackend)(nil)
+)
+
+// NewContractBackend wraps be for go-ethereum contract bindings.
+func NewContractBackend(be Types.Backend) *ContractBackend {
+	return &ContractBackend{be: be}
+}
+
+// ContractCaller
+func (c *ContractBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return c.be.GetCode(ctx, contract.Bytes(), blockNumber)
+}
+func (c *ContractBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return c.be.Call(ctx, fromEthCallMsg(call), blockNumber)
+}
+
+// ContractTransactor
+func (c *ContractBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b, err := c.be.BlockByNumber(ctx, number, false)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || b.Header == nil {
+		return nil, ethereum.NotFound
+	}
+	return toGethHeader(b.Header), nil
+}
+func (c *ContractBackend) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return c.be.GetCode(ctx, account.Bytes(), nil)
+}
+func (c *ContractBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return c.be.GetTransactionCount(ctx, account.Bytes(), nil)
+}
+func (c *ContractBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return c.be.GasPrice(ctx)
+}
+
+// SuggestGasTipCap returns the gas price above the latest base fee, as the
+// Backend has no eth_maxPriorityFeePerGas
+func (c *ContractBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	price, err := c.be.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if head.BaseFee == nil {
+		return price, nil
+	}
+	tip := new(big.Int).Sub(price, head.BaseFee)
+	if tip.Sign() < 0 {
+		tip.SetUint64(0)
+	}
+	return tip, nil
+}
+func (c *ContractBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return c.be.EstimateGas(ctx, fromEthCallMsg(call))
+}
+func (c *ContractBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = c.be.SendRawTx(ctx, "0x"+hex.EncodeToString(raw))
+	return err
+}
+
+// ContractFilterer
+func (c *ContractBackend) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	logs, err := c.be.GetLogs(ctx, fromEthFilter(q))
+	if err != nil {
+		return nil, err
+	}
+	var out []types.Log
+	for _, l := range logs {
+		if gl := toGethLog(l); ethFilterMatches(q, &gl) {
+			out = append(out, gl)
+		}
+	}
+	return out, nil
+}
+func (c *ContractBackend) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	fq := fromEthFilter(q)
+	logs, stop, err := asSubscriptions(c.be).SubscribeLogs(ctx, &fq)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer stop()
+		for {
+			select {
+			case l, ok := <-logs:
+				if !ok {
+					return nil
+				}
+				gl := toGethLog(l)
+				if !ethFilterMatches(q, &gl) {
+					continue
+				}
+				select {
+				case ch <- gl:
+				case <-quit:
+					return nil
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// DeployBackend
+func (c *ContractBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	r, err := c.be.ReceiptByHash(ctx, txHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		// bind.WaitMined polls until the receipt stops being NotFound
+		return nil, ethereum.NotFound
+	}
+	return toGethReceipt(r), nil
+}
+
+// fromEthCallMsg converts a go-ethereum call message. A fee cap stands in for
+// the gas price when only dynamic fees are set.
+func fromEthCallMsg(msg ethereum.CallMsg) Types.CallMsg {
+	out := Types.CallMsg{Data: msg.Data, Value: msg.Value, GasPrice: msg.GasPrice}
+	if msg.From != (common.Address{}) {
+		out.From = msg.From.Hex()
+	}
+	if msg.To != nil {
+		out.To = msg.To.Hex()
+	}
+	if msg.Gas != 0 {
+		out.Gas = new(big.Int).SetUint64(msg.Gas)
+	}
+	if out.GasPrice == nil && msg.GasFeeCap != nil {
+		out.GasPrice = msg.GasFeeCap
+	}
+	return out
+}
+
+// fromEthFilter converts a go-ethereum filter query. Types.FilterQuery holds
+// one topic per position, so positions with alternatives become wildcards
+// and are checked by ethFilterMatches instead.
+func fromEthFilter(q ethereum.FilterQuery) Types.FilterQuery {
+	out := Types.FilterQuery{FromBlock: q.FromBlock, ToBlock: q.ToBlock}
+	if q.BlockHash != nil {
+		out.BlockHash = q.BlockHash.Bytes()
+	}
+	for _, a := range q.Addresses {
+		out.Addresses = append(out.Addresses, a.Bytes())
+	}
+	for _, alternatives := range q.Topics {
+		var t []byte
+		if len(alternatives) == 1 {
+			t = alternatives[0].Bytes()
+		}
+		out.Topics = append(out.Topics, t)
+	}
+	return out
+}
+
+// ethFilterMatches checks a log against the topic alternatives of q
+func ethFilterMatches(q ethereum.FilterQuery, l *types.Log) bool {
+	if len(q.Topics) > len(l.Topics) {
+		for _, alternatives := range q.Topics[len(l.Topics):] {
+			if len(alternatives) > 0 {
+				return false
+			}
+		}
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 || i >= len(l.Topics) {
+			continue
+		}
+		found := false
+		for _, t := range alternatives {
+			if t == l.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package Services_test
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// testdata/Storer.bin is hand-assembled: get() returns 42 and store(x) emits
+// Stored(x) without touching storage.
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen --v2 --abi testdata/Storer.abi --bin testdata/Storer.bin --pkg Services_test --type Storer --out storer_binding_test.go
+
+func TestContractBackendBinding(t *testing.T) {
+	be := Services.NewMemoryBackendWithConfig(Services.MemoryConfig{})
+	t.Cleanup(be.(interface{ Close() }).Close)
+	ctx := context.Background()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	if err := be.(Types.DevControl).SetBalance(ctx, from.Bytes(), big.NewInt(1e18)); err != nil {
+		t.Fatalf("SetBalance: %v", err)
+	}
+	chainID, err := be.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := Services.NewContractBackend(be)
+	auth := bind.NewKeyedTransactor(key, chainID)
+	auth.Context = ctx
+
+	addr, tx, err := bind.DeployContract(auth, common.FromHex(StorerMetaData.Bin), backend, nil)
+	if err != nil {
+		t.Fatalf("DeployContract: %v", err)
+	}
+	if got, err := bind.WaitDeployed(ctx, backend, tx.Hash()); err != nil || got != addr {
+		t.Fatalf("WaitDeployed = %s, %v; want %s", got, err, addr)
+	}
+
+	storer := NewStorer()
+	c := storer.Instance(backend, addr)
+	if n, err := bind.Call(c, &bind.CallOpts{Context: ctx}, storer.PackGet(), storer.UnpackGet); err != nil || n.Int64() != 42 {
+		t.Fatalf("get() = %v, %v", n, err)
+	}
+
+	var blocks []uint64
+	for x := int64(1); x <= 3; x++ {
+		tx, err := bind.Transact(c, auth, storer.PackStore(big.NewInt(x)))
+		if err != nil {
+			t.Fatalf("store(%d): %v", x, err)
+		}
+		r, err := bind.WaitMined(ctx, backend, tx.Hash())
+		if err != nil || r.Status != 1 || len(r.Logs) != 1 || r.TxHash != tx.Hash() {
+			t.Fatalf("store(%d) receipt: %+v, %v", x, r, err)
+		}
+		if r.Logs[0].Address != addr || r.Logs[0].BlockNumber != r.BlockNumber.Uint64() {
+			t.Errorf("store(%d) log: %+v", x, r.Logs[0])
+		}
+		blocks = append(blocks, r.BlockNumber.Uint64())
+	}
+
+	// Alternatives for the indexed argument go through the backend as a
+	// wildcard and are checked against each log
+	it, err := bind.FilterEvents(c, &bind.FilterOpts{Context: ctx}, storer.UnpackStoredEvent, []any{big.NewInt(1), big.NewInt(3)})
+	if err != nil {
+		t.Fatalf("FilterEvents: %v", err)
+	}
+	var got []int64
+	for it.Next() {
+		got = append(got, it.Value().X.Int64())
+	}
+	if err := it.Error(); err != nil || !slices.Equal(got, []int64{1, 3}) {
+		t.Errorf("Stored(1 or 3) = %v, %v", got, err)
+	}
+
+	stored := storer.abi.Events["Stored"].ID
+	logs, err := backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(blocks[1]),
+		Addresses: []common.Address{addr},
+		Topics:    [][]common.Hash{{stored}, {common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2))}},
+	})
+	if err != nil || len(logs) != 1 || logs[0].Topics[1] != common.BigToHash(big.NewInt(2)) || logs[0].BlockNumber != blocks[1] {
+		t.Errorf("FilterLogs from block %d = %+v, %v", blocks[1], logs, err)
+	}
+	logs, err = backend.FilterLogs(ctx, ethereum.FilterQuery{Topics: [][]common.Hash{{stored}, nil, {stored}}})
+	if err != nil || len(logs) != 0 {
+		t.Errorf("FilterLogs on a missing topic position = %+v, %v", logs, err)
+	}
+}
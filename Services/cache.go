@@ -0,0 +1,574 @@
+package Services
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// CacheConfig configures a CachingBackend. Zero values use the defaults noted.
+type CacheConfig struct {
+	// MaxEntries bounds the number of cached answers (default 10000)
+	MaxEntries int
+	// MaxBytes bounds the approximate encoded size of cached answers (default 64 MiB)
+	MaxBytes int64
+	// ConfirmationDepth is how far below the head a block must be before
+	// number-keyed lookups against it are cached (default 12)
+	ConfirmationDepth uint64
+	// BlockNumberTTL and GasPriceTTL bound how stale those answers may be
+	// (defaults 1s and 3s)
+	BlockNumberTTL time.Duration
+	GasPriceTTL    time.Duration
+	// Metrics receives hit/miss counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// reorgHistory is how many recent head hashes are kept to locate fork points.
+const reorgHistory = 128
+
+// CachingBackend is a finality-aware read cache in front of another backend.
+// Hash-keyed lookups and chain constants are cached until evicted, lookups by
+// block number only once the block is ConfirmationDepth deep, and the head and
+// gas price for a short TTL. A head subscription detects reorgs and drops every
+// answer at or above the fork point.
+// //conversions: Cached values are shared between callers and must not be mutated
+type CachingBackend struct {
+	Types.Backend
+	forwardOptional
+	cfg     CacheConfig
+	lru     *lruCache
+	metrics *Metrics
+	head    atomic.Uint64
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewCachingBackend wraps inner with a response cache and, when inner
+// supports subscriptions, starts following its heads. Call Close to stop.
+func NewCachingBackend(inner Types.Backend, cfg CacheConfig) *CachingBackend {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 64 << 20
+	}
+	if cfg.ConfirmationDepth == 0 {
+		cfg.ConfirmationDepth = 12
+	}
+	if cfg.BlockNumberTTL <= 0 {
+		cfg.BlockNumberTTL = time.Second
+	}
+	if cfg.GasPriceTTL <= 0 {
+		cfg.GasPriceTTL = 3 * time.Second
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CachingBackend{
+		Backend:         inner,
+		forwardOptional: forwardOptional{inner},
+		cfg:             cfg,
+		lru:             newLRUCache(cfg.MaxEntries, cfg.MaxBytes, cfg.Metrics),
+		metrics:         cfg.Metrics,
+		cancel:          cancel,
+		done:            make(chan struct{}),
+	}
+	go c.followHeads(ctx)
+	return c
+}
+
+// Close stops the head subscription. The cache keeps serving until dropped.
+func (c *CachingBackend) Close() {
+	c.cancel()
+	<-c.done
+}
+
+// Purge drops every cached answer.
+func (c *CachingBackend) Purge() {
+	c.lru.invalidate(func(*cacheEntry) bool { return true })
+}
+
+// cacheRule says how long an answer may be served and which block it depends on.
+type cacheRule struct {
+	ttl       time.Duration // zero caches until evicted
+	height    uint64        // block the answer depends on
+	hasHeight bool          // whether a reorg at or below height invalidates it
+}
+
+var ruleForever = cacheRule{}
+
+func atHeight(n uint64) cacheRule { return cacheRule{height: n, hasHeight: true} }
+
+// cachedCall serves key from the cache or calls fn and stores a non-nil answer.
+func cachedCall[T any](c *CachingBackend, method, key string, rule cacheRule, fn func() (T, error)) (T, error) {
+	if v, ok := c.lru.get(key); ok {
+		c.metrics.Inc(metricName("facade_cache_hits_total", "method", method))
+		return v.(T), nil
+	}
+	c.metrics.Inc(metricName("facade_cache_misses_total", "method", method))
+	gen := c.lru.generation()
+	v, err := fn()
+	if err != nil || isNilResult(v) {
+		return v, err
+	}
+	c.lru.add(key, v, rule, gen)
+	return v, nil
+}
+
+// isNilResult reports "not found" answers, which may change and are never cached.
+func isNilResult(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case *big.Int:
+		return x == nil
+	case *Types.Block:
+		return x == nil
+	case *Types.Transaction:
+		return x == nil
+	case *Types.Receipt:
+		return x == nil
+	}
+	return false
+}
+
+// confirmed reports whether num is at least ConfirmationDepth below the head.
+func (c *CachingBackend) confirmed(num *big.Int) bool {
+	head := c.head.Load()
+	if num == nil || num.Sign() < 0 || !num.IsUint64() || head == 0 {
+		return false
+	}
+	return num.Uint64()+c.cfg.ConfirmationDepth <= head
+}
+
+func (c *CachingBackend) observeHead(n uint64) {
+	for {
+		cur := c.head.Load()
+		if n <= cur || c.head.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+// cacheKey joins a method name and its arguments into a map key.
+func cacheKey(method string, args ...any) string {
+	var b strings.Builder
+	b.WriteString(method)
+	for _, a := range args {
+		b.WriteByte('|')
+		switch x := a.(type) {
+		case []byte:
+			b.WriteString(hex.EncodeToString(x))
+		case *big.Int:
+			if x != nil {
+				b.WriteString(x.Text(16))
+			}
+		default:
+			fmt.Fprint(&b, x)
+		}
+	}
+	return b.String()
+}
+
+// Basic blockchain info
+func (c *CachingBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return cachedCall(c, "ChainID", "ChainID", ruleForever, func() (*big.Int, error) { return c.Backend.ChainID(ctx) })
+}
+func (c *CachingBackend) ClientVersion(ctx context.Context) (string, error) {
+	return cachedCall(c, "ClientVersion", "ClientVersion", ruleForever, func() (string, error) { return c.Backend.ClientVersion(ctx) })
+}
+func (c *CachingBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return cachedCall(c, "BlockNumber", "BlockNumber", cacheRule{ttl: c.cfg.BlockNumberTTL}, func() (*big.Int, error) {
+		n, err := c.Backend.BlockNumber(ctx)
+		if err == nil && n != nil && n.IsUint64() {
+			c.observeHead(n.Uint64())
+		}
+		return n, err
+	})
+}
+func (c *CachingBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return cachedCall(c, "GasPrice", "GasPrice", cacheRule{ttl: c.cfg.GasPriceTTL}, func() (*big.Int, error) { return c.Backend.GasPrice(ctx) })
+}
+
+// Block operations
+func (c *CachingBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	if !c.confirmed(num) {
+		return c.Backend.BlockByNumber(ctx, num, fullTx)
+	}
+	return cachedCall(c, "BlockByNumber", cacheKey("BlockByNumber", num, fullTx), atHeight(num.Uint64()), func() (*Types.Block, error) {
+		return c.Backend.BlockByNumber(ctx, num, fullTx)
+	})
+}
+func (c *CachingBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return cachedCall(c, "BlockByHash", cacheKey("BlockByHash", hash, fullTx), ruleForever, func() (*Types.Block, error) {
+		return c.Backend.BlockByHash(ctx, hash, fullTx)
+	})
+}
+func (c *CachingBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	if !c.confirmed(blockNum) {
+		return c.Backend.BlockTransactionCountByNumber(ctx, blockNum)
+	}
+	return cachedCall(c, "BlockTransactionCountByNumber", cacheKey("BlockTransactionCountByNumber", blockNum), atHeight(blockNum.Uint64()), func() (uint64, error) {
+		return c.Backend.BlockTransactionCountByNumber(ctx, blockNum)
+	})
+}
+func (c *CachingBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return cachedCall(c, "BlockTransactionCountByHash", cacheKey("BlockTransactionCountByHash", blockHash), ruleForever, func() (uint64, error) {
+		return c.Backend.BlockTransactionCountByHash(ctx, blockHash)
+	})
+}
+
+// Account operations
+func (c *CachingBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	if !c.confirmed(block) {
+		return c.Backend.Balance(ctx, addr, block)
+	}
+	return cachedCall(c, "Balance", cacheKey("Balance", addr, block), atHeight(block.Uint64()), func() (*big.Int, error) {
+		return c.Backend.Balance(ctx, addr, block)
+	})
+}
+func (c *CachingBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	if !c.confirmed(block) {
+		return c.Backend.GetCode(ctx, addr, block)
+	}
+	return cachedCall(c, "GetCode", cacheKey("GetCode", addr, block), atHeight(block.Uint64()), func() ([]byte, error) {
+		return c.Backend.GetCode(ctx, addr, block)
+	})
+}
+func (c *CachingBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	if !c.confirmed(block) {
+		return c.Backend.GetStorageAt(ctx, addr, key, block)
+	}
+	return cachedCall(c, "GetStorageAt", cacheKey("GetStorageAt", addr, key, block), atHeight(block.Uint64()), func() ([]byte, error) {
+		return c.Backend.GetStorageAt(ctx, addr, key, block)
+	})
+}
+func (c *CachingBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	if !c.confirmed(block) {
+		return c.Backend.GetTransactionCount(ctx, addr, block)
+	}
+	return cachedCall(c, "GetTransactionCount", cacheKey("GetTransactionCount", addr, block), atHeight(block.Uint64()), func() (uint64, error) {
+		return c.Backend.GetTransactionCount(ctx, addr, block)
+	})
+}
+
+// Transaction operations
+func (c *CachingBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	if !c.confirmed(block) {
+		return c.Backend.Call(ctx, msg, block)
+	}
+	key := cacheKey("Call", msg.From, msg.To, msg.Data, msg.Value, msg.Gas, msg.GasPrice, block)
+	return cachedCall(c, "Call", key, atHeight(block.Uint64()), func() ([]byte, error) {
+		return c.Backend.Call(ctx, msg, block)
+	})
+}
+func (c *CachingBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	// A transaction's content is fixed by its hash, mined or not
+	return cachedCall(c, "TxByHash", cacheKey("TxByHash", hash), ruleForever, func() (*Types.Transaction, error) {
+		return c.Backend.TxByHash(ctx, hash)
+	})
+}
+func (c *CachingBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	if !c.confirmed(blockNum) {
+		return c.Backend.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	}
+	return cachedCall(c, "TxByBlockNumberAndIndex", cacheKey("TxByBlockNumberAndIndex", blockNum, index), atHeight(blockNum.Uint64()), func() (*Types.Transaction, error) {
+		return c.Backend.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (c *CachingBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return cachedCall(c, "TxByBlockHashAndIndex", cacheKey("TxByBlockHashAndIndex", blockHash, index), ruleForever, func() (*Types.Transaction, error) {
+		return c.Backend.TxByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+func (c *CachingBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	// Receipts carry their inclusion block, so a reorg past it invalidates them
+	key := cacheKey("ReceiptByHash", hash)
+	if v, ok := c.lru.get(key); ok {
+		c.metrics.Inc(metricName("facade_cache_hits_total", "method", "ReceiptByHash"))
+		return v.(*Types.Receipt), nil
+	}
+	c.metrics.Inc(metricName("facade_cache_misses_total", "method", "ReceiptByHash"))
+	gen := c.lru.generation()
+	r, err := c.Backend.ReceiptByHash(ctx, hash)
+	if err != nil || r == nil {
+		return r, err
+	}
+	c.lru.add(key, r, atHeight(r.BlockNumber), gen)
+	return r, nil
+}
+
+// Log operations
+func (c *CachingBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	var rule cacheRule
+	switch {
+	case len(q.BlockHash) > 0:
+		rule = ruleForever
+	case q.FromBlock != nil && c.confirmed(q.ToBlock):
+		// A reorg anywhere in the range changes the answer; the fork point is
+		// at or below ToBlock whenever it touches the range
+		rule = atHeight(q.ToBlock.Uint64())
+	default:
+		return c.Backend.GetLogs(ctx, q)
+	}
+	key := cacheKey("GetLogs", q.FromBlock, q.ToBlock, q.BlockHash, q.Addresses, q.Topics)
+	return cachedCall(c, "GetLogs", key, rule, func() ([]*Types.Log, error) {
+		return c.Backend.GetLogs(ctx, q)
+	})
+}
+
+// Uncle operations (for PoW chains)
+func (c *CachingBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	if !c.confirmed(blockNum) {
+		return asUncles(c.Backend).UncleCountByBlockNumber(ctx, blockNum)
+	}
+	return cachedCall(c, "UncleCountByBlockNumber", cacheKey("UncleCountByBlockNumber", blockNum), atHeight(blockNum.Uint64()), func() (uint64, error) {
+		return asUncles(c.Backend).UncleCountByBlockNumber(ctx, blockNum)
+	})
+}
+func (c *CachingBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return cachedCall(c, "UncleCountByBlockHash", cacheKey("UncleCountByBlockHash", blockHash), ruleForever, func() (uint64, error) {
+		return asUncles(c.Backend).UncleCountByBlockHash(ctx, blockHash)
+	})
+}
+func (c *CachingBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	if !c.confirmed(blockNum) {
+		return asUncles(c.Backend).UncleByBlockNumberAndIndex(ctx, blockNum, index)
+	}
+	return cachedCall(c, "UncleByBlockNumberAndIndex", cacheKey("UncleByBlockNumberAndIndex", blockNum, index), atHeight(blockNum.Uint64()), func() (*Types.Block, error) {
+		return asUncles(c.Backend).UncleByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (c *CachingBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return cachedCall(c, "UncleByBlockHashAndIndex", cacheKey("UncleByBlockHashAndIndex", blockHash, index), ruleForever, func() (*Types.Block, error) {
+		return asUncles(c.Backend).UncleByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+
+// followHeads tracks the head for confirmation depth and watches for reorgs,
+// resubscribing with backoff whenever the stream ends.
+func (c *CachingBackend) followHeads(ctx context.Context) {
+	defer close(c.done)
+	if !Types.Supports(c.Backend, Types.CapSubscriptions) {
+		log.Printf("⚠️ Cache: backend has no head subscription; the head comes from BlockNumber calls and reorgs are not detected")
+		return
+	}
+	hashes := map[uint64][]byte{}
+	delay := time.Second
+	for ctx.Err() == nil {
+		ch, stop, err := asSubscriptions(c.Backend).SubscribeNewHeads(ctx)
+		if err != nil {
+			log.Printf("⚠️ Cache head subscription failed: %v", err)
+		} else {
+			delay = time.Second
+			c.consumeHeads(ctx, ch, hashes)
+			stop()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, 30*time.Second)
+	}
+}
+
+func (c *CachingBackend) consumeHeads(ctx context.Context, ch <-chan *Types.Block, hashes map[uint64][]byte) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case b, ok := <-ch:
+			if !ok {
+				return
+			}
+			if b == nil || b.Header == nil {
+				continue
+			}
+			c.onHead(ctx, b.Header, hashes)
+		}
+	}
+}
+
+// onHead records h and, if it does not extend the known chain, finds the
+// fork point by walking parents and invalidates everything from there up.
+func (c *CachingBackend) onHead(ctx context.Context, h *Types.BlockHeader, hashes map[uint64][]byte) {
+	n := h.Number
+	known, seen := hashes[n]
+	parent, hasParent := hashes[n-1]
+	reorg := (seen && !bytes.Equal(known, h.Hash)) || (n > 0 && hasParent && !bytes.Equal(parent, h.ParentHash))
+	// An unseen head below the newest recorded one may be a stale repeat or
+	// sit on a new branch; either way it is not kept as history
+	trusted := reorg || seen
+	if !trusted {
+		trusted = true
+		for k := range hashes {
+			if k >= n {
+				trusted = false
+				break
+			}
+		}
+	}
+
+	if reorg {
+		fork := c.findFork(ctx, h, hashes)
+		c.metrics.Inc("facade_cache_reorgs_total")
+		dropped := c.lru.invalidate(func(e *cacheEntry) bool { return e.hasHeight && e.height >= fork })
+		for k := range hashes {
+			if k >= fork {
+				delete(hashes, k)
+			}
+		}
+		log.Printf("🔁 Cache reorg at block %d (new head %d), dropped %d entries", fork, n, dropped)
+		c.head.Store(n)
+	} else {
+		c.observeHead(n)
+	}
+	if trusted {
+		hashes[n] = h.Hash
+	}
+	if n >= reorgHistory {
+		delete(hashes, n-reorgHistory)
+	}
+}
+
+// findFork walks the new head's ancestors until one matches a recorded hash
+// and returns the first block number that changed.
+func (c *CachingBackend) findFork(ctx context.Context, h *Types.BlockHeader, hashes map[uint64][]byte) uint64 {
+	n, parent := h.Number, h.ParentHash
+	for i := 0; i < reorgHistory && n > 0; i++ {
+		known, ok := hashes[n-1]
+		if !ok {
+			break
+		}
+		if bytes.Equal(known, parent) {
+			return n
+		}
+		b, err := c.Backend.BlockByHash(ctx, parent, false)
+		if err != nil || b == nil || b.Header == nil {
+			break
+		}
+		n, parent = b.Header.Number, b.Header.ParentHash
+		hashes[n] = b.Header.Hash
+	}
+	// The common ancestor is older than the recorded history, and answers
+	// cached from before the cache followed heads may sit on either branch
+	return 0
+}
+
+// cacheEntry is one cached answer.
+type cacheEntry struct {
+	key       string
+	value     any
+	size      int64
+	expires   time.Time
+	height    uint64
+	hasHeight bool
+}
+
+// lruCache is a mutex-guarded LRU bounded by entry count and approximate bytes.
+type lruCache struct {
+	mu         sync.Mutex
+	ll         *list.List
+	items      map[string]*list.Element
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
+	gen        uint64
+	metrics    *Metrics
+}
+
+func newLRUCache(maxEntries int, maxBytes int64, metrics *Metrics) *lruCache {
+	return &lruCache{
+		ll:         list.New(),
+		items:      map[string]*list.Element{},
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		metrics:    metrics,
+	}
+}
+
+func (l *lruCache) get(key string) (any, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		l.removeLocked(el)
+		return nil, false
+	}
+	l.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// generation changes on every invalidation; answers fetched under an older
+// generation may predate a reorg and are not stored.
+func (l *lruCache) generation() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.gen
+}
+
+func (l *lruCache) add(key string, v any, rule cacheRule, gen uint64) {
+	e := &cacheEntry{
+		key:       key,
+		value:     v,
+		size:      int64(len(key) + len(canonicalEncoding(v))),
+		height:    rule.height,
+		hasHeight: rule.hasHeight,
+	}
+	if rule.ttl > 0 {
+		e.expires = time.Now().Add(rule.ttl)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if gen != l.gen || e.size > l.maxBytes {
+		return
+	}
+	if el, ok := l.items[key]; ok {
+		l.removeLocked(el)
+	}
+	l.items[key] = l.ll.PushFront(e)
+	l.bytes += e.size
+	for l.ll.Len() > l.maxEntries || l.bytes > l.maxBytes {
+		l.removeLocked(l.ll.Back())
+		l.metrics.Inc("facade_cache_evictions_total")
+	}
+}
+
+// invalidate drops every entry matching drop and returns how many were removed.
+func (l *lruCache) invalidate(drop func(*cacheEntry) bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gen++
+	n := 0
+	for el := l.ll.Front(); el != nil; {
+		next := el.Next()
+		if drop(el.Value.(*cacheEntry)) {
+			l.removeLocked(el)
+			n++
+		}
+		el = next
+	}
+	return n
+}
+
+func (l *lruCache) removeLocked(el *list.Element) {
+	e := l.ll.Remove(el).(*cacheEntry)
+	delete(l.items, e.key)
+	l.bytes -= e.size
+}
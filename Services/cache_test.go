@@ -0,0 +1,166 @@
+package Services_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// reorgChain is a scripted chain: every block has a hash made of its number
+// and the branch it was mined on, blocks in logBlocks carry one log while
+// still on branch zero, and heads past genesis go to one buffered head
+// subscription as they are mined or reorganised
+type reorgChain struct {
+	Types.Backend
+	mu        sync.Mutex
+	branches  []byte // branch of each block, by number
+	logBlocks map[uint64]bool
+	heads     chan *Types.Block
+}
+
+func newReorgChain(n int, logBlocks ...uint64) *reorgChain {
+	c := &reorgChain{branches: make([]byte, n+1), logBlocks: map[uint64]bool{}, heads: make(chan *Types.Block, 256)}
+	for _, b := range logBlocks {
+		c.logBlocks[b] = true
+	}
+	for i := 1; i <= n; i++ {
+		c.heads <- c.block(uint64(i))
+	}
+	return c
+}
+
+func chainHash(n uint64, branch byte) []byte { return []byte{branch, byte(n >> 8), byte(n)} }
+
+// block builds block n of the current chain; callers hold mu or own c
+func (c *reorgChain) block(n uint64) *Types.Block {
+	h := &Types.BlockHeader{Number: n, Hash: chainHash(n, c.branches[n])}
+	if n > 0 {
+		h.ParentHash = chainHash(n-1, c.branches[n-1])
+	}
+	return &Types.Block{Header: h}
+}
+
+// mine appends k blocks and announces each
+func (c *reorgChain) mine(k int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for range k {
+		c.branches = append(c.branches, c.branches[len(c.branches)-1])
+		c.heads <- c.block(uint64(len(c.branches) - 1))
+	}
+}
+
+// reorg moves blocks from and up to a new branch and announces the new head
+func (c *reorgChain) reorg(from uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for n := from; n < uint64(len(c.branches)); n++ {
+		c.branches[n]++
+	}
+	c.heads <- c.block(uint64(len(c.branches) - 1))
+}
+
+func (c *reorgChain) BlockNumber(ctx context.Context) (*big.Int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return big.NewInt(int64(len(c.branches) - 1)), nil
+}
+
+func (c *reorgChain) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for n := range c.branches {
+		if b := c.block(uint64(n)); string(b.Header.Hash) == string(hash) {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *reorgChain) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []*Types.Log
+	for n := q.FromBlock.Uint64(); n <= q.ToBlock.Uint64() && n < uint64(len(c.branches)); n++ {
+		if c.logBlocks[n] && c.branches[n] == 0 {
+			out = append(out, &Types.Log{BlockNumber: n, BlockHash: chainHash(n, 0)})
+		}
+	}
+	return out, nil
+}
+
+func (c *reorgChain) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return c.heads, func() {}, nil
+}
+
+func (c *reorgChain) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return nil, nil, errors.New("not supported")
+}
+
+func (c *reorgChain) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return nil, nil, errors.New("not supported")
+}
+
+// TestCacheReorgInsideLogRange caches a log query spanning blocks 3-4, replaces
+// block 4 and expects the query to see the new chain.
+func TestCacheReorgInsideLogRange(t *testing.T) {
+	be := newReorgChain(5, 3, 4)
+	ctx := context.Background()
+	metrics := Services.NewMetrics()
+	c := Services.NewCachingBackend(be, Services.CacheConfig{ConfirmationDepth: 1, Metrics: metrics})
+	t.Cleanup(c.Close)
+
+	q := Types.FilterQuery{FromBlock: big.NewInt(3), ToBlock: big.NewInt(4)}
+	logs := func() []*Types.Log {
+		t.Helper()
+		out, err := c.GetLogs(ctx, q)
+		if err != nil {
+			t.Fatalf("GetLogs: %v", err)
+		}
+		return out
+	}
+
+	// Heads seen by the cache confirm the range, after which it is served
+	// from the cache
+	hits := `facade_cache_hits_total{method="GetLogs"}`
+	waitFor(t, "a cached GetLogs", func() bool {
+		if n := len(logs()); n != 2 {
+			t.Fatalf("before reorg: %d logs, want 2", n)
+		}
+		return metrics.Get(hits) > 0
+	})
+
+	// Replace blocks 4 and up; block 3 and its log survive
+	be.reorg(4)
+	waitFor(t, "the cache to see the reorg", func() bool { return metrics.Get("facade_cache_reorgs_total") > 0 })
+	if got := logs(); len(got) != 1 || got[0].BlockNumber != 3 {
+		t.Fatalf("after reorg: %d logs, want the one in block 3", len(got))
+	}
+}
+
+func TestCacheHeadSeenFirstByBlockNumberIsNoReorg(t *testing.T) {
+	be := newReorgChain(1)
+	ctx := context.Background()
+	metrics := Services.NewMetrics()
+	c := Services.NewCachingBackend(be, Services.CacheConfig{BlockNumberTTL: time.Nanosecond, Metrics: metrics})
+	t.Cleanup(c.Close)
+
+	// BlockNumber usually learns of each block before the head subscription
+	// delivers it, which is not a reorg
+	for range 20 {
+		be.mine(1)
+		if _, err := c.BlockNumber(ctx); err != nil {
+			t.Fatalf("BlockNumber: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := metrics.Get("facade_cache_reorgs_total"); n != 0 {
+		t.Errorf("%d reorgs counted on a chain that never reorganised", n)
+	}
+}
@@ -0,0 +1,146 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Optional backend interfaces are reached through these helpers, which fall
+// back to unsupported for backends that do not serve them. Decorators use
+// them to pass optional calls through and report the wrapped backends'
+// capabilities as a Types.CapabilityReporter.
+
+func asUncles(be Types.Backend) Types.UncleBackend {
+	return optional[Types.UncleBackend](be, Types.CapUncles)
+}
+func asMining(be Types.Backend) Types.MiningBackend {
+	return optional[Types.MiningBackend](be, Types.CapMining)
+}
+func asSubscriptions(be Types.Backend) Types.SubscriptionBackend {
+	return optional[Types.SubscriptionBackend](be, Types.CapSubscriptions)
+}
+func asTracing(be Types.Backend) Types.TracingBackend {
+	return optional[Types.TracingBackend](be, Types.CapTracing)
+}
+
+func optional[T any](be Types.Backend, c Types.Capability) T {
+	if impl, ok := be.(T); ok && Types.Supports(be, c) {
+		return impl
+	}
+	return any(unsupported{}).(T)
+}
+
+// supportedByAll reports whether every backend serves c, for decorators that
+// may send a call to any of them
+func supportedByAll(backends []Types.Backend, c Types.Capability) bool {
+	for _, be := range backends {
+		if !Types.Supports(be, c) {
+			return false
+		}
+	}
+	return len(backends) > 0
+}
+
+// unwrapAs finds the first backend of type T in a chain of decorators that
+// expose what they wrap through Unwrap, so admin namespaces stay reachable
+// behind them
+func unwrapAs[T any](be Types.Backend) (T, bool) {
+	for be != nil {
+		if t, ok := be.(T); ok {
+			return t, true
+		}
+		u, ok := be.(interface{ Unwrap() Types.Backend })
+		if !ok {
+			break
+		}
+		be = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// unsupported answers every optional method with Types.ErrNotSupported
+type unsupported struct{}
+
+func (unsupported) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return 0, Types.ErrNotSupported
+}
+func (unsupported) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return 0, Types.ErrNotSupported
+}
+func (unsupported) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return nil, Types.ErrNotSupported
+}
+func (unsupported) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return nil, Types.ErrNotSupported
+}
+func (unsupported) Mining(ctx context.Context) (bool, error)     { return false, Types.ErrNotSupported }
+func (unsupported) Hashrate(ctx context.Context) (uint64, error) { return 0, Types.ErrNotSupported }
+func (unsupported) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return nil, nil, Types.ErrNotSupported
+}
+func (unsupported) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return nil, nil, Types.ErrNotSupported
+}
+func (unsupported) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return nil, nil, Types.ErrNotSupported
+}
+func (unsupported) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return nil, Types.ErrNotSupported
+}
+func (unsupported) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return nil, Types.ErrNotSupported
+}
+
+// forwardOptional passes the optional interfaces through to a wrapped
+// backend. Decorators that embed Types.Backend embed it alongside and
+// override what they handle themselves.
+type forwardOptional struct {
+	inner Types.Backend
+}
+
+func (f forwardOptional) Supports(c Types.Capability) bool { return Types.Supports(f.inner, c) }
+
+// Mining operations (for PoW chains)
+func (f forwardOptional) Mining(ctx context.Context) (bool, error) {
+	return asMining(f.inner).Mining(ctx)
+}
+func (f forwardOptional) Hashrate(ctx context.Context) (uint64, error) {
+	return asMining(f.inner).Hashrate(ctx)
+}
+
+// Uncle operations (for PoW chains)
+func (f forwardOptional) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return asUncles(f.inner).UncleCountByBlockNumber(ctx, blockNum)
+}
+func (f forwardOptional) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return asUncles(f.inner).UncleCountByBlockHash(ctx, blockHash)
+}
+func (f forwardOptional) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return asUncles(f.inner).UncleByBlockNumberAndIndex(ctx, blockNum, index)
+}
+func (f forwardOptional) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return asUncles(f.inner).UncleByBlockHashAndIndex(ctx, blockHash, index)
+}
+
+// Streaming (for WS subscriptions)
+func (f forwardOptional) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return asSubscriptions(f.inner).SubscribeNewHeads(ctx)
+}
+func (f forwardOptional) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return asSubscriptions(f.inner).SubscribeLogs(ctx, q)
+}
+func (f forwardOptional) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return asSubscriptions(f.inner).SubscribePendingTxs(ctx)
+}
+
+// Tracing
+func (f forwardOptional) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return asTracing(f.inner).TraceTransaction(ctx, hash, config)
+}
+func (f forwardOptional) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return asTracing(f.inner).TraceCall(ctx, msg, block, config)
+}
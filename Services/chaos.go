@@ -0,0 +1,859 @@
+package Services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/goccy/go-yaml"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// ChaosConfig configures a ChaosBackend.
+type ChaosConfig struct {
+	// Rules are the faults to inject, see ChaosRule
+	Rules []ChaosRule
+	// Enabled starts the backend injecting; otherwise it passes calls
+	// through until enabled with SetEnabled or chaos_enable
+	Enabled bool
+	// Seed makes the injected faults reproducible; 0 picks a random seed
+	Seed uint64
+	// Metrics receives injected fault counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// ChaosRule injects faults into the calls it matches. A call matches when its
+// JSON-RPC method matches Method and every Args predicate holds.
+//
+// Args are compared case-insensitively against the call's arguments, named
+// after the JSON-RPC parameters: "block" (hex number or "latest"), "address",
+// "hash", "key", "index", "from", "to", and for eth_subscribe "kind"
+// (newHeads, logs or newPendingTransactions). Handlers resolve the "latest"
+// tag through eth_blockNumber before calling the backend, so over JSON-RPC
+// "block" is always a number and rules on eth_blockNumber, including
+// StaleBlocks, also apply to calls made at the latest block.
+type ChaosRule struct {
+	// Name identifies the rule for chaos_removeRule
+	Name string `json:"name,omitempty"`
+	// Method is a JSON-RPC method name; a trailing * matches a prefix and
+	// an empty Method matches every call
+	Method string            `json:"method,omitempty"`
+	Args   map[string]string `json:"args,omitempty"`
+
+	// Latency delays matching calls before they are forwarded
+	Latency *ChaosLatency `json:"latency,omitempty"`
+	// ErrorRate is the fraction of matching calls failed with Error
+	// (default code -32000, "chaos: injected failure")
+	ErrorRate float64      `json:"errorRate,omitempty"`
+	Error     *Types.Error `json:"error,omitempty"`
+	// StaleBlocks answers "latest" lookups this many blocks behind the head,
+	// like a lagging node
+	StaleBlocks uint64 `json:"staleBlocks,omitempty"`
+
+	// Subscription faults, for rules matching eth_subscribe. DropRate and
+	// DuplicateRate are per-message fractions. ReorgEvery fakes a reorg of
+	// ReorgDepth blocks (default 1) every that many heads, or blocks carrying
+	// logs: sibling heads with the same numbers and parent but new hashes are
+	// sent from the fork point, and logs are re-sent as removed and then
+	// again as added. The chain itself is not changed, so the next real head
+	// switches back to it.
+	DropRate      float64 `json:"dropRate,omitempty"`
+	DuplicateRate float64 `json:"duplicateRate,omitempty"`
+	ReorgEvery    uint64  `json:"reorgEvery,omitempty"`
+	ReorgDepth    uint64  `json:"reorgDepth,omitempty"`
+}
+
+// ChaosLatency is a latency distribution. Fixed waits Mean; uniform draws
+// from [Min, Max); normal draws around Mean with StdDev; exponential waits Min
+// plus an exponential tail averaging Mean. Max, when set, caps every draw.
+// An empty Distribution is uniform when Max is set and fixed otherwise.
+type ChaosLatency struct {
+	Distribution string        `json:"distribution,omitempty"`
+	Min          ChaosDuration `json:"min,omitempty"`
+	Max          ChaosDuration `json:"max,omitempty"`
+	Mean         ChaosDuration `json:"mean,omitempty"`
+	StdDev       ChaosDuration `json:"stddev,omitempty"`
+}
+
+// ChaosDuration is a time.Duration that encodes to JSON as a string such as
+// "250ms". Plain numbers are read as milliseconds.
+type ChaosDuration time.Duration
+
+func (d ChaosDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *ChaosDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var ms float64
+		if err := json.Unmarshal(data, &ms); err != nil {
+			return fmt.Errorf("duration must be a string like \"250ms\" or milliseconds: %s", data)
+		}
+		*d = ChaosDuration(ms * float64(time.Millisecond))
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = ChaosDuration(v)
+	return nil
+}
+
+// chaosHistory bounds the heads and log blocks kept per subscription for
+// replaying reorgs.
+const chaosHistory = 64
+
+// ChaosBackend injects configured faults into the calls it forwards, to test
+// how clients cope with a flaky provider. Every matching rule applies, in
+// order: latencies add up, the first error that fires is returned, and for
+// stale heads and subscription faults the largest setting wins.
+// //debugging: Injected errors are logged alongside the RPC request log
+type ChaosBackend struct {
+	Types.Backend
+	forwardOptional
+	metrics *Metrics
+
+	mu      sync.Mutex
+	enabled bool
+	rules   []ChaosRule
+	rng     *rand.Rand
+}
+
+// NewChaosBackend wraps inner with fault injection. Invalid rules are
+// dropped with a log line; use SetRules to have them rejected instead.
+func NewChaosBackend(inner Types.Backend, cfg ChaosConfig) *ChaosBackend {
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	c := &ChaosBackend{
+		Backend:         inner,
+		forwardOptional: forwardOptional{inner},
+		metrics:         cfg.Metrics,
+		enabled:         cfg.Enabled,
+		rng:             rand.New(rand.NewPCG(seed, seed)),
+	}
+	for _, r := range cfg.Rules {
+		if err := r.validate(); err != nil {
+			log.Printf("⚠️ chaos: skipping rule %q: %v", r.Name, err)
+			continue
+		}
+		c.rules = append(c.rules, r)
+	}
+	return c
+}
+
+// LoadChaosRules reads a JSON or YAML list of rules from path.
+func LoadChaosRules(path string) ([]ChaosRule, error) {
+	rules, err := loadRules[ChaosRule](path)
+	if err != nil {
+		return nil, fmt.Errorf("chaos rules %s: %w", path, err)
+	}
+	for i, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("chaos rules %s: rule %d: %w", path, i, err)
+		}
+	}
+	return rules, nil
+}
+
+// loadRules reads a rules file holding a JSON list, or YAML
+func loadRules[T any](path string) ([]T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '[' {
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+	}
+	var rules []T
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Unwrap returns the wrapped backend
+func (c *ChaosBackend) Unwrap() Types.Backend { return c.Backend }
+
+// Enabled reports whether faults are being injected
+func (c *ChaosBackend) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enabled
+}
+
+// SetEnabled turns fault injection on or off; rules are kept either way
+func (c *ChaosBackend) SetEnabled(on bool) {
+	c.mu.Lock()
+	c.enabled = on
+	c.mu.Unlock()
+	log.Printf("🐒 chaos: enabled=%v", on)
+}
+
+// Rules returns a copy of the current rules
+func (c *ChaosBackend) Rules() []ChaosRule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ChaosRule{}, c.rules...)
+}
+
+// SetRules replaces the rules. Nothing changes if any rule is invalid.
+func (c *ChaosBackend) SetRules(rules []ChaosRule) error {
+	for i, r := range rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	c.mu.Lock()
+	c.rules = append([]ChaosRule{}, rules...)
+	c.mu.Unlock()
+	return nil
+}
+
+// AddRule appends a rule, replacing any rule with the same non-empty name
+func (c *ChaosBackend) AddRule(r ChaosRule) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.Name != "" {
+		for i := range c.rules {
+			if c.rules[i].Name == r.Name {
+				c.rules[i] = r
+				return nil
+			}
+		}
+	}
+	c.rules = append(c.rules, r)
+	return nil
+}
+
+// RemoveRule drops the rules with the given name and reports whether any were
+// found
+func (c *ChaosBackend) RemoveRule(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.rules[:0]
+	for _, r := range c.rules {
+		if r.Name != name {
+			kept = append(kept, r)
+		}
+	}
+	removed := len(kept) < len(c.rules)
+	c.rules = kept
+	return removed
+}
+
+func (r ChaosRule) validate() error {
+	for name, v := range map[string]float64{"errorRate": r.ErrorRate, "dropRate": r.DropRate, "duplicateRate": r.DuplicateRate} {
+		if v < 0 || v > 1 || math.IsNaN(v) {
+			return fmt.Errorf("%s %v is outside [0, 1]", name, v)
+		}
+	}
+	if r.Error != nil && r.Error.Code == 0 {
+		return errors.New("error needs a non-zero code")
+	}
+	if l := r.Latency; l != nil {
+		switch l.Distribution {
+		case "", "fixed", "uniform", "normal", "exponential":
+		default:
+			return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+		}
+		if l.Min < 0 || l.Max < 0 || l.Mean < 0 || l.StdDev < 0 {
+			return errors.New("latency durations must not be negative")
+		}
+		if l.Max > 0 && l.Min > l.Max {
+			return errors.New("latency min exceeds max")
+		}
+	}
+	return nil
+}
+
+// matches reports whether r applies to method called with args, given as
+// name/value pairs
+func (r *ChaosRule) matches(method string, args []string) bool {
+	if !matchMethod(r.Method, method) {
+		return false
+	}
+	for name, want := range r.Args {
+		found := false
+		for i := 0; i+1 < len(args); i += 2 {
+			if args[i] == name && strings.EqualFold(args[i+1], want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchMethod matches a method name against a rule pattern: an exact name,
+// a prefix ending in *, or empty for every method
+func matchMethod(pattern, method string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(method, prefix)
+	}
+	return pattern == "" || pattern == method
+}
+
+// chaosEffect gathers what the matching rules do to a call
+type chaosEffect struct {
+	delay      time.Duration
+	err        error
+	stale      uint64
+	drop, dup  float64
+	reorgEvery uint64
+	reorgDepth uint64
+}
+
+// effect rolls the matching rules for one call
+func (c *ChaosBackend) effect(method string, args ...string) chaosEffect {
+	var eff chaosEffect
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return eff
+	}
+	for i := range c.rules {
+		r := &c.rules[i]
+		if !r.matches(method, args) {
+			continue
+		}
+		if r.Latency != nil {
+			eff.delay += r.Latency.sample(c.rng)
+		}
+		if eff.err == nil && r.ErrorRate > 0 && c.rng.Float64() < r.ErrorRate {
+			rpcErr := &Types.Error{Code: -32000, Message: "chaos: injected failure"}
+			if r.Error != nil {
+				copied := *r.Error
+				rpcErr = &copied
+			}
+			eff.err = rpcErr
+		}
+		eff.stale = max(eff.stale, r.StaleBlocks)
+		eff.drop = max(eff.drop, r.DropRate)
+		eff.dup = max(eff.dup, r.DuplicateRate)
+		if r.ReorgEvery > 0 && (eff.reorgEvery == 0 || r.ReorgEvery < eff.reorgEvery) {
+			eff.reorgEvery = r.ReorgEvery
+		}
+		eff.reorgDepth = max(eff.reorgDepth, r.ReorgDepth)
+	}
+	if eff.reorgEvery > 0 && eff.reorgDepth == 0 {
+		eff.reorgDepth = 1
+	}
+	return eff
+}
+
+// roll reports whether an event with probability p happens
+func (c *ChaosBackend) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < p
+}
+
+// inject applies latency and errors to a call and returns the stale head
+// distance to use for it
+func (c *ChaosBackend) inject(ctx context.Context, method string, args ...string) (uint64, error) {
+	eff := c.effect(method, args...)
+	if eff.delay > 0 {
+		c.metrics.Inc(metricName("facade_chaos_faults_total", "method", method, "fault", "latency"))
+		t := time.NewTimer(eff.delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return 0, ctx.Err()
+		}
+	}
+	if eff.err != nil {
+		c.metrics.Inc(metricName("facade_chaos_faults_total", "method", method, "fault", "error"))
+		log.Printf("🐒 chaos: failing %s: %v", method, eff.err)
+		return 0, eff.err
+	}
+	return eff.stale, nil
+}
+
+// at resolves a "latest" block to stale blocks behind the head; explicit
+// blocks are kept
+func (c *ChaosBackend) at(ctx context.Context, block *big.Int, stale uint64) (*big.Int, error) {
+	if block != nil || stale == 0 {
+		return block, nil
+	}
+	head, err := c.Backend.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return staleHead(head, stale), nil
+}
+
+// staleHead returns head minus stale, stopping at genesis
+func staleHead(head *big.Int, stale uint64) *big.Int {
+	n := new(big.Int).Sub(head, new(big.Int).SetUint64(stale))
+	if n.Sign() < 0 {
+		n.SetUint64(0)
+	}
+	return n
+}
+
+func (l *ChaosLatency) sample(rng *rand.Rand) time.Duration {
+	var d time.Duration
+	lo, hi, mean := time.Duration(l.Min), time.Duration(l.Max), time.Duration(l.Mean)
+	dist := l.Distribution
+	if dist == "" {
+		dist = "fixed"
+		if hi > 0 {
+			dist = "uniform"
+		}
+	}
+	switch dist {
+	case "fixed":
+		d = mean
+	case "uniform":
+		d = lo
+		if hi > lo {
+			d += time.Duration(rng.Int64N(int64(hi - lo)))
+		}
+	case "normal":
+		d = mean + time.Duration(rng.NormFloat64()*float64(l.StdDev))
+		d = max(d, lo)
+	case "exponential":
+		d = lo + time.Duration(rng.ExpFloat64()*float64(mean))
+	}
+	if hi > 0 && d > hi {
+		d = hi
+	}
+	return max(d, 0)
+}
+
+// Basic blockchain info
+func (c *ChaosBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	if _, err := c.inject(ctx, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return c.Backend.ChainID(ctx)
+}
+func (c *ChaosBackend) ClientVersion(ctx context.Context) (string, error) {
+	if _, err := c.inject(ctx, "web3_clientVersion"); err != nil {
+		return "", err
+	}
+	return c.Backend.ClientVersion(ctx)
+}
+func (c *ChaosBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	stale, err := c.inject(ctx, "eth_blockNumber")
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.Backend.BlockNumber(ctx)
+	if err != nil || stale == 0 {
+		return head, err
+	}
+	return staleHead(head, stale), nil
+}
+
+// Block operations
+func (c *ChaosBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	stale, err := c.inject(ctx, "eth_getBlockByNumber", "block", blockArg(num))
+	if err != nil {
+		return nil, err
+	}
+	if num, err = c.at(ctx, num, stale); err != nil {
+		return nil, err
+	}
+	return c.Backend.BlockByNumber(ctx, num, fullTx)
+}
+func (c *ChaosBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	if _, err := c.inject(ctx, "eth_getBlockByHash", "hash", hexArg(hash)); err != nil {
+		return nil, err
+	}
+	return c.Backend.BlockByHash(ctx, hash, fullTx)
+}
+func (c *ChaosBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	stale, err := c.inject(ctx, "eth_getBlockTransactionCountByNumber", "block", blockArg(blockNum))
+	if err != nil {
+		return 0, err
+	}
+	if blockNum, err = c.at(ctx, blockNum, stale); err != nil {
+		return 0, err
+	}
+	return c.Backend.BlockTransactionCountByNumber(ctx, blockNum)
+}
+func (c *ChaosBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	if _, err := c.inject(ctx, "eth_getBlockTransactionCountByHash", "hash", hexArg(blockHash)); err != nil {
+		return 0, err
+	}
+	return c.Backend.BlockTransactionCountByHash(ctx, blockHash)
+}
+
+// Account operations
+func (c *ChaosBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	stale, err := c.inject(ctx, "eth_getBalance", "address", hexArg(addr), "block", blockArg(block))
+	if err != nil {
+		return nil, err
+	}
+	if block, err = c.at(ctx, block, stale); err != nil {
+		return nil, err
+	}
+	return c.Backend.Balance(ctx, addr, block)
+}
+func (c *ChaosBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	stale, err := c.inject(ctx, "eth_getCode", "address", hexArg(addr), "block", blockArg(block))
+	if err != nil {
+		return nil, err
+	}
+	if block, err = c.at(ctx, block, stale); err != nil {
+		return nil, err
+	}
+	return c.Backend.GetCode(ctx, addr, block)
+}
+func (c *ChaosBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	stale, err := c.inject(ctx, "eth_getStorageAt", "address", hexArg(addr), "key", hexArg(key), "block", blockArg(block))
+	if err != nil {
+		return nil, err
+	}
+	if block, err = c.at(ctx, block, stale); err != nil {
+		return nil, err
+	}
+	return c.Backend.GetStorageAt(ctx, addr, key, block)
+}
+func (c *ChaosBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	stale, err := c.inject(ctx, "eth_getTransactionCount", "address", hexArg(addr), "block", blockArg(block))
+	if err != nil {
+		return 0, err
+	}
+	if block, err = c.at(ctx, block, stale); err != nil {
+		return 0, err
+	}
+	return c.Backend.GetTransactionCount(ctx, addr, block)
+}
+
+// Transaction operations
+func (c *ChaosBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	stale, err := c.inject(ctx, "eth_call", "from", msg.From, "to", msg.To, "block", blockArg(block))
+	if err != nil {
+		return nil, err
+	}
+	if block, err = c.at(ctx, block, stale); err != nil {
+		return nil, err
+	}
+	return c.Backend.Call(ctx, msg, block)
+}
+func (c *ChaosBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	if _, err := c.inject(ctx, "eth_estimateGas", "from", msg.From, "to", msg.To); err != nil {
+		return 0, err
+	}
+	return c.Backend.EstimateGas(ctx, msg)
+}
+func (c *ChaosBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	if _, err := c.inject(ctx, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return c.Backend.GasPrice(ctx)
+}
+func (c *ChaosBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	if _, err := c.inject(ctx, "eth_sendRawTransaction"); err != nil {
+		return nil, err
+	}
+	return c.Backend.SendRawTx(ctx, rawHex)
+}
+func (c *ChaosBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	if _, err := c.inject(ctx, "eth_getTransactionByHash", "hash", hexArg(hash)); err != nil {
+		return nil, err
+	}
+	return c.Backend.TxByHash(ctx, hash)
+}
+func (c *ChaosBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	stale, err := c.inject(ctx, "eth_getTransactionByBlockNumberAndIndex", "block", blockArg(blockNum), "index", uintArg(index))
+	if err != nil {
+		return nil, err
+	}
+	if blockNum, err = c.at(ctx, blockNum, stale); err != nil {
+		return nil, err
+	}
+	return c.Backend.TxByBlockNumberAndIndex(ctx, blockNum, index)
+}
+func (c *ChaosBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	if _, err := c.inject(ctx, "eth_getTransactionByBlockHashAndIndex", "hash", hexArg(blockHash), "index", uintArg(index)); err != nil {
+		return nil, err
+	}
+	return c.Backend.TxByBlockHashAndIndex(ctx, blockHash, index)
+}
+func (c *ChaosBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	if _, err := c.inject(ctx, "eth_getTransactionReceipt", "hash", hexArg(hash)); err != nil {
+		return nil, err
+	}
+	return c.Backend.ReceiptByHash(ctx, hash)
+}
+
+// Log operations
+func (c *ChaosBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	args := []string{}
+	if len(q.Addresses) == 1 {
+		args = append(args, "address", hexArg(q.Addresses[0]))
+	}
+	if q.BlockHash != nil {
+		args = append(args, "hash", hexArg(q.BlockHash))
+	}
+	if _, err := c.inject(ctx, "eth_getLogs", args...); err != nil {
+		return nil, err
+	}
+	return c.Backend.GetLogs(ctx, q)
+}
+
+// Network operations
+func (c *ChaosBackend) PeerCount(ctx context.Context) (uint64, error) {
+	if _, err := c.inject(ctx, "net_peerCount"); err != nil {
+		return 0, err
+	}
+	return c.Backend.PeerCount(ctx)
+}
+func (c *ChaosBackend) Listening(ctx context.Context) (bool, error) {
+	if _, err := c.inject(ctx, "net_listening"); err != nil {
+		return false, err
+	}
+	return c.Backend.Listening(ctx)
+}
+func (c *ChaosBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	if _, err := c.inject(ctx, "eth_syncing"); err != nil {
+		return nil, err
+	}
+	return c.Backend.Syncing(ctx)
+}
+
+// Mining operations (for PoW chains)
+func (c *ChaosBackend) Mining(ctx context.Context) (bool, error) {
+	if _, err := c.inject(ctx, "eth_mining"); err != nil {
+		return false, err
+	}
+	return c.forwardOptional.Mining(ctx)
+}
+func (c *ChaosBackend) Hashrate(ctx context.Context) (uint64, error) {
+	if _, err := c.inject(ctx, "eth_hashrate"); err != nil {
+		return 0, err
+	}
+	return c.forwardOptional.Hashrate(ctx)
+}
+
+// Uncle operations (for PoW chains)
+func (c *ChaosBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	if _, err := c.inject(ctx, "eth_getUncleCountByBlockNumber", "block", blockArg(blockNum)); err != nil {
+		return 0, err
+	}
+	return c.forwardOptional.UncleCountByBlockNumber(ctx, blockNum)
+}
+func (c *ChaosBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	if _, err := c.inject(ctx, "eth_getUncleCountByBlockHash", "hash", hexArg(blockHash)); err != nil {
+		return 0, err
+	}
+	return c.forwardOptional.UncleCountByBlockHash(ctx, blockHash)
+}
+func (c *ChaosBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	if _, err := c.inject(ctx, "eth_getUncleByBlockNumberAndIndex", "block", blockArg(blockNum), "index", uintArg(index)); err != nil {
+		return nil, err
+	}
+	return c.forwardOptional.UncleByBlockNumberAndIndex(ctx, blockNum, index)
+}
+func (c *ChaosBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	if _, err := c.inject(ctx, "eth_getUncleByBlockHashAndIndex", "hash", hexArg(blockHash), "index", uintArg(index)); err != nil {
+		return nil, err
+	}
+	return c.forwardOptional.UncleByBlockHashAndIndex(ctx, blockHash, index)
+}
+
+// Streaming (for WS subscriptions)
+func (c *ChaosBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	if _, err := c.inject(ctx, "eth_subscribe", "kind", "newHeads"); err != nil {
+		return nil, nil, err
+	}
+	in, stop, err := c.forwardOptional.SubscribeNewHeads(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	// A reorg sends siblings of the last heads from the fork point, as geth
+	// does when it switches to a competing chain
+	var recent []*Types.Block
+	var seen uint64
+	replay := func(b *Types.Block, eff chaosEffect, deliver []*Types.Block) []*Types.Block {
+		if b == nil || b.Header == nil {
+			return deliver
+		}
+		recent = append(recent, b)
+		if len(recent) > chaosHistory {
+			recent = recent[1:]
+		}
+		seen++
+		if eff.reorgEvery == 0 || seen%eff.reorgEvery != 0 {
+			return deliver
+		}
+		depth := min(int(min(eff.reorgDepth, chaosHistory)), len(recent))
+		parent := recent[len(recent)-depth].Header.ParentHash
+		for _, orig := range recent[len(recent)-depth:] {
+			sibling := siblingHead(orig, parent)
+			deliver = append(deliver, sibling)
+			parent = sibling.Header.Hash
+		}
+		return deliver
+	}
+	out, cancel := chaosRelay(c, ctx, "newHeads", in, stop, replay)
+	return out, cancel, nil
+}
+
+// siblingHead returns a head competing with b at its height, built on
+// parent. Its hash is derived from b's, so a seeded run replays the same
+// siblings.
+func siblingHead(b *Types.Block, parent []byte) *Types.Block {
+	header := *b.Header
+	header.ParentHash = parent
+	header.Hash = crypto.Keccak256([]byte("chaos sibling"), b.Header.Hash)
+	sibling := *b
+	sibling.Header = &header
+	return &sibling
+}
+
+func (c *ChaosBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	if _, err := c.inject(ctx, "eth_subscribe", "kind", "logs"); err != nil {
+		return nil, nil, err
+	}
+	in, stop, err := c.forwardOptional.SubscribeLogs(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Logs are grouped by block. A reorg fires when a new block's first log
+	// arrives: the previous blocks' logs are re-sent as removed, then as
+	// added, as geth does for logs moved to the new chain.
+	var blocks [][]*Types.Log
+	var seen uint64
+	replay := func(l *Types.Log, eff chaosEffect, deliver []*Types.Log) []*Types.Log {
+		var out []*Types.Log
+		if n := len(blocks); n == 0 || blocks[n-1][0].BlockNumber != l.BlockNumber {
+			seen++
+			if eff.reorgEvery > 0 && seen > 1 && (seen-1)%eff.reorgEvery == 0 {
+				depth := min(int(min(eff.reorgDepth, chaosHistory)), n)
+				moved := blocks[n-depth:]
+				for i := len(moved) - 1; i >= 0; i-- {
+					for j := len(moved[i]) - 1; j >= 0; j-- {
+						removed := *moved[i][j]
+						removed.Removed = true
+						out = append(out, &removed)
+					}
+				}
+				for _, logs := range moved {
+					out = append(out, logs...)
+				}
+			}
+			blocks = append(blocks, nil)
+			if len(blocks) > chaosHistory {
+				blocks = blocks[1:]
+			}
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], l)
+		return append(out, deliver...)
+	}
+	out, cancel := chaosRelay(c, ctx, "logs", in, stop, replay)
+	return out, cancel, nil
+}
+func (c *ChaosBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	if _, err := c.inject(ctx, "eth_subscribe", "kind", "newPendingTransactions"); err != nil {
+		return nil, nil, err
+	}
+	in, stop, err := c.forwardOptional.SubscribePendingTxs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, cancel := chaosRelay(c, ctx, "newPendingTransactions", in, stop, nil)
+	return out, cancel, nil
+}
+
+// Tracing
+func (c *ChaosBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	if _, err := c.inject(ctx, "debug_traceTransaction", "hash", hexArg(hash)); err != nil {
+		return nil, err
+	}
+	return c.forwardOptional.TraceTransaction(ctx, hash, config)
+}
+func (c *ChaosBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	if _, err := c.inject(ctx, "debug_traceCall", "from", msg.From, "to", msg.To, "block", blockArg(block)); err != nil {
+		return nil, err
+	}
+	return c.forwardOptional.TraceCall(ctx, msg, block, config)
+}
+
+// chaosRelay forwards a subscription, dropping and duplicating messages as
+// the rules for its kind say at the time each message arrives. replay, if
+// set, sees every message and returns what to deliver in its place, so it
+// can add reorg replays around it.
+func chaosRelay[T any](c *ChaosBackend, ctx context.Context, kind string, in <-chan T, stop func(), replay func(v T, eff chaosEffect, deliver []T) []T) (<-chan T, func()) {
+	out := make(chan T, max(cap(in), 16))
+	done := make(chan struct{})
+	var once sync.Once
+	cancel := func() { once.Do(func() { close(done) }) }
+	fault := func(name string) {
+		c.metrics.Inc(metricName("facade_chaos_faults_total", "method", "eth_subscribe", "fault", name))
+	}
+	go func() {
+		defer close(out)
+		defer stop()
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				eff := c.effect("eth_subscribe", "kind", kind)
+				deliver := []T{v}
+				if c.roll(eff.drop) {
+					fault("drop")
+					deliver = nil
+				} else if c.roll(eff.dup) {
+					fault("duplicate")
+					deliver = append(deliver, v)
+				}
+				if replay != nil {
+					before := len(deliver)
+					if deliver = replay(v, eff, deliver); len(deliver) > before {
+						fault("reorg")
+					}
+				}
+				for _, m := range deliver {
+					select {
+					case out <- m:
+					case <-done:
+						return
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, cancel
+}
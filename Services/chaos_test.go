@@ -0,0 +1,268 @@
+package Services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// newChaos wraps the seeded memory chain with rules, seeded so every run
+// rolls the same faults
+func newChaos(t *testing.T, rules ...Services.ChaosRule) (*Services.ChaosBackend, *Services.Metrics) {
+	metrics := Services.NewMetrics()
+	return Services.NewChaosBackend(seededMemory(t), Services.ChaosConfig{Rules: rules, Enabled: true, Seed: 1, Metrics: metrics}), metrics
+}
+
+func rpcCode(err error) int {
+	var rpcErr *Types.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code
+	}
+	return 0
+}
+
+func TestChaosRuleMatching(t *testing.T) {
+	ctx := context.Background()
+	target := addr(0xab)
+	c, metrics := newChaos(t,
+		// Args compare case-insensitively
+		Services.ChaosRule{Name: "one-account", Method: "eth_getBalance", Args: map[string]string{"address": "0xAB" + strings.Repeat("00", 19)}, ErrorRate: 1, Error: &Types.Error{Code: -32005, Message: "rate limited"}},
+		Services.ChaosRule{Name: "code", Method: "eth_getCo*", ErrorRate: 1},
+	)
+
+	if _, err := c.Balance(ctx, target, nil); rpcCode(err) != -32005 {
+		t.Errorf("matching balance returned %v, want -32005", err)
+	}
+	if _, err := c.Balance(ctx, addr(2), nil); err != nil {
+		t.Errorf("balance of another account failed: %v", err)
+	}
+	if _, err := c.GetCode(ctx, addr(2), nil); rpcCode(err) != -32000 {
+		t.Errorf("code matched by prefix returned %v, want the default -32000", err)
+	}
+	if _, err := c.ChainID(ctx); err != nil {
+		t.Errorf("unmatched call failed: %v", err)
+	}
+	if n := metrics.Get(`facade_chaos_faults_total{method="eth_getBalance",fault="error"}`); n != 1 {
+		t.Errorf("balance faults counted %d, want 1", n)
+	}
+
+	// Rules can be dropped and faults switched off at runtime
+	if !c.RemoveRule("one-account") || c.RemoveRule("one-account") {
+		t.Error("RemoveRule did not report the rule once")
+	}
+	if _, err := c.Balance(ctx, target, nil); err != nil {
+		t.Errorf("balance failed after its rule was removed: %v", err)
+	}
+	c.SetEnabled(false)
+	if _, err := c.GetCode(ctx, addr(2), nil); err != nil {
+		t.Errorf("call failed with chaos disabled: %v", err)
+	}
+	if err := c.SetRules([]Services.ChaosRule{{ErrorRate: 2}}); err == nil || len(c.Rules()) != 1 {
+		t.Errorf("SetRules accepted an error rate of 2 or dropped the old rules: %v", err)
+	}
+}
+
+func TestChaosErrorRateIsSeeded(t *testing.T) {
+	ctx := context.Background()
+	rule := Services.ChaosRule{Method: "eth_chainId", ErrorRate: 0.3}
+	pattern := func() string {
+		c, _ := newChaos(t, rule)
+		var b strings.Builder
+		for i := 0; i < 200; i++ {
+			if _, err := c.ChainID(ctx); err != nil {
+				b.WriteByte('x')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		return b.String()
+	}
+	first := pattern()
+	if failed := strings.Count(first, "x"); failed < 30 || failed > 90 {
+		t.Errorf("%d of 200 calls failed at a 0.3 error rate", failed)
+	}
+	if second := pattern(); second != first {
+		t.Errorf("same seed failed different calls:\n%s\n%s", first, second)
+	}
+}
+
+func TestChaosLatencyAndStaleHeads(t *testing.T) {
+	ctx := context.Background()
+	c, metrics := newChaos(t,
+		Services.ChaosRule{Method: "eth_gasPrice", Latency: &Services.ChaosLatency{Mean: Services.ChaosDuration(50 * time.Millisecond)}},
+		Services.ChaosRule{Method: "eth_*", StaleBlocks: 2},
+	)
+
+	start := time.Now()
+	if _, err := c.GasPrice(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("delayed call took %s, want at least 50ms", d)
+	}
+	if n := metrics.Get(`facade_chaos_faults_total{method="eth_gasPrice",fault="latency"}`); n != 1 {
+		t.Errorf("latency faults counted %d, want 1", n)
+	}
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := c.GasPrice(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("delayed call past its deadline returned %v", err)
+	}
+
+	real := head(t, c.Unwrap())
+	if got := head(t, c); got != real-2 {
+		t.Errorf("stale head %d, want %d", got, real-2)
+	}
+	b, err := c.BlockByNumber(ctx, nil, false)
+	if err != nil || b.Header.Number != real-2 {
+		t.Errorf("latest block %+v, %v, want block %d", b, err, real-2)
+	}
+}
+
+// headFeed serves the heads the test sends
+type headFeed struct {
+	Types.BaseBackend
+	heads chan *Types.Block
+}
+
+func (f *headFeed) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return f.heads, func() {}, nil
+}
+func (f *headFeed) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return nil, nil, Types.ErrNotSupported
+}
+func (f *headFeed) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return nil, nil, Types.ErrNotSupported
+}
+
+// feedChain returns heads 1..n, each built on the one before
+func feedChain(n int) []*Types.Block {
+	var out []*Types.Block
+	parent := bytes.Repeat([]byte{0}, 32)
+	for i := 1; i <= n; i++ {
+		hash := bytes.Repeat([]byte{byte(i)}, 32)
+		out = append(out, &Types.Block{Header: &Types.BlockHeader{Number: uint64(i), ParentHash: parent, Hash: hash}})
+		parent = hash
+	}
+	return out
+}
+
+// relayHeads feeds heads through a chaos subscription and collects what comes
+// out once the feed closes
+func relayHeads(t *testing.T, rule Services.ChaosRule, heads []*Types.Block) []*Types.Block {
+	t.Helper()
+	feed := &headFeed{heads: make(chan *Types.Block, len(heads))}
+	for _, b := range heads {
+		feed.heads <- b
+	}
+	close(feed.heads)
+	c := Services.NewChaosBackend(feed, Services.ChaosConfig{Rules: []Services.ChaosRule{rule}, Enabled: true, Seed: 1, Metrics: Services.NewMetrics()})
+	out, stop, err := c.SubscribeNewHeads(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	var got []*Types.Block
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case b, ok := <-out:
+			if !ok {
+				return got
+			}
+			got = append(got, b)
+		case <-timeout:
+			t.Fatalf("subscription still open after %d heads", len(got))
+		}
+	}
+}
+
+func TestChaosHeadDropsAndDuplicates(t *testing.T) {
+	heads := feedChain(100)
+	kind := map[string]string{"kind": "newHeads"}
+	if got := relayHeads(t, Services.ChaosRule{Method: "eth_subscribe", Args: kind, DropRate: 1}, heads); len(got) != 0 {
+		t.Errorf("%d heads delivered at a drop rate of 1", len(got))
+	}
+	got := relayHeads(t, Services.ChaosRule{Method: "eth_subscribe", Args: kind, DropRate: 0.2}, heads)
+	if len(got) < 60 || len(got) > 95 {
+		t.Errorf("%d of 100 heads delivered at a drop rate of 0.2", len(got))
+	}
+	if again := relayHeads(t, Services.ChaosRule{Method: "eth_subscribe", Args: kind, DropRate: 0.2}, heads); len(again) != len(got) {
+		t.Errorf("same seed delivered %d heads, then %d", len(got), len(again))
+	}
+	if got := relayHeads(t, Services.ChaosRule{Method: "eth_subscribe", Args: kind, DuplicateRate: 1}, heads[:3]); len(got) != 6 || got[0] != got[1] {
+		t.Errorf("duplicate rate 1 delivered %d heads, want each twice", len(got))
+	}
+}
+
+func TestChaosHeadReorgSendsSiblings(t *testing.T) {
+	heads := feedChain(4)
+	got := relayHeads(t, Services.ChaosRule{Method: "eth_subscribe", Args: map[string]string{"kind": "newHeads"}, ReorgEvery: 2, ReorgDepth: 2}, heads)
+
+	// 1 2 1' 2' 3 4 3' 4'
+	if len(got) != 8 {
+		t.Fatalf("delivered %d heads, want 8", len(got))
+	}
+	for _, c := range []struct{ at, orig int }{{2, 0}, {3, 1}, {6, 2}, {7, 3}} {
+		sib, orig := got[c.at].Header, heads[c.orig].Header
+		if sib.Number != orig.Number || bytes.Equal(sib.Hash, orig.Hash) {
+			t.Errorf("head %d: number %d hash 0x%x, want a sibling of block %d", c.at, sib.Number, sib.Hash, orig.Number)
+		}
+	}
+	// Each reorg forks from the parent of its first replaced head, and the
+	// siblings chain onto each other
+	for _, c := range []struct{ first, second, orig int }{{2, 3, 0}, {6, 7, 2}} {
+		if !bytes.Equal(got[c.first].Header.ParentHash, heads[c.orig].Header.ParentHash) {
+			t.Errorf("sibling at %d does not fork from block %d's parent", c.first, heads[c.orig].Header.Number)
+		}
+		if !bytes.Equal(got[c.second].Header.ParentHash, got[c.first].Header.Hash) {
+			t.Errorf("sibling at %d does not build on the sibling before it", c.second)
+		}
+	}
+	// The original heads were not modified
+	if heads[0].Header.Hash[0] != 1 || got[0] != heads[0] {
+		t.Error("original head changed by the reorg")
+	}
+}
+
+func TestChaosAdminMethodsNeedOptIn(t *testing.T) {
+	c, _ := newChaos(t, Services.ChaosRule{Name: "gas", Method: "eth_gasPrice", ErrorRate: 1})
+	h := Services.NewHandlers(c)
+	call := func(method string, params ...any) *Types.Response {
+		t.Helper()
+		if params == nil {
+			params = []any{}
+		}
+		resp, err := h.Handle(context.Background(), Types.Request{Jsonrpc: "2.0", ID: 1, Method: method, Params: params})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &resp
+	}
+
+	for _, m := range []string{"chaos_enable", "chaos_disable", "chaos_setRules", "chaos_addRule", "chaos_removeRule"} {
+		if resp := call(m, "gas"); resp.Error == nil || resp.Error.Code != -32601 {
+			t.Errorf("%s served without SetChaosAdmin: %+v", m, resp)
+		}
+	}
+	if resp := call("chaos_status"); resp.Error != nil {
+		t.Errorf("chaos_status: %+v", resp.Error)
+	}
+	if !c.Enabled() || len(c.Rules()) != 1 {
+		t.Fatal("chaos state changed by refused calls")
+	}
+
+	h.SetChaosAdmin(true)
+	if resp := call("chaos_removeRule", "gas"); resp.Error != nil || resp.Result != true {
+		t.Errorf("chaos_removeRule with SetChaosAdmin: %+v", resp)
+	}
+	if resp := call("chaos_disable"); resp.Error != nil || c.Enabled() {
+		t.Errorf("chaos_disable with SetChaosAdmin: %+v", resp)
+	}
+}
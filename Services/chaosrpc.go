@@ -0,0 +1,78 @@
+package Services
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// isChaosMethod reports whether a method belongs to the chaos admin namespace
+func isChaosMethod(method string) bool {
+	return strings.HasPrefix(method, "chaos_")
+}
+
+// handleChaos serves the chaos_* admin methods that switch fault injection
+// and edit its rules at runtime. Rules use ChaosRule's JSON shape. Handle
+// only routes the write methods here when SetChaosAdmin is on.
+func (h *Handlers) handleChaos(cb *ChaosBackend, req Types.Request) (Types.Response, error) {
+	switch req.Method {
+	case "chaos_enable", "chaos_disable":
+		cb.SetEnabled(req.Method == "chaos_enable")
+		return finish(req, true, nil)
+
+	case "chaos_status":
+		return finish(req, map[string]any{"enabled": cb.Enabled(), "rules": cb.Rules()}, nil)
+
+	case "chaos_setRules":
+		// params: [rules]; an empty list clears them
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing rules")
+		}
+		var rules []ChaosRule
+		if err := chaosParam(req.Params[0], &rules); err != nil {
+			return invalidParams(req, err.Error())
+		}
+		if err := cb.SetRules(rules); err != nil {
+			return invalidParams(req, err.Error())
+		}
+		return finish(req, true, nil)
+
+	case "chaos_addRule":
+		// params: [rule]
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing rule")
+		}
+		var rule ChaosRule
+		if err := chaosParam(req.Params[0], &rule); err != nil {
+			return invalidParams(req, err.Error())
+		}
+		if err := cb.AddRule(rule); err != nil {
+			return invalidParams(req, err.Error())
+		}
+		return finish(req, true, nil)
+
+	case "chaos_removeRule":
+		// params: [name]
+		name, ok := "", len(req.Params) > 0
+		if ok {
+			name, ok = req.Params[0].(string)
+		}
+		if !ok {
+			return invalidParams(req, "missing rule name")
+		}
+		return finish(req, cb.RemoveRule(name), nil)
+
+	default:
+		return Types.RespErr(req.ID, -32601, "Method not found"), nil
+	}
+}
+
+// chaosParam decodes a JSON-RPC param into dst through its JSON form
+func chaosParam(p any, dst any) error {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
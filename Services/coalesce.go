@@ -0,0 +1,208 @@
+package Services
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// CoalescingBackend collapses identical concurrent reads (same method and
+// arguments) into a single call to the wrapped backend and hands the result
+// to every waiting caller. Writes and subscriptions pass straight through.
+// //conversions: Results are shared between callers and must not be mutated
+type CoalescingBackend struct {
+	Types.Backend
+	forwardOptional
+	flights flightGroup
+	metrics *Metrics
+}
+
+// NewCoalescingBackend wraps inner. A nil metrics uses DefaultMetrics.
+func NewCoalescingBackend(inner Types.Backend, metrics *Metrics) *CoalescingBackend {
+	if metrics == nil {
+		metrics = DefaultMetrics
+	}
+	return &CoalescingBackend{Backend: inner, forwardOptional: forwardOptional{inner}, metrics: metrics, flights: flightGroup{calls: map[string]*flight{}}}
+}
+
+// flight is one in-progress backend call and the callers waiting on it.
+type flight struct {
+	done    chan struct{}
+	v       any
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flightGroup is a singleflight that keeps each caller's cancellation
+// independent: the shared call runs on a detached context and is only
+// cancelled once every waiter has given up.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (any, error)) (v any, shared bool, err error) {
+	g.mu.Lock()
+	f, shared := g.calls[key]
+	if shared {
+		f.waiters++
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = f
+		go func() {
+			f.v, f.err = fn(callCtx)
+			g.mu.Lock()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.v, shared, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody is left to receive the answer; later callers start afresh
+			f.cancel()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+// coalesce runs fn once per concurrent set of identical calls.
+func coalesce[T any](c *CoalescingBackend, ctx context.Context, method, key string, fn func(context.Context) (T, error)) (T, error) {
+	v, shared, err := c.flights.do(ctx, key, func(ctx context.Context) (any, error) { return fn(ctx) })
+	if shared {
+		c.metrics.Inc(metricName("facade_coalesce_hits_total", "method", method))
+	} else {
+		c.metrics.Inc(metricName("facade_coalesce_misses_total", "method", method))
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// Basic blockchain info
+func (c *CoalescingBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return coalesce(c, ctx, "ChainID", "ChainID", c.Backend.ChainID)
+}
+func (c *CoalescingBackend) ClientVersion(ctx context.Context) (string, error) {
+	return coalesce(c, ctx, "ClientVersion", "ClientVersion", c.Backend.ClientVersion)
+}
+func (c *CoalescingBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return coalesce(c, ctx, "BlockNumber", "BlockNumber", c.Backend.BlockNumber)
+}
+func (c *CoalescingBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return coalesce(c, ctx, "GasPrice", "GasPrice", c.Backend.GasPrice)
+}
+
+// Block operations
+func (c *CoalescingBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return coalesce(c, ctx, "BlockByNumber", cacheKey("BlockByNumber", num, fullTx), func(ctx context.Context) (*Types.Block, error) {
+		return c.Backend.BlockByNumber(ctx, num, fullTx)
+	})
+}
+func (c *CoalescingBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return coalesce(c, ctx, "BlockByHash", cacheKey("BlockByHash", hash, fullTx), func(ctx context.Context) (*Types.Block, error) {
+		return c.Backend.BlockByHash(ctx, hash, fullTx)
+	})
+}
+func (c *CoalescingBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return coalesce(c, ctx, "BlockTransactionCountByNumber", cacheKey("BlockTransactionCountByNumber", blockNum), func(ctx context.Context) (uint64, error) {
+		return c.Backend.BlockTransactionCountByNumber(ctx, blockNum)
+	})
+}
+func (c *CoalescingBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return coalesce(c, ctx, "BlockTransactionCountByHash", cacheKey("BlockTransactionCountByHash", blockHash), func(ctx context.Context) (uint64, error) {
+		return c.Backend.BlockTransactionCountByHash(ctx, blockHash)
+	})
+}
+
+// Account operations
+func (c *CoalescingBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return coalesce(c, ctx, "Balance", cacheKey("Balance", addr, block), func(ctx context.Context) (*big.Int, error) {
+		return c.Backend.Balance(ctx, addr, block)
+	})
+}
+func (c *CoalescingBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return coalesce(c, ctx, "GetCode", cacheKey("GetCode", addr, block), func(ctx context.Context) ([]byte, error) {
+		return c.Backend.GetCode(ctx, addr, block)
+	})
+}
+func (c *CoalescingBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return coalesce(c, ctx, "GetStorageAt", cacheKey("GetStorageAt", addr, key, block), func(ctx context.Context) ([]byte, error) {
+		return c.Backend.GetStorageAt(ctx, addr, key, block)
+	})
+}
+func (c *CoalescingBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return coalesce(c, ctx, "GetTransactionCount", cacheKey("GetTransactionCount", addr, block), func(ctx context.Context) (uint64, error) {
+		return c.Backend.GetTransactionCount(ctx, addr, block)
+	})
+}
+
+// Transaction operations
+func (c *CoalescingBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	key := cacheKey("Call", msg.From, msg.To, msg.Data, msg.Value, msg.Gas, msg.GasPrice, block)
+	return coalesce(c, ctx, "Call", key, func(ctx context.Context) ([]byte, error) {
+		return c.Backend.Call(ctx, msg, block)
+	})
+}
+func (c *CoalescingBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	key := cacheKey("EstimateGas", msg.From, msg.To, msg.Data, msg.Value, msg.Gas, msg.GasPrice)
+	return coalesce(c, ctx, "EstimateGas", key, func(ctx context.Context) (uint64, error) {
+		return c.Backend.EstimateGas(ctx, msg)
+	})
+}
+func (c *CoalescingBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return coalesce(c, ctx, "TxByHash", cacheKey("TxByHash", hash), func(ctx context.Context) (*Types.Transaction, error) {
+		return c.Backend.TxByHash(ctx, hash)
+	})
+}
+func (c *CoalescingBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return coalesce(c, ctx, "TxByBlockNumberAndIndex", cacheKey("TxByBlockNumberAndIndex", blockNum, index), func(ctx context.Context) (*Types.Transaction, error) {
+		return c.Backend.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (c *CoalescingBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return coalesce(c, ctx, "TxByBlockHashAndIndex", cacheKey("TxByBlockHashAndIndex", blockHash, index), func(ctx context.Context) (*Types.Transaction, error) {
+		return c.Backend.TxByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+func (c *CoalescingBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return coalesce(c, ctx, "ReceiptByHash", cacheKey("ReceiptByHash", hash), func(ctx context.Context) (*Types.Receipt, error) {
+		return c.Backend.ReceiptByHash(ctx, hash)
+	})
+}
+
+// Log operations
+func (c *CoalescingBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	key := cacheKey("GetLogs", q.FromBlock, q.ToBlock, q.BlockHash, q.Addresses, q.Topics)
+	return coalesce(c, ctx, "GetLogs", key, func(ctx context.Context) ([]*Types.Log, error) {
+		return c.Backend.GetLogs(ctx, q)
+	})
+}
+
+// Network operations
+func (c *CoalescingBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return coalesce(c, ctx, "PeerCount", "PeerCount", c.Backend.PeerCount)
+}
+func (c *CoalescingBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return coalesce(c, ctx, "Syncing", "Syncing", c.Backend.Syncing)
+}
@@ -0,0 +1,157 @@
+package Services_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// gatedBalances holds every Balance call until gate closes, then answers
+// with the address's first byte, or with errBalance for the addresses in failing
+type gatedBalances struct {
+	Types.Backend
+	gate    chan struct{}
+	failing map[byte]bool
+	calls   atomic.Int32
+}
+
+var errBalance = errors.New("balance unavailable")
+
+func (g *gatedBalances) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.gate:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if g.failing[addr[0]] {
+		return nil, errBalance
+	}
+	return big.NewInt(int64(addr[0])), nil
+}
+
+func addr(b byte) []byte {
+	a := make([]byte, 20)
+	a[0] = b
+	return a
+}
+
+func TestCoalesceIdenticalCalls(t *testing.T) {
+	inner := &gatedBalances{gate: make(chan struct{})}
+	metrics := Services.NewMetrics()
+	c := Services.NewCoalescingBackend(inner, metrics)
+
+	const n = 10
+	var wg sync.WaitGroup
+	got := make([]*big.Int, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i], errs[i] = c.Balance(context.Background(), addr(7), nil)
+		}()
+	}
+	// Hits are only counted on return, so give the callers time to join
+	waitFor(t, "the flight starts", func() bool { return inner.calls.Load() == 1 })
+	time.Sleep(50 * time.Millisecond)
+	close(inner.gate)
+	wg.Wait()
+
+	if calls := inner.calls.Load(); calls != 1 {
+		t.Errorf("backend called %d times for %d identical calls, want once", calls, n)
+	}
+	if hits := metrics.Get(`facade_coalesce_hits_total{method="Balance"}`); hits != n-1 {
+		t.Errorf("%d hits counted, want %d", hits, n-1)
+	}
+	for i := range n {
+		if errs[i] != nil || got[i].Int64() != 7 {
+			t.Errorf("caller %d got %v, %v", i, got[i], errs[i])
+		}
+	}
+
+	// Once the flight lands, the next call goes to the backend again
+	if _, err := c.Balance(context.Background(), addr(7), nil); err != nil || inner.calls.Load() != 2 {
+		t.Errorf("call after the flight: %v, %d backend calls", err, inner.calls.Load())
+	}
+}
+
+func TestCoalesceErrorsStayWithTheirKey(t *testing.T) {
+	inner := &gatedBalances{gate: make(chan struct{}), failing: map[byte]bool{1: true}}
+	c := Services.NewCoalescingBackend(inner, Services.NewMetrics())
+
+	type result struct {
+		v   *big.Int
+		err error
+	}
+	failed, ok := make(chan result), make(chan result)
+	go func() {
+		v, err := c.Balance(context.Background(), addr(1), nil)
+		failed <- result{v, err}
+	}()
+	go func() {
+		v, err := c.Balance(context.Background(), addr(2), nil)
+		ok <- result{v, err}
+	}()
+	waitFor(t, "both keys are in flight", func() bool { return inner.calls.Load() == 2 })
+	close(inner.gate)
+
+	if r := <-failed; !errors.Is(r.err, errBalance) {
+		t.Errorf("failing key returned %v, %v", r.v, r.err)
+	}
+	if r := <-ok; r.err != nil || r.v.Int64() != 2 {
+		t.Errorf("other key returned %v, %v, want 2", r.v, r.err)
+	}
+}
+
+func TestCoalesceCancellationStaysWithItsCaller(t *testing.T) {
+	inner := &gatedBalances{gate: make(chan struct{})}
+	metrics := Services.NewMetrics()
+	c := Services.NewCoalescingBackend(inner, metrics)
+
+	// One caller of key 1 gives up; the other caller of key 1 and the
+	// caller of key 2 still get their answers
+	cancelled, cancel := context.WithCancel(context.Background())
+	gaveUp := make(chan error)
+	go func() {
+		_, err := c.Balance(cancelled, addr(1), nil)
+		gaveUp <- err
+	}()
+	waitFor(t, "the first call is in flight", func() bool { return inner.calls.Load() == 1 })
+	sameKey, otherKey := make(chan *big.Int), make(chan *big.Int)
+	go func() {
+		v, _ := c.Balance(context.Background(), addr(1), nil)
+		sameKey <- v
+	}()
+	go func() {
+		v, _ := c.Balance(context.Background(), addr(2), nil)
+		otherKey <- v
+	}()
+	waitFor(t, "the second key is in flight", func() bool { return inner.calls.Load() == 2 })
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	if err := <-gaveUp; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller returned %v", err)
+	}
+	close(inner.gate)
+	if v := <-sameKey; v == nil || v.Int64() != 1 {
+		t.Errorf("remaining caller of the cancelled key got %v, want 1", v)
+	}
+	if v := <-otherKey; v == nil || v.Int64() != 2 {
+		t.Errorf("caller of the other key got %v, want 2", v)
+	}
+	if calls := inner.calls.Load(); calls != 2 {
+		t.Errorf("backend called %d times, want 2", calls)
+	}
+	if hits := metrics.Get(`facade_coalesce_hits_total{method="Balance"}`); hits != 1 {
+		t.Errorf("%d hits counted, want the caller that joined key 1", hits)
+	}
+}
@@ -0,0 +1,215 @@
+package Services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/jupitermetalabs/geth-facade/Types"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings understood by the HTTP server
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+// encoder compresses a complete response body
+type encoder interface {
+	encode(dst *bytes.Buffer, src []byte) error
+}
+
+type gzipEncoder struct{ pool sync.Pool }
+
+func (e *gzipEncoder) encode(dst *bytes.Buffer, src []byte) error {
+	w, _ := e.pool.Get().(*gzip.Writer)
+	if w == nil {
+		w = gzip.NewWriter(dst)
+	} else {
+		w.Reset(dst)
+	}
+	defer e.pool.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+type brotliEncoder struct{ pool sync.Pool }
+
+func (e *brotliEncoder) encode(dst *bytes.Buffer, src []byte) error {
+	w, _ := e.pool.Get().(*brotli.Writer)
+	if w == nil {
+		w = brotli.NewWriterLevel(dst, brotli.DefaultCompression)
+	} else {
+		w.Reset(dst)
+	}
+	defer e.pool.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+type zstdEncoder struct{ enc *zstd.Encoder }
+
+func (e *zstdEncoder) encode(dst *bytes.Buffer, src []byte) error {
+	// //conversions: EncodeAll is safe for concurrent use and avoids per-request writers
+	dst.Write(e.enc.EncodeAll(src, nil))
+	return nil
+}
+
+var (
+	encodersOnce sync.Once
+	encoders     map[string]encoder
+)
+
+func getEncoder(name string) encoder {
+	encodersOnce.Do(func() {
+		encoders = map[string]encoder{
+			EncodingGzip:   &gzipEncoder{},
+			EncodingBrotli: &brotliEncoder{},
+		}
+		if zenc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)); err == nil {
+			encoders[EncodingZstd] = &zstdEncoder{enc: zenc}
+		}
+	})
+	return encoders[name]
+}
+
+// negotiateEncoding picks the coding to use for a response from the client's
+// Accept-Encoding header. Client q-values take precedence; ties are broken by
+// the order of offered.
+func negotiateEncoding(header string, offered []string) string {
+	if header == "" || len(offered) == 0 {
+		return ""
+	}
+	weights := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range offered {
+		q, ok := weights[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ && getEncoder(enc) != nil {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// bufferedWriter captures the handler output so the compression decision can
+// be made once the full body size is known. JSON-RPC responses are rendered in
+// a single write, so buffering adds no latency.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int)              { w.status = code }
+func (w *bufferedWriter) WriteHeaderNow()                   {}
+func (w *bufferedWriter) Write(b []byte) (int, error)       { return w.buf.Write(b) }
+func (w *bufferedWriter) WriteString(s string) (int, error) { return w.buf.WriteString(s) }
+func (w *bufferedWriter) Written() bool                     { return w.status != 0 || w.buf.Len() > 0 }
+func (w *bufferedWriter) Size() int                         { return w.buf.Len() }
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// compressResponses returns middleware that compresses response bodies of at
+// least minSize bytes using the best coding accepted by the client.
+func compressResponses(offered []string, minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		c.Header("Vary", "Accept-Encoding")
+		enc := negotiateEncoding(c.GetHeader("Accept-Encoding"), offered)
+		if enc == "" {
+			c.Next()
+			return
+		}
+
+		orig := c.Writer
+		bw := &bufferedWriter{ResponseWriter: orig}
+		c.Writer = bw
+		c.Next()
+		c.Writer = orig
+
+		body := bw.buf.Bytes()
+		h := orig.Header()
+		if len(body) >= minSize && h.Get("Content-Encoding") == "" {
+			var out bytes.Buffer
+			if err := getEncoder(enc).encode(&out, body); err == nil {
+				h.Set("Content-Encoding", enc)
+				h.Del("Content-Length")
+				body = out.Bytes()
+			}
+		}
+		orig.WriteHeader(bw.Status())
+		_, _ = orig.Write(body)
+	}
+}
+
+// limitRequests returns middleware that bounds request bodies to maxSize
+// bytes (0 = unlimited) and, when acceptGzip is set, transparently decodes
+// gzip-encoded bodies. The limit applies to the decoded size to guard against
+// decompression bombs.
+func limitRequests(acceptGzip bool, maxSize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := c.Request.Body
+		if acceptGzip && strings.EqualFold(c.GetHeader("Content-Encoding"), EncodingGzip) {
+			zr, err := gzip.NewReader(body)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, Types.RespErr(nil, -32700, "Parse error"))
+				return
+			}
+			defer zr.Close()
+			body = io.NopCloser(zr)
+			c.Request.Header.Del("Content-Encoding")
+			c.Request.Header.Del("Content-Length")
+			c.Request.ContentLength = -1
+		}
+		if maxSize > 0 {
+			body = http.MaxBytesReader(c.Writer, body, maxSize)
+		}
+		c.Request.Body = body
+		c.Next()
+	}
+}
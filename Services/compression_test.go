@@ -0,0 +1,149 @@
+package Services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	offered := []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", EncodingGzip},
+		{"gzip, br", EncodingBrotli},                    // tie: server order
+		{"br;q=0.5, gzip;q=0.9", EncodingGzip},          // client q-values win
+		{"br;q=0, gzip", EncodingGzip},                  // q=0 refuses a coding
+		{"BR ; Q=0.8, zstd;q=0.8", EncodingBrotli},      // case-insensitive
+		{"*", EncodingBrotli},                           // wildcard
+		{"*;q=0.1, gzip;q=0.5", EncodingGzip},           // explicit beats wildcard
+		{"gzip;q=0, *", EncodingBrotli},                 // wildcard covers the rest
+		{"*;q=0", ""},                                   // nothing acceptable
+		{"deflate, compress", ""},                       // nothing offered
+		{"zstd;q=bogus, gzip;q=0.5", EncodingZstd},      // bad q-value reads as 1
+		{"  gzip ;  q=0.7  ,, br;q=0.6 ", EncodingGzip}, // stray whitespace and commas
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header, offered); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+	if got := negotiateEncoding("br, gzip", []string{EncodingGzip}); got != EncodingGzip {
+		t.Errorf("negotiateEncoding offered gzip only = %q, want gzip", got)
+	}
+}
+
+// compressionServer serves a memory chain with the given transport options
+func compressionServer(t *testing.T, opts HTTPOptions) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(NewHTTPServerWithOptions(NewHandlers(NewMemoryBackend(big.NewInt(7))), opts).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, url string, body io.Reader, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	// A bare transport leaves Accept-Encoding and decoding to the test
+	resp, err := (&http.Transport{DisableCompression: true}).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestCompressResponsesThreshold(t *testing.T) {
+	opts := DefaultHTTPOptions()
+	opts.MinCompressSize = 200
+	srv := compressionServer(t, opts)
+	accept := map[string]string{"Accept-Encoding": "gzip"}
+
+	// eth_chainId answers in well under 200 bytes
+	small := post(t, srv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`), accept)
+	if enc := small.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("small response encoded as %q", enc)
+	}
+	if body, _ := io.ReadAll(small.Body); !bytes.Contains(body, []byte(`"0x7"`)) {
+		t.Errorf("small response %s", body)
+	}
+	if vary := small.Header.Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("Vary = %q", vary)
+	}
+
+	// A full block is well over it
+	big := post(t, srv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x0",true]}`), accept)
+	if enc := big.Header.Get("Content-Encoding"); enc != EncodingGzip {
+		t.Fatalf("large response encoded as %q, want gzip", enc)
+	}
+	zr, err := gzip.NewReader(big.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); !bytes.Contains(body, []byte(`"stateRoot"`)) {
+		t.Errorf("decoded large response %s", body)
+	}
+
+	// Without Accept-Encoding nothing is compressed
+	plain := post(t, srv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x0",true]}`), nil)
+	if enc := plain.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("response without Accept-Encoding encoded as %q", enc)
+	}
+}
+
+func gzipped(t *testing.T, s string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return &buf
+}
+
+func TestGzipRequestBodies(t *testing.T) {
+	opts := DefaultHTTPOptions()
+	opts.MaxRequestBodySize = 1024
+	srv := compressionServer(t, opts)
+	gz := map[string]string{"Content-Encoding": "gzip"}
+
+	ok := post(t, srv.URL, gzipped(t, `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`), gz)
+	if body, _ := io.ReadAll(ok.Body); ok.StatusCode != http.StatusOK || !bytes.Contains(body, []byte(`"0x7"`)) {
+		t.Errorf("gzip request: %d %s", ok.StatusCode, body)
+	}
+
+	// Padding compresses to a few bytes but decodes past the limit
+	bomb := `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[],"pad":"` + strings.Repeat("a", 64*1024) + `"}`
+	if compressed := gzipped(t, bomb); compressed.Len() >= 1024 {
+		t.Fatalf("padding compressed to %d bytes", compressed.Len())
+	}
+	if resp := post(t, srv.URL, gzipped(t, bomb), gz); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("decoded body over the limit: status %d, want 400", resp.StatusCode)
+	}
+
+	if resp := post(t, srv.URL, strings.NewReader("not gzip"), gz); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("corrupt gzip body: status %d, want 400", resp.StatusCode)
+	}
+
+	// With decoding off, the gzip bytes reach the JSON decoder as they are
+	opts.AcceptGzipRequests = false
+	off := compressionServer(t, opts)
+	if resp := post(t, off.URL, gzipped(t, `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`), gz); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("gzip request with decoding off: status %d, want 400", resp.StatusCode)
+	}
+}
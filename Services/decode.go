@@ -0,0 +1,371 @@
+package Services
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Decoders for geth-style JSON-RPC payloads. These are the inverse of the
+// marshal* helpers in handlers.go and are used by backends that speak
+// JSON-RPC to an upstream node.
+// //conversions: Hex strings are converted back into the []byte/uint64 fields of Types
+
+// hexBytes decodes a 0x-prefixed hex string, tolerating odd-length quantities
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*b = nil
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		// Quantities such as value or gasPrice may be plain numbers in fixtures
+		n, ok := new(big.Int).SetString(string(data), 10)
+		if !ok {
+			return errors.New("invalid quantity: " + string(data))
+		}
+		*b = n.Bytes()
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	out, err := decodeHex(s)
+	if err != nil {
+		return err
+	}
+	*b = out
+	return nil
+}
+
+// hexUint decodes a 0x-prefixed hex quantity into a uint64. Plain JSON
+// numbers are accepted too, as produced by hand-written fixtures.
+type hexUint uint64
+
+func (u *hexUint) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*u = 0
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		return json.Unmarshal(data, (*uint64)(u))
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	n, err := parseHexUint64(s)
+	if err != nil {
+		return err
+	}
+	*u = hexUint(n)
+	return nil
+}
+
+// hexBig decodes a 0x-prefixed hex quantity (or a plain JSON number) into a big.Int
+type hexBig big.Int
+
+func (b *hexBig) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '"' {
+		n, ok := new(big.Int).SetString(string(data), 10)
+		if !ok {
+			return errors.New("invalid quantity: " + string(data))
+		}
+		*b = hexBig(*n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"), 16)
+	if !ok {
+		return errors.New("invalid hex quantity: " + s)
+	}
+	*b = hexBig(*n)
+	return nil
+}
+
+func (b *hexBig) toBig() *big.Int { return new(big.Int).Set((*big.Int)(b)) }
+
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+type rpcHeader struct {
+	Number        hexUint  `json:"number"`
+	Hash          hexBytes `json:"hash"`
+	ParentHash    hexBytes `json:"parentHash"`
+	StateRoot     hexBytes `json:"stateRoot"`
+	ReceiptsRoot  hexBytes `json:"receiptsRoot"`
+	LogsBloom     hexBytes `json:"logsBloom"`
+	Miner         hexBytes `json:"miner"`
+	GasLimit      hexUint  `json:"gasLimit"`
+	GasUsed       hexUint  `json:"gasUsed"`
+	Timestamp     hexUint  `json:"timestamp"`
+	MixHash       hexBytes `json:"mixHash"`
+	BaseFee       hexBytes `json:"baseFeePerGas"`
+	ExtraData     hexBytes `json:"extraData"`
+	BlobGasUsed   hexBytes `json:"blobGasUsed"`
+	ExcessBlobGas hexBytes `json:"excessBlobGas"`
+}
+
+type rpcBlock struct {
+	rpcHeader
+	Transactions []json.RawMessage `json:"transactions"`
+	Uncles       []hexBytes        `json:"uncles"`
+	Withdrawals  []struct {
+		Index          hexUint  `json:"index"`
+		ValidatorIndex hexUint  `json:"validatorIndex"`
+		Address        hexBytes `json:"address"`
+		Amount         hexUint  `json:"amount"`
+	} `json:"withdrawals"`
+}
+
+type rpcTx struct {
+	Hash                 hexBytes   `json:"hash"`
+	From                 hexBytes   `json:"from"`
+	To                   hexBytes   `json:"to"`
+	Input                hexBytes   `json:"input"`
+	Nonce                hexUint    `json:"nonce"`
+	Value                hexBytes   `json:"value"`
+	Gas                  hexUint    `json:"gas"`
+	GasPrice             hexBytes   `json:"gasPrice"`
+	Type                 hexUint    `json:"type"`
+	R                    hexBytes   `json:"r"`
+	S                    hexBytes   `json:"s"`
+	V                    hexUint    `json:"v"`
+	MaxFeePerGas         hexBytes   `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas hexBytes   `json:"maxPriorityFeePerGas"`
+	MaxFeePerBlobGas     hexBytes   `json:"maxFeePerBlobGas"`
+	BlobVersionedHashes  []hexBytes `json:"blobVersionedHashes"`
+	AccessList           []struct {
+		Address     hexBytes   `json:"address"`
+		StorageKeys []hexBytes `json:"storageKeys"`
+	} `json:"accessList"`
+}
+
+type rpcLog struct {
+	Address     hexBytes   `json:"address"`
+	Topics      []hexBytes `json:"topics"`
+	Data        hexBytes   `json:"data"`
+	BlockNumber hexUint    `json:"blockNumber"`
+	BlockHash   hexBytes   `json:"blockHash"`
+	TxIndex     hexUint    `json:"transactionIndex"`
+	TxHash      hexBytes   `json:"transactionHash"`
+	LogIndex    hexUint    `json:"logIndex"`
+	Removed     bool       `json:"removed"`
+}
+
+type rpcReceipt struct {
+	TxHash            hexBytes  `json:"transactionHash"`
+	Status            hexUint   `json:"status"`
+	CumulativeGasUsed hexUint   `json:"cumulativeGasUsed"`
+	GasUsed           hexUint   `json:"gasUsed"`
+	Logs              []*rpcLog `json:"logs"`
+	ContractAddress   hexBytes  `json:"contractAddress"`
+	Type              hexUint   `json:"type"`
+	BlockHash         hexBytes  `json:"blockHash"`
+	BlockNumber       hexUint   `json:"blockNumber"`
+	TransactionIndex  hexUint   `json:"transactionIndex"`
+}
+
+func (h *rpcHeader) toHeader() *Types.BlockHeader {
+	return &Types.BlockHeader{
+		ParentHash:          h.ParentHash,
+		StateRoot:           h.StateRoot,
+		ReceiptsRoot:        h.ReceiptsRoot,
+		LogsBloom:           h.LogsBloom,
+		Miner:               h.Miner,
+		Number:              uint64(h.Number),
+		GasLimit:            uint64(h.GasLimit),
+		GasUsed:             uint64(h.GasUsed),
+		Timestamp:           uint64(h.Timestamp),
+		MixHashOrPrevRandao: h.MixHash,
+		BaseFee:             h.BaseFee,
+		ExtraData:           h.ExtraData,
+		Hash:                h.Hash,
+	}
+}
+
+func (t *rpcTx) toTx() *Types.Transaction {
+	tx := &Types.Transaction{
+		Hash:                 t.Hash,
+		From:                 t.From,
+		To:                   t.To,
+		Input:                t.Input,
+		Nonce:                uint64(t.Nonce),
+		Value:                t.Value,
+		Gas:                  uint64(t.Gas),
+		GasPrice:             t.GasPrice,
+		Type:                 uint32(t.Type),
+		R:                    t.R,
+		S:                    t.S,
+		V:                    uint32(t.V),
+		MaxFeePerGas:         t.MaxFeePerGas,
+		MaxPriorityFeePerGas: t.MaxPriorityFeePerGas,
+		MaxFeePerBlobGas:     t.MaxFeePerBlobGas,
+	}
+	for _, h := range t.BlobVersionedHashes {
+		tx.BlobVersionedHashes = append(tx.BlobVersionedHashes, h)
+	}
+	if len(t.AccessList) > 0 {
+		tx.AccessList = &Types.AccessList{}
+		for _, at := range t.AccessList {
+			tuple := &Types.AccessTuple{Address: at.Address}
+			for _, k := range at.StorageKeys {
+				tuple.StorageKeys = append(tuple.StorageKeys, k)
+			}
+			tx.AccessList.AccessTuples = append(tx.AccessList.AccessTuples, tuple)
+		}
+	}
+	return tx
+}
+
+func (l *rpcLog) toLog() *Types.Log {
+	out := &Types.Log{
+		Address:     l.Address,
+		Data:        l.Data,
+		BlockNumber: uint64(l.BlockNumber),
+		BlockHash:   l.BlockHash,
+		TxIndex:     uint64(l.TxIndex),
+		TxHash:      l.TxHash,
+		LogIndex:    uint64(l.LogIndex),
+		Removed:     l.Removed,
+	}
+	for _, t := range l.Topics {
+		out.Topics = append(out.Topics, t)
+	}
+	return out
+}
+
+// decodeBlock converts an eth_getBlockBy* result. A JSON null yields a nil block.
+func decodeBlock(raw json.RawMessage) (*Types.Block, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var rb rpcBlock
+	if err := json.Unmarshal(raw, &rb); err != nil {
+		return nil, err
+	}
+	b := &Types.Block{
+		Header:        rb.toHeader(),
+		Transactions:  []*Types.Transaction{},
+		BlobGasUsed:   rb.BlobGasUsed,
+		ExcessBlobGas: rb.ExcessBlobGas,
+	}
+	for _, u := range rb.Uncles {
+		b.Ommers = append(b.Ommers, u)
+	}
+	for _, w := range rb.Withdrawals {
+		b.Withdrawals = append(b.Withdrawals, &Types.Withdrawal{
+			Index:          uint64(w.Index),
+			ValidatorIndex: uint64(w.ValidatorIndex),
+			Address:        w.Address,
+			Amount:         uint64(w.Amount),
+		})
+	}
+	for _, rawTx := range rb.Transactions {
+		// Transactions are either bare hashes or full objects depending on fullTx
+		var h hexBytes
+		if err := json.Unmarshal(rawTx, &h); err == nil {
+			b.Transactions = append(b.Transactions, &Types.Transaction{Hash: h})
+			continue
+		}
+		tx, err := decodeTx(rawTx)
+		if err != nil {
+			return nil, err
+		}
+		b.Transactions = append(b.Transactions, tx)
+	}
+	return b, nil
+}
+
+// decodeTx converts an eth_getTransactionBy* result. A JSON null yields nil.
+func decodeTx(raw json.RawMessage) (*Types.Transaction, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var rt rpcTx
+	if err := json.Unmarshal(raw, &rt); err != nil {
+		return nil, err
+	}
+	return rt.toTx(), nil
+}
+
+// decodeReceipt converts an eth_getTransactionReceipt result. A JSON null yields nil.
+func decodeReceipt(raw json.RawMessage) (*Types.Receipt, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var rr rpcReceipt
+	if err := json.Unmarshal(raw, &rr); err != nil {
+		return nil, err
+	}
+	r := &Types.Receipt{
+		TxHash:            rr.TxHash,
+		Status:            uint64(rr.Status),
+		CumulativeGasUsed: uint64(rr.CumulativeGasUsed),
+		GasUsed:           uint64(rr.GasUsed),
+		Logs:              []*Types.Log{},
+		ContractAddress:   rr.ContractAddress,
+		Type:              uint32(rr.Type),
+		BlockHash:         rr.BlockHash,
+		BlockNumber:       uint64(rr.BlockNumber),
+		TransactionIndex:  uint64(rr.TransactionIndex),
+	}
+	for _, l := range rr.Logs {
+		r.Logs = append(r.Logs, l.toLog())
+	}
+	return r, nil
+}
+
+// decodeLog converts a single log object
+func decodeLog(raw json.RawMessage) (*Types.Log, error) {
+	var rl rpcLog
+	if err := json.Unmarshal(raw, &rl); err != nil {
+		return nil, err
+	}
+	return rl.toLog(), nil
+}
+
+// decodeLogs converts an eth_getLogs result
+func decodeLogs(raw json.RawMessage) ([]*Types.Log, error) {
+	var rls []*rpcLog
+	if err := json.Unmarshal(raw, &rls); err != nil {
+		return nil, err
+	}
+	logs := make([]*Types.Log, len(rls))
+	for i, rl := range rls {
+		logs[i] = rl.toLog()
+	}
+	return logs, nil
+}
+
+// decodeSyncing converts an eth_syncing result. geth returns false when in
+// sync and a progress object otherwise.
+func decodeSyncing(raw json.RawMessage) (map[string]any, error) {
+	var syncing bool
+	if json.Unmarshal(raw, &syncing) == nil {
+		return map[string]any{"syncing": syncing}, nil
+	}
+	var progress map[string]any
+	if err := json.Unmarshal(raw, &progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
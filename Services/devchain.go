@@ -0,0 +1,429 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Dev-chain controls for the memory backend, served as evm_* and anvil_*
+// RPCs through Types.DevControl.
+var _ Types.DevControl = (*mem)(nil)
+
+// devSigner recovers senders as usual, except for transactions sent on behalf
+// of impersonated accounts, which carry a placeholder signature.
+type devSigner struct {
+	types.Signer
+	senders map[common.Hash]common.Address
+}
+
+func newDevSigner(chainID *big.Int) *devSigner {
+	return &devSigner{Signer: types.LatestSignerForChainID(chainID), senders: map[common.Hash]common.Address{}}
+}
+
+func (s *devSigner) Sender(tx *types.Transaction) (common.Address, error) {
+	if from, ok := s.senders[tx.Hash()]; ok {
+		return from, nil
+	}
+	return s.Signer.Sender(tx)
+}
+
+func (s *devSigner) impersonated(tx *types.Transaction) bool {
+	_, ok := s.senders[tx.Hash()]
+	return ok
+}
+
+// memSnapshot is everything evm_revert restores
+type memSnapshot struct {
+	head            uint64
+	roots           []common.Hash
+	timeOffset      int64
+	nextTimestamp   uint64
+	pending         *state.StateDB
+	pendingHeader   *types.Header
+	pendingTxs      []*types.Transaction
+	pendingReceipts []*types.Receipt
+	pendingGas      uint64
+}
+
+// nextTimeLocked picks the next block's timestamp: the one set through
+// evm_setNextBlockTimestamp, else the clock shifted by evm_increaseTime.
+// Blocks are always at least a second apart.
+func (m *mem) nextTimeLocked(parent *types.Block) uint64 {
+	now := time.Now().Unix()
+	if ts := m.nextTimestamp; ts != 0 {
+		m.nextTimestamp = 0
+		// Time carries on from the chosen timestamp
+		m.timeOffset = int64(ts) - now
+		return ts
+	}
+	return max(uint64(now+m.timeOffset), parent.Time()+1)
+}
+
+// retimeLocked drops an empty pending block so its timestamp is picked again
+func (m *mem) retimeLocked() {
+	if len(m.pendingTxs) == 0 {
+		m.pendingHeader = nil
+	}
+}
+
+// Mining and time
+func (m *mem) Mine(ctx context.Context, blocks uint64, timestamp uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if timestamp != 0 {
+		if err := m.setNextTimestampLocked(timestamp); err != nil {
+			return err
+		}
+	}
+	for i := uint64(0); i < max(blocks, 1); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		m.mineLocked()
+	}
+	return nil
+}
+func (m *mem) IncreaseTime(ctx context.Context, seconds uint64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeOffset += int64(seconds)
+	m.retimeLocked()
+	return m.timeOffset, nil
+}
+func (m *mem) SetNextBlockTimestamp(ctx context.Context, timestamp uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setNextTimestampLocked(timestamp)
+}
+
+func (m *mem) setNextTimestampLocked(timestamp uint64) error {
+	if parent := m.head().Time(); timestamp <= parent {
+		return fmt.Errorf("timestamp %d is lower than or equal to previous block's timestamp %d", timestamp, parent)
+	}
+	if m.pendingHeader != nil && len(m.pendingTxs) > 0 {
+		// Pending transactions already ran against the open block's time
+		return errors.New("cannot change the timestamp of a block with pending transactions")
+	}
+	m.pendingHeader = nil
+	m.nextTimestamp = timestamp
+	return nil
+}
+
+// Snapshots
+func (m *mem) Snapshot(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastSnapshot++
+	snap := &memSnapshot{
+		head:            m.head().NumberU64(),
+		roots:           append([]common.Hash(nil), m.roots...),
+		timeOffset:      m.timeOffset,
+		nextTimestamp:   m.nextTimestamp,
+		pending:         m.pending.Copy(),
+		pendingTxs:      append([]*types.Transaction(nil), m.pendingTxs...),
+		pendingReceipts: append([]*types.Receipt(nil), m.pendingReceipts...),
+		pendingGas:      m.pendingGas,
+	}
+	if m.pendingHeader != nil {
+		snap.pendingHeader = types.CopyHeader(m.pendingHeader)
+	}
+	m.snapshots[m.lastSnapshot] = snap
+	return m.lastSnapshot, nil
+}
+func (m *mem) Revert(ctx context.Context, id uint64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap, ok := m.snapshots[id]
+	if !ok {
+		return false, nil
+	}
+	// A snapshot is used up by reverting to it, and later ones no longer apply
+	for n := range m.snapshots {
+		if n >= id {
+			delete(m.snapshots, n)
+		}
+	}
+	m.truncateLocked(snap.head)
+	m.roots = append([]common.Hash(nil), snap.roots...)
+	m.timeOffset, m.nextTimestamp = snap.timeOffset, snap.nextTimestamp
+	m.pending = snap.pending.Copy()
+	m.pendingHeader = nil
+	if snap.pendingHeader != nil {
+		m.pendingHeader = types.CopyHeader(snap.pendingHeader)
+	}
+	m.pendingTxs = append([]*types.Transaction(nil), snap.pendingTxs...)
+	m.pendingReceipts = append([]*types.Receipt(nil), snap.pendingReceipts...)
+	m.pendingGas = snap.pendingGas
+	return true, nil
+}
+
+// truncateLocked drops every block above head. The block slices are copied
+// rather than resliced, since in-flight calls may still read the old ones.
+func (m *mem) truncateLocked(head uint64) {
+	for n := head + 1; n < uint64(len(m.blocks)); n++ {
+		for _, tx := range m.blocks[n].Transactions() {
+			delete(m.txIndex, tx.Hash())
+			delete(m.receipts, tx.Hash())
+		}
+		delete(m.byHash, m.blocks[n].Hash())
+		delete(m.logs, n)
+	}
+	m.blocks = append([]*types.Block(nil), m.blocks[:head+1]...)
+	m.chain = append([]*Types.Block(nil), m.chain[:head+1]...)
+	m.roots = append([]common.Hash(nil), m.roots[:head+1]...)
+	m.logIndex.Truncate(head)
+}
+
+// State overrides
+func (m *mem) SetBalance(ctx context.Context, addr []byte, balance *big.Int) error {
+	amount, overflow := uint256.FromBig(balance)
+	if overflow {
+		return errors.New("balance exceeds 256 bits")
+	}
+	return m.override(func(st *state.StateDB) {
+		st.SetBalance(common.BytesToAddress(addr), amount, tracing.BalanceChangeUnspecified)
+	})
+}
+func (m *mem) SetCode(ctx context.Context, addr []byte, code []byte) error {
+	return m.override(func(st *state.StateDB) {
+		st.SetCode(common.BytesToAddress(addr), code)
+	})
+}
+func (m *mem) SetStorageAt(ctx context.Context, addr []byte, slot []byte, value []byte) error {
+	return m.override(func(st *state.StateDB) {
+		st.SetState(common.BytesToAddress(addr), common.BytesToHash(slot), common.BytesToHash(value))
+	})
+}
+func (m *mem) SetNonce(ctx context.Context, addr []byte, nonce uint64) error {
+	return m.override(func(st *state.StateDB) {
+		st.SetNonce(common.BytesToAddress(addr), nonce, tracing.NonceChangeUnspecified)
+	})
+}
+
+// override applies fn to the latest state, re-rooting the head block as Anvil
+// does, and to the pending state so the next block keeps the change.
+func (m *mem) override(fn func(st *state.StateDB)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.head().NumberU64()
+	st, err := state.New(m.roots[n], m.statedb)
+	if err != nil {
+		return err
+	}
+	fn(st)
+	root, err := st.Commit(n, true, false)
+	if err != nil {
+		return err
+	}
+	m.roots[n] = root
+	if len(m.pendingTxs) == 0 {
+		m.pending, err = state.New(root, m.statedb)
+		return err
+	}
+	fn(m.pending)
+	m.pending.Finalise(true)
+	return nil
+}
+
+// Accounts
+func (m *mem) Accounts(ctx context.Context) ([][]byte, error) {
+	out := make([][]byte, 0, len(DevKeys))
+	for _, k := range DevKeys {
+		if key, err := crypto.HexToECDSA(k); err == nil {
+			out = append(out, crypto.PubkeyToAddress(key.PublicKey).Bytes())
+		}
+	}
+	return out, nil
+}
+func (m *mem) ImpersonateAccount(ctx context.Context, addr []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.impersonated[common.BytesToAddress(addr)] = true
+	return nil
+}
+func (m *mem) StopImpersonatingAccount(ctx context.Context, addr []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.impersonated, common.BytesToAddress(addr))
+	return nil
+}
+
+// SendTransaction builds a transaction from msg at the sender's pending nonce,
+// estimating gas if none is given, and signs it with a dev key or, for an
+// impersonated sender, a placeholder signature.
+func (m *mem) SendTransaction(ctx context.Context, msg Types.CallMsg) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tx, err := m.signLocked(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.addTxLocked(tx); err != nil {
+		return nil, err
+	}
+	if m.cfg.BlockTime == 0 {
+		m.mineLocked()
+	}
+	return tx.Hash().Bytes(), nil
+}
+
+// signLocked builds and signs the transaction SendTransaction would send
+func (m *mem) signLocked(ctx context.Context, msg Types.CallMsg) (*types.Transaction, error) {
+	from := common.HexToAddress(msg.From)
+	key, signable := m.keys[from]
+	if !signable && !m.impersonated[from] {
+		return nil, fmt.Errorf("no signer available for %s", from)
+	}
+
+	header := m.pendingHeaderLocked()
+	gas := uint64(0)
+	if msg.Gas != nil && msg.Gas.IsUint64() {
+		gas = msg.Gas.Uint64()
+	}
+	if gas == 0 {
+		estimate, err := m.envLocked(m.pending, header).estimateGas(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		gas = estimate
+	}
+	var to *common.Address
+	if msg.To != "" {
+		addr := common.HexToAddress(msg.To)
+		to = &addr
+	}
+	value := new(big.Int)
+	if msg.Value != nil {
+		value.Set(msg.Value)
+	}
+	nonce := m.pending.GetNonce(from)
+	var inner types.TxData
+	if msg.GasPrice != nil {
+		inner = &types.LegacyTx{Nonce: nonce, GasPrice: msg.GasPrice, Gas: gas, To: to, Value: value, Data: msg.Data}
+	} else {
+		tip := big.NewInt(1_000_000_000)
+		inner = &types.DynamicFeeTx{
+			ChainID:   m.chainID,
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: new(big.Int).Add(header.BaseFee, tip),
+			Gas:       gas,
+			To:        to,
+			Value:     value,
+			Data:      msg.Data,
+		}
+	}
+
+	var tx *types.Transaction
+	if signable {
+		var err error
+		if tx, err = types.SignNewTx(key, m.signer.Signer, inner); err != nil {
+			return nil, err
+		}
+	} else {
+		// The placeholder signature embeds the sender so hashes stay unique
+		sig := make([]byte, 65)
+		copy(sig[12:32], from.Bytes())
+		sig[63] = 1
+		var err error
+		if tx, err = types.NewTx(inner).WithSignature(m.signer.Signer, sig); err != nil {
+			return nil, err
+		}
+		m.signer.senders[tx.Hash()] = from
+	}
+	return tx, nil
+}
+
+// Reorgs
+func (m *mem) Reorg(ctx context.Context, depth uint64, txs []Types.ReorgTx) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	head := m.head().NumberU64()
+	if depth == 0 || depth > head {
+		return fmt.Errorf("reorg depth must be between 1 and %d", head)
+	}
+	raws := make([]*types.Transaction, len(txs))
+	for i, rt := range txs {
+		if rt.Offset >= depth {
+			return fmt.Errorf("transaction %d: offset %d is past the %d new blocks", i, rt.Offset, depth)
+		}
+		switch {
+		case rt.Raw != nil:
+			raws[i] = new(types.Transaction)
+			if err := raws[i].UnmarshalBinary(rt.Raw); err != nil {
+				return fmt.Errorf("transaction %d: invalid raw transaction: %w", i, err)
+			}
+		case rt.Msg == nil:
+			return fmt.Errorf("transaction %d: neither raw nor message", i)
+		}
+	}
+
+	// Orphaned logs go out as removed, oldest first as geth sends them
+	fork := head - depth
+	var removed []*Types.Log
+	for n := fork + 1; n <= head; n++ {
+		for _, l := range m.logs[n] {
+			r := *l
+			r.Removed = true
+			removed = append(removed, &r)
+		}
+	}
+	queued := m.pendingTxs
+	m.truncateLocked(fork)
+	// Snapshots above the fork point describe the orphaned branch
+	for id, snap := range m.snapshots {
+		if snap.head > fork {
+			delete(m.snapshots, id)
+		}
+	}
+	m.pending, _ = state.New(m.roots[fork], m.statedb)
+	m.pendingHeader = nil
+	m.pendingTxs, m.pendingReceipts, m.pendingGas = nil, nil, 0
+	for _, l := range removed {
+		m.logFeed.send(l)
+	}
+
+	// The new branch keeps the chain's height even if a transaction fails;
+	// the first failure is reported once it is mined
+	m.reorgs++
+	var firstErr error
+	for offset := uint64(0); offset < depth; offset++ {
+		// Marked so an empty block never hashes like the one it replaces
+		m.pendingHeaderLocked().Extra = fmt.Appendf(nil, "geth-facade dev reorg %d", m.reorgs)
+		for i, rt := range txs {
+			if rt.Offset != offset {
+				continue
+			}
+			tx, err := raws[i], error(nil)
+			if tx == nil {
+				tx, err = m.signLocked(ctx, *rt.Msg)
+			}
+			if err == nil {
+				err = m.addTxLocked(tx)
+			}
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("transaction %d: %w", i, err)
+			}
+		}
+		m.mineLocked()
+	}
+	// Work pending on the old head moves onto the new one
+	for _, tx := range queued {
+		if err := m.addTxLocked(tx); err != nil {
+			log.Printf("⚠️ Dropping pending transaction %s after reorg: %v", tx.Hash(), err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,322 @@
+package Services
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// jsonNull is an explicit null result; a nil result would be omitted from the response
+var jsonNull = json.RawMessage("null")
+
+// maxDevMine caps the blocks one anvil_mine or hardhat_mine call may mine
+const maxDevMine = 100_000
+
+// isDevMethod reports whether a method belongs to the dev-chain control
+// namespaces. The eth_ methods listed here need a local signer, which only
+// dev backends have.
+func isDevMethod(method string) bool {
+	switch method {
+	case "eth_accounts", "eth_sendTransaction":
+		return true
+	}
+	return strings.HasPrefix(method, "evm_") || strings.HasPrefix(method, "anvil_") || strings.HasPrefix(method, "hardhat_")
+}
+
+// handleDev serves the evm_*, anvil_* and hardhat_* methods used by Hardhat
+// and Foundry test tooling. Results follow Anvil's shapes.
+func (h *Handlers) handleDev(ctx context.Context, ctl Types.DevControl, req Types.Request) (Types.Response, error) {
+	switch req.Method {
+	case "evm_mine":
+		// params: [timestamp?]
+		var ts uint64
+		if len(req.Params) > 0 && req.Params[0] != nil {
+			n, err := devQuantity(req.Params[0])
+			if err != nil {
+				return invalidParams(req, err.Error())
+			}
+			ts = n.Uint64()
+		}
+		if err := ctl.Mine(ctx, 1, ts); err != nil {
+			return finish(req, nil, err)
+		}
+		return finish(req, "0x0", nil)
+
+	case "anvil_mine", "hardhat_mine":
+		// params: [count?, interval?]
+		count, interval := uint64(1), uint64(0)
+		if len(req.Params) > 0 && req.Params[0] != nil {
+			n, err := devQuantity(req.Params[0])
+			if err != nil {
+				return invalidParams(req, err.Error())
+			}
+			if n.Cmp(big.NewInt(maxDevMine)) > 0 {
+				return invalidParams(req, fmt.Sprintf("cannot mine more than %d blocks at once", maxDevMine))
+			}
+			count = n.Uint64()
+		}
+		if len(req.Params) > 1 && req.Params[1] != nil {
+			n, err := devQuantity(req.Params[1])
+			if err != nil {
+				return invalidParams(req, err.Error())
+			}
+			interval = n.Uint64()
+		}
+		if interval == 0 {
+			return finish(req, jsonNull, ctl.Mine(ctx, count, 0))
+		}
+		head, err := h.be.BlockByNumber(ctx, nil, false)
+		if err != nil || head == nil {
+			return finish(req, nil, errors.New("header not found"))
+		}
+		ts := head.Header.Timestamp
+		for i := uint64(0); i < count; i++ {
+			ts += interval
+			if err := ctl.Mine(ctx, 1, ts); err != nil {
+				return finish(req, nil, err)
+			}
+		}
+		return finish(req, jsonNull, nil)
+
+	case "evm_increaseTime", "anvil_increaseTime":
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing seconds")
+		}
+		n, err := devQuantity(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		total, err := ctl.IncreaseTime(ctx, n.Uint64())
+		return finish(req, total, err)
+
+	case "evm_setNextBlockTimestamp", "anvil_setNextBlockTimestamp":
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing timestamp")
+		}
+		n, err := devQuantity(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		return finish(req, jsonNull, ctl.SetNextBlockTimestamp(ctx, n.Uint64()))
+
+	case "evm_snapshot", "anvil_snapshot":
+		id, err := ctl.Snapshot(ctx)
+		return finish(req, "0x"+new(big.Int).SetUint64(id).Text(16), err)
+
+	case "evm_revert", "anvil_revert":
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing snapshot id")
+		}
+		n, err := devQuantity(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		ok, err := ctl.Revert(ctx, n.Uint64())
+		return finish(req, ok, err)
+
+	case "anvil_setBalance", "hardhat_setBalance":
+		if len(req.Params) < 2 {
+			return invalidParams(req, "need address and balance")
+		}
+		addr, err := devAddress(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		bal, err := devQuantity(req.Params[1])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		return finish(req, jsonNull, ctl.SetBalance(ctx, addr, bal))
+
+	case "anvil_setCode", "hardhat_setCode":
+		if len(req.Params) < 2 {
+			return invalidParams(req, "need address and code")
+		}
+		addr, err := devAddress(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		code, err := decodeHex(mustString(req.Params[1]))
+		if err != nil {
+			return invalidParams(req, "invalid code")
+		}
+		return finish(req, jsonNull, ctl.SetCode(ctx, addr, code))
+
+	case "anvil_setStorageAt", "hardhat_setStorageAt":
+		if len(req.Params) < 3 {
+			return invalidParams(req, "need address, slot and value")
+		}
+		addr, err := devAddress(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		slot, err := devQuantity(req.Params[1])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		value, err := devQuantity(req.Params[2])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		if err := ctl.SetStorageAt(ctx, addr, leftPad32(slot.Bytes()), leftPad32(value.Bytes())); err != nil {
+			return finish(req, nil, err)
+		}
+		return finish(req, true, nil)
+
+	case "anvil_setNonce", "hardhat_setNonce":
+		if len(req.Params) < 2 {
+			return invalidParams(req, "need address and nonce")
+		}
+		addr, err := devAddress(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		nonce, err := devQuantity(req.Params[1])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		return finish(req, jsonNull, ctl.SetNonce(ctx, addr, nonce.Uint64()))
+
+	case "anvil_impersonateAccount", "hardhat_impersonateAccount",
+		"anvil_stopImpersonatingAccount", "hardhat_stopImpersonatingAccount":
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing address")
+		}
+		addr, err := devAddress(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		if strings.HasSuffix(req.Method, "_impersonateAccount") {
+			err = ctl.ImpersonateAccount(ctx, addr)
+		} else {
+			err = ctl.StopImpersonatingAccount(ctx, addr)
+		}
+		if err != nil {
+			return finish(req, nil, err)
+		}
+		if strings.HasPrefix(req.Method, "hardhat_") {
+			return finish(req, true, nil)
+		}
+		return finish(req, jsonNull, nil)
+
+	case "eth_accounts":
+		accounts, err := ctl.Accounts(ctx)
+		if err != nil {
+			return finish(req, nil, err)
+		}
+		out := make([]string, 0, len(accounts))
+		for _, a := range accounts {
+			out = append(out, "0x"+hex.EncodeToString(a))
+		}
+		return finish(req, out, nil)
+
+	case "eth_sendTransaction":
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing tx object")
+		}
+		msg, err := toCallMsg(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		if obj, ok := req.Params[0].(map[string]any); ok && msg.Data == nil {
+			// Some tooling sends calldata as "input" instead of "data"
+			if msg.Data, err = decodeHex(mustString(obj["input"])); err != nil {
+				return invalidParams(req, "invalid input")
+			}
+		}
+		txh, err := ctl.SendTransaction(ctx, msg)
+		if err != nil {
+			return finish(req, nil, err)
+		}
+		return finish(req, "0x"+hex.EncodeToString(txh), nil)
+
+	case "anvil_reorg":
+		// params: [depth, [[tx, blockOffset], ...]?]; tx is raw hex or a tx object
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing depth")
+		}
+		depth, err := devQuantity(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		var txs []Types.ReorgTx
+		if len(req.Params) > 1 && req.Params[1] != nil {
+			list, ok := req.Params[1].([]any)
+			if !ok {
+				return invalidParams(req, "transactions must be a list of [tx, blockOffset] pairs")
+			}
+			for _, entry := range list {
+				pair, ok := entry.([]any)
+				if !ok || len(pair) != 2 {
+					return invalidParams(req, "transactions must be a list of [tx, blockOffset] pairs")
+				}
+				offset, err := devQuantity(pair[1])
+				if err != nil {
+					return invalidParams(req, err.Error())
+				}
+				rt := Types.ReorgTx{Offset: offset.Uint64()}
+				if raw, isRaw := pair[0].(string); isRaw {
+					if rt.Raw, err = decodeHex(raw); err != nil {
+						return invalidParams(req, "invalid raw transaction")
+					}
+				} else {
+					msg, err := toCallMsg(pair[0])
+					if err != nil {
+						return invalidParams(req, err.Error())
+					}
+					if obj, ok := pair[0].(map[string]any); ok && msg.Data == nil {
+						if msg.Data, err = decodeHex(mustString(obj["input"])); err != nil {
+							return invalidParams(req, "invalid input")
+						}
+					}
+					rt.Msg = &msg
+				}
+				txs = append(txs, rt)
+			}
+		}
+		return finish(req, jsonNull, ctl.Reorg(ctx, depth.Uint64(), txs))
+
+	default:
+		return Types.RespErr(req.ID, -32601, "Method not found"), nil
+	}
+}
+
+// devAddress parses an address parameter, checking any EIP-55 checksum.
+func devAddress(v any) ([]byte, error) {
+	a, err := Types.ParseAddress(mustString(v))
+	if err != nil {
+		return nil, err
+	}
+	return a.Bytes(), nil
+}
+
+// devQuantity parses a quantity given as a hex string, a decimal string or a
+// JSON number, since test tooling sends all three.
+func devQuantity(v any) (*big.Int, error) {
+	switch q := v.(type) {
+	case float64:
+		if q < 0 {
+			return nil, fmt.Errorf("invalid quantity %v", q)
+		}
+		n, _ := new(big.Float).SetFloat64(q).Int(nil)
+		return n, nil
+	case string:
+		n, ok := new(big.Int), false
+		if strings.HasPrefix(q, "0x") || strings.HasPrefix(q, "0X") {
+			_, ok = n.SetString(q[2:], 16)
+		} else {
+			_, ok = n.SetString(q, 10)
+		}
+		if !ok || n.Sign() < 0 {
+			return nil, fmt.Errorf("invalid quantity %q", q)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("invalid quantity %v", v)
+}
@@ -0,0 +1,216 @@
+package Services_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// devRPC serves requests against a fresh automining memory chain
+type devRPC struct {
+	t *testing.T
+	h *Services.Handlers
+}
+
+func newDevRPC(t *testing.T) *devRPC {
+	be := Services.NewMemoryBackendWithConfig(Services.MemoryConfig{})
+	t.Cleanup(be.(interface{ Close() }).Close)
+	return &devRPC{t: t, h: Services.NewHandlers(be)}
+}
+
+// call returns the result as decoded JSON, or the JSON-RPC error
+func (d *devRPC) call(method string, params ...any) (any, *Types.Error) {
+	d.t.Helper()
+	if params == nil {
+		params = []any{}
+	}
+	resp, err := d.h.Handle(context.Background(), Types.Request{Jsonrpc: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		d.t.Fatalf("%s: %v", method, err)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	enc, err := json.Marshal(resp.Result)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	var out any
+	if err := json.Unmarshal(enc, &out); err != nil {
+		d.t.Fatal(err)
+	}
+	return out, nil
+}
+
+// must is call for requests that have to succeed
+func (d *devRPC) must(method string, params ...any) any {
+	d.t.Helper()
+	out, rpcErr := d.call(method, params...)
+	if rpcErr != nil {
+		d.t.Fatalf("%s: %d %s", method, rpcErr.Code, rpcErr.Message)
+	}
+	return out
+}
+
+func (d *devRPC) block(tag string) map[string]any {
+	d.t.Helper()
+	b, _ := d.must("eth_getBlockByNumber", tag, false).(map[string]any)
+	if b == nil {
+		d.t.Fatalf("block %s not found", tag)
+	}
+	return b
+}
+
+const devTarget = "0x00000000000000000000000000000000000000aa"
+
+func TestDevSnapshotRevert(t *testing.T) {
+	d := newDevRPC(t)
+	head := d.must("eth_blockNumber")
+
+	id := d.must("evm_snapshot")
+	d.must("anvil_setBalance", devTarget, "0x64")
+	d.must("anvil_mine", "0x3")
+	if got := d.must("eth_getBalance", devTarget, "latest"); got != "0x64" {
+		t.Fatalf("balance before revert = %v", got)
+	}
+
+	if ok := d.must("evm_revert", id); ok != true {
+		t.Fatalf("evm_revert(%v) = %v, want true", id, ok)
+	}
+	if got := d.must("eth_blockNumber"); got != head {
+		t.Errorf("head after revert = %v, want %v", got, head)
+	}
+	if got := d.must("eth_getBalance", devTarget, "latest"); got != "0x0" {
+		t.Errorf("balance after revert = %v, want 0x0", got)
+	}
+	// A snapshot is used up by reverting to it
+	if ok := d.must("anvil_revert", id); ok != false {
+		t.Errorf("second revert to %v = %v, want false", id, ok)
+	}
+}
+
+func TestDevSetters(t *testing.T) {
+	for _, ns := range []string{"anvil", "hardhat"} {
+		t.Run(ns, func(t *testing.T) {
+			d := newDevRPC(t)
+			d.must(ns+"_setBalance", devTarget, "1000")
+			d.must(ns+"_setCode", devTarget, "0x6080")
+			if ok := d.must(ns+"_setStorageAt", devTarget, "0x1", "0x2a"); ok != true {
+				t.Errorf("%s_setStorageAt = %v, want true", ns, ok)
+			}
+			d.must(ns+"_setNonce", devTarget, float64(7))
+
+			for _, c := range []struct {
+				method string
+				params []any
+				want   string
+			}{
+				{"eth_getBalance", []any{devTarget, "latest"}, "0x3e8"},
+				{"eth_getCode", []any{devTarget, "latest"}, "0x6080"},
+				{"eth_getStorageAt", []any{devTarget, "0x1", "latest"}, "0x" + strings.Repeat("0", 62) + "2a"},
+				{"eth_getTransactionCount", []any{devTarget, "latest"}, "0x7"},
+			} {
+				if got := d.must(c.method, c.params...); got != c.want {
+					t.Errorf("%s = %v, want %s", c.method, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDevImpersonation(t *testing.T) {
+	d := newDevRPC(t)
+	send := func() *Types.Error {
+		_, rpcErr := d.call("eth_sendTransaction", map[string]any{"from": devTarget, "to": "0x00000000000000000000000000000000000000bb", "value": "0x1"})
+		return rpcErr
+	}
+	d.must("anvil_setBalance", devTarget, "0xde0b6b3a7640000")
+	if send() == nil {
+		t.Fatal("sent from an account without a key before impersonating it")
+	}
+
+	if got := d.must("anvil_impersonateAccount", devTarget); got != nil {
+		t.Errorf("anvil_impersonateAccount = %v, want null", got)
+	}
+	if rpcErr := send(); rpcErr != nil {
+		t.Fatalf("send while impersonating: %s", rpcErr.Message)
+	}
+	if got := d.must("eth_getBalance", "0x00000000000000000000000000000000000000bb", "latest"); got != "0x1" {
+		t.Errorf("recipient balance = %v, want 0x1", got)
+	}
+
+	if got := d.must("hardhat_stopImpersonatingAccount", devTarget); got != true {
+		t.Errorf("hardhat_stopImpersonatingAccount = %v, want true", got)
+	}
+	if send() == nil {
+		t.Error("sent from an account after impersonation stopped")
+	}
+}
+
+func TestDevMine(t *testing.T) {
+	d := newDevRPC(t)
+	start := d.block("latest")
+
+	if got := d.must("anvil_mine", "0x3", "0xa"); got != nil {
+		t.Errorf("anvil_mine = %v, want null", got)
+	}
+	head := d.block("latest")
+	if got, want := head["number"], fmt.Sprintf("0x%x", mustQuantity(t, start["number"])+3); got != want {
+		t.Errorf("head after mining 3 = %v, want %s", got, want)
+	}
+	if got, want := mustQuantity(t, head["timestamp"]), mustQuantity(t, start["timestamp"])+30; got != want {
+		t.Errorf("timestamp after 3 blocks 10s apart = %d, want %d", got, want)
+	}
+
+	d.must("hardhat_mine", float64(2))
+	d.must("evm_mine")
+	if got, want := mustQuantity(t, d.must("eth_blockNumber")), mustQuantity(t, head["number"])+3; got != want {
+		t.Errorf("head = %d, want %d", got, want)
+	}
+
+	// Mining is capped per call
+	if _, rpcErr := d.call("anvil_mine", "100000000000"); rpcErr == nil || rpcErr.Code != -32602 {
+		t.Errorf("anvil_mine over the cap returned %+v, want -32602", rpcErr)
+	}
+}
+
+func TestDevInvalidParams(t *testing.T) {
+	d := newDevRPC(t)
+	for _, c := range []struct {
+		method string
+		params []any
+	}{
+		{"anvil_setBalance", []any{"0x1234", "0x1"}},
+		{"anvil_setBalance", []any{"0x5aAeb6053f3E94C9b9A09f33669435E7Ef1BeAed", "0x1"}}, // bad checksum
+		{"hardhat_setCode", []any{42, "0x00"}},
+		{"anvil_setCode", []any{devTarget, "0xzz"}},
+		{"anvil_setStorageAt", []any{"aa", "0x0", "0x0"}},
+		{"anvil_setNonce", []any{devTarget + "00", 1}},
+		{"anvil_impersonateAccount", []any{"0x"}},
+		{"anvil_mine", []any{"-1"}},
+		{"evm_revert", []any{"0xzz"}},
+		{"eth_sendTransaction", []any{map[string]any{"from": devTarget, "to": "0x12"}}},
+		{"eth_sendTransaction", []any{map[string]any{"from": devTarget, "input": "0xzz"}}},
+		{"eth_sendTransaction", []any{}},
+	} {
+		if _, rpcErr := d.call(c.method, c.params...); rpcErr == nil || rpcErr.Code != -32602 {
+			t.Errorf("%s%v returned %+v, want -32602", c.method, c.params, rpcErr)
+		}
+	}
+}
+
+// mustQuantity reads a hex quantity from a decoded result
+func mustQuantity(t *testing.T, v any) uint64 {
+	t.Helper()
+	s, _ := v.(string)
+	var n uint64
+	if _, err := fmt.Sscanf(s, "0x%x", &n); err != nil {
+		t.Fatalf("quantity %v: %v", v, err)
+	}
+	return n
+}
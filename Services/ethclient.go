@@ -0,0 +1,346 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// EthClientBackend implements Types.Backend on top of a go-ethereum
+// *ethclient.Client, for services that already hold one. Results are
+// converted from go-ethereum's core types, so header hashes are recomputed
+// from the header fields rather than taken from the node.
+//
+// Subscriptions need a client dialled over WebSocket or IPC; over HTTP the
+// backend reports no subscription capability.
+type EthClientBackend struct {
+	client *ethclient.Client
+
+	mu     sync.Mutex
+	signer types.Signer
+}
+
+// NewEthClientBackend wraps client. The caller keeps ownership of the client.
+func NewEthClientBackend(client *ethclient.Client) *EthClientBackend {
+	return &EthClientBackend{client: client}
+}
+
+// signerFor returns the signer used to recover transaction senders, loading
+// the chain ID on first use
+func (e *EthClientBackend) signerFor(ctx context.Context) (types.Signer, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.signer == nil {
+		id, err := e.client.ChainID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.signer = types.LatestSignerForChainID(id)
+	}
+	return e.signer, nil
+}
+
+// ethBlock maps the facade's block number, where nil is latest, to ethclient's
+func ethBlock(n *big.Int) *big.Int {
+	if n == nil || n.Sign() < 0 {
+		return nil
+	}
+	return n
+}
+
+// notFound turns ethereum.NotFound into the facade's nil result
+func notFound[T any](v *T, err error) (*T, error) {
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	return v, err
+}
+
+func (e *EthClientBackend) convertBlock(ctx context.Context, b *types.Block, fullTx bool) (*Types.Block, error) {
+	if b == nil {
+		return nil, nil
+	}
+	signer, err := e.signerFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := fromGethBlock(b, signer)
+	if !fullTx {
+		for i, tx := range out.Transactions {
+			out.Transactions[i] = &Types.Transaction{Hash: tx.Hash}
+		}
+	}
+	return out, nil
+}
+
+// Basic blockchain info
+func (e *EthClientBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return e.client.ChainID(ctx)
+}
+func (e *EthClientBackend) ClientVersion(ctx context.Context) (string, error) {
+	var v string
+	err := e.client.Client().CallContext(ctx, &v, "web3_clientVersion")
+	return v, err
+}
+func (e *EthClientBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	n, err := e.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(n), nil
+}
+
+// Block operations
+func (e *EthClientBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	b, err := notFound(e.client.BlockByNumber(ctx, ethBlock(num)))
+	if err != nil {
+		return nil, err
+	}
+	return e.convertBlock(ctx, b, fullTx)
+}
+func (e *EthClientBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	b, err := notFound(e.client.BlockByHash(ctx, common.BytesToHash(hash)))
+	if err != nil {
+		return nil, err
+	}
+	return e.convertBlock(ctx, b, fullTx)
+}
+func (e *EthClientBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	// ethclient only counts by hash
+	var n hexutil.Uint64
+	err := e.client.Client().CallContext(ctx, &n, "eth_getBlockTransactionCountByNumber", blockArg(ethBlock(blockNum)))
+	return uint64(n), err
+}
+func (e *EthClientBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	n, err := e.client.TransactionCount(ctx, common.BytesToHash(blockHash))
+	return uint64(n), err
+}
+
+// Account operations
+func (e *EthClientBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return e.client.BalanceAt(ctx, common.BytesToAddress(addr), ethBlock(block))
+}
+func (e *EthClientBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return e.client.CodeAt(ctx, common.BytesToAddress(addr), ethBlock(block))
+}
+func (e *EthClientBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return e.client.StorageAt(ctx, common.BytesToAddress(addr), common.BytesToHash(key), ethBlock(block))
+}
+func (e *EthClientBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return e.client.NonceAt(ctx, common.BytesToAddress(addr), ethBlock(block))
+}
+
+// Transaction operations
+func (e *EthClientBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return e.client.CallContract(ctx, toEthCallMsg(msg), ethBlock(block))
+}
+func (e *EthClientBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return e.client.EstimateGas(ctx, toEthCallMsg(msg))
+}
+func (e *EthClientBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return e.client.SuggestGasPrice(ctx)
+}
+func (e *EthClientBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	raw, err := decodeHex(rawHex)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	if err := e.client.SendTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+	return tx.Hash().Bytes(), nil
+}
+func (e *EthClientBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	tx, _, err := e.client.TransactionByHash(ctx, common.BytesToHash(hash))
+	return e.convertTx(ctx, tx, err)
+}
+func (e *EthClientBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	b, err := notFound(e.client.BlockByNumber(ctx, ethBlock(blockNum)))
+	if err != nil || b == nil || index >= uint64(len(b.Transactions())) {
+		return nil, err
+	}
+	return e.convertTx(ctx, b.Transactions()[index], nil)
+}
+func (e *EthClientBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	tx, err := e.client.TransactionInBlock(ctx, common.BytesToHash(blockHash), uint(index))
+	return e.convertTx(ctx, tx, err)
+}
+func (e *EthClientBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	r, err := notFound(e.client.TransactionReceipt(ctx, common.BytesToHash(hash)))
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return fromGethReceipt(r), nil
+}
+
+func (e *EthClientBackend) convertTx(ctx context.Context, tx *types.Transaction, err error) (*Types.Transaction, error) {
+	tx, err = notFound(tx, err)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	signer, err := e.signerFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromGethTx(tx, signer), nil
+}
+
+// Log operations
+func (e *EthClientBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	f := toEthFilter(&q)
+	if f.FromBlock == nil && f.BlockHash == nil {
+		// ethclient sends a missing fromBlock as 0x0 rather than latest
+		f.FromBlock = big.NewInt(int64(rpc.LatestBlockNumber))
+	}
+	logs, err := e.client.FilterLogs(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Types.Log, len(logs))
+	for i := range logs {
+		out[i] = fromGethLog(&logs[i])
+	}
+	return out, nil
+}
+
+// Network operations
+func (e *EthClientBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return e.client.PeerCount(ctx)
+}
+func (e *EthClientBackend) Listening(ctx context.Context) (bool, error) {
+	var v bool
+	err := e.client.Client().CallContext(ctx, &v, "net_listening")
+	return v, err
+}
+func (e *EthClientBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	var raw json.RawMessage
+	if err := e.client.Client().CallContext(ctx, &raw, "eth_syncing"); err != nil {
+		return nil, err
+	}
+	return decodeSyncing(raw)
+}
+
+// Streaming (for WS subscriptions)
+func (e *EthClientBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	in := make(chan *types.Header, 16)
+	sub, err := e.client.SubscribeNewHead(ctx, in)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, stop := relay(ctx, sub, in, func(h *types.Header) *Types.Block {
+		return &Types.Block{Header: fromGethHeader(h)}
+	})
+	return out, stop, nil
+}
+func (e *EthClientBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	in := make(chan types.Log, 64)
+	sub, err := e.client.SubscribeFilterLogs(ctx, toEthFilter(q), in)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, stop := relay(ctx, sub, in, func(l types.Log) *Types.Log { return fromGethLog(&l) })
+	return out, stop, nil
+}
+func (e *EthClientBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	in := make(chan common.Hash, 256)
+	sub, err := e.client.Client().EthSubscribe(ctx, in, "newPendingTransactions")
+	if err != nil {
+		return nil, nil, err
+	}
+	out, stop := relay(ctx, sub, in, common.Hash.Bytes)
+	return out, stop, nil
+}
+
+// Supports reports subscriptions only for clients that can carry them
+func (e *EthClientBackend) Supports(c Types.Capability) bool {
+	return c == Types.CapSubscriptions && e.client.Client().SupportsSubscriptions()
+}
+
+// relay converts a go-ethereum subscription into the facade's channel and
+// stop function. The channel closes once stop is called, ctx is done or the
+// subscription fails.
+func relay[In, Out any](ctx context.Context, sub ethereum.Subscription, in <-chan In, conv func(In) Out) (<-chan Out, func()) {
+	out := make(chan Out, cap(in))
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() { once.Do(func() { close(done) }) }
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case v := <-in:
+				select {
+				case out <- conv(v):
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, stop
+}
+
+// toEthCallMsg converts a call message for go-ethereum
+func toEthCallMsg(msg Types.CallMsg) ethereum.CallMsg {
+	out := ethereum.CallMsg{Data: msg.Data, Value: msg.Value, GasPrice: msg.GasPrice}
+	if msg.From != "" {
+		out.From = common.HexToAddress(msg.From)
+	}
+	if msg.To != "" {
+		to := common.HexToAddress(msg.To)
+		out.To = &to
+	}
+	if msg.Gas != nil {
+		out.Gas = msg.Gas.Uint64()
+	}
+	return out
+}
+
+// toEthFilter converts a filter query for go-ethereum
+func toEthFilter(q *Types.FilterQuery) ethereum.FilterQuery {
+	var out ethereum.FilterQuery
+	if q == nil {
+		return out
+	}
+	out.FromBlock, out.ToBlock = q.FromBlock, q.ToBlock
+	if len(q.BlockHash) > 0 {
+		h := common.BytesToHash(q.BlockHash)
+		out.BlockHash = &h
+		out.FromBlock, out.ToBlock = nil, nil
+	}
+	for _, a := range q.Addresses {
+		out.Addresses = append(out.Addresses, common.BytesToAddress(a))
+	}
+	for _, t := range q.Topics {
+		// an empty position is a wildcard
+		if len(t) == 0 {
+			out.Topics = append(out.Topics, nil)
+			continue
+		}
+		out.Topics = append(out.Topics, []common.Hash{common.BytesToHash(t)})
+	}
+	return out
+}
@@ -0,0 +1,310 @@
+package Services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// ethService serves one block of real geth objects under the eth namespace
+// and records the arguments it is called with.
+type ethService struct {
+	chainID *big.Int
+	signer  types.Signer
+	block   *types.Block
+	receipt *types.Receipt
+
+	mu      sync.Mutex
+	call    map[string]any
+	filter  map[string]any
+	sent    []*types.Transaction
+	numbers []rpc.BlockNumber
+}
+
+func (s *ethService) ChainId() *hexutil.Big { return (*hexutil.Big)(s.chainID) }
+
+func (s *ethService) BlockNumber() hexutil.Uint64 { return hexutil.Uint64(s.block.NumberU64()) }
+
+func (s *ethService) GetBlockByNumber(n rpc.BlockNumber, full bool) (map[string]any, error) {
+	s.mu.Lock()
+	s.numbers = append(s.numbers, n)
+	s.mu.Unlock()
+	if n != rpc.LatestBlockNumber && n.Int64() != s.block.Number().Int64() {
+		return nil, nil
+	}
+	return s.rpcBlock(full), nil
+}
+
+func (s *ethService) GetBlockByHash(h common.Hash, full bool) (map[string]any, error) {
+	if h != s.block.Hash() {
+		return nil, nil
+	}
+	return s.rpcBlock(full), nil
+}
+
+func (s *ethService) GetTransactionByHash(h common.Hash) (map[string]any, error) {
+	for i, tx := range s.block.Transactions() {
+		if tx.Hash() == h {
+			return s.rpcTx(tx, i), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *ethService) GetTransactionReceipt(h common.Hash) (*types.Receipt, error) {
+	if h != s.receipt.TxHash {
+		return nil, nil
+	}
+	return s.receipt, nil
+}
+
+func (s *ethService) GetLogs(crit map[string]any) ([]*types.Log, error) {
+	s.mu.Lock()
+	s.filter = crit
+	s.mu.Unlock()
+	return s.receipt.Logs, nil
+}
+
+func (s *ethService) Call(args map[string]any, block rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	s.mu.Lock()
+	s.call = args
+	s.mu.Unlock()
+	return hexutil.Bytes{0x2a}, nil
+}
+
+func (s *ethService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	s.mu.Lock()
+	s.sent = append(s.sent, tx)
+	s.mu.Unlock()
+	return tx.Hash(), nil
+}
+
+// rpcBlock renders the block as eth_getBlockBy* does
+func (s *ethService) rpcBlock(full bool) map[string]any {
+	var m map[string]any
+	raw, _ := json.Marshal(s.block.Header())
+	json.Unmarshal(raw, &m)
+	txs := []any{}
+	for i, tx := range s.block.Transactions() {
+		if full {
+			txs = append(txs, s.rpcTx(tx, i))
+		} else {
+			txs = append(txs, tx.Hash())
+		}
+	}
+	m["transactions"] = txs
+	m["uncles"] = []common.Hash{}
+	m["withdrawals"] = s.block.Withdrawals()
+	return m
+}
+
+// rpcTx renders a transaction with its inclusion fields and sender
+func (s *ethService) rpcTx(tx *types.Transaction, index int) map[string]any {
+	var m map[string]any
+	raw, _ := tx.MarshalJSON()
+	json.Unmarshal(raw, &m)
+	from, _ := types.Sender(s.signer, tx)
+	m["from"] = from
+	m["blockHash"] = s.block.Hash()
+	m["blockNumber"] = (*hexutil.Big)(s.block.Number())
+	m["transactionIndex"] = hexutil.Uint64(index)
+	return m
+}
+
+// newEthService builds block 5 holding one EIP-1559 transaction with an
+// access list, whose receipt carries one log, plus a withdrawal
+func newEthService(t *testing.T) *ethService {
+	t.Helper()
+	key, _ := crypto.GenerateKey()
+	chainID := big.NewInt(1337)
+	signer := types.LatestSignerForChainID(chainID)
+	to := common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     3,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(2e9),
+		Gas:       50000,
+		To:        &to,
+		Value:     big.NewInt(1e18),
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+		AccessList: types.AccessList{{
+			Address:     to,
+			StorageKeys: []common.Hash{common.HexToHash("0x01")},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := &types.Receipt{
+		Type:              types.DynamicFeeTxType,
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 30000,
+		GasUsed:           30000,
+		TxHash:            tx.Hash(),
+		Logs: []*types.Log{{
+			Address: to,
+			Topics:  []common.Hash{common.HexToHash("0xaa"), common.HexToHash("0xbb")},
+			Data:    []byte{0x2a},
+		}},
+	}
+	receipt.Bloom = types.CreateBloom(receipt)
+	header := &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Coinbase:   common.HexToAddress("0xdddddddddddddddddddddddddddddddddddddddd"),
+		Difficulty: common.Big0,
+		Number:     big.NewInt(5),
+		GasLimit:   30_000_000,
+		GasUsed:    30000,
+		Time:       1_700_000_000,
+		Extra:      []byte("stub"),
+		BaseFee:    big.NewInt(7),
+	}
+	block := types.NewBlock(header, &types.Body{
+		Transactions: []*types.Transaction{tx},
+		Withdrawals:  []*types.Withdrawal{{Index: 1, Validator: 2, Address: to, Amount: 3}},
+	}, []*types.Receipt{receipt}, trie.NewStackTrie(nil))
+
+	receipt.BlockHash, receipt.BlockNumber = block.Hash(), block.Number()
+	l := receipt.Logs[0]
+	l.BlockNumber, l.BlockHash, l.TxHash, l.Index = 5, block.Hash(), tx.Hash(), 4
+	return &ethService{chainID: chainID, signer: signer, block: block, receipt: receipt}
+}
+
+func TestEthClientBackend(t *testing.T) {
+	svc := newEthService(t)
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Stop)
+	client := ethclient.NewClient(rpc.DialInProc(srv))
+	t.Cleanup(client.Close)
+	be := Services.NewEthClientBackend(client)
+	ctx := context.Background()
+	gtx := svc.block.Transactions()[0]
+	sender, _ := types.Sender(svc.signer, gtx)
+
+	// geth types to Types
+	b, err := be.BlockByNumber(ctx, big.NewInt(5), true)
+	if err != nil || b == nil {
+		t.Fatalf("BlockByNumber: %+v, %v", b, err)
+	}
+	h := b.Header
+	if h.Number != 5 || !bytes.Equal(h.Hash, svc.block.Hash().Bytes()) || h.GasLimit != 30_000_000 || h.Timestamp != 1_700_000_000 ||
+		!bytes.Equal(h.BaseFee, []byte{7}) || !bytes.Equal(h.ExtraData, []byte("stub")) || !bytes.Equal(h.LogsBloom, svc.block.Bloom().Bytes()) {
+		t.Errorf("header: %+v", h)
+	}
+	if len(b.Withdrawals) != 1 || b.Withdrawals[0].ValidatorIndex != 2 || b.Withdrawals[0].Amount != 3 {
+		t.Errorf("withdrawals: %+v", b.Withdrawals)
+	}
+	if len(b.Transactions) != 1 {
+		t.Fatalf("transactions: %+v", b.Transactions)
+	}
+	tx := b.Transactions[0]
+	if !bytes.Equal(tx.Hash, gtx.Hash().Bytes()) || !bytes.Equal(tx.From, sender.Bytes()) || tx.Type != types.DynamicFeeTxType ||
+		tx.Nonce != 3 || tx.Gas != 50000 || new(big.Int).SetBytes(tx.Value).Cmp(big.NewInt(1e18)) != 0 ||
+		new(big.Int).SetBytes(tx.MaxFeePerGas).Int64() != 2e9 || new(big.Int).SetBytes(tx.MaxPriorityFeePerGas).Int64() != 1e9 {
+		t.Errorf("transaction: %+v", tx)
+	}
+	if tx.AccessList == nil || len(tx.AccessList.AccessTuples) != 1 || len(tx.AccessList.AccessTuples[0].StorageKeys) != 1 {
+		t.Errorf("access list: %+v", tx.AccessList)
+	}
+
+	b, err = be.BlockByNumber(ctx, nil, false)
+	if err != nil || len(b.Transactions) != 1 || b.Transactions[0].From != nil || !bytes.Equal(b.Transactions[0].Hash, gtx.Hash().Bytes()) {
+		t.Errorf("latest block without full transactions: %+v, %v", b, err)
+	}
+	if svc.numbers[len(svc.numbers)-1] != rpc.LatestBlockNumber {
+		t.Errorf("nil block number sent as %v, want latest", svc.numbers[len(svc.numbers)-1])
+	}
+	if b, err := be.BlockByNumber(ctx, big.NewInt(6), false); b != nil || err != nil {
+		t.Errorf("missing block: %+v, %v", b, err)
+	}
+
+	got, err := be.TxByHash(ctx, gtx.Hash().Bytes())
+	if err != nil || !reflect.DeepEqual(got, tx) {
+		t.Errorf("TxByHash = %+v, %v; want %+v", got, err, tx)
+	}
+
+	r, err := be.ReceiptByHash(ctx, gtx.Hash().Bytes())
+	if err != nil || r == nil {
+		t.Fatalf("ReceiptByHash: %+v, %v", r, err)
+	}
+	if r.Status != 1 || r.GasUsed != 30000 || r.Type != types.DynamicFeeTxType || r.BlockNumber != 5 || len(r.Logs) != 1 ||
+		r.Logs[0].LogIndex != 4 || !bytes.Equal(r.Logs[0].Data, []byte{0x2a}) || !bytes.Equal(r.BlockHash, svc.block.Hash().Bytes()) {
+		t.Errorf("receipt: %+v", r)
+	}
+	if r, err := be.ReceiptByHash(ctx, make([]byte, 32)); r != nil || err != nil {
+		t.Errorf("missing receipt: %+v, %v", r, err)
+	}
+
+	// Types to geth types
+	out, err := be.Call(ctx, Types.CallMsg{
+		From:  sender.Hex(),
+		To:    "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		Data:  []byte{1, 2},
+		Value: big.NewInt(5),
+		Gas:   big.NewInt(90000),
+	}, nil)
+	if err != nil || !bytes.Equal(out, []byte{0x2a}) {
+		t.Errorf("Call = %x, %v", out, err)
+	}
+	want := map[string]any{
+		"from":  hexutil.Encode(sender.Bytes()),
+		"to":    "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"input": "0x0102",
+		"value": "0x5",
+		"gas":   "0x15f90",
+	}
+	if !reflect.DeepEqual(svc.call, want) {
+		t.Errorf("eth_call arguments = %v, want %v", svc.call, want)
+	}
+
+	logs, err := be.GetLogs(ctx, Types.FilterQuery{
+		FromBlock: big.NewInt(5),
+		ToBlock:   big.NewInt(5),
+		Addresses: [][]byte{common.HexToAddress("0xbb").Bytes()},
+		Topics:    [][]byte{common.HexToHash("0xaa").Bytes(), nil},
+	})
+	if err != nil || len(logs) != 1 || len(logs[0].Topics) != 2 || logs[0].BlockNumber != 5 {
+		t.Errorf("GetLogs = %+v, %v", logs, err)
+	}
+	wantFilter := map[string]any{
+		"fromBlock": "0x5",
+		"toBlock":   "0x5",
+		"address":   []any{"0x00000000000000000000000000000000000000bb"},
+		"topics":    []any{[]any{common.HexToHash("0xaa").Hex()}, nil},
+	}
+	if !reflect.DeepEqual(svc.filter, wantFilter) {
+		t.Errorf("eth_getLogs criteria = %v, want %v", svc.filter, wantFilter)
+	}
+	if _, err := be.GetLogs(ctx, Types.FilterQuery{}); err != nil || svc.filter["fromBlock"] != "latest" {
+		t.Errorf("GetLogs without bounds sent fromBlock %v, %v", svc.filter["fromBlock"], err)
+	}
+
+	raw, _ := gtx.MarshalBinary()
+	hash, err := be.SendRawTx(ctx, "0x"+hex.EncodeToString(raw))
+	if err != nil || !bytes.Equal(hash, gtx.Hash().Bytes()) || len(svc.sent) != 1 || svc.sent[0].Hash() != gtx.Hash() {
+		t.Errorf("SendRawTx = %x, %v", hash, err)
+	}
+}
@@ -0,0 +1,200 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// devChainConfig enables every fork up to Prague from genesis, like geth --dev.
+func devChainConfig(chainID *big.Int) *params.ChainConfig {
+	cfg := *params.AllDevChainProtocolChanges
+	cfg.ChainID = new(big.Int).Set(chainID)
+	return &cfg
+}
+
+// evmEnv is what a call needs to execute against a block: its state, header
+// and the chain behind it for BLOCKHASH.
+type evmEnv struct {
+	config *params.ChainConfig
+	state  *state.StateDB
+	header *types.Header
+	chain  headerChain
+}
+
+// headerChain serves headers to the EVM from a snapshot of the canonical
+// chain, so execution needs no lock on the backend.
+type headerChain struct {
+	config *params.ChainConfig
+	blocks []*types.Block
+}
+
+func (c headerChain) Engine() consensus.Engine    { return nil }
+func (c headerChain) Config() *params.ChainConfig { return c.config }
+func (c headerChain) GetHeader(hash common.Hash, n uint64) *types.Header {
+	if n >= uint64(len(c.blocks)) || c.blocks[n].Hash() != hash {
+		return nil
+	}
+	return c.blocks[n].Header()
+}
+
+// newEVM builds an EVM over st for a block. Calls run with NoBaseFee so a
+// zero gas price is accepted, as in geth's eth_call.
+func (e *evmEnv) newEVM(st *state.StateDB, header *types.Header, cfg vm.Config) *vm.EVM {
+	blockCtx := core.NewEVMBlockContext(header, e.chain, &header.Coinbase)
+	return vm.NewEVM(blockCtx, st, e.config, cfg)
+}
+
+// callMessage turns an eth_call request into a message that skips nonce and
+// EOA checks; gas defaults to the block gas limit and price to zero.
+func callMessage(msg Types.CallMsg, gas uint64) *core.Message {
+	out := &core.Message{
+		From:             common.HexToAddress(msg.From),
+		Value:            new(big.Int),
+		GasLimit:         gas,
+		GasPrice:         new(big.Int),
+		GasFeeCap:        new(big.Int),
+		GasTipCap:        new(big.Int),
+		Data:             msg.Data,
+		SkipNonceChecks:  true,
+		SkipFromEOACheck: true,
+	}
+	if msg.To != "" {
+		to := common.HexToAddress(msg.To)
+		out.To = &to
+	}
+	if msg.Value != nil {
+		out.Value.Set(msg.Value)
+	}
+	if msg.GasPrice != nil {
+		out.GasPrice.Set(msg.GasPrice)
+		out.GasFeeCap.Set(msg.GasPrice)
+		out.GasTipCap.Set(msg.GasPrice)
+	}
+	return out
+}
+
+// execute runs msg on a copy of the environment's state. The EVM is
+// cancelled when ctx is done.
+func (e *evmEnv) execute(ctx context.Context, msg *core.Message) (*core.ExecutionResult, error) {
+	evm := e.newEVM(e.state.Copy(), e.header, vm.Config{NoBaseFee: true})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-done:
+		}
+	}()
+	gp := new(core.GasPool).AddGas(math.MaxUint64)
+	res, err := core.ApplyMessage(evm, msg, gp)
+	if err != nil {
+		return nil, err
+	}
+	if evm.Cancelled() {
+		return nil, ctx.Err()
+	}
+	return res, nil
+}
+
+// revertError maps a failed execution to geth's error: code 3 with the revert
+// data for reverts, a plain error otherwise.
+func revertError(res *core.ExecutionResult) error {
+	if !errors.Is(res.Err, vm.ErrExecutionReverted) {
+		return res.Err
+	}
+	msg := "execution reverted"
+	if reason, err := abi.UnpackRevert(res.Revert()); err == nil {
+		msg += ": " + reason
+	}
+	return &Types.Error{Code: 3, Message: msg, Data: hexutil.Encode(res.Revert())}
+}
+
+// estimateGas binary searches for the lowest gas limit at which msg succeeds,
+// between the gas used by a run at the cap and the cap itself.
+func (e *evmEnv) estimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	hi := e.header.GasLimit
+	if msg.Gas != nil && msg.Gas.IsUint64() && msg.Gas.Uint64() >= params.TxGas {
+		hi = msg.Gas.Uint64()
+	}
+	// A priced call cannot use more gas than the sender can pay for
+	if msg.GasPrice != nil && msg.GasPrice.Sign() > 0 {
+		funds := e.state.GetBalance(common.HexToAddress(msg.From)).ToBig()
+		if msg.Value != nil {
+			if funds.Cmp(msg.Value) < 0 {
+				return 0, core.ErrInsufficientFundsForTransfer
+			}
+			funds.Sub(funds, msg.Value)
+		}
+		if allowance := new(big.Int).Div(funds, msg.GasPrice); allowance.IsUint64() && allowance.Uint64() < hi {
+			hi = allowance.Uint64()
+		}
+	}
+	limit := hi
+
+	res, err := e.execute(ctx, callMessage(msg, hi))
+	if err != nil {
+		if errors.Is(err, core.ErrIntrinsicGas) {
+			return 0, fmt.Errorf("gas required exceeds allowance (%d)", limit)
+		}
+		return 0, err
+	}
+	if res.Failed() {
+		if errors.Is(res.Err, vm.ErrExecutionReverted) {
+			return 0, revertError(res)
+		}
+		return 0, fmt.Errorf("gas required exceeds allowance (%d)", limit)
+	}
+
+	// Execution below the gas it used cannot succeed. Its peak use before refunds
+	// plus the 63/64 rule usually bounds the answer, so try there first.
+	lo := res.UsedGas - 1
+	if guess := (res.MaxUsedGas + params.CallStipend) * 64 / 63; guess < hi {
+		if ok, err := e.succeeds(ctx, msg, guess); err != nil {
+			return 0, err
+		} else if ok {
+			hi = guess
+		} else {
+			lo = guess
+		}
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		ok, err := e.succeeds(ctx, msg, mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, nil
+}
+
+// succeeds reports whether msg runs without failing at the given gas limit
+func (e *evmEnv) succeeds(ctx context.Context, msg Types.CallMsg, gas uint64) (bool, error) {
+	res, err := e.execute(ctx, callMessage(msg, gas))
+	if err != nil {
+		if errors.Is(err, core.ErrIntrinsicGas) || errors.Is(err, core.ErrFloorDataGas) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !res.Failed(), nil
+}
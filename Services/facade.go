@@ -20,6 +20,7 @@ type Server struct {
 	backend  Types.Backend
 	httpAddr string
 	wsAddr   string
+	httpOpts HTTPOptions
 }
 
 // Config holds the configuration for the facade server.
@@ -30,15 +31,32 @@ type Config struct {
 	HTTPAddr string
 	// WSAddr is the WebSocket server address (e.g., ":8546")
 	WSAddr string
+	// HTTPOptions tunes compression and keep-alive behaviour; nil uses DefaultHTTPOptions
+	HTTPOptions *HTTPOptions
+	// Shadow, when set, mirrors a sample of the read traffic to a second backend
+	Shadow *Shadow
+	// ChaosAdmin serves the chaos_* methods that switch and edit fault
+	// injection, see Handlers.SetChaosAdmin
+	ChaosAdmin bool
 }
 
 // NewServer creates a new facade server with the given configuration.
 func NewServer(config Config) *Server {
+	httpOpts := DefaultHTTPOptions()
+	if config.HTTPOptions != nil {
+		httpOpts = *config.HTTPOptions
+	}
+	handlers := NewHandlers(config.Backend)
+	if config.Shadow != nil {
+		handlers.SetShadow(config.Shadow)
+	}
+	handlers.SetChaosAdmin(config.ChaosAdmin)
 	return &Server{
-		handlers: NewHandlers(config.Backend),
+		handlers: handlers,
 		backend:  config.Backend,
 		httpAddr: config.HTTPAddr,
 		wsAddr:   config.WSAddr,
+		httpOpts: httpOpts,
 	}
 }
 
@@ -48,7 +66,7 @@ func (s *Server) Start() error {
 	// Start HTTP server in a goroutine
 	go func() {
 		log.Printf("HTTP JSON-RPC server starting on %s", s.httpAddr)
-		httpServer := NewHTTPServer(s.handlers)
+		httpServer := NewHTTPServerWithOptions(s.handlers, s.httpOpts)
 		if err := httpServer.Serve(s.httpAddr); err != nil {
 			log.Printf("HTTP server error: %v", err)
 		}
@@ -63,7 +81,7 @@ func (s *Server) Start() error {
 // StartHTTP starts only the HTTP server.
 func (s *Server) StartHTTP() error {
 	log.Printf("HTTP JSON-RPC server starting on %s", s.httpAddr)
-	httpServer := NewHTTPServer(s.handlers)
+	httpServer := NewHTTPServerWithOptions(s.handlers, s.httpOpts)
 	return httpServer.Serve(s.httpAddr)
 }
 
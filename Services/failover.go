@@ -0,0 +1,597 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Strategy selects the order in which healthy upstreams are tried.
+type Strategy int
+
+const (
+	// StrategyRoundRobin spreads calls evenly across healthy upstreams
+	StrategyRoundRobin Strategy = iota
+	// StrategyLeastLatency prefers the upstream with the lowest average latency
+	StrategyLeastLatency
+	// StrategyPriority always prefers the healthy upstream with the lowest Priority value
+	StrategyPriority
+)
+
+// Upstream is one backend managed by a FailoverBackend.
+type Upstream struct {
+	// Name identifies the upstream in logs and Status output
+	Name string
+	// Backend serves the calls routed to this upstream
+	Backend Types.Backend
+	// Priority orders upstreams for StrategyPriority (lower is preferred)
+	Priority int
+}
+
+// FailoverConfig configures a FailoverBackend.
+type FailoverConfig struct {
+	Upstreams []Upstream
+	Strategy  Strategy
+	// HealthInterval is how often every upstream's head is probed via BlockNumber
+	HealthInterval time.Duration
+	// MaxHeadLag is how many blocks an upstream may trail the best head before ejection
+	MaxHeadLag uint64
+	// MaxErrorRate ejects an upstream whose smoothed error rate exceeds it (0..1)
+	MaxErrorRate float64
+	// MinSamples is the number of calls required before the error rate is trusted
+	MinSamples int
+}
+
+// UpstreamStatus is a point-in-time health snapshot of one upstream.
+type UpstreamStatus struct {
+	Name      string
+	Healthy   bool
+	Head      uint64
+	Latency   time.Duration
+	ErrorRate float64
+	LastError string
+}
+
+// FailoverBackend implements Types.Backend on top of several upstream
+// backends. Calls are routed by the configured Strategy and fail over to the
+// next healthy upstream on error. Upstreams that lag the best head or fail too
+// often are ejected and reinstated once the health probe passes again.
+// //future: May add weighted strategies and per-method routing
+type FailoverBackend struct {
+	cfg       FailoverConfig
+	upstreams []*upstream
+	rr        atomic.Uint64
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type upstream struct {
+	Upstream
+
+	mu      sync.Mutex
+	healthy bool
+	down    chan struct{} // closed when the upstream is ejected
+	latency time.Duration // exponentially weighted moving average
+	errRate float64       // exponentially weighted moving average
+	samples int
+	head    uint64
+	lastErr error
+}
+
+// ewmaWeight is the weight of the newest sample in the moving averages
+const ewmaWeight = 0.1
+
+// NewFailoverBackend creates a composite backend and starts health probing.
+func NewFailoverBackend(cfg FailoverConfig) *FailoverBackend {
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = 5 * time.Second
+	}
+	if cfg.MaxHeadLag == 0 {
+		cfg.MaxHeadLag = 5
+	}
+	if cfg.MaxErrorRate <= 0 {
+		cfg.MaxErrorRate = 0.5
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 10
+	}
+	f := &FailoverBackend{cfg: cfg, stop: make(chan struct{})}
+	for i, u := range cfg.Upstreams {
+		if u.Name == "" {
+			u.Name = fmt.Sprintf("upstream-%d", i)
+		}
+		f.upstreams = append(f.upstreams, &upstream{Upstream: u, healthy: true, down: make(chan struct{})})
+	}
+	go f.healthLoop()
+	return f
+}
+
+// Close stops health probing. Upstream backends are not closed.
+func (f *FailoverBackend) Close() {
+	f.closeOnce.Do(func() { close(f.stop) })
+}
+
+// Status reports the health of every upstream.
+func (f *FailoverBackend) Status() []UpstreamStatus {
+	out := make([]UpstreamStatus, len(f.upstreams))
+	for i, u := range f.upstreams {
+		u.mu.Lock()
+		out[i] = UpstreamStatus{
+			Name:      u.Name,
+			Healthy:   u.healthy,
+			Head:      u.head,
+			Latency:   u.latency,
+			ErrorRate: u.errRate,
+		}
+		if u.lastErr != nil {
+			out[i].LastError = u.lastErr.Error()
+		}
+		u.mu.Unlock()
+	}
+	return out
+}
+
+// candidates returns upstreams in the order they should be tried. When none
+// is healthy every upstream is returned by priority as a last resort.
+func (f *FailoverBackend) candidates() []*upstream {
+	var healthy []*upstream
+	for _, u := range f.upstreams {
+		u.mu.Lock()
+		if u.healthy {
+			healthy = append(healthy, u)
+		}
+		u.mu.Unlock()
+	}
+	if len(healthy) == 0 {
+		all := append([]*upstream(nil), f.upstreams...)
+		sort.SliceStable(all, func(i, j int) bool { return all[i].Priority < all[j].Priority })
+		return all
+	}
+
+	switch f.cfg.Strategy {
+	case StrategyLeastLatency:
+		lat := make(map[*upstream]time.Duration, len(healthy))
+		for _, u := range healthy {
+			u.mu.Lock()
+			lat[u] = u.latency
+			u.mu.Unlock()
+		}
+		sort.SliceStable(healthy, func(i, j int) bool { return lat[healthy[i]] < lat[healthy[j]] })
+	case StrategyPriority:
+		sort.SliceStable(healthy, func(i, j int) bool { return healthy[i].Priority < healthy[j].Priority })
+	default:
+		start := int(f.rr.Add(1)-1) % len(healthy)
+		healthy = append(healthy[start:], healthy[:start]...)
+	}
+	return healthy
+}
+
+// record updates the moving averages after a call and ejects the upstream
+// when its error rate crosses the threshold.
+func (f *FailoverBackend) record(u *upstream, took time.Duration, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		u.lastErr = err
+	}
+	if u.samples == 0 {
+		u.latency = took
+	} else {
+		u.latency = time.Duration(float64(u.latency)*(1-ewmaWeight) + float64(took)*ewmaWeight)
+	}
+	u.errRate = u.errRate*(1-ewmaWeight) + failed*ewmaWeight
+	u.samples++
+	if u.healthy && u.samples >= f.cfg.MinSamples && u.errRate > f.cfg.MaxErrorRate {
+		f.ejectLocked(u, "error rate too high")
+	}
+}
+
+func (f *FailoverBackend) ejectLocked(u *upstream, reason string) {
+	if !u.healthy {
+		return
+	}
+	u.healthy = false
+	close(u.down)
+	log.Printf("⚠️ Upstream %s ejected: %s", u.Name, reason)
+}
+
+func (f *FailoverBackend) reinstateLocked(u *upstream) {
+	if u.healthy {
+		return
+	}
+	u.healthy = true
+	u.down = make(chan struct{})
+	u.errRate = 0
+	u.samples = 0
+	log.Printf("✅ Upstream %s reinstated", u.Name)
+}
+
+// failoverRetryable reports whether err should make the call move on to the
+// next upstream. Context errors belong to the caller and JSON-RPC errors are
+// authoritative answers from a live node, so neither fails over.
+func failoverRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	// RPC errors and unsupported methods are answers, not upstream faults
+	var rpcErr *Types.Error
+	return !errors.As(err, &rpcErr) && !errors.Is(err, Types.ErrNotSupported)
+}
+
+// failoverCall runs fn against upstreams in strategy order until one succeeds.
+func failoverCall[T any](f *FailoverBackend, ctx context.Context, fn func(Types.Backend) (T, error)) (T, error) {
+	var (
+		zero    T
+		lastErr error
+	)
+	cands := f.candidates()
+	if len(cands) == 0 {
+		return zero, errors.New("failover backend: no upstreams configured")
+	}
+	for _, u := range cands {
+		start := time.Now()
+		v, err := fn(u.Backend)
+		retry := failoverRetryable(ctx, err)
+		if retry {
+			f.record(u, time.Since(start), err)
+		} else if ctx.Err() == nil {
+			f.record(u, time.Since(start), nil)
+		}
+		if !retry {
+			return v, err
+		}
+		lastErr = err
+	}
+	return zero, lastErr
+}
+
+func (f *FailoverBackend) healthLoop() {
+	t := time.NewTicker(f.cfg.HealthInterval)
+	defer t.Stop()
+	for {
+		f.probe()
+		select {
+		case <-t.C:
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// probe measures every upstream's head and ejects or reinstates it based on
+// its lag behind the best head. An ejected upstream must also serve its head
+// block before it is reinstated, so one that answers BlockNumber but fails
+// real calls does not flap back into rotation.
+func (f *FailoverBackend) probe() {
+	heads := make([]*big.Int, len(f.upstreams))
+	errs := make([]error, len(f.upstreams))
+	var wg sync.WaitGroup
+	for i, u := range f.upstreams {
+		u.mu.Lock()
+		ejected := !u.healthy
+		u.mu.Unlock()
+		wg.Add(1)
+		go func(i int, u *upstream) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), f.cfg.HealthInterval)
+			defer cancel()
+			heads[i], errs[i] = u.Backend.BlockNumber(ctx)
+			if errs[i] != nil || heads[i] == nil || !ejected {
+				return
+			}
+			b, err := u.Backend.BlockByNumber(ctx, heads[i], false)
+			if err == nil && (b == nil || b.Header == nil) {
+				err = fmt.Errorf("no block %s", heads[i])
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("head block: %w", err)
+			}
+		}(i, u)
+	}
+	wg.Wait()
+
+	var best uint64
+	for i, h := range heads {
+		if errs[i] == nil && h != nil && h.Uint64() > best {
+			best = h.Uint64()
+		}
+	}
+	for i, u := range f.upstreams {
+		u.mu.Lock()
+		switch {
+		case errs[i] != nil:
+			u.lastErr = errs[i]
+			f.ejectLocked(u, "health probe failed: "+errs[i].Error())
+		case heads[i] == nil:
+			f.ejectLocked(u, "health probe returned no head")
+		default:
+			u.head = heads[i].Uint64()
+			if best-u.head > f.cfg.MaxHeadLag {
+				f.ejectLocked(u, fmt.Sprintf("head lags by %d blocks", best-u.head))
+			} else if !u.healthy {
+				f.reinstateLocked(u)
+			}
+		}
+		u.mu.Unlock()
+	}
+}
+
+// Basic blockchain info
+func (f *FailoverBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*big.Int, error) { return be.ChainID(ctx) })
+}
+func (f *FailoverBackend) ClientVersion(ctx context.Context) (string, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (string, error) { return be.ClientVersion(ctx) })
+}
+func (f *FailoverBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*big.Int, error) { return be.BlockNumber(ctx) })
+}
+
+// Block operations
+func (f *FailoverBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Block, error) { return be.BlockByNumber(ctx, num, fullTx) })
+}
+func (f *FailoverBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Block, error) { return be.BlockByHash(ctx, hash, fullTx) })
+}
+func (f *FailoverBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return be.BlockTransactionCountByNumber(ctx, blockNum) })
+}
+func (f *FailoverBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return be.BlockTransactionCountByHash(ctx, blockHash) })
+}
+
+// Account operations
+func (f *FailoverBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*big.Int, error) { return be.Balance(ctx, addr, block) })
+}
+func (f *FailoverBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) ([]byte, error) { return be.GetCode(ctx, addr, block) })
+}
+func (f *FailoverBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) ([]byte, error) { return be.GetStorageAt(ctx, addr, key, block) })
+}
+func (f *FailoverBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return be.GetTransactionCount(ctx, addr, block) })
+}
+
+// Transaction operations
+func (f *FailoverBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) ([]byte, error) { return be.Call(ctx, msg, block) })
+}
+func (f *FailoverBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return be.EstimateGas(ctx, msg) })
+}
+func (f *FailoverBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*big.Int, error) { return be.GasPrice(ctx) })
+}
+func (f *FailoverBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	// Re-sending the same signed transaction elsewhere is safe: it has one hash
+	return failoverCall(f, ctx, func(be Types.Backend) ([]byte, error) { return be.SendRawTx(ctx, rawHex) })
+}
+func (f *FailoverBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Transaction, error) { return be.TxByHash(ctx, hash) })
+}
+func (f *FailoverBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Transaction, error) {
+		return be.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (f *FailoverBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Transaction, error) {
+		return be.TxByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+func (f *FailoverBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Receipt, error) { return be.ReceiptByHash(ctx, hash) })
+}
+
+// Log operations
+func (f *FailoverBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) ([]*Types.Log, error) { return be.GetLogs(ctx, q) })
+}
+
+// Network operations
+func (f *FailoverBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return be.PeerCount(ctx) })
+}
+func (f *FailoverBackend) Listening(ctx context.Context) (bool, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (bool, error) { return be.Listening(ctx) })
+}
+func (f *FailoverBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (map[string]any, error) { return be.Syncing(ctx) })
+}
+
+// Mining operations (for PoW chains)
+func (f *FailoverBackend) Mining(ctx context.Context) (bool, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (bool, error) { return asMining(be).Mining(ctx) })
+}
+func (f *FailoverBackend) Hashrate(ctx context.Context) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return asMining(be).Hashrate(ctx) })
+}
+
+// Uncle operations (for PoW chains)
+func (f *FailoverBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return asUncles(be).UncleCountByBlockNumber(ctx, blockNum) })
+}
+func (f *FailoverBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (uint64, error) { return asUncles(be).UncleCountByBlockHash(ctx, blockHash) })
+}
+func (f *FailoverBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Block, error) {
+		return asUncles(be).UncleByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (f *FailoverBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (*Types.Block, error) {
+		return asUncles(be).UncleByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+
+// Tracing
+func (f *FailoverBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (json.RawMessage, error) {
+		return asTracing(be).TraceTransaction(ctx, hash, config)
+	})
+}
+func (f *FailoverBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return failoverCall(f, ctx, func(be Types.Backend) (json.RawMessage, error) {
+		return asTracing(be).TraceCall(ctx, msg, block, config)
+	})
+}
+
+// Supports reports the optional interfaces every upstream serves, since a
+// call may be routed to any of them.
+func (f *FailoverBackend) Supports(c Types.Capability) bool {
+	backends := make([]Types.Backend, 0, len(f.upstreams))
+	for _, u := range f.upstreams {
+		backends = append(backends, u.Backend)
+	}
+	return supportedByAll(backends, c)
+}
+
+// headDedupeDepth is how many blocks of head hashes are remembered to drop
+// duplicates when a newHeads subscription moves between upstreams
+const headDedupeDepth = 64
+
+// Streaming (for WS subscriptions)
+func (f *FailoverBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	// Skip heads already delivered by the previous upstream after a switch
+	seen := map[string]uint64{}
+	var highest uint64
+	return failoverSubscribe(f, ctx, func(be Types.Backend) (<-chan *Types.Block, func(), error) {
+		return asSubscriptions(be).SubscribeNewHeads(ctx)
+	}, func(b *Types.Block) bool {
+		if b == nil || b.Header == nil {
+			return false
+		}
+		if _, dup := seen[string(b.Header.Hash)]; dup {
+			return false
+		}
+		seen[string(b.Header.Hash)] = b.Header.Number
+		if b.Header.Number > highest {
+			highest = b.Header.Number
+			for h, n := range seen {
+				if n+headDedupeDepth < highest {
+					delete(seen, h)
+				}
+			}
+		}
+		return true
+	})
+}
+func (f *FailoverBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return failoverSubscribe(f, ctx, func(be Types.Backend) (<-chan *Types.Log, func(), error) {
+		return asSubscriptions(be).SubscribeLogs(ctx, q)
+	}, nil)
+}
+func (f *FailoverBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return failoverSubscribe(f, ctx, func(be Types.Backend) (<-chan []byte, func(), error) {
+		return asSubscriptions(be).SubscribePendingTxs(ctx)
+	}, nil)
+}
+
+// failoverSubscribe opens a subscription on the best upstream and moves it to
+// another one whenever the upstream stream ends or the upstream is ejected.
+// keep, when set, filters items (used to de-duplicate across switches).
+func failoverSubscribe[T any](f *FailoverBackend, ctx context.Context, open func(Types.Backend) (<-chan T, func(), error), keep func(T) bool) (<-chan T, func(), error) {
+	connect := func(avoid *upstream) (*upstream, <-chan T, func(), <-chan struct{}, error) {
+		var lastErr error = errors.New("failover backend: no upstreams configured")
+		// Try the upstream we are moving away from only as a last resort
+		cands := f.candidates()
+		for i, u := range cands {
+			if u == avoid {
+				cands = append(append(cands[:i:i], cands[i+1:]...), u)
+				break
+			}
+		}
+		for _, u := range cands {
+			ch, cancel, err := open(u.Backend)
+			if err == nil {
+				u.mu.Lock()
+				down := u.down
+				if !u.healthy {
+					// Last-resort upstream: only a closed stream moves us on
+					down = nil
+				}
+				u.mu.Unlock()
+				return u, ch, cancel, down, nil
+			}
+			f.record(u, 0, err)
+			lastErr = err
+		}
+		return nil, nil, nil, nil, lastErr
+	}
+
+	cur, in, cancel, down, err := connect(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan T, 16)
+	stop := make(chan struct{})
+	var once sync.Once
+	go func() {
+		defer close(out)
+		defer func() {
+			if cancel != nil {
+				cancel()
+			}
+		}()
+		for {
+			select {
+			case v, ok := <-in:
+				if ok {
+					if keep != nil && !keep(v) {
+						continue
+					}
+					select {
+					case out <- v:
+						continue
+					case <-stop:
+						return
+					case <-ctx.Done():
+						return
+					}
+				}
+				f.record(cur, 0, errors.New("subscription closed by upstream"))
+			case <-down:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+
+			// Current upstream died or was ejected: move to another one
+			log.Printf("🔁 Resubscribing away from upstream %s", cur.Name)
+			cancel()
+			cancel = nil
+			for cancel == nil {
+				var err error
+				cur, in, cancel, down, err = connect(cur)
+				if err == nil {
+					break
+				}
+				select {
+				case <-time.After(f.cfg.HealthInterval):
+				case <-stop:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, func() { once.Do(func() { close(stop) }) }, nil
+}
@@ -0,0 +1,162 @@
+package Services_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+var errReset = errors.New("read tcp: connection reset by peer")
+
+// flakyUpstream answers ChainID, BlockNumber and BlockByNumber from its own
+// settings and counts the calls; fail breaks all three, failBlocks only the
+// block lookups
+type flakyUpstream struct {
+	Types.Backend
+	id int64
+
+	mu         sync.Mutex
+	head       int64
+	fail       bool
+	failBlocks bool
+	calls      int
+}
+
+func (u *flakyUpstream) set(fn func(u *flakyUpstream)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	fn(u)
+}
+
+func (u *flakyUpstream) called() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.calls
+}
+
+func (u *flakyUpstream) ChainID(ctx context.Context) (*big.Int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.calls++
+	if u.fail {
+		return nil, errReset
+	}
+	return big.NewInt(u.id), nil
+}
+
+func (u *flakyUpstream) BlockNumber(ctx context.Context) (*big.Int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.fail {
+		return nil, errReset
+	}
+	return big.NewInt(u.head), nil
+}
+
+func (u *flakyUpstream) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.fail || u.failBlocks {
+		return nil, errReset
+	}
+	return &Types.Block{Header: &Types.BlockHeader{Number: num.Uint64()}}, nil
+}
+
+func healthy(f *Services.FailoverBackend) map[string]bool {
+	out := map[string]bool{}
+	for _, s := range f.Status() {
+		out[s.Name] = s.Healthy
+	}
+	return out
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFailoverSwitchesOnFailure(t *testing.T) {
+	primary := &flakyUpstream{id: 1, head: 10}
+	backup := &flakyUpstream{id: 2, head: 10}
+	f := Services.NewFailoverBackend(Services.FailoverConfig{
+		Upstreams:      []Services.Upstream{{Name: "primary", Backend: primary}, {Name: "backup", Backend: backup, Priority: 1}},
+		Strategy:       Services.StrategyPriority,
+		HealthInterval: time.Hour,
+	})
+	t.Cleanup(f.Close)
+	ctx := context.Background()
+
+	if id, err := f.ChainID(ctx); err != nil || id.Int64() != 1 {
+		t.Fatalf("ChainID = %v, %v, want the primary's", id, err)
+	}
+	primary.set(func(u *flakyUpstream) { u.fail = true })
+	if id, err := f.ChainID(ctx); err != nil || id.Int64() != 2 {
+		t.Fatalf("ChainID with the primary down = %v, %v, want the backup's", id, err)
+	}
+
+	// Both down: the last transport error comes back
+	backup.set(func(u *flakyUpstream) { u.fail = true })
+	if _, err := f.ChainID(ctx); !errors.Is(err, errReset) {
+		t.Errorf("ChainID with every upstream down: %v", err)
+	}
+}
+
+func TestFailoverEjectsAndReinstates(t *testing.T) {
+	primary := &flakyUpstream{id: 1, head: 10}
+	backup := &flakyUpstream{id: 2, head: 10}
+	f := Services.NewFailoverBackend(Services.FailoverConfig{
+		Upstreams:      []Services.Upstream{{Name: "primary", Backend: primary}, {Name: "backup", Backend: backup, Priority: 1}},
+		Strategy:       Services.StrategyPriority,
+		HealthInterval: 20 * time.Millisecond,
+		MaxErrorRate:   0.1,
+		MinSamples:     2,
+	})
+	t.Cleanup(f.Close)
+	ctx := context.Background()
+
+	// Failing calls eject the primary; it still answers BlockNumber, but
+	// that alone does not bring it back while it cannot serve blocks
+	primary.set(func(u *flakyUpstream) { u.fail = true })
+	for range 2 {
+		f.ChainID(ctx)
+	}
+	if healthy(f)["primary"] {
+		t.Fatal("primary still healthy after failing calls")
+	}
+	primary.set(func(u *flakyUpstream) { u.fail, u.failBlocks = false, true })
+	time.Sleep(100 * time.Millisecond)
+	if healthy(f)["primary"] {
+		t.Fatal("primary reinstated on its head height alone")
+	}
+	before := primary.called()
+	if id, err := f.ChainID(ctx); err != nil || id.Int64() != 2 {
+		t.Errorf("ChainID while the primary is ejected = %v, %v", id, err)
+	}
+	if primary.called() != before {
+		t.Error("ejected primary was still called")
+	}
+
+	primary.set(func(u *flakyUpstream) { u.failBlocks = false })
+	waitFor(t, "the primary is reinstated", func() bool { return healthy(f)["primary"] })
+	if id, err := f.ChainID(ctx); err != nil || id.Int64() != 1 {
+		t.Errorf("ChainID after reinstatement = %v, %v, want the primary's", id, err)
+	}
+
+	// Falling behind the best head ejects too
+	primary.set(func(u *flakyUpstream) { u.head = 1 })
+	waitFor(t, "the lagging primary is ejected", func() bool { return !healthy(f)["primary"] })
+	primary.set(func(u *flakyUpstream) { u.head = 10 })
+	waitFor(t, "the caught-up primary is reinstated", func() bool { return healthy(f)["primary"] })
+}
@@ -0,0 +1,66 @@
+package Services
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// feedBuffer is how many undelivered values a subscriber may fall behind by
+// before further values are dropped for it.
+const feedBuffer = 256
+
+// feed fans values out to any number of subscribers. Sends never block: a
+// subscriber that stops reading loses values instead of stalling the producer.
+type feed[T any] struct {
+	mu   sync.Mutex
+	subs map[chan T]func(T) bool
+}
+
+// subscribe returns a channel of future values accepted by match (all values
+// if match is nil) and a stop func. The channel is closed after stop is
+// called or ctx is done.
+func (f *feed[T]) subscribe(ctx context.Context, match func(T) bool) (<-chan T, func()) {
+	ch := make(chan T, feedBuffer)
+	f.mu.Lock()
+	if f.subs == nil {
+		f.subs = map[chan T]func(T) bool{}
+	}
+	f.subs[ch] = match
+	f.mu.Unlock()
+
+	var once sync.Once
+	done := make(chan struct{})
+	stop := func() {
+		once.Do(func() {
+			f.mu.Lock()
+			delete(f.subs, ch)
+			close(ch)
+			f.mu.Unlock()
+			close(done)
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+		case <-done:
+		}
+	}()
+	return ch, stop
+}
+
+func (f *feed[T]) send(v T) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch, match := range f.subs {
+		if match != nil && !match(v) {
+			continue
+		}
+		select {
+		case ch <- v:
+		default:
+			log.Printf("⚠️ Subscriber fell %d values behind, dropping", feedBuffer)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package Services
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// isFilterMethod reports whether a method belongs to the polling filter API
+func isFilterMethod(method string) bool {
+	switch method {
+	case "eth_newFilter", "eth_newBlockFilter", "eth_newPendingTransactionFilter",
+		"eth_getFilterChanges", "eth_getFilterLogs", "eth_uninstallFilter":
+		return true
+	}
+	return false
+}
+
+// handleFilter serves the polling filter methods through the handlers'
+// FilterManager. Results follow geth's shapes.
+func (h *Handlers) handleFilter(ctx context.Context, req Types.Request) (Types.Response, error) {
+	switch req.Method {
+	case "eth_newFilter":
+		// params: [filter]
+		if len(req.Params) < 1 {
+			return invalidParams(req, "missing filter")
+		}
+		q, err := toFilterQuery(req.Params[0])
+		if err != nil {
+			return invalidParams(req, err.Error())
+		}
+		id, err := h.filters.NewLogFilter(ctx, *q)
+		return finish(req, id, err)
+
+	case "eth_newBlockFilter":
+		id, err := h.filters.NewBlockFilter(ctx)
+		return finish(req, id, err)
+
+	case "eth_newPendingTransactionFilter":
+		id, err := h.filters.NewPendingTxFilter(ctx)
+		return finish(req, id, err)
+	}
+
+	// The rest take params: [filterId]
+	id, ok := "", len(req.Params) > 0
+	if ok {
+		id, ok = req.Params[0].(string)
+	}
+	if !ok {
+		return invalidParams(req, "missing filter id")
+	}
+	switch req.Method {
+	case "eth_getFilterChanges":
+		changes, err := h.filters.Changes(ctx, id)
+		if err != nil {
+			return finish(req, nil, err)
+		}
+		if changes.Kind == FilterLogs {
+			return finish(req, marshalLogs(changes.Logs), nil)
+		}
+		hashes := make([]string, len(changes.Hashes))
+		for i, hash := range changes.Hashes {
+			hashes[i] = "0x" + hex.EncodeToString(hash)
+		}
+		return finish(req, hashes, nil)
+
+	case "eth_getFilterLogs":
+		logs, err := h.filters.Logs(ctx, id)
+		if err != nil {
+			return finish(req, nil, err)
+		}
+		return finish(req, marshalLogs(logs), nil)
+
+	case "eth_uninstallFilter":
+		return finish(req, h.filters.Uninstall(id), nil)
+
+	default:
+		return Types.RespErr(req.ID, -32601, "Method not found"), nil
+	}
+}
@@ -0,0 +1,390 @@
+package Services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// ErrFilterNotFound is returned for unknown, uninstalled and expired filters.
+// It carries geth's message, on which polling clients reinstall their filter.
+var ErrFilterNotFound = errors.New("filter not found")
+
+// FilterKind tells what a filter collects.
+type FilterKind string
+
+const (
+	FilterLogs       FilterKind = "logs"
+	FilterBlocks     FilterKind = "blocks"
+	FilterPendingTxs FilterKind = "pendingTransactions"
+)
+
+// FilterConfig configures a FilterManager.
+type FilterConfig struct {
+	// Timeout uninstalls filters that are not polled for this long (default 5m, as geth)
+	Timeout time.Duration
+	// MaxBuffered bounds the changes a filter keeps between polls (default
+	// 10000); beyond it the oldest are dropped
+	MaxBuffered int
+	// MaxFilters bounds the filters installed at once (default 10000)
+	MaxFilters int
+	// Metrics receives expiry and drop counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// FilterChanges is what a filter gathered since it was last polled: logs for
+// log filters, block or transaction hashes for the others.
+type FilterChanges struct {
+	Kind   FilterKind
+	Logs   []*Types.Log
+	Hashes [][]byte
+}
+
+// FilterManager serves the polling filters of eth_newFilter and friends.
+// On backends with subscriptions each filter holds one and buffers what it
+// delivers, including logs removed by reorgs. Otherwise block and log filters
+// are brought up to date with BlockByNumber and GetLogs when polled, and
+// pending transaction filters are not supported.
+type FilterManager struct {
+	be      Types.Backend
+	cfg     FilterConfig
+	metrics *Metrics
+
+	mu      sync.Mutex
+	filters map[string]*filter
+}
+
+type filter struct {
+	kind     FilterKind
+	query    Types.FilterQuery
+	timer    *time.Timer
+	deadline time.Time
+	stop     func() // ends the subscription, if any
+
+	mu      sync.Mutex
+	logs    []*Types.Log
+	hashes  [][]byte
+	ended   bool   // the subscription closed
+	polling bool   // no subscription; last is the newest block covered
+	last    uint64 // when polling
+}
+
+// NewFilterManager creates a filter manager for be. It starts no goroutines
+// until filters are installed.
+func NewFilterManager(be Types.Backend, cfg FilterConfig) *FilterManager {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	if cfg.MaxBuffered <= 0 {
+		cfg.MaxBuffered = 10000
+	}
+	if cfg.MaxFilters <= 0 {
+		cfg.MaxFilters = 10000
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	return &FilterManager{be: be, cfg: cfg, metrics: cfg.Metrics, filters: map[string]*filter{}}
+}
+
+// NewLogFilter installs a filter for logs matching q from now on.
+func (fm *FilterManager) NewLogFilter(ctx context.Context, q Types.FilterQuery) (string, error) {
+	if len(q.BlockHash) > 0 {
+		return "", errors.New("cannot specify blockhash")
+	}
+	return fm.install(ctx, FilterLogs, q)
+}
+
+// NewBlockFilter installs a filter for the hashes of new blocks.
+func (fm *FilterManager) NewBlockFilter(ctx context.Context) (string, error) {
+	return fm.install(ctx, FilterBlocks, Types.FilterQuery{})
+}
+
+// NewPendingTxFilter installs a filter for the hashes of new pending transactions.
+func (fm *FilterManager) NewPendingTxFilter(ctx context.Context) (string, error) {
+	return fm.install(ctx, FilterPendingTxs, Types.FilterQuery{})
+}
+
+func (fm *FilterManager) install(ctx context.Context, kind FilterKind, q Types.FilterQuery) (string, error) {
+	f := &filter{kind: kind, query: q}
+	if Types.Supports(fm.be, Types.CapSubscriptions) {
+		if err := fm.subscribe(f); err != nil {
+			return "", err
+		}
+	} else if kind == FilterPendingTxs {
+		return "", Types.ErrNotSupported
+	} else {
+		head, err := fm.be.BlockNumber(ctx)
+		if err != nil {
+			return "", err
+		}
+		f.polling, f.last = true, head.Uint64()
+	}
+
+	id := newFilterID()
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if len(fm.filters) >= fm.cfg.MaxFilters {
+		if f.stop != nil {
+			f.stop()
+		}
+		return "", errors.New("too many filters")
+	}
+	fm.filters[id] = f
+	f.deadline = time.Now().Add(fm.cfg.Timeout)
+	f.timer = time.AfterFunc(fm.cfg.Timeout, func() { fm.expire(id) })
+	return id, nil
+}
+
+// subscribe feeds f from a backend subscription that outlives the request
+func (fm *FilterManager) subscribe(f *filter) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := asSubscriptions(fm.be)
+	var stop func()
+	var err error
+	switch f.kind {
+	case FilterLogs:
+		var ch <-chan *Types.Log
+		if ch, stop, err = sub.SubscribeLogs(ctx, &f.query); err == nil {
+			go collect(f, ch, func(l *Types.Log) {
+				if inFilterRange(&f.query, l.BlockNumber) {
+					f.logs = buffer(fm, f, f.logs, l)
+				}
+			})
+		}
+	case FilterBlocks:
+		var ch <-chan *Types.Block
+		if ch, stop, err = sub.SubscribeNewHeads(ctx); err == nil {
+			go collect(f, ch, func(b *Types.Block) { f.hashes = buffer(fm, f, f.hashes, b.Header.Hash) })
+		}
+	case FilterPendingTxs:
+		var ch <-chan []byte
+		if ch, stop, err = sub.SubscribePendingTxs(ctx); err == nil {
+			go collect(f, ch, func(h []byte) { f.hashes = buffer(fm, f, f.hashes, h) })
+		}
+	}
+	if err != nil {
+		cancel()
+		return err
+	}
+	f.stop = func() {
+		stop()
+		cancel()
+	}
+	return nil
+}
+
+// collect buffers what a subscription delivers until it closes
+func collect[T any](f *filter, ch <-chan T, add func(T)) {
+	for v := range ch {
+		f.mu.Lock()
+		add(v)
+		f.mu.Unlock()
+	}
+	f.mu.Lock()
+	f.ended = true
+	f.mu.Unlock()
+}
+
+// buffer appends v, dropping the oldest change once the filter is full
+func buffer[T any](fm *FilterManager, f *filter, buf []T, v T) []T {
+	if len(buf) >= fm.cfg.MaxBuffered {
+		fm.metrics.Inc(metricName("facade_filter_dropped_total", "kind", string(f.kind)))
+		buf = buf[1:]
+	}
+	return append(buf, v)
+}
+
+// Changes returns and clears what the filter gathered since the last poll.
+// A filter whose subscription has ended hands over its last changes and is
+// then uninstalled, so the client's next poll makes it reinstall.
+func (fm *FilterManager) Changes(ctx context.Context, id string) (FilterChanges, error) {
+	f, err := fm.touch(id)
+	if err != nil {
+		return FilterChanges{}, err
+	}
+	if f.polling {
+		if err := fm.poll(ctx, f); err != nil {
+			return FilterChanges{}, err
+		}
+	}
+	f.mu.Lock()
+	out := FilterChanges{Kind: f.kind, Logs: f.logs, Hashes: f.hashes}
+	f.logs, f.hashes = nil, nil
+	ended := f.ended
+	f.mu.Unlock()
+	if ended {
+		fm.Uninstall(id)
+	}
+	return out, nil
+}
+
+// poll brings a filter without a subscription up to the head. The backend
+// is queried without holding the filter's lock; when concurrent polls cover
+// the same blocks, the first to finish buffers them and the others' results
+// are dropped. Blocks reorganised at the same height are not noticed.
+func (fm *FilterManager) poll(ctx context.Context, f *filter) error {
+	f.mu.Lock()
+	last := f.last
+	f.mu.Unlock()
+
+	num, err := fm.be.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	head := num.Uint64()
+	var (
+		hashes  [][]byte
+		logs    []*Types.Log
+		covered = head // newest block fetched
+		dropped uint64
+	)
+	switch {
+	case head <= last:
+		// Nothing new, or the chain went back; carry on from the new head
+	case f.kind == FilterBlocks:
+		from := last + 1
+		if limit := uint64(fm.cfg.MaxBuffered); head-last > limit {
+			dropped = head - last - limit
+			from = head - limit + 1
+		}
+		covered = from - 1
+		for n := from; n <= head; n++ {
+			var b *Types.Block
+			b, err = fm.be.BlockByNumber(ctx, new(big.Int).SetUint64(n), false)
+			if err != nil || b == nil || b.Header == nil {
+				break
+			}
+			hashes = append(hashes, b.Header.Hash)
+			covered = n
+		}
+	case f.kind == FilterLogs:
+		q := f.query
+		from, to := last+1, head
+		if q.FromBlock != nil && q.FromBlock.Sign() >= 0 {
+			from = max(from, q.FromBlock.Uint64())
+		}
+		if q.ToBlock != nil && q.ToBlock.Sign() >= 0 {
+			to = min(to, q.ToBlock.Uint64())
+		}
+		if from <= to {
+			q.FromBlock, q.ToBlock = new(big.Int).SetUint64(from), new(big.Int).SetUint64(to)
+			if logs, err = fm.be.GetLogs(ctx, q); err != nil {
+				return err
+			}
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.last != last {
+		// Another poll moved the filter on while this one was fetching
+		return err
+	}
+	if dropped > 0 {
+		fm.metrics.Add(metricName("facade_filter_dropped_total", "kind", string(f.kind)), dropped)
+	}
+	for _, h := range hashes {
+		f.hashes = buffer(fm, f, f.hashes, h)
+	}
+	for _, l := range logs {
+		f.logs = buffer(fm, f, f.logs, l)
+	}
+	// Blocks fetched before an error are kept; the next poll resumes after them
+	f.last = covered
+	return err
+}
+
+// Logs returns every log matching a log filter's query, as eth_getLogs would.
+func (fm *FilterManager) Logs(ctx context.Context, id string) ([]*Types.Log, error) {
+	f, err := fm.touch(id)
+	if err != nil {
+		return nil, err
+	}
+	if f.kind != FilterLogs {
+		return nil, ErrFilterNotFound
+	}
+	return fm.be.GetLogs(ctx, f.query)
+}
+
+// Uninstall removes a filter and reports whether it was installed.
+func (fm *FilterManager) Uninstall(id string) bool {
+	fm.mu.Lock()
+	f, ok := fm.filters[id]
+	if ok {
+		delete(fm.filters, id)
+		f.timer.Stop()
+	}
+	fm.mu.Unlock()
+	if ok && f.stop != nil {
+		f.stop()
+	}
+	return ok
+}
+
+// Close uninstalls every filter.
+func (fm *FilterManager) Close() {
+	fm.mu.Lock()
+	ids := make([]string, 0, len(fm.filters))
+	for id := range fm.filters {
+		ids = append(ids, id)
+	}
+	fm.mu.Unlock()
+	for _, id := range ids {
+		fm.Uninstall(id)
+	}
+}
+
+// touch finds a filter and restarts its idle timeout
+func (fm *FilterManager) touch(id string) (*filter, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	f, ok := fm.filters[id]
+	if !ok {
+		return nil, ErrFilterNotFound
+	}
+	f.deadline = time.Now().Add(fm.cfg.Timeout)
+	f.timer.Reset(fm.cfg.Timeout)
+	return f, nil
+}
+
+// expire uninstalls a filter left idle past its deadline
+func (fm *FilterManager) expire(id string) {
+	fm.mu.Lock()
+	f, ok := fm.filters[id]
+	if !ok || time.Now().Before(f.deadline) {
+		// Polled while the timer fired; the reset timer fires again
+		fm.mu.Unlock()
+		return
+	}
+	delete(fm.filters, id)
+	fm.mu.Unlock()
+	if f.stop != nil {
+		f.stop()
+	}
+	fm.metrics.Inc(metricName("facade_filter_expired_total", "kind", string(f.kind)))
+}
+
+// inFilterRange reports whether block n lies within a query's explicit bounds
+func inFilterRange(q *Types.FilterQuery, n uint64) bool {
+	if q.FromBlock != nil && q.FromBlock.Sign() >= 0 && n < q.FromBlock.Uint64() {
+		return false
+	}
+	if q.ToBlock != nil && q.ToBlock.Sign() >= 0 && n > q.ToBlock.Uint64() {
+		return false
+	}
+	return true
+}
+
+// newFilterID returns a random id in geth's format
+func newFilterID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return "0x" + hex.EncodeToString(b[:])
+}
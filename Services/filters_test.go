@@ -0,0 +1,283 @@
+package Services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// polledChain hides the memory chain's subscriptions, so filters poll it.
+// While gate is set, BlockByNumber waits for it to close.
+type polledChain struct {
+	Types.Backend
+	mu      sync.Mutex
+	gate    chan struct{}
+	fetches int
+	ranges  [][2]uint64
+}
+
+func (p *polledChain) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	p.mu.Lock()
+	p.fetches++
+	gate := p.gate
+	p.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+	return p.Backend.BlockByNumber(ctx, num, fullTx)
+}
+
+func (p *polledChain) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	p.mu.Lock()
+	p.ranges = append(p.ranges, [2]uint64{q.FromBlock.Uint64(), q.ToBlock.Uint64()})
+	p.mu.Unlock()
+	return p.Backend.GetLogs(ctx, q)
+}
+
+// fedHeads serves new heads from a channel the test writes to and closes
+type fedHeads struct {
+	Types.Backend
+	heads chan *Types.Block
+}
+
+func (f *fedHeads) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return f.heads, func() {}, nil
+}
+func (f *fedHeads) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return nil, nil, Types.ErrNotSupported
+}
+func (f *fedHeads) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return nil, nil, Types.ErrNotSupported
+}
+
+func newPolledChain(t *testing.T) (*mem, *polledChain) {
+	t.Helper()
+	m := newMem(MemoryConfig{ChainID: big.NewInt(7)})
+	t.Cleanup(m.Close)
+	return m, &polledChain{Backend: m}
+}
+
+func mine(t *testing.T, m *mem, n uint64) {
+	t.Helper()
+	if err := m.Mine(context.Background(), n, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func blockHash(t *testing.T, be Types.Backend, n uint64) []byte {
+	t.Helper()
+	b, err := be.BlockByNumber(context.Background(), new(big.Int).SetUint64(n), false)
+	if err != nil || b == nil {
+		t.Fatalf("block %d: %v", n, err)
+	}
+	return b.Header.Hash
+}
+
+func TestFilterExpiry(t *testing.T) {
+	_, chain := newPolledChain(t)
+	metrics := NewMetrics()
+	fm := NewFilterManager(chain, FilterConfig{Timeout: 50 * time.Millisecond, Metrics: metrics})
+	ctx := context.Background()
+	id, err := fm.NewBlockFilter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Polls keep the filter alive well past one timeout
+	for range 4 {
+		time.Sleep(25 * time.Millisecond)
+		if _, err := fm.Changes(ctx, id); err != nil {
+			t.Fatalf("Changes on a polled filter: %v", err)
+		}
+	}
+
+	// A timer firing just as a poll resets the deadline leaves the filter
+	if _, err := fm.touch(id); err != nil {
+		t.Fatal(err)
+	}
+	fm.expire(id)
+	if _, err := fm.Changes(ctx, id); err != nil {
+		t.Fatalf("filter expired by a stale timer: %v", err)
+	}
+	if n := metrics.Get(`facade_filter_expired_total{kind="blocks"}`); n != 0 {
+		t.Fatalf("%d filters expired while polled", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := fm.Changes(ctx, id); !errors.Is(err, ErrFilterNotFound) {
+		t.Fatalf("Changes after the timeout: %v, want ErrFilterNotFound", err)
+	}
+	if n := metrics.Get(`facade_filter_expired_total{kind="blocks"}`); n != 1 {
+		t.Errorf("%d filters expired, want 1", n)
+	}
+	if fm.Uninstall(id) {
+		t.Error("Uninstall found an expired filter")
+	}
+}
+
+func TestFilterPollingCatchUp(t *testing.T) {
+	m, chain := newPolledChain(t)
+	metrics := NewMetrics()
+	fm := NewFilterManager(chain, FilterConfig{MaxBuffered: 3, Metrics: metrics})
+	ctx := context.Background()
+	blocks, err := fm.NewBlockFilter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, err := fm.NewLogFilter(ctx, Types.FilterQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mine(t, m, 2)
+	got, err := fm.Changes(ctx, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Hashes) != 2 || !bytes.Equal(got.Hashes[1], blockHash(t, m, 2)) {
+		t.Fatalf("first poll = %x, want blocks 1 and 2", got.Hashes)
+	}
+
+	// Falling further behind than MaxBuffered keeps only the newest blocks
+	mine(t, m, 5)
+	got, err = fm.Changes(ctx, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Hashes) != 3 || !bytes.Equal(got.Hashes[0], blockHash(t, m, 5)) || !bytes.Equal(got.Hashes[2], blockHash(t, m, 7)) {
+		t.Fatalf("catch-up poll = %x, want blocks 5 to 7", got.Hashes)
+	}
+	if n := metrics.Get(`facade_filter_dropped_total{kind="blocks"}`); n != 2 {
+		t.Errorf("%d block hashes dropped, want 2", n)
+	}
+	if got, _ := fm.Changes(ctx, blocks); len(got.Hashes) != 0 {
+		t.Errorf("poll without new blocks = %x", got.Hashes)
+	}
+
+	// The log filter asks for everything since install in one range, then
+	// nothing until the next block
+	if _, err := fm.Changes(ctx, logs); err != nil {
+		t.Fatal(err)
+	}
+	fm.Changes(ctx, logs)
+	mine(t, m, 1)
+	fm.Changes(ctx, logs)
+	if want := [][2]uint64{{1, 7}, {8, 8}}; len(chain.ranges) != 2 || chain.ranges[0] != want[0] || chain.ranges[1] != want[1] {
+		t.Errorf("GetLogs ranges %v, want %v", chain.ranges, want)
+	}
+}
+
+func TestFilterPollFetchesUnlocked(t *testing.T) {
+	m, chain := newPolledChain(t)
+	fm := NewFilterManager(chain, FilterConfig{Metrics: NewMetrics()})
+	ctx := context.Background()
+	id, err := fm.NewBlockFilter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := fm.touch(id)
+	mine(t, m, 3)
+
+	gate := make(chan struct{})
+	chain.mu.Lock()
+	chain.gate = gate
+	chain.mu.Unlock()
+	first := make(chan FilterChanges)
+	go func() {
+		got, _ := fm.Changes(ctx, id)
+		first <- got
+	}()
+	for {
+		chain.mu.Lock()
+		n := chain.fetches
+		chain.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The filter stays free while the poll waits on the backend
+	if !f.mu.TryLock() {
+		t.Fatal("filter locked during the backend fetch")
+	}
+	f.mu.Unlock()
+
+	// A second poll racing the first delivers each block once between them
+	second := make(chan FilterChanges)
+	go func() {
+		got, _ := fm.Changes(ctx, id)
+		second <- got
+	}()
+	close(gate)
+	a, b := <-first, <-second
+	if n := len(a.Hashes) + len(b.Hashes); n != 3 {
+		t.Errorf("racing polls delivered %d hashes, want 3", n)
+	}
+}
+
+func TestFilterSubscriptionBuffer(t *testing.T) {
+	m := newMem(MemoryConfig{ChainID: big.NewInt(7)})
+	t.Cleanup(m.Close)
+	feed := &fedHeads{Backend: m, heads: make(chan *Types.Block)}
+	metrics := NewMetrics()
+	fm := NewFilterManager(feed, FilterConfig{MaxBuffered: 2, Metrics: metrics})
+	ctx := context.Background()
+	id, err := fm.NewBlockFilter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := func(n byte) *Types.Block { return &Types.Block{Header: &Types.BlockHeader{Hash: []byte{n}}} }
+
+	// The oldest heads go once the buffer is full
+	for n := byte(1); n <= 4; n++ {
+		feed.heads <- head(n)
+	}
+	feed.heads <- head(5)
+	for metrics.Get(`facade_filter_dropped_total{kind="blocks"}`) < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	got, err := fm.Changes(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Hashes) != 2 || got.Hashes[0][0] != 4 || got.Hashes[1][0] != 5 {
+		t.Fatalf("Changes = %x, want heads 4 and 5", got.Hashes)
+	}
+	if n := metrics.Get(`facade_filter_dropped_total{kind="blocks"}`); n != 3 {
+		t.Errorf("%d heads dropped, want 3", n)
+	}
+
+	// Once the subscription ends, the last heads are handed over and the
+	// filter goes so the client reinstalls it
+	feed.heads <- head(6)
+	close(feed.heads)
+	deadline := time.Now().Add(time.Second)
+	for {
+		f, err := fm.touch(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.mu.Lock()
+		ended := f.ended
+		f.mu.Unlock()
+		if ended {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("filter never saw its subscription end")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, err := fm.Changes(ctx, id); err != nil || len(got.Hashes) != 1 || got.Hashes[0][0] != 6 {
+		t.Fatalf("last Changes = %x, %v, want head 6", got.Hashes, err)
+	}
+	if _, err := fm.Changes(ctx, id); !errors.Is(err, ErrFilterNotFound) {
+		t.Errorf("Changes after the subscription ended: %v, want ErrFilterNotFound", err)
+	}
+}
@@ -0,0 +1,503 @@
+package Services
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Chain fixtures describe a small, fixed chain in the same shapes geth
+// returns over JSON-RPC, as JSON or YAML:
+//
+//	chainId: "0xaa36a7"
+//	blocks:            # eth_getBlockByNumber(n, true) results, in any order
+//	  - number: "0x0"
+//	    hash: "0x..."
+//	    transactions: [ ... full transaction objects ... ]
+//	receipts:          # eth_getTransactionReceipt results, logs included
+//	  - transactionHash: "0x..."
+//	    status: "0x1"
+//	    logs: [ ... ]
+//	accounts:          # state snapshots per address, applying from "block" on
+//	  "0xabc...":
+//	    - block: 0
+//	      balance: "0xde0b6b3a7640000"
+//	      nonce: 0
+//	      code: "0x"
+//	      storage: { "0x0": "0x2a" }
+//
+// Block, transaction and log positions in receipts are filled in from the
+// blocks, so they only need the hash. Fields omitted from a later account
+// snapshot carry over from the previous one.
+// //test: Lets frontend work proceed against known data
+
+type fixtureFile struct {
+	ChainID       *hexBig                     `json:"chainId"`
+	ClientVersion string                      `json:"clientVersion"`
+	GasPrice      *hexBig                     `json:"gasPrice"`
+	Blocks        []json.RawMessage           `json:"blocks"`
+	Receipts      []json.RawMessage           `json:"receipts"`
+	Accounts      map[string]fixtureSnapshots `json:"accounts"`
+}
+
+type fixtureAccount struct {
+	Block   hexUint             `json:"block"`
+	Balance *hexBig             `json:"balance"`
+	Nonce   *hexUint            `json:"nonce"`
+	Code    *hexBytes           `json:"code"`
+	Storage map[string]hexBytes `json:"storage"`
+}
+
+// fixtureSnapshots accepts either a list of snapshots or a single one for block 0
+type fixtureSnapshots []fixtureAccount
+
+func (s *fixtureSnapshots) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var one fixtureAccount
+		if err := json.Unmarshal(trimmed, &one); err != nil {
+			return err
+		}
+		*s = fixtureSnapshots{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]fixtureAccount)(s))
+}
+
+// accountState is an account as of a given block
+type accountState struct {
+	block   uint64
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[string][]byte
+}
+
+// txLocation places a transaction in the chain
+type txLocation struct {
+	block *Types.Block
+	index int
+}
+
+// fixture serves a fixed chain loaded from a ChainFixture file
+// //test: Deterministic data for development and integration tests
+type fixture struct {
+	chainID       *big.Int
+	clientVersion string
+	gasPrice      *big.Int
+	head          uint64
+	byNumber      map[uint64]*Types.Block
+	byHash        map[string]*Types.Block
+	txs           map[string]txLocation
+	receipts      map[string]*Types.Receipt
+	logs          map[uint64][]*Types.Log
+	accounts      map[string][]*accountState
+}
+
+// LoadFixtureBackend reads a JSON or YAML chain fixture from path.
+func LoadFixtureBackend(path string) (Types.Backend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	be, err := NewFixtureBackend(data)
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", path, err)
+	}
+	return be, nil
+}
+
+// NewFixtureBackend builds a backend from fixture data. Input that is not a
+// JSON object is parsed as YAML.
+func NewFixtureBackend(data []byte) (Types.Backend, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+		data = converted
+	}
+	var ff fixtureFile
+	if err := json.Unmarshal(data, &ff); err != nil {
+		return nil, err
+	}
+
+	f := &fixture{
+		chainID:       big.NewInt(11155111),
+		clientVersion: "fixture-backend/0.1.0",
+		gasPrice:      big.NewInt(1_000_000_000),
+		byNumber:      map[uint64]*Types.Block{},
+		byHash:        map[string]*Types.Block{},
+		txs:           map[string]txLocation{},
+		receipts:      map[string]*Types.Receipt{},
+		logs:          map[uint64][]*Types.Log{},
+		accounts:      map[string][]*accountState{},
+	}
+	if ff.ChainID != nil {
+		f.chainID = ff.ChainID.toBig()
+	}
+	if ff.ClientVersion != "" {
+		f.clientVersion = ff.ClientVersion
+	}
+	if ff.GasPrice != nil {
+		f.gasPrice = ff.GasPrice.toBig()
+	}
+	if err := f.loadBlocks(ff.Blocks); err != nil {
+		return nil, err
+	}
+	if err := f.loadReceipts(ff.Receipts); err != nil {
+		return nil, err
+	}
+	if err := f.loadAccounts(ff.Accounts); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *fixture) loadBlocks(raw []json.RawMessage) error {
+	for i, r := range raw {
+		b, err := decodeBlock(r)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		if b == nil {
+			continue
+		}
+		n := b.Header.Number
+		if len(b.Header.Hash) == 0 {
+			return fmt.Errorf("block %d has no hash", n)
+		}
+		if _, dup := f.byNumber[n]; dup {
+			return fmt.Errorf("block %d defined twice", n)
+		}
+		f.byNumber[n] = b
+		f.byHash[string(b.Header.Hash)] = b
+		f.head = max(f.head, n)
+		for idx, tx := range b.Transactions {
+			if len(tx.Hash) == 0 {
+				return fmt.Errorf("block %d transaction %d has no hash", n, idx)
+			}
+			f.txs[string(tx.Hash)] = txLocation{block: b, index: idx}
+		}
+	}
+	// Link parents that were left out
+	for n, b := range f.byNumber {
+		if parent, ok := f.byNumber[n-1]; ok && n > 0 && len(b.Header.ParentHash) == 0 {
+			b.Header.ParentHash = parent.Header.Hash
+		}
+	}
+	return nil
+}
+
+func (f *fixture) loadReceipts(raw []json.RawMessage) error {
+	for i, r := range raw {
+		rc, err := decodeReceipt(r)
+		if err != nil {
+			return fmt.Errorf("receipt %d: %w", i, err)
+		}
+		if rc == nil {
+			continue
+		}
+		if _, ok := f.txs[string(rc.TxHash)]; !ok {
+			return fmt.Errorf("receipt for unknown transaction 0x%x", rc.TxHash)
+		}
+		f.receipts[string(rc.TxHash)] = rc
+	}
+
+	// Fill positions and synthesize receipts for transactions without one,
+	// walking blocks in order so log indexes run across each block
+	numbers := make([]uint64, 0, len(f.byNumber))
+	for n := range f.byNumber {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for _, n := range numbers {
+		b := f.byNumber[n]
+		var cumulative, logIndex uint64
+		for idx, tx := range b.Transactions {
+			rc, ok := f.receipts[string(tx.Hash)]
+			if !ok {
+				rc = &Types.Receipt{TxHash: tx.Hash, Status: 1, GasUsed: tx.Gas, Logs: []*Types.Log{}, Type: tx.Type}
+				f.receipts[string(tx.Hash)] = rc
+			}
+			rc.BlockHash = b.Header.Hash
+			rc.BlockNumber = n
+			rc.TransactionIndex = uint64(idx)
+			cumulative += rc.GasUsed
+			if rc.CumulativeGasUsed == 0 {
+				rc.CumulativeGasUsed = cumulative
+			}
+			for _, l := range rc.Logs {
+				l.BlockNumber = n
+				l.BlockHash = b.Header.Hash
+				l.TxHash = tx.Hash
+				l.TxIndex = uint64(idx)
+				l.LogIndex = logIndex
+				logIndex++
+				f.logs[n] = append(f.logs[n], l)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fixture) loadAccounts(accounts map[string]fixtureSnapshots) error {
+	for addrHex, snaps := range accounts {
+		addr, err := decodeHex(addrHex)
+		if err != nil {
+			return fmt.Errorf("account %s: %w", addrHex, err)
+		}
+		sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].Block < snaps[j].Block })
+		prev := &accountState{balance: new(big.Int), storage: map[string][]byte{}}
+		var states []*accountState
+		for _, s := range snaps {
+			st := &accountState{block: uint64(s.Block), balance: prev.balance, nonce: prev.nonce, code: prev.code, storage: map[string][]byte{}}
+			for k, v := range prev.storage {
+				st.storage[k] = v
+			}
+			if s.Balance != nil {
+				st.balance = s.Balance.toBig()
+			}
+			if s.Nonce != nil {
+				st.nonce = uint64(*s.Nonce)
+			}
+			if s.Code != nil {
+				st.code = *s.Code
+			}
+			for k, v := range s.Storage {
+				key, err := decodeHex(k)
+				if err != nil {
+					return fmt.Errorf("account %s storage key %s: %w", addrHex, k, err)
+				}
+				st.storage[storageSlot(key)] = leftPad32(v)
+			}
+			states = append(states, st)
+			prev = st
+		}
+		f.accounts[string(addr)] = states
+	}
+	return nil
+}
+
+// storageSlot normalises a storage key to its 32-byte hex form
+func storageSlot(key []byte) string { return hex.EncodeToString(leftPad32(key)) }
+
+func leftPad32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b[len(b)-32:]
+	}
+	out := make([]byte, 32)
+	copy(out[32-len(b):], b)
+	return out
+}
+
+// resolve maps a requested block number to a block in the fixture, nil meaning the head
+func (f *fixture) resolve(num *big.Int) uint64 {
+	if num == nil || num.Sign() < 0 || !num.IsUint64() {
+		return f.head
+	}
+	return num.Uint64()
+}
+
+// state returns addr as of block, or nil if it has no snapshot yet
+func (f *fixture) state(addr []byte, block *big.Int) *accountState {
+	n := f.resolve(block)
+	states := f.accounts[string(addr)]
+	i := sort.Search(len(states), func(i int) bool { return states[i].block > n })
+	if i == 0 {
+		return nil
+	}
+	return states[i-1]
+}
+
+// Basic blockchain info
+func (f *fixture) ChainID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(f.chainID), nil
+}
+func (f *fixture) ClientVersion(ctx context.Context) (string, error) {
+	return f.clientVersion, nil
+}
+func (f *fixture) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).SetUint64(f.head), nil
+}
+
+// Block operations
+func (f *fixture) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return f.byNumber[f.resolve(num)], nil
+}
+func (f *fixture) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return f.byHash[string(hash)], nil
+}
+func (f *fixture) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	if b := f.byNumber[f.resolve(blockNum)]; b != nil {
+		return uint64(len(b.Transactions)), nil
+	}
+	return 0, nil
+}
+func (f *fixture) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	if b := f.byHash[string(blockHash)]; b != nil {
+		return uint64(len(b.Transactions)), nil
+	}
+	return 0, nil
+}
+
+// Account operations
+func (f *fixture) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	if st := f.state(addr, block); st != nil {
+		return new(big.Int).Set(st.balance), nil
+	}
+	return big.NewInt(0), nil
+}
+func (f *fixture) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	if st := f.state(addr, block); st != nil && st.code != nil {
+		return st.code, nil
+	}
+	return []byte{}, nil
+}
+func (f *fixture) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	if st := f.state(addr, block); st != nil {
+		if v, ok := st.storage[storageSlot(key)]; ok {
+			return v, nil
+		}
+	}
+	return make([]byte, 32), nil
+}
+func (f *fixture) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	if st := f.state(addr, block); st != nil {
+		return st.nonce, nil
+	}
+	return 0, nil
+}
+
+// Transaction operations
+func (f *fixture) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	// //future: Fixtures may declare canned call results
+	return []byte{}, nil
+}
+func (f *fixture) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return 21000, nil
+}
+func (f *fixture) GasPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(f.gasPrice), nil
+}
+func (f *fixture) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	return nil, errors.New("fixture backend is read-only")
+}
+func (f *fixture) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	if loc, ok := f.txs[string(hash)]; ok {
+		return loc.block.Transactions[loc.index], nil
+	}
+	return nil, nil
+}
+func (f *fixture) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return txAt(f.byNumber[f.resolve(blockNum)], index), nil
+}
+func (f *fixture) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return txAt(f.byHash[string(blockHash)], index), nil
+}
+func (f *fixture) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return f.receipts[string(hash)], nil
+}
+
+func txAt(b *Types.Block, index uint64) *Types.Transaction {
+	if b == nil || index >= uint64(len(b.Transactions)) {
+		return nil
+	}
+	return b.Transactions[index]
+}
+
+// Log operations
+func (f *fixture) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	out := []*Types.Log{}
+	if len(q.BlockHash) > 0 {
+		b := f.byHash[string(q.BlockHash)]
+		if b == nil {
+			return nil, fmt.Errorf("unknown block 0x%x", q.BlockHash)
+		}
+		for _, l := range f.logs[b.Header.Number] {
+			if logMatches(&q, l) {
+				out = append(out, l)
+			}
+		}
+		return out, nil
+	}
+	from, to := filterRange(&q, f.head)
+	for n := from; n <= min(to, f.head); n++ {
+		for _, l := range f.logs[n] {
+			if logMatches(&q, l) {
+				out = append(out, l)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Network operations
+func (f *fixture) PeerCount(ctx context.Context) (uint64, error) { return 0, nil }
+func (f *fixture) Listening(ctx context.Context) (bool, error)   { return true, nil }
+func (f *fixture) Syncing(ctx context.Context) (map[string]any, error) {
+	return map[string]any{"syncing": false}, nil
+}
+
+// Mining operations (for PoW chains)
+func (f *fixture) Mining(ctx context.Context) (bool, error)     { return false, nil }
+func (f *fixture) Hashrate(ctx context.Context) (uint64, error) { return 0, nil }
+
+// Uncle operations (for PoW chains)
+// //future: Fixtures only list uncle hashes, so uncle bodies are not served
+func (f *fixture) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	if b := f.byNumber[f.resolve(blockNum)]; b != nil {
+		return uint64(len(b.Ommers)), nil
+	}
+	return 0, nil
+}
+func (f *fixture) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	if b := f.byHash[string(blockHash)]; b != nil {
+		return uint64(len(b.Ommers)), nil
+	}
+	return 0, nil
+}
+func (f *fixture) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return nil, nil
+}
+func (f *fixture) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return nil, nil
+}
+
+// Subscriptions: the chain is fixed, so streams stay open without events
+func (f *fixture) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	ch, stop := idleSubscription[*Types.Block](ctx)
+	return ch, stop, nil
+}
+func (f *fixture) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	ch, stop := idleSubscription[*Types.Log](ctx)
+	return ch, stop, nil
+}
+func (f *fixture) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	ch, stop := idleSubscription[[]byte](ctx)
+	return ch, stop, nil
+}
+
+// idleSubscription returns a channel that never delivers and closes on stop or ctx
+func idleSubscription[T any](ctx context.Context) (<-chan T, func()) {
+	ch := make(chan T)
+	stop := make(chan struct{})
+	var once sync.Once
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+		case <-stop:
+		}
+	}()
+	return ch, func() { once.Do(func() { close(stop) }) }
+}
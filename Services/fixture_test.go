@@ -0,0 +1,174 @@
+package Services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+	"github.com/jupitermetalabs/geth-facade/Types/backendtest"
+)
+
+// fixtureOf exports a chain as fixture JSON through the handlers, so the
+// fixture holds exactly what a client of that chain would have seen
+func fixtureOf(t *testing.T, be Types.Backend) []byte {
+	t.Helper()
+	h := Services.NewHandlers(be)
+	call := func(out any, method string, params ...any) {
+		t.Helper()
+		resp, err := h.Handle(context.Background(), Types.Request{Jsonrpc: "2.0", ID: 1, Method: method, Params: params})
+		if err != nil || resp.Error != nil {
+			t.Fatalf("%s: %v, %v", method, err, resp.Error)
+		}
+		enc, err := json.Marshal(resp.Result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(enc, out); err != nil {
+			t.Fatalf("%s result: %v", method, err)
+		}
+	}
+
+	var chainID, head string
+	call(&chainID, "eth_chainId")
+	call(&head, "eth_blockNumber")
+	var blocks, receipts []json.RawMessage
+	accounts := map[string][]map[string]any{}
+	var n big.Int
+	n.SetString(head[2:], 16)
+	for i := uint64(0); i <= n.Uint64(); i++ {
+		var block struct {
+			Transactions []struct {
+				Hash string `json:"hash"`
+				From string `json:"from"`
+			} `json:"transactions"`
+		}
+		var raw json.RawMessage
+		call(&raw, "eth_getBlockByNumber", fmt.Sprintf("0x%x", i), true)
+		if err := json.Unmarshal(raw, &block); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, raw)
+		for _, tx := range block.Transactions {
+			var receipt json.RawMessage
+			call(&receipt, "eth_getTransactionReceipt", tx.Hash)
+			receipts = append(receipts, receipt)
+			var created struct {
+				ContractAddress *string `json:"contractAddress"`
+			}
+			json.Unmarshal(receipt, &created)
+			for _, addr := range []*string{&tx.From, created.ContractAddress} {
+				if addr == nil || accounts[*addr] != nil {
+					continue
+				}
+				var genesis, balance, nonce, code string
+				call(&genesis, "eth_getBalance", *addr, "0x0")
+				call(&balance, "eth_getBalance", *addr, head)
+				call(&nonce, "eth_getTransactionCount", *addr, head)
+				call(&code, "eth_getCode", *addr, head)
+				accounts[*addr] = []map[string]any{
+					{"block": 0, "balance": genesis},
+					{"block": head, "balance": balance, "nonce": nonce, "code": code},
+				}
+			}
+		}
+	}
+	data, err := json.Marshal(map[string]any{"chainId": chainID, "blocks": blocks, "receipts": receipts, "accounts": accounts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFixtureConformance(t *testing.T) {
+	backendtest.RunConformance(t, func(t *testing.T) Types.Backend {
+		be, err := Services.NewFixtureBackend(fixtureOf(t, seededMemory(t)))
+		if err != nil {
+			t.Fatalf("NewFixtureBackend: %v", err)
+		}
+		return be
+	})
+}
+
+func TestFixtureLoadsExample(t *testing.T) {
+	be, err := Services.LoadFixtureBackend("../Scripts/examples/fixtures/chain.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	hexBytes := func(s string) []byte {
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	if id, err := be.ChainID(ctx); err != nil || id.Int64() != 11155111 {
+		t.Errorf("ChainID = %v, %v", id, err)
+	}
+	if head, err := be.BlockNumber(ctx); err != nil || head.Int64() != 1 {
+		t.Errorf("BlockNumber = %v, %v", head, err)
+	}
+	block, err := be.BlockByNumber(ctx, big.NewInt(1), true)
+	if err != nil || block == nil || len(block.Transactions) != 2 {
+		t.Fatalf("block 1 = %+v, %v", block, err)
+	}
+	if !bytes.Equal(block.Header.ParentHash, hexBytes("0x1000000000000000000000000000000000000000000000000000000000000000")) {
+		t.Errorf("block 1 parent 0x%x", block.Header.ParentHash)
+	}
+
+	// The receipt only names its transaction; the rest comes from block 1
+	r, err := be.ReceiptByHash(ctx, block.Transactions[1].Hash)
+	if err != nil || r == nil || r.BlockNumber != 1 || r.TransactionIndex != 1 || len(r.Logs) != 1 {
+		t.Fatalf("receipt = %+v, %v", r, err)
+	}
+	if l := r.Logs[0]; l.BlockNumber != 1 || !bytes.Equal(l.TxHash, block.Transactions[1].Hash) {
+		t.Errorf("log placed at block %d tx 0x%x", l.BlockNumber, l.TxHash)
+	}
+	if logs, err := be.GetLogs(ctx, Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(1)}); err != nil || len(logs) != 1 {
+		t.Errorf("GetLogs = %d logs, %v", len(logs), err)
+	}
+
+	// Account snapshots apply from their block, and omitted fields carry over
+	sender := block.Transactions[0].From
+	for _, c := range []struct {
+		block   int64
+		balance string
+		nonce   uint64
+	}{{0, "100000000000000000000", 0}, {1, "92160000000000000000", 1}} {
+		bal, err := be.Balance(ctx, sender, big.NewInt(c.block))
+		if err != nil || bal.String() != c.balance {
+			t.Errorf("balance at %d = %v, %v, want %s", c.block, bal, err, c.balance)
+		}
+		if n, err := be.GetTransactionCount(ctx, sender, big.NewInt(c.block)); err != nil || n != c.nonce {
+			t.Errorf("nonce at %d = %d, %v, want %d", c.block, n, err, c.nonce)
+		}
+	}
+	token := block.Transactions[1].To
+	if code, err := be.GetCode(ctx, token, nil); err != nil || len(code) != 5 {
+		t.Errorf("token code = 0x%x, %v", code, err)
+	}
+	if v, err := be.GetStorageAt(ctx, token, make([]byte, 32), nil); err != nil || new(big.Int).SetBytes(v).String() != "1000000000000000000000" {
+		t.Errorf("token slot 0 = 0x%x, %v", v, err)
+	}
+}
+
+func TestFixtureRejectsMalformed(t *testing.T) {
+	for name, data := range map[string]string{
+		"yaml":    "blocks: [",
+		"block":   `{"blocks": [{"number": "zz"}]}`,
+		"receipt": `{"receipts": [{"transactionHash": "0x01"}]}`,
+		"account": `{"accounts": {"0xzz": {"balance": "0x1"}}}`,
+	} {
+		if _, err := Services.NewFixtureBackend([]byte(data)); err == nil {
+			t.Errorf("%s: malformed fixture accepted", name)
+		}
+	}
+}
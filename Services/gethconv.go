@@ -0,0 +1,230 @@
+package Services
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Conversions between go-ethereum's core types and the facade's Types.
+// //conversions: Hashes and addresses become []byte, quantities big-endian []byte or uint64
+
+func bigBytes(n *big.Int) []byte {
+	if n == nil {
+		return nil
+	}
+	return n.Bytes()
+}
+
+// fromGethHeader converts a header; the hash is taken from the header itself
+func fromGethHeader(h *types.Header) *Types.BlockHeader {
+	out := &Types.BlockHeader{
+		ParentHash:          h.ParentHash.Bytes(),
+		StateRoot:           h.Root.Bytes(),
+		ReceiptsRoot:        h.ReceiptHash.Bytes(),
+		LogsBloom:           h.Bloom.Bytes(),
+		Miner:               h.Coinbase.Bytes(),
+		Number:              h.Number.Uint64(),
+		GasLimit:            h.GasLimit,
+		GasUsed:             h.GasUsed,
+		Timestamp:           h.Time,
+		MixHashOrPrevRandao: h.MixDigest.Bytes(),
+		BaseFee:             bigBytes(h.BaseFee),
+		ExtraData:           h.Extra,
+		Hash:                h.Hash().Bytes(),
+	}
+	if h.BlobGasUsed != nil {
+		out.BlobGasUsedField = *h.BlobGasUsed
+	}
+	if h.ExcessBlobGas != nil {
+		out.ExcessBlobGasField = *h.ExcessBlobGas
+	}
+	return out
+}
+
+// fromGethBlock converts a block with full transactions
+func fromGethBlock(b *types.Block, signer types.Signer) *Types.Block {
+	out := &Types.Block{
+		Header:       fromGethHeader(b.Header()),
+		Transactions: make([]*Types.Transaction, 0, len(b.Transactions())),
+	}
+	for _, tx := range b.Transactions() {
+		out.Transactions = append(out.Transactions, fromGethTx(tx, signer))
+	}
+	for _, u := range b.Uncles() {
+		out.Ommers = append(out.Ommers, u.Hash().Bytes())
+	}
+	if b.Header().WithdrawalsHash != nil {
+		out.WithdrawalsRoot = b.Header().WithdrawalsHash.Bytes()
+	}
+	for _, w := range b.Withdrawals() {
+		out.Withdrawals = append(out.Withdrawals, &Types.Withdrawal{
+			Index:          w.Index,
+			ValidatorIndex: w.Validator,
+			Address:        w.Address.Bytes(),
+			Amount:         w.Amount,
+		})
+	}
+	return out
+}
+
+// fromGethTx converts a signed transaction, recovering its sender with signer
+func fromGethTx(tx *types.Transaction, signer types.Signer) *Types.Transaction {
+	v, r, s := tx.RawSignatureValues()
+	out := &Types.Transaction{
+		Hash:     tx.Hash().Bytes(),
+		Input:    tx.Data(),
+		Nonce:    tx.Nonce(),
+		Value:    bigBytes(tx.Value()),
+		Gas:      tx.Gas(),
+		GasPrice: bigBytes(tx.GasPrice()),
+		Type:     uint32(tx.Type()),
+		R:        bigBytes(r),
+		S:        bigBytes(s),
+		V:        uint32(v.Uint64()),
+	}
+	if from, err := types.Sender(signer, tx); err == nil {
+		out.From = from.Bytes()
+	}
+	if to := tx.To(); to != nil {
+		out.To = to.Bytes()
+	}
+	if tx.Type() >= types.DynamicFeeTxType {
+		out.MaxFeePerGas = bigBytes(tx.GasFeeCap())
+		out.MaxPriorityFeePerGas = bigBytes(tx.GasTipCap())
+	}
+	if tx.Type() == types.BlobTxType {
+		out.MaxFeePerBlobGas = bigBytes(tx.BlobGasFeeCap())
+		for _, h := range tx.BlobHashes() {
+			out.BlobVersionedHashes = append(out.BlobVersionedHashes, h.Bytes())
+		}
+	}
+	if al := tx.AccessList(); len(al) > 0 {
+		out.AccessList = &Types.AccessList{}
+		for _, t := range al {
+			tuple := &Types.AccessTuple{Address: t.Address.Bytes()}
+			for _, k := range t.StorageKeys {
+				tuple.StorageKeys = append(tuple.StorageKeys, k.Bytes())
+			}
+			out.AccessList.AccessTuples = append(out.AccessList.AccessTuples, tuple)
+		}
+	}
+	return out
+}
+
+// fromGethReceipt converts a receipt whose block fields are already set
+func fromGethReceipt(r *types.Receipt) *Types.Receipt {
+	out := &Types.Receipt{
+		TxHash:            r.TxHash.Bytes(),
+		Status:            r.Status,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		GasUsed:           r.GasUsed,
+		Logs:              fromGethLogs(r.Logs),
+		Type:              uint32(r.Type),
+		BlockHash:         r.BlockHash.Bytes(),
+		TransactionIndex:  uint64(r.TransactionIndex),
+	}
+	if r.BlockNumber != nil {
+		out.BlockNumber = r.BlockNumber.Uint64()
+	}
+	if r.ContractAddress != (common.Address{}) {
+		out.ContractAddress = r.ContractAddress.Bytes()
+	}
+	return out
+}
+
+func fromGethLog(l *types.Log) *Types.Log {
+	out := &Types.Log{
+		Address:     l.Address.Bytes(),
+		Data:        l.Data,
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash.Bytes(),
+		TxIndex:     uint64(l.TxIndex),
+		TxHash:      l.TxHash.Bytes(),
+		LogIndex:    uint64(l.Index),
+		Removed:     l.Removed,
+	}
+	for _, t := range l.Topics {
+		out.Topics = append(out.Topics, t.Bytes())
+	}
+	return out
+}
+
+func fromGethLogs(logs []*types.Log) []*Types.Log {
+	out := make([]*Types.Log, 0, len(logs))
+	for _, l := range logs {
+		out = append(out, fromGethLog(l))
+	}
+	return out
+}
+
+// toGethHeader converts a header back for go-ethereum callers. Fields the
+// facade does not carry are left zero, so Hash of the result need not match.
+func toGethHeader(h *Types.BlockHeader) *types.Header {
+	out := &types.Header{
+		ParentHash:  common.BytesToHash(h.ParentHash),
+		Root:        common.BytesToHash(h.StateRoot),
+		ReceiptHash: common.BytesToHash(h.ReceiptsRoot),
+		Coinbase:    common.BytesToAddress(h.Miner),
+		Difficulty:  new(big.Int),
+		Number:      new(big.Int).SetUint64(h.Number),
+		GasLimit:    h.GasLimit,
+		GasUsed:     h.GasUsed,
+		Time:        h.Timestamp,
+		Extra:       h.ExtraData,
+		MixDigest:   common.BytesToHash(h.MixHashOrPrevRandao),
+	}
+	if len(h.LogsBloom) == types.BloomByteLength {
+		out.Bloom = types.BytesToBloom(h.LogsBloom)
+	}
+	if len(h.BaseFee) > 0 {
+		out.BaseFee = new(big.Int).SetBytes(h.BaseFee)
+	}
+	if h.BlobGasUsedField != 0 || h.ExcessBlobGasField != 0 {
+		blobGasUsed, excessBlobGas := h.BlobGasUsedField, h.ExcessBlobGasField
+		out.BlobGasUsed, out.ExcessBlobGas = &blobGasUsed, &excessBlobGas
+	}
+	return out
+}
+
+// toGethReceipt converts a receipt back, recomputing its bloom from the logs
+func toGethReceipt(r *Types.Receipt) *types.Receipt {
+	out := &types.Receipt{
+		Type:              uint8(r.Type),
+		Status:            r.Status,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		TxHash:            common.BytesToHash(r.TxHash),
+		GasUsed:           r.GasUsed,
+		BlockHash:         common.BytesToHash(r.BlockHash),
+		BlockNumber:       new(big.Int).SetUint64(r.BlockNumber),
+		TransactionIndex:  uint(r.TransactionIndex),
+	}
+	if len(r.ContractAddress) > 0 {
+		out.ContractAddress = common.BytesToAddress(r.ContractAddress)
+	}
+	for _, l := range r.Logs {
+		gl := toGethLog(l)
+		out.Logs = append(out.Logs, &gl)
+	}
+	out.Bloom = types.CreateBloom(out)
+	return out
+}
+
+func toGethLog(l *Types.Log) types.Log {
+	out := types.Log{
+		Address:     common.BytesToAddress(l.Address),
+		Data:        l.Data,
+		BlockNumber: l.BlockNumber,
+		TxHash:      common.BytesToHash(l.TxHash),
+		TxIndex:     uint(l.TxIndex),
+		BlockHash:   common.BytesToHash(l.BlockHash),
+		Index:       uint(l.LogIndex),
+		Removed:     l.Removed,
+	}
+	for _, t := range l.Topics {
+		out.Topics = append(out.Topics, common.BytesToHash(t))
+	}
+	return out
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/jupitermetalabs/geth-facade/Types"
 )
@@ -15,48 +17,104 @@ import (
 // Handlers manages JSON-RPC request handling
 // //debugging: Includes request/response logging for debugging
 // //future: May add rate limiting and caching
-type Handlers struct{ be Types.Backend }
+type Handlers struct {
+	be      Types.Backend
+	shadow  *Shadow
+	guard   *chainGuard
+	filters *FilterManager
+	// quiet drops the per-request traffic log, for Handlers that serve the
+	// facade itself rather than clients, such as a Shadow's
+	quiet bool
+	// chaosAdmin serves the chaos_* methods that change fault injection
+	chaosAdmin bool
+}
+
+func NewHandlers(be Types.Backend) *Handlers {
+	return &Handlers{be: be, filters: NewFilterManager(be, FilterConfig{})}
+}
+
+// SetFilters replaces the default FilterManager serving eth_newFilter and
+// the other polling filter methods. Call it before serving.
+func (h *Handlers) SetFilters(fm *FilterManager) { h.filters = fm }
+
+// SetShadow mirrors a sample of the served requests to s. Call it before
+// serving.
+func (h *Handlers) SetShadow(s *Shadow) { h.shadow = s }
 
-func NewHandlers(be Types.Backend) *Handlers { return &Handlers{be: be} }
+// SetChaosAdmin lets clients switch fault injection and edit its rules with
+// chaos_enable, chaos_setRules and the other chaos_* write methods. They are
+// not served otherwise, so anyone who can reach the endpoint cannot turn the
+// faults off; chaos_status is always served. Call it before serving.
+func (h *Handlers) SetChaosAdmin(on bool) { h.chaosAdmin = on }
 
 func (h *Handlers) Handle(ctx context.Context, req Types.Request) (Types.Response, error) {
+	if resp, ok := h.admit(req); !ok {
+		return resp, nil
+	}
+	if h.shadow == nil {
+		return h.handle(ctx, req)
+	}
+	start := time.Now()
+	resp, err := h.handle(ctx, req)
+	h.shadow.Mirror(req, resp, time.Since(start))
+	return resp, err
+}
+
+// logResponse logs the answer to a client request
+func (h *Handlers) logResponse(req Types.Request, resp Types.Response) {
+	if !h.quiet {
+		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+	}
+}
+
+// admit applies the chain limits set by a MultiChainServer, if any
+func (h *Handlers) admit(req Types.Request) (Types.Response, bool) {
+	if h.guard == nil {
+		return Types.Response{}, true
+	}
+	return h.guard.admit(req)
+}
+
+func (h *Handlers) handle(ctx context.Context, req Types.Request) (Types.Response, error) {
 	// //debugging: Log incoming request for debugging
-	reqJSON, _ := json.Marshal(req)
-	log.Printf("📥 RPC Request: %s", string(reqJSON))
+	if !h.quiet {
+		reqJSON, _ := json.Marshal(req)
+		log.Printf("📥 RPC Request: %s", string(reqJSON))
+	}
 
 	switch req.Method {
 	case "web3_clientVersion":
 		v, err := h.be.ClientVersion(ctx)
 		resp, _ := finish(req, v, err)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, err
 	case "net_version":
 		id, err := h.be.ChainID(ctx)
 		resp, _ := finish(req, id.String(), err)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, err
 	case "eth_chainId":
 		id, err := h.be.ChainID(ctx)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+id.Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_blockNumber":
 		n, err := h.be.BlockNumber(ctx)
 		resp, _ := finish(req, "0x"+n.Text(16), err)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, err
 
 	case "eth_getBlockByNumber":
 		// params: [blockTag, fullTx(bool)]
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing block tag")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		tag, _ := req.Params[0].(string)
@@ -69,205 +127,205 @@ func (h *Handlers) Handle(ctx context.Context, req Types.Request) (Types.Respons
 		num, err := parseBlockTag(ctx, h.be, tag)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		b, err := h.be.BlockByNumber(ctx, num, full)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalBlock(b, full), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getBalance":
 		if len(req.Params) < 2 {
 			resp, _ := invalidParams(req, "need address and block tag")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		addrStr, _ := req.Params[0].(string)
-		addr, err := hex.DecodeString(strings.TrimPrefix(addrStr, "0x"))
+		addr, err := Types.ParseAddress(addrStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[1]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		bal, err := h.be.Balance(ctx, addr, num)
+		bal, err := h.be.Balance(ctx, addr.Bytes(), num)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+bal.Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_call":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing call object")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		msg, err := toCallMsg(req.Params[0])
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		var num *big.Int
 		if len(req.Params) > 1 {
 			num, err = parseBlockTag(ctx, h.be, mustString(req.Params[1]))
 			if err != nil {
 				resp, _ := finish(req, nil, err)
-				log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+				h.logResponse(req, resp)
 				return resp, err
 			}
 		}
 		out, err := h.be.Call(ctx, msg, num)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+hex.EncodeToString(out), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_estimateGas":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing tx object")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		msg, err := toCallMsg(req.Params[0])
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		g, err := h.be.EstimateGas(ctx, msg)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+big.NewInt(int64(g)).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_gasPrice":
 		p, err := h.be.GasPrice(ctx)
 		resp, _ := finish(req, "0x"+p.Text(16), err)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, err
 
 	case "eth_sendRawTransaction":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing raw tx")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		raw, _ := req.Params[0].(string)
 		txh, err := h.be.SendRawTx(ctx, raw)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+hex.EncodeToString(txh), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getTransactionByHash":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing tx hash")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		hashStr, _ := req.Params[0].(string)
-		hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+		hash, err := Types.ParseHash(hashStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
-		tx, err := h.be.TxByHash(ctx, hash)
+		tx, err := h.be.TxByHash(ctx, hash.Bytes())
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalTx(tx), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getTransactionReceipt":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing tx hash")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		hashStr, _ := req.Params[0].(string)
-		hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+		hash, err := Types.ParseHash(hashStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
-		rcpt, err := h.be.ReceiptByHash(ctx, hash)
+		rcpt, err := h.be.ReceiptByHash(ctx, hash.Bytes())
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalReceipt(rcpt), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getLogs":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing filter")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		q, err := toFilterQuery(req.Params[0])
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		logs, err := h.be.GetLogs(ctx, *q)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalLogs(logs), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Block operations by hash
 	case "eth_getBlockByHash":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing block hash")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		hashStr, _ := req.Params[0].(string)
-		hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+		hash, err := Types.ParseHash(hashStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		full := false
 		if len(req.Params) > 1 {
@@ -275,213 +333,217 @@ func (h *Handlers) Handle(ctx context.Context, req Types.Request) (Types.Respons
 				full = b
 			}
 		}
-		b, err := h.be.BlockByHash(ctx, hash, full)
+		b, err := h.be.BlockByHash(ctx, hash.Bytes(), full)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalBlock(b, full), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Block transaction count operations
 	case "eth_getBlockTransactionCountByNumber":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing block tag")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[0]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		count, err := h.be.BlockTransactionCountByNumber(ctx, num)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+new(big.Int).SetUint64(count).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getBlockTransactionCountByHash":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing block hash")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		hashStr, _ := req.Params[0].(string)
-		hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+		hash, err := Types.ParseHash(hashStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
-		count, err := h.be.BlockTransactionCountByHash(ctx, hash)
+		count, err := h.be.BlockTransactionCountByHash(ctx, hash.Bytes())
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+new(big.Int).SetUint64(count).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Account operations
 	case "eth_getCode":
 		if len(req.Params) < 2 {
 			resp, _ := invalidParams(req, "need address and block tag")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		addrStr, _ := req.Params[0].(string)
-		addr, err := hex.DecodeString(strings.TrimPrefix(addrStr, "0x"))
+		addr, err := Types.ParseAddress(addrStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[1]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		code, err := h.be.GetCode(ctx, addr, num)
+		code, err := h.be.GetCode(ctx, addr.Bytes(), num)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+hex.EncodeToString(code), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getStorageAt":
 		if len(req.Params) < 3 {
 			resp, _ := invalidParams(req, "need address, key, and block tag")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		addrStr, _ := req.Params[0].(string)
-		addr, err := hex.DecodeString(strings.TrimPrefix(addrStr, "0x"))
+		addr, err := Types.ParseAddress(addrStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		keyStr, _ := req.Params[1].(string)
-		key, err := hex.DecodeString(strings.TrimPrefix(keyStr, "0x"))
+		// Slots may be written short ("0x0"), as geth accepts
+		if digits, ok := strings.CutPrefix(keyStr, "0x"); ok && len(digits) < 2*Types.HashLength {
+			keyStr = "0x" + strings.Repeat("0", 2*Types.HashLength-len(digits)) + digits
+		}
+		key, err := Types.ParseHash(keyStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[2]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		storage, err := h.be.GetStorageAt(ctx, addr, key, num)
+		storage, err := h.be.GetStorageAt(ctx, addr.Bytes(), key.Bytes(), num)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+hex.EncodeToString(storage), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getTransactionCount":
 		if len(req.Params) < 2 {
 			resp, _ := invalidParams(req, "need address and block tag")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		addrStr, _ := req.Params[0].(string)
-		addr, err := hex.DecodeString(strings.TrimPrefix(addrStr, "0x"))
+		addr, err := Types.ParseAddress(addrStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[1]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		count, err := h.be.GetTransactionCount(ctx, addr, num)
+		count, err := h.be.GetTransactionCount(ctx, addr.Bytes(), num)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+new(big.Int).SetUint64(count).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Transaction operations by block and index
 	case "eth_getTransactionByBlockNumberAndIndex":
 		if len(req.Params) < 2 {
 			resp, _ := invalidParams(req, "need block tag and index")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[0]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		index, err := parseHexUint64(mustString(req.Params[1]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		tx, err := h.be.TxByBlockNumberAndIndex(ctx, num, index)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalTx(tx), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getTransactionByBlockHashAndIndex":
 		if len(req.Params) < 2 {
 			resp, _ := invalidParams(req, "need block hash and index")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		hashStr, _ := req.Params[0].(string)
-		hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+		hash, err := Types.ParseHash(hashStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		index, err := parseHexUint64(mustString(req.Params[1]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		tx, err := h.be.TxByBlockHashAndIndex(ctx, hash, index)
+		tx, err := h.be.TxByBlockHashAndIndex(ctx, hash.Bytes(), index)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalTx(tx), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Network operations
@@ -489,22 +551,22 @@ func (h *Handlers) Handle(ctx context.Context, req Types.Request) (Types.Respons
 		count, err := h.be.PeerCount(ctx)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+new(big.Int).SetUint64(count).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "net_listening":
 		listening, err := h.be.Listening(ctx)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, listening, nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Sync operations
@@ -512,146 +574,245 @@ func (h *Handlers) Handle(ctx context.Context, req Types.Request) (Types.Respons
 		sync, err := h.be.Syncing(ctx)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, sync, nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Mining operations (for PoW chains)
 	case "eth_mining":
-		mining, err := h.be.Mining(ctx)
+		mining, err := asMining(h.be).Mining(ctx)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, mining, nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_hashrate":
-		hashrate, err := h.be.Hashrate(ctx)
+		hashrate, err := asMining(h.be).Hashrate(ctx)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+new(big.Int).SetUint64(hashrate).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	// Uncle operations (for PoW chains)
 	case "eth_getUncleCountByBlockNumber":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing block tag")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[0]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		count, err := h.be.UncleCountByBlockNumber(ctx, num)
+		count, err := asUncles(h.be).UncleCountByBlockNumber(ctx, num)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+new(big.Int).SetUint64(count).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getUncleCountByBlockHash":
 		if len(req.Params) < 1 {
 			resp, _ := invalidParams(req, "missing block hash")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		hashStr, _ := req.Params[0].(string)
-		hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+		hash, err := Types.ParseHash(hashStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
-		count, err := h.be.UncleCountByBlockHash(ctx, hash)
+		count, err := asUncles(h.be).UncleCountByBlockHash(ctx, hash.Bytes())
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, "0x"+new(big.Int).SetUint64(count).Text(16), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getUncleByBlockNumberAndIndex":
 		if len(req.Params) < 2 {
 			resp, _ := invalidParams(req, "need block tag and index")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		num, err := parseBlockTag(ctx, h.be, mustString(req.Params[0]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		index, err := parseHexUint64(mustString(req.Params[1]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		uncle, err := h.be.UncleByBlockNumberAndIndex(ctx, num, index)
+		uncle, err := asUncles(h.be).UncleByBlockNumberAndIndex(ctx, num, index)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalBlock(uncle, false), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	case "eth_getUncleByBlockHashAndIndex":
 		if len(req.Params) < 2 {
 			resp, _ := invalidParams(req, "need block hash and index")
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, nil
 		}
 		hashStr, _ := req.Params[0].(string)
-		hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+		hash, err := Types.ParseHash(hashStr)
 		if err != nil {
-			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
-			return resp, err
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
 		}
 		index, err := parseHexUint64(mustString(req.Params[1]))
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
-		uncle, err := h.be.UncleByBlockHashAndIndex(ctx, hash, index)
+		uncle, err := asUncles(h.be).UncleByBlockHashAndIndex(ctx, hash.Bytes(), index)
 		if err != nil {
 			resp, _ := finish(req, nil, err)
-			log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+			h.logResponse(req, resp)
 			return resp, err
 		}
 		resp, _ := finish(req, marshalBlock(uncle, false), nil)
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
+		return resp, nil
+
+	// Tracing
+	case "debug_traceTransaction":
+		// params: [txHash, config?]
+		if len(req.Params) < 1 {
+			resp, _ := invalidParams(req, "missing tx hash")
+			h.logResponse(req, resp)
+			return resp, nil
+		}
+		hash, err := Types.ParseHash(mustString(req.Params[0]))
+		if err != nil {
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
+		}
+		trace, err := asTracing(h.be).TraceTransaction(ctx, hash.Bytes(), traceConfig(req.Params, 1))
+		resp, _ := finish(req, trace, err)
+		h.logResponse(req, resp)
+		return resp, err
+
+	case "debug_traceCall":
+		// params: [callObject, blockTag?, config?]
+		if len(req.Params) < 1 {
+			resp, _ := invalidParams(req, "missing call object")
+			h.logResponse(req, resp)
+			return resp, nil
+		}
+		msg, err := toCallMsg(req.Params[0])
+		if err != nil {
+			resp, _ := invalidParams(req, err.Error())
+			h.logResponse(req, resp)
+			return resp, nil
+		}
+		var num *big.Int
+		if len(req.Params) > 1 && req.Params[1] != nil {
+			if num, err = parseBlockTag(ctx, h.be, mustString(req.Params[1])); err != nil {
+				resp, _ := finish(req, nil, err)
+				h.logResponse(req, resp)
+				return resp, err
+			}
+		}
+		trace, err := asTracing(h.be).TraceCall(ctx, msg, num, traceConfig(req.Params, 2))
+		resp, _ := finish(req, trace, err)
+		h.logResponse(req, resp)
+		return resp, err
+
+	case "rpc_modules":
+		resp, _ := finish(req, h.modules(), nil)
+		h.logResponse(req, resp)
 		return resp, nil
 
 	default:
+		if isFilterMethod(req.Method) {
+			resp, err := h.handleFilter(ctx, req)
+			h.logResponse(req, resp)
+			return resp, err
+		}
+		if cb, ok := unwrapAs[*ChaosBackend](h.be); ok && isChaosMethod(req.Method) && (h.chaosAdmin || req.Method == "chaos_status") {
+			resp, err := h.handleChaos(cb, req)
+			h.logResponse(req, resp)
+			return resp, err
+		}
+		if ctl, ok := unwrapAs[Types.DevControl](h.be); ok && isDevMethod(req.Method) {
+			resp, err := h.handleDev(ctx, ctl, req)
+			h.logResponse(req, resp)
+			return resp, err
+		}
 		resp := Types.RespErr(req.ID, -32601, "Method not found")
-		log.Printf("📤 RPC Response: %s -> %+v", req.Method, resp)
+		h.logResponse(req, resp)
 		return resp, nil
 	}
 }
 
+// modules lists the served namespaces as geth's rpc_modules does. Namespaces
+// made up only of methods the backend does not support, or that a chain's
+// method allowlist shuts out, are left out.
+func (h *Handlers) modules() map[string]string {
+	mods := map[string]string{"eth": "1.0", "net": "1.0", "web3": "1.0", "rpc": "1.0"}
+	if Types.Supports(h.be, Types.CapTracing) {
+		mods["debug"] = "1.0"
+	}
+	if _, ok := unwrapAs[Types.DevControl](h.be); ok {
+		mods["evm"], mods["anvil"], mods["hardhat"] = "1.0", "1.0", "1.0"
+	}
+	if _, ok := unwrapAs[*ChaosBackend](h.be); ok {
+		mods["chaos"] = "1.0"
+	}
+	if h.guard != nil {
+		for ns := range mods {
+			if !h.guard.allowsNamespace(ns) {
+				delete(mods, ns)
+			}
+		}
+	}
+	return mods
+}
+
+// traceConfig returns the tracer config object at params[i], if any
+func traceConfig(params []any, i int) map[string]any {
+	if len(params) > i {
+		if cfg, ok := params[i].(map[string]any); ok {
+			return cfg
+		}
+	}
+	return nil
+}
+
 func parseBlockTag(ctx context.Context, be Types.Backend, tag string) (*big.Int, error) {
 	switch strings.ToLower(tag) {
 	case "latest", "":
@@ -671,6 +832,15 @@ func parseBlockTag(ctx context.Context, be Types.Backend, tag string) (*big.Int,
 
 func finish(req Types.Request, v any, err error) (Types.Response, error) {
 	if err != nil {
+		if errors.Is(err, Types.ErrNotSupported) {
+			return Types.RespErr(req.ID, -32601, fmt.Sprintf("the method %s does not exist/is not available", req.Method)), nil
+		}
+		var rpcErr *Types.Error
+		if errors.As(err, &rpcErr) {
+			resp := Types.RespErr(req.ID, rpcErr.Code, rpcErr.Message)
+			resp.Error.Data = rpcErr.Data
+			return resp, nil
+		}
 		return Types.RespErr(req.ID, -32000, err.Error()), nil
 	}
 	return Types.RespOK(req.ID, v), nil
@@ -701,9 +871,15 @@ func toCallMsg(p any) (Types.CallMsg, error) {
 		msg := Types.CallMsg{}
 
 		if from, ok := callObj["from"].(string); ok {
+			if _, err := Types.ParseAddress(from); err != nil {
+				return Types.CallMsg{}, fmt.Errorf("invalid from: %w", err)
+			}
 			msg.From = from
 		}
 		if to, ok := callObj["to"].(string); ok {
+			if _, err := Types.ParseAddress(to); err != nil {
+				return Types.CallMsg{}, fmt.Errorf("invalid to: %w", err)
+			}
 			msg.To = to
 		}
 		if data, ok := callObj["data"].(string); ok {
@@ -763,31 +939,28 @@ func toFilterQuery(p any) (*Types.FilterQuery, error) {
 			query.Addresses = make([][]byte, len(addresses))
 			for i, addr := range addresses {
 				if addrStr, ok := addr.(string); ok {
-					addrBytes, err := hex.DecodeString(strings.TrimPrefix(addrStr, "0x"))
-					if err == nil {
-						query.Addresses[i] = addrBytes
+					a, err := Types.ParseAddress(addrStr)
+					if err != nil {
+						return nil, err
 					}
+					query.Addresses[i] = a.Bytes()
 				}
 			}
 		}
 		if topics, ok := filterObj["topics"].([]any); ok {
 			query.Topics = make([][]byte, len(topics))
 			for i, topic := range topics {
-				if topicArr, ok := topic.([]any); ok {
+				topicStr, ok := topic.(string)
+				if topicArr, isArr := topic.([]any); isArr && len(topicArr) > 0 {
 					// For now, just take the first topic if it's an array
-					if len(topicArr) > 0 {
-						if topicStr, ok := topicArr[0].(string); ok {
-							topicBytes, err := hex.DecodeString(strings.TrimPrefix(topicStr, "0x"))
-							if err == nil {
-								query.Topics[i] = topicBytes
-							}
-						}
-					}
-				} else if topicStr, ok := topic.(string); ok {
-					topicBytes, err := hex.DecodeString(strings.TrimPrefix(topicStr, "0x"))
-					if err == nil {
-						query.Topics[i] = topicBytes
+					topicStr, ok = topicArr[0].(string)
+				}
+				if ok {
+					t, err := Types.ParseHash(topicStr)
+					if err != nil {
+						return nil, err
 					}
+					query.Topics[i] = t.Bytes()
 				}
 			}
 		}
@@ -801,6 +974,9 @@ func toFilterQuery(p any) (*Types.FilterQuery, error) {
 // //conversions: Converts []byte fields to hex strings for JSON-RPC
 // //debugging: Used for block data serialization
 func marshalBlock(b *Types.Block, full bool) map[string]any {
+	if b == nil {
+		return nil
+	}
 	result := map[string]any{
 		"number":        "0x" + new(big.Int).SetUint64(b.Header.Number).Text(16),
 		"hash":          "0x" + hex.EncodeToString(b.Header.Hash),
@@ -870,6 +1046,9 @@ func marshalBlock(b *Types.Block, full bool) map[string]any {
 }
 
 func marshalTx(tx *Types.Transaction) map[string]any {
+	if tx == nil {
+		return nil
+	}
 	result := map[string]any{
 		"hash":     "0x" + hex.EncodeToString(tx.Hash),
 		"from":     "0x" + hex.EncodeToString(tx.From),
@@ -948,6 +1127,9 @@ func marshalLogs(logs []*Types.Log) []map[string]any {
 }
 
 func marshalReceipt(receipt *Types.Receipt) map[string]any {
+	if receipt == nil {
+		return nil
+	}
 	result := map[string]any{
 		"transactionHash":   "0x" + hex.EncodeToString(receipt.TxHash),
 		"status":            "0x" + new(big.Int).SetUint64(receipt.Status).Text(16),
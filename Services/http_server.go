@@ -7,18 +7,79 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jupitermetalabs/geth-facade/Types"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // HTTPServer provides HTTP JSON-RPC server using Gin framework
 // //future: May add rate limiting, authentication, and metrics
 // //debugging: Includes request logging and error handling
 type HTTPServer struct {
-	h *Handlers
+	h    *Handlers
+	opts HTTPOptions
 }
 
-func NewHTTPServer(h *Handlers) *HTTPServer { return &HTTPServer{h: h} }
+// HTTPOptions tunes the transport behaviour of HTTPServer: response
+// compression, request decoding, HTTP/2 cleartext and keep-alive handling.
+type HTTPOptions struct {
+	// Encodings lists the response codings offered to clients in server
+	// preference order ("br", "zstd", "gzip"). Empty disables compression.
+	Encodings []string
+	// MinCompressSize is the smallest response body in bytes worth compressing
+	MinCompressSize int
+	// AcceptGzipRequests enables decoding of gzip-encoded request bodies
+	AcceptGzipRequests bool
+	// MaxRequestBodySize bounds the (decoded) request body in bytes; 0 = unlimited
+	MaxRequestBodySize int64
+	// EnableH2C serves HTTP/2 over cleartext TCP next to HTTP/1.1
+	EnableH2C bool
+
+	// Keep-alive and timeout tuning
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	DisableKeepAlives bool
+}
+
+// DefaultHTTPOptions returns the options used by NewHTTPServer.
+func DefaultHTTPOptions() HTTPOptions {
+	return HTTPOptions{
+		Encodings:          []string{EncodingBrotli, EncodingZstd, EncodingGzip},
+		MinCompressSize:    1024,
+		AcceptGzipRequests: true,
+		MaxRequestBodySize: 5 * 1024 * 1024, // matches geth's default body limit
+		ReadHeaderTimeout:  10 * time.Second,
+		WriteTimeout:       30 * time.Second,
+		IdleTimeout:        60 * time.Second,
+	}
+}
+
+func NewHTTPServer(h *Handlers) *HTTPServer { return NewHTTPServerWithOptions(h, DefaultHTTPOptions()) }
+
+// NewHTTPServerWithOptions creates an HTTPServer with custom transport options.
+func NewHTTPServerWithOptions(h *Handlers, opts HTTPOptions) *HTTPServer {
+	return &HTTPServer{h: h, opts: opts}
+}
 
 func (s *HTTPServer) Serve(addr string) error {
+	// Create HTTP server
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: s.opts.ReadHeaderTimeout,
+		WriteTimeout:      s.opts.WriteTimeout,
+		IdleTimeout:       s.opts.IdleTimeout,
+		MaxHeaderBytes:    s.opts.MaxHeaderBytes,
+	}
+	srv.SetKeepAlivesEnabled(!s.opts.DisableKeepAlives)
+
+	return srv.ListenAndServe()
+}
+
+// Handler builds the HTTP handler serving JSON-RPC and health endpoints, so it
+// can be mounted in a custom http.Server.
+func (s *HTTPServer) Handler() http.Handler {
 	// //debugging: Set Gin to release mode for production
 	gin.SetMode(gin.ReleaseMode)
 
@@ -36,6 +97,12 @@ func (s *HTTPServer) Serve(addr string) error {
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
 	r.Use(cors.New(config))
 
+	// Request decoding and response compression
+	r.Use(limitRequests(s.opts.AcceptGzipRequests, s.opts.MaxRequestBodySize))
+	if len(s.opts.Encodings) > 0 {
+		r.Use(compressResponses(s.opts.Encodings, s.opts.MinCompressSize))
+	}
+
 	// Add health check endpoints
 	r.GET("/health", s.healthCheck)
 	r.GET("/ready", s.readyCheck)
@@ -44,16 +111,10 @@ func (s *HTTPServer) Serve(addr string) error {
 	r.POST("/", s.handleJSONRPC)
 	r.GET("/", s.handleJSONRPC) // Support GET for some clients
 
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:              addr,
-		Handler:           r,
-		ReadHeaderTimeout: 10 * time.Second,
-		WriteTimeout:      30 * time.Second,
-		IdleTimeout:       60 * time.Second,
+	if s.opts.EnableH2C {
+		return h2c.NewHandler(r, &http2.Server{IdleTimeout: s.opts.IdleTimeout})
 	}
-
-	return srv.ListenAndServe()
+	return r
 }
 
 func (s *HTTPServer) handleJSONRPC(c *gin.Context) {
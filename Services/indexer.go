@@ -0,0 +1,377 @@
+package Services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// IndexerConfig configures an Indexer.
+type IndexerConfig struct {
+	// Upstream is the chain being mirrored
+	Upstream Types.Backend
+	// Store receives the mirrored chain
+	Store *StoreBackend
+	// StartBlock is where an empty store starts backfilling
+	StartBlock uint64
+	// Concurrency bounds parallel receipt fetches per block (default 8)
+	Concurrency int
+	// PollInterval is how often the head is polled when the upstream has no
+	// head subscription (default 5s)
+	PollInterval time.Duration
+	// MaxReorgDepth bounds how far back a fork is searched for (default 128)
+	MaxReorgDepth uint64
+	// Metrics defaults to DefaultMetrics
+	Metrics *Metrics
+}
+
+// Indexer mirrors an upstream chain into a StoreBackend: it backfills from
+// StartBlock, then follows new heads, rewinding the store when the upstream
+// reorganises. As a Types.Backend it serves blocks, transactions, receipts
+// and logs from the store once they are mirrored and sends everything else,
+// including state reads, calls and transactions, upstream.
+// //debugging: Progress and reorgs are logged and counted in facade_indexer_*
+type Indexer struct {
+	Types.Backend
+	forwardOptional
+	cfg    IndexerConfig
+	store  *StoreBackend
+	m      *Metrics
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// ErrDeepReorg is returned when no common ancestor is found within MaxReorgDepth.
+var ErrDeepReorg = errors.New("indexer: reorg deeper than the configured limit")
+
+// NewIndexer starts mirroring cfg.Upstream into cfg.Store. Call Close to stop.
+func NewIndexer(cfg IndexerConfig) *Indexer {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 8
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.MaxReorgDepth == 0 {
+		cfg.MaxReorgDepth = 128
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ix := &Indexer{
+		Backend:         cfg.Upstream,
+		forwardOptional: forwardOptional{cfg.Upstream},
+		cfg:             cfg,
+		store:           cfg.Store,
+		m:               cfg.Metrics,
+		wake:            make(chan struct{}, 1),
+		cancel:          cancel,
+		done:            make(chan struct{}),
+	}
+	go ix.run(ctx)
+	return ix
+}
+
+// Close stops indexing. The store stays open.
+func (ix *Indexer) Close() {
+	ix.cancel()
+	<-ix.done
+}
+
+func (ix *Indexer) run(ctx context.Context) {
+	defer close(ix.done)
+	go ix.watchHeads(ctx)
+	ticker := time.NewTicker(ix.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := ix.sync(ctx); err != nil && ctx.Err() == nil {
+			ix.m.Inc("facade_indexer_errors_total")
+			log.Printf("⚠️ Indexer sync failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ix.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchHeads wakes the sync loop on every upstream head, resubscribing after
+// failures; the poll ticker covers upstreams without subscriptions.
+func (ix *Indexer) watchHeads(ctx context.Context) {
+	if !Types.Supports(ix.cfg.Upstream, Types.CapSubscriptions) {
+		return
+	}
+	for ctx.Err() == nil {
+		heads, stop, err := asSubscriptions(ix.cfg.Upstream).SubscribeNewHeads(ctx)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(ix.cfg.PollInterval):
+				continue
+			}
+		}
+		for range heads {
+			select {
+			case ix.wake <- struct{}{}:
+			default:
+			}
+		}
+		stop()
+	}
+}
+
+// sync brings the store up to the upstream head
+func (ix *Indexer) sync(ctx context.Context) error {
+	target, err := ix.cfg.Upstream.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := ix.reconcile(ctx); err != nil {
+		return err
+	}
+	next := ix.cfg.StartBlock
+	if head, ok := ix.store.Head(); ok {
+		next = head + 1
+	}
+	for n := next; n <= target.Uint64(); n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		block, receipts, err := ix.fetch(ctx, n)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			// The upstream head moved back under us; the next round reconciles
+			return nil
+		}
+		if err := ix.store.InsertBlock(block, receipts); err != nil {
+			// A parent mismatch means a reorg landed mid-backfill: rewind and
+			// go again. Anything else waits for the next round.
+			rewound, rerr := ix.reconcile(ctx)
+			if rerr != nil {
+				return errors.Join(err, rerr)
+			}
+			if !rewound {
+				return fmt.Errorf("insert block %d: %w", n, err)
+			}
+			select {
+			case ix.wake <- struct{}{}:
+			default:
+			}
+			return nil
+		}
+		ix.m.Inc("facade_indexer_blocks_total")
+		if n%1000 == 0 || n == target.Uint64() {
+			log.Printf("📥 Indexed block %d of %s", n, target)
+		}
+	}
+	return nil
+}
+
+// reconcile rewinds the store to the last block it shares with the upstream
+// and reports whether it dropped anything. It looks up the upstream block at
+// the store's head once and follows parent hashes from there, so the walk
+// stays on one upstream chain even while the upstream switches forks under it.
+func (ix *Indexer) reconcile(ctx context.Context) (bool, error) {
+	head, ok := ix.store.Head()
+	if !ok {
+		return false, nil
+	}
+	remote, err := ix.cfg.Upstream.BlockByNumber(ctx, new(big.Int).SetUint64(head), false)
+	if err == nil && remote == nil {
+		// The upstream is now shorter than the mirror; start from its head
+		remote, err = ix.cfg.Upstream.BlockByNumber(ctx, nil, false)
+	}
+	if err != nil {
+		return false, err
+	}
+	for remote != nil && remote.Header != nil && remote.Header.Number <= head && head-remote.Header.Number <= ix.cfg.MaxReorgDepth {
+		n := remote.Header.Number
+		local, err := ix.store.BlockByNumber(ctx, new(big.Int).SetUint64(n), false)
+		if err != nil {
+			return false, err
+		}
+		if local == nil {
+			// Below the first mirrored block: start over
+			break
+		}
+		if bytes.Equal(remote.Header.Hash, local.Header.Hash) {
+			if n < head {
+				ix.m.Inc("facade_indexer_reorgs_total")
+				log.Printf("🔁 Indexer reorg: rewinding %d blocks to %d", head-n, n)
+				return true, ix.store.Rewind(n)
+			}
+			return false, nil
+		}
+		if n == 0 {
+			break
+		}
+		if remote, err = ix.cfg.Upstream.BlockByHash(ctx, remote.Header.ParentHash, false); err != nil {
+			return false, err
+		}
+	}
+	first, _, _ := ix.store.Range()
+	if head-first > ix.cfg.MaxReorgDepth {
+		return false, fmt.Errorf("%w (%d blocks)", ErrDeepReorg, ix.cfg.MaxReorgDepth)
+	}
+	// The whole mirror is off the canonical chain
+	ix.m.Inc("facade_indexer_reorgs_total")
+	log.Printf("🔁 Indexer reorg: no common ancestor in store, re-indexing from block %d", ix.cfg.StartBlock)
+	return true, ix.store.Reset()
+}
+
+// fetch downloads block n with its receipts, fetching receipts in parallel
+func (ix *Indexer) fetch(ctx context.Context, n uint64) (*Types.Block, []*Types.Receipt, error) {
+	block, err := ix.cfg.Upstream.BlockByNumber(ctx, new(big.Int).SetUint64(n), true)
+	if err != nil || block == nil {
+		return nil, nil, err
+	}
+	receipts := make([]*Types.Receipt, len(block.Transactions))
+	errs := make([]error, len(block.Transactions))
+	sem := make(chan struct{}, ix.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, tx := range block.Transactions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, hash []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			receipts[i], errs[i] = ix.cfg.Upstream.ReceiptByHash(ctx, hash)
+			if errs[i] == nil && receipts[i] == nil {
+				errs[i] = fmt.Errorf("upstream has no receipt for 0x%x", hash)
+			}
+		}(i, tx.Hash)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	return block, receipts, nil
+}
+
+// covered reports whether block n has been mirrored
+func (ix *Indexer) covered(n *big.Int) bool {
+	first, head, ok := ix.store.Range()
+	if !ok {
+		return false
+	}
+	if n == nil || n.Sign() < 0 {
+		return true
+	}
+	return n.IsUint64() && n.Uint64() >= first && n.Uint64() <= head
+}
+
+// Basic blockchain info: the head is the mirrored head, so clients never ask
+// for blocks the mirror does not have yet
+func (ix *Indexer) BlockNumber(ctx context.Context) (*big.Int, error) {
+	if _, head, ok := ix.store.Range(); ok {
+		return new(big.Int).SetUint64(head), nil
+	}
+	return ix.Backend.BlockNumber(ctx)
+}
+
+// Block operations
+func (ix *Indexer) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	if ix.covered(num) {
+		return ix.store.BlockByNumber(ctx, num, fullTx)
+	}
+	return ix.Backend.BlockByNumber(ctx, num, fullTx)
+}
+func (ix *Indexer) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	if b, err := ix.store.BlockByHash(ctx, hash, fullTx); err != nil || b != nil {
+		return b, err
+	}
+	return ix.Backend.BlockByHash(ctx, hash, fullTx)
+}
+func (ix *Indexer) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	if ix.covered(blockNum) {
+		return ix.store.BlockTransactionCountByNumber(ctx, blockNum)
+	}
+	return ix.Backend.BlockTransactionCountByNumber(ctx, blockNum)
+}
+func (ix *Indexer) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	b, err := ix.store.BlockByHash(ctx, blockHash, false)
+	if err != nil {
+		return 0, err
+	}
+	if b != nil {
+		return uint64(len(b.Transactions)), nil
+	}
+	return ix.Backend.BlockTransactionCountByHash(ctx, blockHash)
+}
+
+// Transaction operations
+func (ix *Indexer) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	if tx, err := ix.store.TxByHash(ctx, hash); err != nil || tx != nil {
+		return tx, err
+	}
+	// Pending and not yet mirrored transactions
+	return ix.Backend.TxByHash(ctx, hash)
+}
+func (ix *Indexer) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	if ix.covered(blockNum) {
+		return ix.store.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	}
+	return ix.Backend.TxByBlockNumberAndIndex(ctx, blockNum, index)
+}
+func (ix *Indexer) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	if b, err := ix.store.BlockByHash(ctx, blockHash, true); err != nil || b != nil {
+		return txAt(b, index), err
+	}
+	return ix.Backend.TxByBlockHashAndIndex(ctx, blockHash, index)
+}
+func (ix *Indexer) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	if r, err := ix.store.ReceiptByHash(ctx, hash); err != nil || r != nil {
+		return r, err
+	}
+	return ix.Backend.ReceiptByHash(ctx, hash)
+}
+
+// Log operations are served locally when the whole range is mirrored
+func (ix *Indexer) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	if len(q.BlockHash) > 0 {
+		b, err := ix.store.BlockByHash(ctx, q.BlockHash, false)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			return ix.store.GetLogs(ctx, q)
+		}
+		return ix.Backend.GetLogs(ctx, q)
+	}
+	first, head, ok := ix.store.Range()
+	if ok {
+		from, to := filterRange(&q, head)
+		if from >= first && to <= head {
+			return ix.store.GetLogs(ctx, q)
+		}
+	}
+	return ix.Backend.GetLogs(ctx, q)
+}
+
+// Supports reports the upstream's capabilities, plus subscriptions, which the
+// mirror serves for heads and logs.
+func (ix *Indexer) Supports(c Types.Capability) bool {
+	return c == Types.CapSubscriptions || Types.Supports(ix.cfg.Upstream, c)
+}
+
+// Subscriptions for heads and logs follow the mirror
+func (ix *Indexer) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return ix.store.SubscribeNewHeads(ctx)
+}
+func (ix *Indexer) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return ix.store.SubscribeLogs(ctx, q)
+}
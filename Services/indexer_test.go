@@ -0,0 +1,154 @@
+package Services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// mirrored reports whether the store holds the upstream's head block
+func mirrored(t *testing.T, upstream Types.Backend, s *Services.StoreBackend) func() bool {
+	return func() bool {
+		ctx := context.Background()
+		want, err := upstream.BlockByNumber(ctx, nil, false)
+		if err != nil || want == nil {
+			t.Fatalf("upstream head: %v", err)
+		}
+		got, _ := s.BlockByNumber(ctx, new(big.Int).SetUint64(want.Header.Number), false)
+		_, head, _ := s.Range()
+		return got != nil && bytes.Equal(got.Header.Hash, want.Header.Hash) && head == want.Header.Number
+	}
+}
+
+func newIndexer(t *testing.T, upstream Types.Backend, s *Services.StoreBackend, metrics *Services.Metrics) *Services.Indexer {
+	t.Helper()
+	ix := Services.NewIndexer(Services.IndexerConfig{Upstream: upstream, Store: s, PollInterval: time.Hour, Metrics: metrics})
+	t.Cleanup(ix.Close)
+	return ix
+}
+
+func TestIndexerCatchUpAndReorg(t *testing.T) {
+	upstream := seededMemory(t)
+	ctl := upstream.(Types.DevControl)
+	ctx := context.Background()
+	s := openStore(t, filepath.Join(t.TempDir(), "chain.db"), big.NewInt(7))
+	t.Cleanup(func() { s.Close() })
+	metrics := Services.NewMetrics()
+	ix := newIndexer(t, upstream, s, metrics)
+
+	// Backfill, then follow new heads, one of them holding a transfer
+	waitFor(t, "the backfill", mirrored(t, upstream, s))
+	snap, err := ctl.Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := ctl.Accounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := func(wei int64) []byte {
+		t.Helper()
+		hash, err := ctl.SendTransaction(ctx, Types.CallMsg{From: hexAddr(accounts[0]), To: hexAddr(accounts[1]), Value: big.NewInt(wei)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	orphaned := transfer(1)
+	if err := ctl.Mine(ctx, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the new heads are mirrored", mirrored(t, upstream, s))
+	all := Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: new(big.Int).SetUint64(head(t, upstream))}
+	if got, want := logKeys(t, ix, all), logKeys(t, upstream, all); len(want) == 0 || !sameKeys(got, want) {
+		t.Errorf("mirrored logs %v, want %v", got, want)
+	}
+
+	// Reverting and mining a longer branch replaces the new blocks, so the
+	// mirror has to rewind past them
+	if ok, err := ctl.Revert(ctx, snap); err != nil || !ok {
+		t.Fatalf("Revert: %v, %v", ok, err)
+	}
+	transfer(2)
+	if err := ctl.Mine(ctx, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the reorg is mirrored", mirrored(t, upstream, s))
+	if n := metrics.Get("facade_indexer_reorgs_total"); n == 0 {
+		t.Error("reorg not counted")
+	}
+	if got := logKeys(t, ix, all); !sameKeys(got, logKeys(t, upstream, all)) {
+		t.Errorf("logs after the reorg %v, want %v", got, logKeys(t, upstream, all))
+	}
+	if r, _ := s.ReceiptByHash(ctx, orphaned); r != nil {
+		t.Error("receipt of an orphaned transaction still mirrored")
+	}
+}
+
+func hexAddr(b []byte) string { return "0x" + hex.EncodeToString(b) }
+
+func TestIndexerResumesAfterRestart(t *testing.T) {
+	upstream := seededMemory(t)
+	ctl := upstream.(Types.DevControl)
+	path := filepath.Join(t.TempDir(), "chain.db")
+	s := openStore(t, path, big.NewInt(7))
+	ix := Services.NewIndexer(Services.IndexerConfig{Upstream: upstream, Store: s, PollInterval: time.Hour, Metrics: Services.NewMetrics()})
+	waitFor(t, "the backfill", mirrored(t, upstream, s))
+	ix.Close()
+	s.Close()
+
+	if err := ctl.Mine(context.Background(), 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	s = openStore(t, path, nil)
+	t.Cleanup(func() { s.Close() })
+	metrics := Services.NewMetrics()
+	newIndexer(t, upstream, s, metrics)
+	waitFor(t, "the restarted indexer catches up", mirrored(t, upstream, s))
+	if n := metrics.Get("facade_indexer_blocks_total"); n != 2 {
+		t.Errorf("restarted indexer fetched %d blocks, want only the 2 new ones", n)
+	}
+}
+
+// brokenParents serves full blocks whose parent hash does not match the chain,
+// so the store refuses them while headers still agree
+type brokenParents struct {
+	Types.Backend
+	fetches atomic.Int32
+}
+
+func (b *brokenParents) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	block, err := b.Backend.BlockByNumber(ctx, num, fullTx)
+	if !fullTx || block == nil || block.Header.Number < 2 {
+		return block, err
+	}
+	b.fetches.Add(1)
+	header := *block.Header
+	header.ParentHash = bytes.Repeat([]byte{0xee}, 32)
+	return &Types.Block{Header: &header, Transactions: block.Transactions}, err
+}
+
+func TestIndexerInsertFailureWaitsForNextRound(t *testing.T) {
+	upstream := &brokenParents{Backend: seededMemory(t)}
+	s := openStore(t, filepath.Join(t.TempDir(), "chain.db"), big.NewInt(7))
+	t.Cleanup(func() { s.Close() })
+	metrics := Services.NewMetrics()
+	newIndexer(t, upstream, s, metrics)
+
+	waitFor(t, "the failed insert is reported", func() bool { return metrics.Get("facade_indexer_errors_total") > 0 })
+	time.Sleep(100 * time.Millisecond)
+	if n := upstream.fetches.Load(); n > 2 {
+		t.Errorf("block 2 fetched %d times; the indexer retried without waiting", n)
+	}
+	if _, head, _ := s.Range(); head != 1 {
+		t.Errorf("store head %d, want 1", head)
+	}
+}
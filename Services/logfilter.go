@@ -0,0 +1,58 @@
+package Services
+
+import (
+	"bytes"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// logMatches reports whether l satisfies the address and topic criteria of q.
+// An empty address list matches any emitter and an empty topic position is a
+// wildcard. Block range checks are left to the caller, which knows the head.
+func logMatches(q *Types.FilterQuery, l *Types.Log) bool {
+	if q == nil {
+		return true
+	}
+	if len(q.Addresses) > 0 {
+		found := false
+		for _, a := range q.Addresses {
+			if bytes.Equal(a, l.Address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(q.Topics) > len(l.Topics) {
+		// Trailing wildcards do not require the log to have that many topics
+		for i := len(l.Topics); i < len(q.Topics); i++ {
+			if len(q.Topics[i]) > 0 {
+				return false
+			}
+		}
+	}
+	for i, t := range q.Topics {
+		if len(t) == 0 || i >= len(l.Topics) {
+			continue
+		}
+		if !bytes.Equal(t, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterRange resolves a query's block range against head; a nil bound means
+// the latest block, as in geth.
+func filterRange(q *Types.FilterQuery, head uint64) (from, to uint64) {
+	from, to = head, head
+	if q.FromBlock != nil && q.FromBlock.Sign() >= 0 {
+		from = q.FromBlock.Uint64()
+	}
+	if q.ToBlock != nil && q.ToBlock.Sign() >= 0 {
+		to = q.ToBlock.Uint64()
+	}
+	return from, to
+}
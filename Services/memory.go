@@ -7,143 +7,586 @@ package Services
 
 import (
 	"context"
-	"encoding/hex"
+	"crypto/ecdsa"
 	"errors"
+	"fmt"
+	"log"
 	"math/big"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+	"github.com/jupitermetalabs/geth-facade/LogIndex"
 	"github.com/jupitermetalabs/geth-facade/Types"
 )
 
-// mem provides an in-memory mock backend implementation
-// //test: Mock implementation for testing and development
-// //debugging: Provides predictable test data
+// DevKeys are the well-known private keys of the Hardhat/Anvil test mnemonic.
+// Their accounts are funded in every memory chain so wallets can sign against it.
+// //test: Never use these keys on a real network
+var DevKeys = []string{
+	"ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+	"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+	"5de4111afa1a4b94908f83103eb1f1706367c2e68ca870fc3fb9a804cdab365a",
+}
+
+// MemoryConfig configures the in-memory development chain.
+type MemoryConfig struct {
+	// ChainID defaults to 11155111
+	ChainID *big.Int
+	// BlockTime mines a block on this interval; zero mines one block per
+	// submitted transaction (automine)
+	BlockTime time.Duration
+	// Alloc funds additional accounts at genesis, keyed by 0x-prefixed address
+	Alloc map[string]*big.Int
+	// GasLimit per block (default 30M)
+	GasLimit uint64
+	// BaseFee is the fixed base fee of every block (default 1 gwei)
+	BaseFee *big.Int
+}
+
+// mem is an in-memory development chain in the spirit of Anvil or Hardhat:
+// raw transactions are decoded, their senders recovered and their transfers
+// and nonces applied to a state trie, and blocks are mined with real hashes,
+// roots, receipts and logs.
+// //test: Development chain for testing wallets and dapps end to end
+// //debugging: Deterministic state, inspectable through the usual RPCs
 type mem struct {
-	chainID *big.Int
-	num     *big.Int
+	mu       sync.Mutex
+	cfg      MemoryConfig
+	chainID  *big.Int
+	config   *params.ChainConfig
+	signer   *devSigner
+	statedb  state.Database
+	blocks   []*types.Block
+	roots    []common.Hash // state root per block, moved by dev state overrides
+	chain    []*Types.Block
+	byHash   map[common.Hash]uint64
+	txIndex  map[common.Hash]txLookup
+	receipts map[common.Hash]*Types.Receipt
+	logs     map[uint64][]*Types.Log
+	logIndex *LogIndex.Index
+
+	// Work towards the next block
+	pending         *state.StateDB
+	pendingHeader   *types.Header
+	pendingTxs      []*types.Transaction
+	pendingReceipts []*types.Receipt
+	pendingGas      uint64
+
+	// Dev controls, see devchain.go
+	keys          map[common.Address]*ecdsa.PrivateKey
+	impersonated  map[common.Address]bool
+	timeOffset    int64
+	nextTimestamp uint64
+	snapshots     map[uint64]*memSnapshot
+	lastSnapshot  uint64
+	reorgs        uint64
+
+	heads   feed[*Types.Block]
+	logFeed feed[*Types.Log]
+	txFeed  feed[[]byte]
+	stop    chan struct{}
+	closed  sync.Once
+}
+
+// txLookup places a mined transaction in the chain
+type txLookup struct {
+	block uint64
+	index int
 }
 
 // NewMemoryBackend creates a new mock backend for testing and development.
 // This implementation simulates a blockchain with:
-// - Incrementing block numbers every 6 seconds
-// - Mock balances for demonstration
-// - Stub responses for all RPC methods
+// - A block mined every 6 seconds, including any submitted transactions
+// - Mock balances for demonstration, plus the funded DevKeys accounts
+// - Real transaction decoding, sender recovery, transfers and receipts
 //
 // WARNING: This is for testing only. Do not use in production.
 func NewMemoryBackend(chainID *big.Int) Types.Backend {
-	ci := big.NewInt(11155111)
-	if chainID != nil {
-		ci = new(big.Int).Set(chainID)
-	}
-	m := &mem{chainID: ci, num: big.NewInt(0)}
-	go func() {
-		t := time.NewTicker(6 * time.Second)
-		for range t.C {
-			m.num = new(big.Int).Add(m.num, big.NewInt(1))
-		}
-	}()
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	return NewMemoryBackendWithConfig(MemoryConfig{
+		ChainID:   chainID,
+		BlockTime: 6 * time.Second,
+		Alloc: map[string]*big.Int{
+			// 100 and 150 tokens for demonstration
+			"0x31fcb3c05f73242aedd88b024e33d25a81fe67db": new(big.Int).Mul(big.NewInt(100), ether),
+			"0xa2902c128d42a64f371457b82bb6abb05b9b8bf1": new(big.Int).Mul(big.NewInt(150), ether),
+		},
+	})
+}
+
+// NewMemoryBackendWithConfig creates an in-memory development chain.
+func NewMemoryBackendWithConfig(cfg MemoryConfig) Types.Backend {
+	return newMem(cfg)
+}
+
+func newMem(cfg MemoryConfig) *mem {
+	if cfg.ChainID == nil {
+		cfg.ChainID = big.NewInt(11155111)
+	}
+	if cfg.GasLimit == 0 {
+		cfg.GasLimit = 30_000_000
+	}
+	if cfg.BaseFee == nil {
+		cfg.BaseFee = big.NewInt(1_000_000_000)
+	}
+	m := &mem{
+		cfg:      cfg,
+		chainID:  new(big.Int).Set(cfg.ChainID),
+		config:   devChainConfig(cfg.ChainID),
+		signer:   newDevSigner(cfg.ChainID),
+		statedb:  state.NewDatabase(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil), nil),
+		byHash:   map[common.Hash]uint64{},
+		txIndex:  map[common.Hash]txLookup{},
+		receipts: map[common.Hash]*Types.Receipt{},
+		logs:     map[uint64][]*Types.Log{},
+		logIndex: LogIndex.New(0),
+		keys:     map[common.Address]*ecdsa.PrivateKey{},
+		stop:     make(chan struct{}),
+
+		impersonated: map[common.Address]bool{},
+		snapshots:    map[uint64]*memSnapshot{},
+	}
+	m.genesis()
+	if cfg.BlockTime > 0 {
+		go m.mineLoop(cfg.BlockTime)
+	}
 	return m
 }
 
+// Close stops interval mining.
+func (m *mem) Close() {
+	m.closed.Do(func() { close(m.stop) })
+}
+
+func (m *mem) genesis() {
+	st, _ := state.New(types.EmptyRootHash, m.statedb)
+	thousands := new(uint256.Int).Mul(uint256.NewInt(10_000), uint256.NewInt(1_000_000_000_000_000_000))
+	for _, k := range DevKeys {
+		key, err := crypto.HexToECDSA(k)
+		if err != nil {
+			continue
+		}
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		m.keys[addr] = key
+		st.AddBalance(addr, thousands, tracing.BalanceChangeUnspecified)
+	}
+	for addr, bal := range m.cfg.Alloc {
+		amount, _ := uint256.FromBig(bal)
+		st.SetBalance(common.HexToAddress(addr), amount, tracing.BalanceChangeUnspecified)
+	}
+	root, err := st.Commit(0, true, false)
+	if err != nil {
+		log.Printf("⚠️ Memory chain genesis commit failed: %v", err)
+	}
+	header := m.newHeader(big.NewInt(0), uint64(time.Now().Unix()))
+	header.Root = root
+	m.pending, _ = state.New(root, m.statedb)
+	body := &types.Body{Withdrawals: []*types.Withdrawal{}}
+	m.appendBlock(types.NewBlock(header, body, nil, trie.NewStackTrie(nil)), nil)
+}
+
+func (m *mem) mineLoop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-t.C:
+			m.mu.Lock()
+			m.mineLocked()
+			m.mu.Unlock()
+		}
+	}
+}
+
+func (m *mem) head() *types.Block { return m.blocks[len(m.blocks)-1] }
+
+// mineLocked seals the pending transactions into a new block and publishes it.
+func (m *mem) mineLocked() *types.Block {
+	header := m.pendingHeaderLocked()
+	root, err := m.pending.Commit(header.Number.Uint64(), true, false)
+	if err != nil {
+		log.Printf("⚠️ Memory chain state commit failed: %v", err)
+		root = m.roots[len(m.roots)-1]
+	}
+	header.Root = root
+	header.GasUsed = m.pendingGas
+	body := &types.Body{Transactions: m.pendingTxs, Withdrawals: []*types.Withdrawal{}}
+	block := types.NewBlock(header, body, m.pendingReceipts, trie.NewStackTrie(nil))
+	m.appendBlock(block, m.pendingReceipts)
+
+	m.pending, _ = state.New(root, m.statedb)
+	m.pendingHeader = nil
+	m.pendingTxs, m.pendingReceipts, m.pendingGas = nil, nil, 0
+	return block
+}
+
+// appendBlock indexes a sealed block and its receipts and notifies subscribers.
+func (m *mem) appendBlock(block *types.Block, receipts []*types.Receipt) {
+	n := block.NumberU64()
+	var logIndex uint
+	var blockLogs []*Types.Log
+	for i, r := range receipts {
+		r.BlockHash = block.Hash()
+		r.BlockNumber = block.Number()
+		r.TransactionIndex = uint(i)
+		for _, l := range r.Logs {
+			l.BlockNumber = n
+			l.BlockHash = block.Hash()
+			l.TxHash = r.TxHash
+			l.TxIndex = uint(i)
+			l.Index = logIndex
+			logIndex++
+		}
+		converted := fromGethReceipt(r)
+		m.receipts[r.TxHash] = converted
+		m.txIndex[r.TxHash] = txLookup{block: n, index: i}
+		blockLogs = append(blockLogs, converted.Logs...)
+	}
+	b := fromGethBlock(block, m.signer)
+	m.blocks = append(m.blocks, block)
+	m.chain = append(m.chain, b)
+	m.roots = append(m.roots, block.Root())
+	m.byHash[block.Hash()] = n
+	m.logs[n] = blockLogs
+	if err := m.logIndex.Add(n, block.Bloom().Bytes()); err != nil {
+		log.Printf("⚠️ Log index: %v", err)
+	}
+
+	if n > 0 {
+		m.heads.send(b)
+		for _, l := range blockLogs {
+			m.logFeed.send(l)
+		}
+	}
+}
+
+// addTxLocked executes tx on top of the pending state. A transaction the EVM
+// rejects leaves no trace; one that reverts is still included, as on mainnet.
+func (m *mem) addTxLocked(tx *types.Transaction) error {
+	if _, known := m.txIndex[tx.Hash()]; known {
+		return errors.New("already known")
+	}
+	for _, p := range m.pendingTxs {
+		if p.Hash() == tx.Hash() {
+			return errors.New("already known")
+		}
+	}
+	if tx.Type() == types.BlobTxType {
+		// Blob sidecars are not accepted over eth_sendRawTransaction here
+		return types.ErrTxTypeNotSupported
+	}
+	if _, err := types.Sender(m.signer, tx); err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	if tx.Gas() > m.cfg.GasLimit {
+		return txpool.ErrGasLimit
+	}
+
+	header := m.pendingHeaderLocked()
+	env := m.envLocked(m.pending, header)
+	evm := env.newEVM(m.pending, header, vm.Config{})
+	gp := new(core.GasPool).AddGas(header.GasLimit - m.pendingGas)
+	snap := m.pending.Snapshot()
+	m.pending.SetTxContext(tx.Hash(), len(m.pendingTxs))
+	msg, err := core.TransactionToMessage(tx, m.signer, header.BaseFee)
+	if err != nil {
+		return err
+	}
+	// Impersonated senders may be contracts
+	msg.SkipFromEOACheck = m.signer.impersonated(tx)
+	receipt, err := core.ApplyTransactionWithEVM(msg, gp, m.pending, header.Number, header.Hash(), header.Time, tx, &m.pendingGas, evm)
+	if err != nil {
+		m.pending.RevertToSnapshot(snap)
+		return err
+	}
+	m.pendingTxs = append(m.pendingTxs, tx)
+	m.pendingReceipts = append(m.pendingReceipts, receipt)
+	m.txFeed.send(tx.Hash().Bytes())
+	return nil
+}
+
+// pendingHeaderLocked returns the header of the block being built, opening
+// it on first use so every transaction in a block sees the same timestamp.
+func (m *mem) pendingHeaderLocked() *types.Header {
+	if m.pendingHeader != nil {
+		return m.pendingHeader
+	}
+	parent := m.head()
+	m.pendingHeader = m.newHeader(new(big.Int).Add(parent.Number(), big.NewInt(1)), m.nextTimeLocked(parent))
+	m.pendingHeader.ParentHash = parent.Hash()
+	return m.pendingHeader
+}
+
+// newHeader fills the fields every dev block shares, including the post-merge,
+// Cancun and Prague fields their hashes commit to.
+func (m *mem) newHeader(number *big.Int, timestamp uint64) *types.Header {
+	var zero uint64
+	beaconRoot := common.Hash{}
+	requests := types.EmptyRequestsHash
+	return &types.Header{
+		Number:           number,
+		GasLimit:         m.cfg.GasLimit,
+		Time:             timestamp,
+		BaseFee:          new(big.Int).Set(m.cfg.BaseFee),
+		Difficulty:       new(big.Int),
+		Extra:            []byte("geth-facade dev"),
+		BlobGasUsed:      &zero,
+		ExcessBlobGas:    &zero,
+		ParentBeaconRoot: &beaconRoot,
+		RequestsHash:     &requests,
+	}
+}
+
+// envLocked prepares EVM execution against st as of header
+func (m *mem) envLocked(st *state.StateDB, header *types.Header) *evmEnv {
+	return &evmEnv{
+		config: m.config,
+		state:  st,
+		header: header,
+		chain:  headerChain{config: m.config, blocks: m.blocks[:len(m.blocks):len(m.blocks)]},
+	}
+}
+
+// callEnv opens the state and header of a block for eth_call and friends
+func (m *mem) callEnv(num *big.Int) (*evmEnv, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.resolveLocked(num)
+	if !ok {
+		return nil, errors.New("header not found")
+	}
+	st, err := state.New(m.roots[n], m.statedb)
+	if err != nil {
+		return nil, err
+	}
+	return m.envLocked(st, m.blocks[n].Header()), nil
+}
+
+// resolveLocked maps a requested block to a canonical block number, nil meaning the head
+func (m *mem) resolveLocked(num *big.Int) (uint64, bool) {
+	head := m.head().NumberU64()
+	if num == nil || num.Sign() < 0 {
+		return head, true
+	}
+	if !num.IsUint64() || num.Uint64() > head {
+		return 0, false
+	}
+	return num.Uint64(), true
+}
+
+// stateAtLocked opens the state as of a block
+func (m *mem) stateAtLocked(num *big.Int) (*state.StateDB, error) {
+	n, ok := m.resolveLocked(num)
+	if !ok {
+		return nil, errors.New("header not found")
+	}
+	return state.New(m.roots[n], m.statedb)
+}
+
 // Basic blockchain info
-func (m *mem) ChainID(ctx context.Context) (*big.Int, error) { return m.chainID, nil }
+func (m *mem) ChainID(ctx context.Context) (*big.Int, error) { return new(big.Int).Set(m.chainID), nil }
 func (m *mem) ClientVersion(ctx context.Context) (string, error) {
-	return "memory-backend/0.1.0 (mock)", nil
+	return "memory-backend/0.2.0 (dev chain)", nil
+}
+func (m *mem) BlockNumber(ctx context.Context) (*big.Int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return new(big.Int).Set(m.head().Number()), nil
 }
-func (m *mem) BlockNumber(ctx context.Context) (*big.Int, error) { return new(big.Int).Set(m.num), nil }
 
 // Block operations
 func (m *mem) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
-	header := &Types.BlockHeader{
-		Number:     m.num.Uint64(),
-		Hash:       []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
-		ParentHash: []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
-		Timestamp:  uint64(time.Now().Unix()),
-		GasLimit:   30000000,
-		GasUsed:    21000,
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.resolveLocked(num)
+	if !ok {
+		return nil, nil
 	}
-	return &Types.Block{Header: header, Transactions: []*Types.Transaction{}}, nil
+	return m.chain[n], nil
 }
 func (m *mem) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
-	header := &Types.BlockHeader{
-		Number:     m.num.Uint64(),
-		Hash:       hash,
-		ParentHash: []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
-		Timestamp:  uint64(time.Now().Unix()),
-		GasLimit:   30000000,
-		GasUsed:    21000,
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n, ok := m.byHash[common.BytesToHash(hash)]; ok {
+		return m.chain[n], nil
 	}
-	return &Types.Block{Header: header, Transactions: []*Types.Transaction{}}, nil
+	return nil, nil
 }
 func (m *mem) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
-	return 0, nil // Mock: no transactions in blocks
+	b, _ := m.BlockByNumber(ctx, blockNum, false)
+	if b == nil {
+		return 0, nil
+	}
+	return uint64(len(b.Transactions)), nil
 }
 func (m *mem) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
-	return 0, nil // Mock: no transactions in blocks
+	b, _ := m.BlockByHash(ctx, blockHash, false)
+	if b == nil {
+		return 0, nil
+	}
+	return uint64(len(b.Transactions)), nil
 }
 
 // Account operations
 func (m *mem) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
-	// Mock balances for demonstration purposes
-	// In a real implementation, you would query your blockchain node
-	addrStr := hex.EncodeToString(addr)
-	switch addrStr {
-	case "31fcb3c05f73242aedd88b024e33d25a81fe67db":
-		// 100 tokens = 100 * 10^18 wei
-		return new(big.Int).Mul(big.NewInt(100), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)), nil
-	case "a2902c128d42a64f371457b82bb6abb05b9b8bf1":
-		// 150 tokens = 150 * 10^18 wei
-		return new(big.Int).Mul(big.NewInt(150), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)), nil
-	default:
-		return big.NewInt(0), nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, err := m.stateAtLocked(block)
+	if err != nil {
+		return nil, err
 	}
+	return st.GetBalance(common.BytesToAddress(addr)).ToBig(), nil
 }
 func (m *mem) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
-	// Mock: return empty code for all addresses
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, err := m.stateAtLocked(block)
+	if err != nil {
+		return nil, err
+	}
+	if code := st.GetCode(common.BytesToAddress(addr)); code != nil {
+		return code, nil
+	}
 	return []byte{}, nil
 }
 func (m *mem) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
-	// Mock: return zero storage for all addresses
-	return make([]byte, 32), nil // 32 bytes of zeros
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, err := m.stateAtLocked(block)
+	if err != nil {
+		return nil, err
+	}
+	v := st.GetState(common.BytesToAddress(addr), common.BytesToHash(key))
+	return v.Bytes(), nil
 }
 func (m *mem) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
-	// Mock: return 0 nonce for all addresses
-	return 0, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, err := m.stateAtLocked(block)
+	if err != nil {
+		return 0, err
+	}
+	return st.GetNonce(common.BytesToAddress(addr)), nil
 }
 
 // Transaction operations
 func (m *mem) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
-	return []byte{}, nil
+	env, err := m.callEnv(block)
+	if err != nil {
+		return nil, err
+	}
+	gas := env.header.GasLimit
+	if msg.Gas != nil && msg.Gas.IsUint64() && msg.Gas.Sign() > 0 {
+		gas = msg.Gas.Uint64()
+	}
+	res, err := env.execute(ctx, callMessage(msg, gas))
+	if err != nil {
+		return nil, err
+	}
+	if res.Failed() {
+		return nil, revertError(res)
+	}
+	return res.Return(), nil
 }
 func (m *mem) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
-	return 21000, nil
+	env, err := m.callEnv(nil)
+	if err != nil {
+		return 0, err
+	}
+	return env.estimateGas(ctx, msg)
+}
+func (m *mem) GasPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(m.cfg.BaseFee), nil
 }
-func (m *mem) GasPrice(ctx context.Context) (*big.Int, error) { return big.NewInt(1_000_000_000), nil }
 func (m *mem) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
-	return []byte{0xde, 0xad, 0xbe, 0xef}, nil
+	raw, err := decodeHex(rawHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.addTxLocked(tx); err != nil {
+		return nil, err
+	}
+	if m.cfg.BlockTime == 0 {
+		m.mineLocked()
+	}
+	return tx.Hash().Bytes(), nil
 }
 func (m *mem) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
-	return &Types.Transaction{Hash: []byte{0xde, 0xad, 0xbe, 0xef}}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := common.BytesToHash(hash)
+	if loc, ok := m.txIndex[h]; ok {
+		return m.chain[loc.block].Transactions[loc.index], nil
+	}
+	for _, tx := range m.pendingTxs {
+		if tx.Hash() == h {
+			return fromGethTx(tx, m.signer), nil
+		}
+	}
+	return nil, nil
 }
 func (m *mem) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
-	return &Types.Transaction{Hash: []byte{0xde, 0xad, 0xbe, 0xef}}, nil
+	b, _ := m.BlockByNumber(ctx, blockNum, true)
+	return txAt(b, index), nil
 }
 func (m *mem) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
-	return &Types.Transaction{Hash: []byte{0xde, 0xad, 0xbe, 0xef}}, nil
+	b, _ := m.BlockByHash(ctx, blockHash, true)
+	return txAt(b, index), nil
 }
 func (m *mem) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
-	return &Types.Receipt{
-		TxHash:      []byte{0xde, 0xad, 0xbe, 0xef},
-		Status:      1,
-		GasUsed:     21000,
-		BlockNumber: 1,
-		Logs:        []*Types.Log{},
-	}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.receipts[common.BytesToHash(hash)], nil
 }
 
 // Log operations
 func (m *mem) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
-	return nil, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []*Types.Log{}
+	if len(q.BlockHash) > 0 {
+		n, ok := m.byHash[common.BytesToHash(q.BlockHash)]
+		if !ok {
+			return nil, errors.New("unknown block")
+		}
+		for _, l := range m.logs[n] {
+			if logMatches(&q, l) {
+				out = append(out, l)
+			}
+		}
+		return out, nil
+	}
+	head := m.head().NumberU64()
+	from, to := filterRange(&q, head)
+	for _, n := range m.logIndex.Match(&q, from, min(to, head)) {
+		for _, l := range m.logs[n] {
+			if logMatches(&q, l) {
+				out = append(out, l)
+			}
+		}
+	}
+	return out, nil
 }
 
 // Network operations
@@ -159,7 +602,7 @@ func (m *mem) Syncing(ctx context.Context) (map[string]any, error) {
 
 // Mining operations (for PoW chains)
 func (m *mem) Mining(ctx context.Context) (bool, error) {
-	return false, nil // Mock: not mining
+	return m.cfg.BlockTime > 0, nil
 }
 func (m *mem) Hashrate(ctx context.Context) (uint64, error) {
 	return 0, nil // Mock: no hashrate
@@ -167,51 +610,28 @@ func (m *mem) Hashrate(ctx context.Context) (uint64, error) {
 
 // Uncle operations (for PoW chains)
 func (m *mem) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
-	return 0, nil // Mock: no uncles
+	return 0, nil // Dev chain: no uncles
 }
 func (m *mem) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
-	return 0, nil // Mock: no uncles
+	return 0, nil // Dev chain: no uncles
 }
 func (m *mem) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
-	return nil, errors.New("no uncles available") // Mock: no uncles
+	return nil, nil // Dev chain: no uncles
 }
 func (m *mem) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
-	return nil, errors.New("no uncles available") // Mock: no uncles
+	return nil, nil // Dev chain: no uncles
 }
 
-// Mock subscription methods for demo purposes
+// Subscriptions deliver newly mined blocks, their logs and submitted transactions
 func (m *mem) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
-	out := make(chan *Types.Block, 1)
-	stop := make(chan struct{})
-	go func() {
-		t := time.NewTicker(6 * time.Second)
-		defer close(out)
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-stop:
-				return
-			case <-t.C:
-				header := &Types.BlockHeader{
-					Number:     m.num.Uint64(),
-					Hash:       []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
-					ParentHash: []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
-					Timestamp:  uint64(time.Now().Unix()),
-					GasLimit:   30000000,
-					GasUsed:    21000,
-				}
-				out <- &Types.Block{Header: header, Transactions: []*Types.Transaction{}}
-			}
-		}
-	}()
-	return out, func() { close(stop) }, nil
+	ch, stop := m.heads.subscribe(ctx, nil)
+	return ch, stop, nil
 }
 func (m *mem) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
-	ch := make(chan *Types.Log)
-	return ch, func() { close(ch) }, nil
+	ch, stop := m.logFeed.subscribe(ctx, func(l *Types.Log) bool { return logMatches(q, l) })
+	return ch, stop, nil
 }
 func (m *mem) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
-	ch := make(chan []byte)
-	return ch, func() { close(ch) }, nil
+	ch, stop := m.txFeed.subscribe(ctx, nil)
+	return ch, stop, nil
 }
@@ -0,0 +1,55 @@
+package Services_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+	"github.com/jupitermetalabs/geth-facade/Types/backendtest"
+)
+
+// emitterCode deploys a contract that logs LOG2(0xaa, caller) with the word
+// 0x2a as data on every call.
+var emitterCode, _ = hex.DecodeString("600e600c600039600e6000f3" + "602a6000523360aa60206000a200")
+
+// seededMemory is an automining memory chain holding a transfer, an emitter
+// deployment, calls to it from two accounts and an empty block.
+func seededMemory(t *testing.T) Types.Backend {
+	be := Services.NewMemoryBackendWithConfig(Services.MemoryConfig{})
+	t.Cleanup(be.(interface{ Close() }).Close)
+	ctl := be.(Types.DevControl)
+	ctx := context.Background()
+
+	accounts, err := ctl.Accounts(ctx)
+	if err != nil || len(accounts) < 2 {
+		t.Fatalf("Accounts: %v, %v", accounts, err)
+	}
+	a, b := "0x"+hex.EncodeToString(accounts[0]), "0x"+hex.EncodeToString(accounts[1])
+	send := func(msg Types.CallMsg) *Types.Receipt {
+		t.Helper()
+		hash, err := ctl.SendTransaction(ctx, msg)
+		if err != nil {
+			t.Fatalf("SendTransaction: %v", err)
+		}
+		r, err := be.ReceiptByHash(ctx, hash)
+		if err != nil || r == nil || r.Status != 1 {
+			t.Fatalf("receipt of 0x%x: %+v, %v", hash, r, err)
+		}
+		return r
+	}
+	send(Types.CallMsg{From: a, To: b, Value: big.NewInt(1_000_000_000_000_000_000)})
+	emitter := "0x" + hex.EncodeToString(send(Types.CallMsg{From: a, Data: emitterCode}).ContractAddress)
+	send(Types.CallMsg{From: a, To: emitter})
+	send(Types.CallMsg{From: b, To: emitter})
+	if err := ctl.Mine(ctx, 1, 0); err != nil {
+		t.Fatalf("Mine: %v", err)
+	}
+	return be
+}
+
+func TestMemoryConformance(t *testing.T) {
+	backendtest.RunConformance(t, seededMemory)
+}
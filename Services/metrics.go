@@ -0,0 +1,93 @@
+package Services
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics is a minimal registry of named counters shared by the backend
+// decorators. Names follow the Prometheus convention and may carry labels,
+// e.g. `facade_quorum_disagreements_total{method="Balance"}`.
+// //future: May be replaced by a Prometheus client registry
+type Metrics struct {
+	mu       sync.RWMutex
+	counters map[string]*atomic.Uint64
+}
+
+// NewMetrics creates an empty registry.
+func NewMetrics() *Metrics {
+	return &Metrics{counters: map[string]*atomic.Uint64{}}
+}
+
+// DefaultMetrics is used by decorators that are not given a registry.
+var DefaultMetrics = NewMetrics()
+
+// Add increments the named counter by delta.
+func (m *Metrics) Add(name string, delta uint64) {
+	m.mu.RLock()
+	c, ok := m.counters[name]
+	m.mu.RUnlock()
+	if !ok {
+		m.mu.Lock()
+		if c, ok = m.counters[name]; !ok {
+			c = new(atomic.Uint64)
+			m.counters[name] = c
+		}
+		m.mu.Unlock()
+	}
+	c.Add(delta)
+}
+
+// Inc increments the named counter by one.
+func (m *Metrics) Inc(name string) { m.Add(name, 1) }
+
+// Get returns the current value of the named counter.
+func (m *Metrics) Get(name string) uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if c, ok := m.counters[name]; ok {
+		return c.Load()
+	}
+	return 0
+}
+
+// Snapshot returns a copy of all counters.
+func (m *Metrics) Snapshot() map[string]uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]uint64, len(m.counters))
+	for k, c := range m.counters {
+		out[k] = c.Load()
+	}
+	return out
+}
+
+// ServeHTTP writes all counters in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snap := m.Snapshot()
+	names := make([]string, 0, len(snap))
+	for k := range snap {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, k := range names {
+		fmt.Fprintf(w, "%s %d\n", k, snap[k])
+	}
+}
+
+// metricName builds a counter name with labels given as key/value pairs.
+func metricName(base string, labels ...string) string {
+	if len(labels) < 2 {
+		return base
+	}
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	return base + "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,332 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+	"golang.org/x/time/rate"
+)
+
+// ChainLimits restricts what clients of one chain may call.
+type ChainLimits struct {
+	// Methods allowlists JSON-RPC methods (exact or "prefix*"); empty allows all
+	Methods []string `json:"methods,omitempty"`
+	// RequestsPerSecond caps the chain's request rate, shared by all of its
+	// clients, with bursts of up to Burst (default 1); 0 is unlimited
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+	Burst             int     `json:"burst,omitempty"`
+	// MaxRequestBodySize overrides the HTTP body limit for this chain; 0 keeps it
+	MaxRequestBodySize int64 `json:"maxRequestBodySize,omitempty"`
+}
+
+// ChainConfig describes one chain served by a MultiChainServer.
+type ChainConfig struct {
+	// Name is the chain's path segment (/<name>); it must be unique
+	Name string
+	// ChainID also addresses the chain (/chain/<id>); nil asks the backend
+	ChainID *big.Int
+	// Backend serves the chain
+	Backend Types.Backend
+	// Limits restricts the chain's methods and request rate
+	Limits ChainLimits
+	// Shadow, when set, mirrors a sample of the chain's reads to a second backend
+	Shadow *Shadow
+}
+
+// MultiChainConfig configures a MultiChainServer.
+type MultiChainConfig struct {
+	Chains []ChainConfig
+	// HTTPAddr and WSAddr are the shared listen addresses
+	HTTPAddr string
+	WSAddr   string
+	// HTTPOptions tunes every chain's HTTP endpoint; nil uses DefaultHTTPOptions
+	HTTPOptions *HTTPOptions
+	// Metrics is served at /metrics and receives per-chain counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// ChainSpec is the file form of a chain, read by LoadChainSpecs. The limit
+// fields sit next to the others, e.g. {"name": "testnet", "chainId": 7001,
+// "upstream": "http://node:8545", "methods": ["eth_*"]}.
+type ChainSpec struct {
+	Name    string `json:"name"`
+	ChainID uint64 `json:"chainId"`
+	// Upstream and UpstreamWS name the JSON-RPC node serving the chain;
+	// without them the caller picks a local backend
+	Upstream   string `json:"upstream,omitempty"`
+	UpstreamWS string `json:"upstreamWs,omitempty"`
+	ChainLimits
+}
+
+// LoadChainSpecs reads a JSON or YAML list of chains.
+func LoadChainSpecs(path string) ([]ChainSpec, error) {
+	return loadRules[ChainSpec](path)
+}
+
+// ChainInfo is the metadata published for a chain at /chains.
+type ChainInfo struct {
+	Name    string   `json:"name"`
+	ChainID string   `json:"chainId"`
+	Paths   []string `json:"paths"`
+	Methods []string `json:"methods,omitempty"`
+	// RequestsPerSecond and Burst echo the chain's rate limit when it has one
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+	Burst             int     `json:"burst,omitempty"`
+}
+
+// MultiChainServer serves several chains from one process. Every chain gets
+// its own HTTP and WebSocket endpoints at /chain/<id> and /<name> on the
+// shared addresses, with its own method allowlist and rate limit. The HTTP
+// address also serves chain metadata at /chains and the shared counters at
+// /metrics.
+type MultiChainServer struct {
+	chains   []*chainEndpoint
+	httpAddr string
+	wsAddr   string
+	httpOpts HTTPOptions
+	metrics  *Metrics
+}
+
+type chainEndpoint struct {
+	info     ChainInfo
+	handlers *Handlers
+	backend  Types.Backend
+	httpOpts HTTPOptions
+}
+
+// NewMultiChainServer validates the chains and builds their handlers. Chains
+// without a ChainID are asked for it, so their backends must be reachable.
+func NewMultiChainServer(cfg MultiChainConfig) (*MultiChainServer, error) {
+	if len(cfg.Chains) == 0 {
+		return nil, errors.New("multichain: no chains")
+	}
+	httpOpts := DefaultHTTPOptions()
+	if cfg.HTTPOptions != nil {
+		httpOpts = *cfg.HTTPOptions
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	s := &MultiChainServer{httpAddr: cfg.HTTPAddr, wsAddr: cfg.WSAddr, httpOpts: httpOpts, metrics: cfg.Metrics}
+	paths := map[string]string{"/chain": "", "/chains": "", "/metrics": "", "/health": "", "/ready": ""}
+	for _, c := range cfg.Chains {
+		if c.Backend == nil {
+			return nil, fmt.Errorf("multichain: chain %q has no backend", c.Name)
+		}
+		if c.Name == "" || strings.ContainsAny(c.Name, "/?#") {
+			return nil, fmt.Errorf("multichain: invalid chain name %q", c.Name)
+		}
+		id := c.ChainID
+		if id == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			got, err := c.Backend.ChainID(ctx)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("multichain: chain %q: chain id: %w", c.Name, err)
+			}
+			id = got
+		}
+		info := ChainInfo{
+			Name:              c.Name,
+			ChainID:           "0x" + id.Text(16),
+			Paths:             []string{"/chain/" + id.String(), "/" + c.Name},
+			Methods:           c.Limits.Methods,
+			RequestsPerSecond: c.Limits.RequestsPerSecond,
+			Burst:             c.Limits.Burst,
+		}
+		for _, p := range info.Paths {
+			if other, dup := paths[p]; dup {
+				if other == "" {
+					return nil, fmt.Errorf("multichain: chain %q: path %s is reserved", c.Name, p)
+				}
+				return nil, fmt.Errorf("multichain: chains %q and %q share path %s", other, c.Name, p)
+			}
+			paths[p] = c.Name
+		}
+
+		h := NewHandlers(c.Backend)
+		h.guard = newChainGuard(c.Name, c.Limits, cfg.Metrics)
+		if c.Shadow != nil {
+			h.SetShadow(c.Shadow)
+		}
+		opts := httpOpts
+		if c.Limits.MaxRequestBodySize > 0 {
+			opts.MaxRequestBodySize = c.Limits.MaxRequestBodySize
+		}
+		s.chains = append(s.chains, &chainEndpoint{info: info, handlers: h, backend: c.Backend, httpOpts: opts})
+	}
+	return s, nil
+}
+
+// Chains returns the metadata of the served chains.
+func (s *MultiChainServer) Chains() []ChainInfo {
+	out := make([]ChainInfo, len(s.chains))
+	for i, c := range s.chains {
+		out[i] = c.info
+	}
+	return out
+}
+
+// Start starts the HTTP and WebSocket servers.
+// This method blocks until one of the servers encounters an error.
+func (s *MultiChainServer) Start() error {
+	go func() {
+		log.Printf("HTTP JSON-RPC server for %d chains starting on %s", len(s.chains), s.httpAddr)
+		if err := s.httpServer().ListenAndServe(); err != nil {
+			log.Printf("HTTP server error: %v", err)
+		}
+	}()
+
+	log.Printf("WebSocket JSON-RPC server for %d chains starting on %s", len(s.chains), s.wsAddr)
+	srv := &http.Server{Addr: s.wsAddr, Handler: s.WSHandler()}
+	return srv.ListenAndServe()
+}
+
+func (s *MultiChainServer) httpServer() *http.Server {
+	srv := &http.Server{
+		Addr:              s.httpAddr,
+		Handler:           s.HTTPHandler(),
+		ReadHeaderTimeout: s.httpOpts.ReadHeaderTimeout,
+		WriteTimeout:      s.httpOpts.WriteTimeout,
+		IdleTimeout:       s.httpOpts.IdleTimeout,
+		MaxHeaderBytes:    s.httpOpts.MaxHeaderBytes,
+	}
+	srv.SetKeepAlivesEnabled(!s.httpOpts.DisableKeepAlives)
+	return srv
+}
+
+// HTTPHandler routes JSON-RPC requests to each chain's endpoint and serves
+// /chains, /metrics, /health and /ready, so it can be mounted in a custom
+// http.Server.
+func (s *MultiChainServer) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	for _, c := range s.chains {
+		h := NewHTTPServerWithOptions(c.handlers, c.httpOpts).Handler()
+		for _, p := range c.info.Paths {
+			mountPrefix(mux, p, h)
+		}
+	}
+	mux.HandleFunc("GET /chains", s.serveChains)
+	mux.HandleFunc("GET /chains/{chain}", s.serveChains)
+	mux.Handle("GET /metrics", s.metrics)
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "healthy", "timestamp": time.Now().Unix()})
+	})
+	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ready", "timestamp": time.Now().Unix()})
+	})
+	return mux
+}
+
+// WSHandler routes WebSocket connections to each chain's endpoint.
+func (s *MultiChainServer) WSHandler() http.Handler {
+	mux := http.NewServeMux()
+	for _, c := range s.chains {
+		ws := NewWSServer(c.handlers, c.backend)
+		for _, p := range c.info.Paths {
+			mountPrefix(mux, p, http.HandlerFunc(ws.handleWS))
+		}
+	}
+	return mux
+}
+
+// serveChains lists every chain, or the one named by name or id
+func (s *MultiChainServer) serveChains(w http.ResponseWriter, r *http.Request) {
+	want := r.PathValue("chain")
+	if want == "" {
+		writeJSON(w, http.StatusOK, s.Chains())
+		return
+	}
+	for _, c := range s.chains {
+		if c.info.Name == want || c.info.Paths[0] == "/chain/"+want || c.info.ChainID == want {
+			writeJSON(w, http.StatusOK, c.info)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown chain " + want})
+}
+
+// mountPrefix serves h at prefix and below it, with the prefix stripped so
+// the chain's handler sees its own root
+func mountPrefix(mux *http.ServeMux, prefix string, h http.Handler) {
+	strip := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		r2.URL.RawPath = ""
+		h.ServeHTTP(w, r2)
+	})
+	mux.Handle(prefix, strip)
+	mux.Handle(prefix+"/", strip)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// chainGuard enforces a chain's ChainLimits in front of its Handlers
+type chainGuard struct {
+	name    string
+	methods []string
+	limiter *rate.Limiter
+	metrics *Metrics
+}
+
+func newChainGuard(name string, l ChainLimits, m *Metrics) *chainGuard {
+	g := &chainGuard{name: name, methods: l.Methods, metrics: m}
+	if l.RequestsPerSecond > 0 {
+		g.limiter = rate.NewLimiter(rate.Limit(l.RequestsPerSecond), max(l.Burst, 1))
+	}
+	return g
+}
+
+// admit counts a request and reports whether it may be served; otherwise
+// it returns the error response for the client
+func (g *chainGuard) admit(req Types.Request) (Types.Response, bool) {
+	if !g.allowed(req.Method) {
+		g.metrics.Inc(metricName("facade_chain_rejected_total", "chain", g.name, "reason", "method"))
+		return Types.RespErr(req.ID, -32601, fmt.Sprintf("the method %s does not exist/is not available", req.Method)), false
+	}
+	if g.limiter != nil && !g.limiter.Allow() {
+		g.metrics.Inc(metricName("facade_chain_rejected_total", "chain", g.name, "reason", "rate"))
+		return Types.RespErr(req.ID, -32005, "request rate limit exceeded"), false
+	}
+	g.metrics.Inc(metricName("facade_chain_requests_total", "chain", g.name, "method", req.Method))
+	return Types.Response{}, true
+}
+
+func (g *chainGuard) allowed(method string) bool {
+	if len(g.methods) == 0 || method == "eth_unsubscribe" {
+		return true
+	}
+	for _, m := range g.methods {
+		if matchMethod(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsNamespace reports whether the allowlist lets through any method of
+// namespace ns, such as "eth" for "eth_getBalance" or "eth_*"
+func (g *chainGuard) allowsNamespace(ns string) bool {
+	if len(g.methods) == 0 {
+		return true
+	}
+	for _, m := range g.methods {
+		prefix, wildcard := strings.CutSuffix(m, "*")
+		if m == "" || strings.HasPrefix(prefix, ns+"_") || wildcard && strings.HasPrefix(ns+"_", prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package Services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// chainServer serves alpha (7) unrestricted, beta (8) allowlisted to a few
+// methods and gamma (9) limited to two requests
+func chainServer(t *testing.T) (*httptest.Server, *Services.Metrics) {
+	var chains []Services.ChainConfig
+	for _, c := range []struct {
+		name   string
+		id     int64
+		limits Services.ChainLimits
+	}{
+		{"alpha", 7, Services.ChainLimits{}},
+		{"beta", 8, Services.ChainLimits{Methods: []string{"eth_chainId", "eth_getBlock*", "rpc_modules"}}},
+		{"gamma", 9, Services.ChainLimits{RequestsPerSecond: 0.001, Burst: 2}},
+	} {
+		be := Services.NewMemoryBackend(big.NewInt(c.id))
+		t.Cleanup(be.(interface{ Close() }).Close)
+		chains = append(chains, Services.ChainConfig{Name: c.name, Backend: be, Limits: c.limits})
+	}
+	metrics := Services.NewMetrics()
+	s, err := Services.NewMultiChainServer(Services.MultiChainConfig{Chains: chains, Metrics: metrics})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.HTTPHandler())
+	t.Cleanup(srv.Close)
+	return srv, metrics
+}
+
+// rpcAt posts a request to path and returns the decoded response, or nil when
+// the path is not served
+func rpcAt(t *testing.T, srv *httptest.Server, path, method string, params ...any) *Types.Response {
+	t.Helper()
+	if params == nil {
+		params = []any{}
+	}
+	body, _ := json.Marshal(Types.Request{Jsonrpc: "2.0", ID: 1, Method: method, Params: params})
+	resp, err := http.Post(srv.URL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	var out Types.Response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("%s %s: %v", path, method, err)
+	}
+	return &out
+}
+
+func getAt(t *testing.T, srv *httptest.Server, path string) (int, []byte) {
+	t.Helper()
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+func TestMultiChainRouting(t *testing.T) {
+	srv, _ := chainServer(t)
+	for path, want := range map[string]string{
+		"/chain/7": "0x7", "/alpha": "0x7",
+		"/chain/8": "0x8", "/beta": "0x8",
+		"/chain/9": "0x9", "/gamma/": "0x9",
+	} {
+		if resp := rpcAt(t, srv, path, "eth_chainId"); resp == nil || resp.Error != nil || resp.Result != want {
+			t.Errorf("eth_chainId at %s = %+v, want %s", path, resp, want)
+		}
+	}
+	for _, path := range []string{"/chain/10", "/delta"} {
+		if resp := rpcAt(t, srv, path, "eth_chainId"); resp != nil {
+			t.Errorf("unknown chain %s answered %+v", path, resp)
+		}
+	}
+}
+
+func TestMultiChainListing(t *testing.T) {
+	srv, _ := chainServer(t)
+	status, body := getAt(t, srv, "/chains")
+	var all []Services.ChainInfo
+	if err := json.Unmarshal(body, &all); status != http.StatusOK || err != nil || len(all) != 3 {
+		t.Fatalf("/chains = %d %s, %v", status, body, err)
+	}
+	if want := []string{"/chain/8", "/beta"}; all[1].Name != "beta" || all[1].ChainID != "0x8" || !reflect.DeepEqual(all[1].Paths, want) {
+		t.Errorf("/chains lists beta as %+v", all[1])
+	}
+
+	for _, key := range []string{"gamma", "9", "0x9"} {
+		var one Services.ChainInfo
+		status, body := getAt(t, srv, "/chains/"+key)
+		if err := json.Unmarshal(body, &one); status != http.StatusOK || err != nil || one.Name != "gamma" || one.Burst != 2 {
+			t.Errorf("/chains/%s = %d %s", key, status, body)
+		}
+	}
+	if status, _ := getAt(t, srv, "/chains/delta"); status != http.StatusNotFound {
+		t.Errorf("/chains/delta = %d, want 404", status)
+	}
+}
+
+func TestMultiChainLimits(t *testing.T) {
+	srv, metrics := chainServer(t)
+
+	// beta only serves its allowlist, and rpc_modules says so
+	for method, allowed := range map[string]bool{"eth_chainId": true, "eth_getBlockByNumber": true, "eth_getBalance": false, "net_version": false} {
+		params := []any{}
+		switch method {
+		case "eth_getBlockByNumber":
+			params = []any{"latest", false}
+		case "eth_getBalance":
+			params = []any{devTarget, "latest"}
+		}
+		resp := rpcAt(t, srv, "/beta", method, params...)
+		if got := resp.Error == nil; got != allowed {
+			t.Errorf("%s on beta allowed=%v (%+v), want %v", method, got, resp.Error, allowed)
+		}
+		if !allowed && resp.Error.Code != -32601 {
+			t.Errorf("%s on beta rejected with %d, want -32601", method, resp.Error.Code)
+		}
+	}
+	if resp := rpcAt(t, srv, "/beta", "rpc_modules"); !reflect.DeepEqual(resp.Result, map[string]any{"eth": "1.0", "rpc": "1.0"}) {
+		t.Errorf("rpc_modules on beta = %v, want eth and rpc only", resp.Result)
+	}
+	if resp := rpcAt(t, srv, "/alpha", "rpc_modules"); resp.Result.(map[string]any)["net"] == nil {
+		t.Errorf("rpc_modules on alpha = %v, want net listed", resp.Result)
+	}
+
+	// gamma answers its burst, then is rate limited
+	for i := 0; i < 3; i++ {
+		resp := rpcAt(t, srv, "/gamma", "eth_chainId")
+		if limited := resp.Error != nil && resp.Error.Code == -32005; limited != (i == 2) {
+			t.Errorf("request %d on gamma: %+v", i, resp)
+		}
+	}
+
+	// Only the limited chains count rejections, per chain and reason, and
+	// /metrics serves them
+	status, body := getAt(t, srv, "/metrics")
+	if status != http.StatusOK {
+		t.Fatalf("/metrics = %d", status)
+	}
+	for name, want := range map[string]uint64{
+		`facade_chain_rejected_total{chain="beta",reason="method"}`:       2,
+		`facade_chain_rejected_total{chain="gamma",reason="rate"}`:        1,
+		`facade_chain_requests_total{chain="gamma",method="eth_chainId"}`: 2,
+		`facade_chain_requests_total{chain="alpha",method="rpc_modules"}`: 1,
+		`facade_chain_rejected_total{chain="alpha",reason="method"}`:      0,
+	} {
+		if got := metrics.Get(name); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+		if line := name + " "; (want > 0) != strings.Contains(string(body), line) {
+			t.Errorf("/metrics listing of %s does not match its count %d:\n%s", name, want, body)
+		}
+	}
+}
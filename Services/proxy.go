@@ -0,0 +1,878 @@
+package Services
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+
+	"github.com/gorilla/websocket"
+)
+
+// ProxyConfig configures a ProxyBackend.
+type ProxyConfig struct {
+	// HTTPURL is the upstream JSON-RPC endpoint used for request/response calls
+	HTTPURL string
+	// WSURL is the upstream WebSocket endpoint used for subscriptions.
+	// Subscriptions fail with an error when it is empty.
+	WSURL string
+	// Headers are added to every upstream request (e.g. API keys)
+	Headers http.Header
+	// HTTPClient overrides the pooled default client
+	HTTPClient *http.Client
+	// Timeout bounds each upstream call that has no earlier context deadline
+	Timeout time.Duration
+	// ResubscribeDelay is the initial backoff between WS reconnect attempts
+	ResubscribeDelay time.Duration
+	// MaxResubscribeDelay caps the reconnect backoff
+	MaxResubscribeDelay time.Duration
+	// Metrics receives the count of notifications dropped for slow
+	// subscribers; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// ProxyBackend implements Types.Backend by forwarding every call to an
+// upstream geth-compatible JSON-RPC node. Results are decoded into Types
+// structures, so the facade can sit in front of any standard node.
+// //future: May add batching of concurrent calls into JSON-RPC batch requests
+type ProxyBackend struct {
+	cfg    ProxyConfig
+	client *http.Client
+	nextID atomic.Uint64
+	ws     *proxyWS
+}
+
+// NewProxyBackend creates a backend that forwards to the configured upstream.
+func NewProxyBackend(cfg ProxyConfig) *ProxyBackend {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.ResubscribeDelay <= 0 {
+		cfg.ResubscribeDelay = time.Second
+	}
+	if cfg.MaxResubscribeDelay < cfg.ResubscribeDelay {
+		cfg.MaxResubscribeDelay = 30 * time.Second
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Transport: newPooledTransport()}
+	}
+	p := &ProxyBackend{cfg: cfg, client: client}
+	if cfg.WSURL != "" {
+		p.ws = newProxyWS(cfg)
+	}
+	return p
+}
+
+// newPooledTransport returns a transport tuned for many concurrent calls to a
+// single upstream host.
+func newPooledTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		MaxIdleConns:        256,
+		MaxIdleConnsPerHost: 256,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+}
+
+// Close releases the upstream WebSocket connection and idle HTTP connections.
+func (p *ProxyBackend) Close() {
+	if p.ws != nil {
+		p.ws.close()
+	}
+	p.client.CloseIdleConnections()
+}
+
+type rpcMessage struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Types.Error    `json:"error,omitempty"`
+}
+
+// call performs a single JSON-RPC request over HTTP and returns the raw result.
+// Upstream JSON-RPC errors are returned as *Types.Error so their code survives.
+func (p *ProxyBackend) call(ctx context.Context, method string, params ...any) (json.RawMessage, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.Timeout)
+		defer cancel()
+	}
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(Types.Request{Jsonrpc: "2.0", Method: method, Params: params, ID: p.nextID.Add(1)})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.HTTPURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range p.cfg.Headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upstream %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var msg rpcMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("upstream %s: %s: %w", method, resp.Status, err)
+	}
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+	return msg.Result, nil
+}
+
+// callInto performs a call and unmarshals the result into out.
+func (p *ProxyBackend) callInto(ctx context.Context, out any, method string, params ...any) error {
+	raw, err := p.call(ctx, method, params...)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (p *ProxyBackend) callBig(ctx context.Context, method string, params ...any) (*big.Int, error) {
+	var n hexBig
+	if err := p.callInto(ctx, &n, method, params...); err != nil {
+		return nil, err
+	}
+	return n.toBig(), nil
+}
+
+func (p *ProxyBackend) callUint(ctx context.Context, method string, params ...any) (uint64, error) {
+	var n hexUint
+	if err := p.callInto(ctx, &n, method, params...); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+func (p *ProxyBackend) callBytes(ctx context.Context, method string, params ...any) ([]byte, error) {
+	var b hexBytes
+	if err := p.callInto(ctx, &b, method, params...); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Basic blockchain info
+func (p *ProxyBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return p.callBig(ctx, "eth_chainId")
+}
+func (p *ProxyBackend) ClientVersion(ctx context.Context) (string, error) {
+	var v string
+	err := p.callInto(ctx, &v, "web3_clientVersion")
+	return v, err
+}
+func (p *ProxyBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return p.callBig(ctx, "eth_blockNumber")
+}
+
+// Block operations
+func (p *ProxyBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	raw, err := p.call(ctx, "eth_getBlockByNumber", blockArg(num), fullTx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBlock(raw)
+}
+func (p *ProxyBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	raw, err := p.call(ctx, "eth_getBlockByHash", hexArg(hash), fullTx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBlock(raw)
+}
+func (p *ProxyBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return p.callUint(ctx, "eth_getBlockTransactionCountByNumber", blockArg(blockNum))
+}
+func (p *ProxyBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return p.callUint(ctx, "eth_getBlockTransactionCountByHash", hexArg(blockHash))
+}
+
+// Account operations
+func (p *ProxyBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return p.callBig(ctx, "eth_getBalance", hexArg(addr), blockArg(block))
+}
+func (p *ProxyBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return p.callBytes(ctx, "eth_getCode", hexArg(addr), blockArg(block))
+}
+func (p *ProxyBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return p.callBytes(ctx, "eth_getStorageAt", hexArg(addr), hexArg(key), blockArg(block))
+}
+func (p *ProxyBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return p.callUint(ctx, "eth_getTransactionCount", hexArg(addr), blockArg(block))
+}
+
+// Transaction operations
+func (p *ProxyBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return p.callBytes(ctx, "eth_call", callArg(msg), blockArg(block))
+}
+func (p *ProxyBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return p.callUint(ctx, "eth_estimateGas", callArg(msg))
+}
+func (p *ProxyBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return p.callBig(ctx, "eth_gasPrice")
+}
+func (p *ProxyBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	if !strings.HasPrefix(rawHex, "0x") {
+		rawHex = "0x" + rawHex
+	}
+	return p.callBytes(ctx, "eth_sendRawTransaction", rawHex)
+}
+func (p *ProxyBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	raw, err := p.call(ctx, "eth_getTransactionByHash", hexArg(hash))
+	if err != nil {
+		return nil, err
+	}
+	return decodeTx(raw)
+}
+func (p *ProxyBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	raw, err := p.call(ctx, "eth_getTransactionByBlockNumberAndIndex", blockArg(blockNum), uintArg(index))
+	if err != nil {
+		return nil, err
+	}
+	return decodeTx(raw)
+}
+func (p *ProxyBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	raw, err := p.call(ctx, "eth_getTransactionByBlockHashAndIndex", hexArg(blockHash), uintArg(index))
+	if err != nil {
+		return nil, err
+	}
+	return decodeTx(raw)
+}
+func (p *ProxyBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	raw, err := p.call(ctx, "eth_getTransactionReceipt", hexArg(hash))
+	if err != nil {
+		return nil, err
+	}
+	return decodeReceipt(raw)
+}
+
+// Log operations
+func (p *ProxyBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	raw, err := p.call(ctx, "eth_getLogs", filterArg(&q))
+	if err != nil {
+		return nil, err
+	}
+	return decodeLogs(raw)
+}
+
+// Network operations
+func (p *ProxyBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return p.callUint(ctx, "net_peerCount")
+}
+func (p *ProxyBackend) Listening(ctx context.Context) (bool, error) {
+	var v bool
+	err := p.callInto(ctx, &v, "net_listening")
+	return v, err
+}
+func (p *ProxyBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	raw, err := p.call(ctx, "eth_syncing")
+	if err != nil {
+		return nil, err
+	}
+	return decodeSyncing(raw)
+}
+
+// Mining operations (for PoW chains)
+func (p *ProxyBackend) Mining(ctx context.Context) (bool, error) {
+	var v bool
+	err := p.callInto(ctx, &v, "eth_mining")
+	return v, err
+}
+func (p *ProxyBackend) Hashrate(ctx context.Context) (uint64, error) {
+	return p.callUint(ctx, "eth_hashrate")
+}
+
+// Uncle operations (for PoW chains)
+func (p *ProxyBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return p.callUint(ctx, "eth_getUncleCountByBlockNumber", blockArg(blockNum))
+}
+func (p *ProxyBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return p.callUint(ctx, "eth_getUncleCountByBlockHash", hexArg(blockHash))
+}
+func (p *ProxyBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	raw, err := p.call(ctx, "eth_getUncleByBlockNumberAndIndex", blockArg(blockNum), uintArg(index))
+	if err != nil {
+		return nil, err
+	}
+	return decodeBlock(raw)
+}
+func (p *ProxyBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	raw, err := p.call(ctx, "eth_getUncleByBlockHashAndIndex", hexArg(blockHash), uintArg(index))
+	if err != nil {
+		return nil, err
+	}
+	return decodeBlock(raw)
+}
+
+// Streaming (for WS subscriptions)
+func (p *ProxyBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	out := make(chan *Types.Block, 16)
+	stop, err := p.subscribe(ctx, []any{"newHeads"}, func(raw json.RawMessage, done <-chan struct{}) {
+		b, err := decodeBlock(raw)
+		if err != nil || b == nil {
+			return
+		}
+		select {
+		case out <- b:
+		case <-done:
+		}
+	}, func() { close(out) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, stop, nil
+}
+func (p *ProxyBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	params := []any{"logs"}
+	if q != nil {
+		params = append(params, filterArg(q))
+	}
+	out := make(chan *Types.Log, 64)
+	stop, err := p.subscribe(ctx, params, func(raw json.RawMessage, done <-chan struct{}) {
+		l, err := decodeLog(raw)
+		if err != nil {
+			return
+		}
+		select {
+		case out <- l:
+		case <-done:
+		}
+	}, func() { close(out) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, stop, nil
+}
+func (p *ProxyBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	out := make(chan []byte, 256)
+	stop, err := p.subscribe(ctx, []any{"newPendingTransactions"}, func(raw json.RawMessage, done <-chan struct{}) {
+		var h hexBytes
+		if err := json.Unmarshal(raw, &h); err != nil {
+			return
+		}
+		select {
+		case out <- h:
+		case <-done:
+		}
+	}, func() { close(out) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, stop, nil
+}
+
+// Tracing
+func (p *ProxyBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return p.call(ctx, "debug_traceTransaction", traceArgs(config, hexArg(hash))...)
+}
+func (p *ProxyBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return p.call(ctx, "debug_traceCall", traceArgs(config, callArg(msg), blockArg(block))...)
+}
+
+// Supports reports subscriptions only when an upstream WebSocket URL is
+// configured; everything else is left to the upstream.
+func (p *ProxyBackend) Supports(c Types.Capability) bool {
+	return c != Types.CapSubscriptions || p.ws != nil
+}
+
+func (p *ProxyBackend) subscribe(ctx context.Context, params []any, deliver func(json.RawMessage, <-chan struct{}), closeOut func()) (func(), error) {
+	if p.ws == nil {
+		return nil, errors.New("proxy backend: no upstream WebSocket URL configured")
+	}
+	return p.ws.subscribe(ctx, params, deliver, closeOut)
+}
+
+// Parameter encoding helpers
+// //conversions: Types values are converted to geth's hex JSON encoding
+
+// traceArgs appends the tracer config, which upstreams reject when null
+func traceArgs(config map[string]any, params ...any) []any {
+	if config != nil {
+		params = append(params, config)
+	}
+	return params
+}
+
+func hexArg(b []byte) string { return "0x" + hex.EncodeToString(b) }
+
+func uintArg(n uint64) string { return "0x" + new(big.Int).SetUint64(n).Text(16) }
+
+func bigArg(n *big.Int) string { return "0x" + n.Text(16) }
+
+// blockArg encodes a block number, mapping nil to "latest" as the Backend
+// interface does.
+func blockArg(n *big.Int) string {
+	if n == nil {
+		return "latest"
+	}
+	return bigArg(n)
+}
+
+func callArg(msg Types.CallMsg) map[string]any {
+	obj := map[string]any{}
+	if msg.From != "" {
+		obj["from"] = msg.From
+	}
+	if msg.To != "" {
+		obj["to"] = msg.To
+	}
+	if len(msg.Data) > 0 {
+		obj["data"] = hexArg(msg.Data)
+	}
+	if msg.Value != nil {
+		obj["value"] = bigArg(msg.Value)
+	}
+	if msg.Gas != nil {
+		obj["gas"] = bigArg(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		obj["gasPrice"] = bigArg(msg.GasPrice)
+	}
+	return obj
+}
+
+func filterArg(q *Types.FilterQuery) map[string]any {
+	obj := map[string]any{}
+	if len(q.BlockHash) > 0 {
+		obj["blockHash"] = hexArg(q.BlockHash)
+	} else {
+		if q.FromBlock != nil {
+			obj["fromBlock"] = bigArg(q.FromBlock)
+		}
+		if q.ToBlock != nil {
+			obj["toBlock"] = bigArg(q.ToBlock)
+		}
+	}
+	if len(q.Addresses) > 0 {
+		addrs := make([]string, len(q.Addresses))
+		for i, a := range q.Addresses {
+			addrs[i] = hexArg(a)
+		}
+		obj["address"] = addrs
+	}
+	if len(q.Topics) > 0 {
+		topics := make([]any, len(q.Topics))
+		for i, t := range q.Topics {
+			// an empty position is a wildcard
+			if len(t) > 0 {
+				topics[i] = hexArg(t)
+			}
+		}
+		obj["topics"] = topics
+	}
+	return obj
+}
+
+// proxyWS multiplexes upstream subscriptions over one WebSocket connection
+// and transparently re-establishes them after the connection drops.
+type proxyWS struct {
+	cfg  ProxyConfig
+	conn *wsRPCConn
+
+	mu     sync.Mutex
+	subs   map[*proxySub]struct{}
+	closed bool
+	wake   chan struct{}
+}
+
+// proxySub is one client subscription. Its upstream ID changes on every
+// resubscription; the output channel stays the same.
+type proxySub struct {
+	params  []any
+	deliver func(json.RawMessage, <-chan struct{})
+	msgs    chan json.RawMessage
+	done    chan struct{}
+	once    sync.Once
+	id      string // guarded by wsRPCConn.mu
+	dropped atomic.Uint64
+}
+
+func newProxyWS(cfg ProxyConfig) *proxyWS {
+	w := &proxyWS{cfg: cfg, subs: map[*proxySub]struct{}{}, wake: make(chan struct{}, 1)}
+	w.conn = newWSRPCConn(cfg.WSURL, cfg.Headers, w.onNotify)
+	go w.maintain()
+	return w
+}
+
+func (w *proxyWS) subscribe(ctx context.Context, params []any, deliver func(json.RawMessage, <-chan struct{}), closeOut func()) (func(), error) {
+	s := &proxySub{
+		params:  params,
+		deliver: deliver,
+		msgs:    make(chan json.RawMessage, 64),
+		done:    make(chan struct{}),
+	}
+	if err := w.conn.ensure(ctx); err != nil {
+		return nil, err
+	}
+	// Register before subscribing so a connection drop in between still
+	// triggers resubscription
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil, errors.New("proxy backend closed")
+	}
+	w.subs[s] = struct{}{}
+	w.mu.Unlock()
+	if err := w.conn.subscribe(ctx, s); err != nil {
+		w.unsubscribe(s)
+		return nil, err
+	}
+
+	// The forwarding goroutine owns the output channel and closes it on exit
+	go func() {
+		defer closeOut()
+		for {
+			select {
+			case raw := <-s.msgs:
+				s.deliver(raw, s.done)
+			case <-s.done:
+				return
+			case <-ctx.Done():
+				w.unsubscribe(s)
+				return
+			}
+		}
+	}()
+	return func() { w.unsubscribe(s) }, nil
+}
+
+func (w *proxyWS) unsubscribe(s *proxySub) {
+	s.once.Do(func() {
+		close(s.done)
+		w.mu.Lock()
+		delete(w.subs, s)
+		w.mu.Unlock()
+		if id := w.conn.unroute(s); id != "" {
+			go w.conn.unsubscribe(id)
+		}
+	})
+}
+
+func (w *proxyWS) onNotify(s *proxySub, raw json.RawMessage) {
+	select {
+	case s.msgs <- raw:
+	case <-s.done:
+	default:
+		// Slow consumer; drop rather than stall the shared reader
+		w.cfg.Metrics.Inc("facade_proxy_notifications_dropped_total")
+		if s.dropped.Add(1) == 1 {
+			log.Printf("⚠️ Proxy subscriber %v fell %d notifications behind, dropping", s.params[0], cap(s.msgs))
+		}
+	}
+}
+
+// maintain reconnects after connection loss and resubscribes every live
+// subscription with exponential backoff.
+func (w *proxyWS) maintain() {
+	for {
+		select {
+		case <-w.conn.lost:
+		case <-w.wake:
+			return
+		}
+		delay := w.cfg.ResubscribeDelay
+		for {
+			w.mu.Lock()
+			closed, n := w.closed, len(w.subs)
+			w.mu.Unlock()
+			if closed {
+				return
+			}
+			if n == 0 {
+				// Reconnect lazily on the next subscribe
+				break
+			}
+			if err := w.resubscribeAll(); err == nil {
+				break
+			}
+			select {
+			case <-time.After(delay):
+			case <-w.wake:
+				return
+			}
+			delay *= 2
+			if delay > w.cfg.MaxResubscribeDelay {
+				delay = w.cfg.MaxResubscribeDelay
+			}
+		}
+	}
+}
+
+func (w *proxyWS) resubscribeAll() error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout)
+	defer cancel()
+	if err := w.conn.ensure(ctx); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	subs := make([]*proxySub, 0, len(w.subs))
+	for s := range w.subs {
+		subs = append(subs, s)
+	}
+	w.mu.Unlock()
+
+	// Subscriptions re-established by an earlier, partly failed attempt are
+	// still live upstream; subscribing them again would deliver twice
+	for _, s := range subs {
+		if w.conn.routed(s) {
+			continue
+		}
+		if err := w.conn.subscribe(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *proxyWS) close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	subs := make([]*proxySub, 0, len(w.subs))
+	for s := range w.subs {
+		subs = append(subs, s)
+	}
+	w.mu.Unlock()
+	for _, s := range subs {
+		w.unsubscribe(s)
+	}
+	close(w.wake)
+	w.conn.close()
+}
+
+// wsRPCConn is a JSON-RPC client over a single WebSocket connection that
+// routes eth_subscription notifications by subscription ID.
+type wsRPCConn struct {
+	url    string
+	header http.Header
+	notify func(*proxySub, json.RawMessage)
+	lost   chan struct{}
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	nextID  uint64
+	pending map[uint64]*pendingCall
+	routes  map[string]*proxySub
+	closed  bool
+
+	writeMu sync.Mutex
+}
+
+func newWSRPCConn(url string, header http.Header, notify func(*proxySub, json.RawMessage)) *wsRPCConn {
+	return &wsRPCConn{
+		url:     url,
+		header:  header,
+		notify:  notify,
+		lost:    make(chan struct{}, 1),
+		pending: map[uint64]*pendingCall{},
+		routes:  map[string]*proxySub{},
+	}
+}
+
+// ensure dials the upstream if there is no live connection.
+func (c *wsRPCConn) ensure(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errors.New("upstream websocket closed")
+	}
+	if c.conn != nil {
+		return nil
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.url, c.header)
+	if err != nil {
+		return fmt.Errorf("upstream websocket: %w", err)
+	}
+	c.conn = conn
+	go c.read(conn)
+	return nil
+}
+
+// pendingCall is an in-flight request. For eth_subscribe calls sub is set and
+// the reader routes the new subscription before handling the next message,
+// so no notification sent right after the response is lost.
+type pendingCall struct {
+	ch  chan rpcMessage
+	sub *proxySub
+}
+
+func (c *wsRPCConn) request(ctx context.Context, method string, params ...any) (json.RawMessage, error) {
+	return c.requestFor(ctx, nil, method, params...)
+}
+
+func (c *wsRPCConn) requestFor(ctx context.Context, sub *proxySub, method string, params ...any) (json.RawMessage, error) {
+	c.mu.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+		return nil, errors.New("upstream websocket not connected")
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan rpcMessage, 1)
+	c.pending[id] = &pendingCall{ch: ch, sub: sub}
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	err := conn.WriteJSON(Types.Request{Jsonrpc: "2.0", Method: method, Params: params, ID: id})
+	c.writeMu.Unlock()
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// subscribe issues eth_subscribe for s; the reader routes the returned ID to s.
+func (c *wsRPCConn) subscribe(ctx context.Context, s *proxySub) error {
+	_, err := c.requestFor(ctx, s, "eth_subscribe", s.params...)
+	return err
+}
+
+func (c *wsRPCConn) unsubscribe(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = c.request(ctx, "eth_unsubscribe", id)
+}
+
+// routed reports whether s has a live subscription on the current connection
+func (c *wsRPCConn) routed(s *proxySub) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return s.id != "" && c.routes[s.id] == s
+}
+
+// unroute removes the routing entry of s and returns its upstream ID.
+func (c *wsRPCConn) unroute(s *proxySub) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := s.id
+	if c.routes[id] == s {
+		delete(c.routes, id)
+	}
+	s.id = ""
+	return id
+}
+
+func (c *wsRPCConn) read(conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.Method == "eth_subscription" {
+			var note struct {
+				Subscription string          `json:"subscription"`
+				Result       json.RawMessage `json:"result"`
+			}
+			if err := json.Unmarshal(msg.Params, &note); err != nil {
+				continue
+			}
+			c.mu.Lock()
+			s := c.routes[note.Subscription]
+			c.mu.Unlock()
+			if s != nil {
+				c.notify(s, note.Result)
+			}
+			continue
+		}
+		var id uint64
+		if err := json.Unmarshal(msg.ID, &id); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		pc := c.pending[id]
+		delete(c.pending, id)
+		if pc != nil && pc.sub != nil && msg.Error == nil {
+			var subID string
+			if json.Unmarshal(msg.Result, &subID) == nil {
+				pc.sub.id = subID
+				c.routes[subID] = pc.sub
+			}
+		}
+		c.mu.Unlock()
+		if pc != nil {
+			pc.ch <- msg
+		}
+	}
+
+	// Connection lost: fail in-flight requests and drop stale routes
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+		for id, pc := range c.pending {
+			pc.ch <- rpcMessage{Error: &Types.Error{Code: -32000, Message: "upstream websocket connection lost"}}
+			delete(c.pending, id)
+		}
+		c.routes = map[string]*proxySub{}
+	}
+	c.mu.Unlock()
+	conn.Close()
+	select {
+	case c.lost <- struct{}{}:
+	default:
+	}
+}
+
+func (c *wsRPCConn) close() {
+	c.mu.Lock()
+	c.closed = true
+	conn := c.conn
+	c.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
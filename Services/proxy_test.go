@@ -0,0 +1,440 @@
+package Services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Upstream payloads as geth returns them
+const (
+	stubTx = `{
+		"hash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"from": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"to": "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"input": "0xdeadbeef",
+		"nonce": "0x7",
+		"value": "0xde0b6b3a7640000",
+		"gas": "0x5208",
+		"gasPrice": "0x3b9aca00",
+		"type": "0x2",
+		"maxFeePerGas": "0x77359400",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"accessList": [{
+			"address": "0xcccccccccccccccccccccccccccccccccccccccc",
+			"storageKeys": ["0x0000000000000000000000000000000000000000000000000000000000000001"]
+		}],
+		"v": "0x1",
+		"r": "0x01",
+		"s": "0x02",
+		"blockHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"blockNumber": "0x10",
+		"transactionIndex": "0x0"
+	}`
+	stubLog = `{
+		"address": "0xcccccccccccccccccccccccccccccccccccccccc",
+		"topics": ["0x00000000000000000000000000000000000000000000000000000000000000aa"],
+		"data": "0x2a",
+		"blockNumber": "0x10",
+		"blockHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"transactionIndex": "0x0",
+		"transactionHash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"logIndex": "0x3",
+		"removed": true
+	}`
+	stubReceipt = `{
+		"transactionHash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"status": "0x1",
+		"cumulativeGasUsed": "0xa410",
+		"gasUsed": "0x5208",
+		"logs": [` + stubLog + `],
+		"contractAddress": null,
+		"type": "0x2",
+		"blockHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"blockNumber": "0x10",
+		"transactionIndex": "0x0"
+	}`
+)
+
+func stubBlock(number uint64, fullTx bool) string {
+	txs := `["0x1111111111111111111111111111111111111111111111111111111111111111"]`
+	if fullTx {
+		txs = "[" + stubTx + "]"
+	}
+	return fmt.Sprintf(`{
+		"number": "0x%x",
+		"hash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"parentHash": "0x3333333333333333333333333333333333333333333333333333333333333333",
+		"miner": "0xdddddddddddddddddddddddddddddddddddddddd",
+		"gasLimit": "0x1c9c380",
+		"gasUsed": "0x5208",
+		"timestamp": "0x6553f100",
+		"baseFeePerGas": "0x7",
+		"extraData": "0x",
+		"uncles": ["0x4444444444444444444444444444444444444444444444444444444444444444"],
+		"withdrawals": [{"index": "0x1", "validatorIndex": "0x2", "address": "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", "amount": "0x3"}],
+		"transactions": %s
+	}`, number, txs)
+}
+
+// rpcStub is an upstream node answering from canned results over HTTP and
+// serving newHeads subscriptions over WebSocket at /ws.
+type rpcStub struct {
+	results map[string]string // method -> raw JSON result or {"error": ...}
+
+	mu         sync.Mutex
+	conns      []*websocket.Conn
+	subscribes int
+	reject     map[int]bool // eth_subscribe calls, by count, answered with an error
+	writeMu    sync.Mutex
+}
+
+func newRPCStub(t *testing.T, results map[string]string) (*rpcStub, *httptest.Server) {
+	s := &rpcStub{results: results}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *rpcStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/ws" {
+		s.serveWS(w, r)
+		return
+	}
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, ok := s.results[req.Method]
+	if !ok {
+		res = `{"error": {"code": -32601, "message": "the method ` + req.Method + ` does not exist/is not available"}}`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasPrefix(res, `{"error"`) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,%s`, req.ID, res[1:])
+		return
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, res)
+}
+
+// serveWS answers every eth_subscribe with a fresh id followed by one head
+// whose number counts the subscriptions made so far
+func (s *rpcStub) serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.mu.Unlock()
+	defer conn.Close()
+	for {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		switch req.Method {
+		case "eth_subscribe":
+			s.mu.Lock()
+			s.subscribes++
+			n := s.subscribes
+			reject := s.reject[n]
+			s.mu.Unlock()
+			if reject {
+				s.write(conn, fmt.Appendf(nil, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"too many subscriptions"}}`, req.ID))
+				continue
+			}
+			id := fmt.Sprintf("0x%x", 0x100+n)
+			s.write(conn, fmt.Appendf(nil, `{"jsonrpc":"2.0","id":%s,"result":%q}`, req.ID, id))
+			s.notify(conn, id, n)
+		default:
+			s.write(conn, fmt.Appendf(nil, `{"jsonrpc":"2.0","id":%s,"result":true}`, req.ID))
+		}
+	}
+}
+
+func (s *rpcStub) write(conn *websocket.Conn, msg []byte) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// notify sends head n to subscription id
+func (s *rpcStub) notify(conn *websocket.Conn, id string, n int) {
+	s.write(conn, fmt.Appendf(nil,
+		`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":%q,"result":%s}}`, id, stubBlock(uint64(n), false)))
+}
+
+// drop closes the upstream side of every WebSocket connection
+func (s *rpcStub) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func hexOf(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestProxyDecoding(t *testing.T) {
+	_, srv := newRPCStub(t, map[string]string{
+		"eth_getBlockByNumber":      stubBlock(16, true),
+		"eth_getBlockByHash":        stubBlock(16, false),
+		"eth_getTransactionByHash":  stubTx,
+		"eth_getTransactionReceipt": stubReceipt,
+		"eth_getLogs":               "[" + stubLog + "]",
+		"eth_blockNumber":           `"0x10"`,
+		"eth_getBalance":            `"0xde0b6b3a7640000"`,
+	})
+	p := Services.NewProxyBackend(Services.ProxyConfig{HTTPURL: srv.URL})
+	t.Cleanup(p.Close)
+	ctx := context.Background()
+	blockHash := hexOf(t, "0x2222222222222222222222222222222222222222222222222222222222222222")
+	txHash := hexOf(t, "0x1111111111111111111111111111111111111111111111111111111111111111")
+
+	b, err := p.BlockByNumber(ctx, big.NewInt(16), true)
+	if err != nil {
+		t.Fatalf("BlockByNumber: %v", err)
+	}
+	h := b.Header
+	if h.Number != 16 || !bytes.Equal(h.Hash, blockHash) || h.GasLimit != 30_000_000 || h.GasUsed != 21000 ||
+		h.Timestamp != 0x6553f100 || !bytes.Equal(h.BaseFee, []byte{7}) || len(h.ParentHash) != 32 || len(h.Miner) != 20 {
+		t.Errorf("header: %+v", h)
+	}
+	if len(b.Ommers) != 1 || len(b.Withdrawals) != 1 || b.Withdrawals[0].ValidatorIndex != 2 || b.Withdrawals[0].Amount != 3 {
+		t.Errorf("uncles %x, withdrawals %+v", b.Ommers, b.Withdrawals)
+	}
+	if len(b.Transactions) != 1 || b.Transactions[0].Nonce != 7 {
+		t.Fatalf("full transactions: %+v", b.Transactions)
+	}
+
+	tx, err := p.TxByHash(ctx, txHash)
+	if err != nil {
+		t.Fatalf("TxByHash: %v", err)
+	}
+	wei := new(big.Int).SetBytes(tx.Value)
+	if !bytes.Equal(tx.Hash, txHash) || tx.Type != 2 || tx.Gas != 21000 || wei.String() != "1000000000000000000" ||
+		!bytes.Equal(tx.Input, []byte{0xde, 0xad, 0xbe, 0xef}) || len(tx.To) != 20 || len(tx.From) != 20 ||
+		new(big.Int).SetBytes(tx.MaxFeePerGas).Uint64() != 2_000_000_000 || tx.V != 1 {
+		t.Errorf("tx: %+v", tx)
+	}
+	if tx.AccessList == nil || len(tx.AccessList.AccessTuples) != 1 || len(tx.AccessList.AccessTuples[0].StorageKeys) != 1 {
+		t.Errorf("access list: %+v", tx.AccessList)
+	}
+
+	// Without full transactions only the hashes come back
+	b, err = p.BlockByHash(ctx, blockHash, false)
+	if err != nil || len(b.Transactions) != 1 || !bytes.Equal(b.Transactions[0].Hash, txHash) || b.Transactions[0].From != nil {
+		t.Errorf("BlockByHash: %+v, %v", b, err)
+	}
+
+	r, err := p.ReceiptByHash(ctx, txHash)
+	if err != nil {
+		t.Fatalf("ReceiptByHash: %v", err)
+	}
+	if r.Status != 1 || r.GasUsed != 21000 || r.CumulativeGasUsed != 42000 || r.BlockNumber != 16 || r.ContractAddress != nil || len(r.Logs) != 1 {
+		t.Errorf("receipt: %+v", r)
+	}
+
+	logs, err := p.GetLogs(ctx, Types.FilterQuery{FromBlock: big.NewInt(16), ToBlock: big.NewInt(16)})
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("GetLogs: %v, %v", logs, err)
+	}
+	l := logs[0]
+	if l.BlockNumber != 16 || l.LogIndex != 3 || !l.Removed || !bytes.Equal(l.Data, []byte{0x2a}) || len(l.Topics) != 1 || l.Topics[0][31] != 0xaa {
+		t.Errorf("log: %+v", l)
+	}
+
+	if n, err := p.BlockNumber(ctx); err != nil || n.Uint64() != 16 {
+		t.Errorf("BlockNumber: %v, %v", n, err)
+	}
+	if bal, err := p.Balance(ctx, make([]byte, 20), nil); err != nil || bal.String() != "1000000000000000000" {
+		t.Errorf("Balance: %v, %v", bal, err)
+	}
+}
+
+func TestProxyUpstreamErrors(t *testing.T) {
+	_, srv := newRPCStub(t, map[string]string{
+		"eth_call": `{"error": {"code": 3, "message": "execution reverted: nope", "data": "0x08c379a0"}}`,
+	})
+	p := Services.NewProxyBackend(Services.ProxyConfig{HTTPURL: srv.URL})
+	t.Cleanup(p.Close)
+	ctx := context.Background()
+
+	_, err := p.Call(ctx, Types.CallMsg{To: "0xcccccccccccccccccccccccccccccccccccccccc"}, nil)
+	var rpcErr *Types.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != 3 || rpcErr.Message != "execution reverted: nope" || rpcErr.Data != "0x08c379a0" {
+		t.Errorf("eth_call error: %#v", err)
+	}
+
+	// The code survives the facade's own handlers too
+	resp, _ := Services.NewHandlers(p).Handle(ctx, Types.Request{Jsonrpc: "2.0", ID: 1, Method: "eth_chainId", Params: []any{}})
+	if resp.Error == nil || resp.Error.Code != -32601 {
+		t.Errorf("eth_chainId response: %+v", resp)
+	}
+
+	// A dead upstream is a transport error, not an RPC error
+	srv.Close()
+	if _, err := p.BlockNumber(ctx); err == nil || errors.As(err, &rpcErr) {
+		t.Errorf("BlockNumber with upstream down: %#v", err)
+	}
+}
+
+func TestProxyResubscribesAfterDrop(t *testing.T) {
+	stub, srv := newRPCStub(t, nil)
+	p := Services.NewProxyBackend(Services.ProxyConfig{
+		HTTPURL:          srv.URL,
+		WSURL:            "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws",
+		ResubscribeDelay: 10 * time.Millisecond,
+	})
+	t.Cleanup(p.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	heads, stop, err := p.SubscribeNewHeads(ctx)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads: %v", err)
+	}
+	defer stop()
+	next := func() uint64 {
+		t.Helper()
+		select {
+		case b, ok := <-heads:
+			if !ok {
+				t.Fatal("subscription closed")
+			}
+			return b.Header.Number
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a head")
+		}
+		return 0
+	}
+	if n := next(); n != 1 {
+		t.Fatalf("first head %d, want 1", n)
+	}
+
+	// The same channel carries on over a new connection and subscription
+	stub.drop()
+	if n := next(); n != 2 {
+		t.Fatalf("head after reconnect %d, want 2", n)
+	}
+	stub.mu.Lock()
+	subscribes := stub.subscribes
+	stub.mu.Unlock()
+	if subscribes != 2 {
+		t.Errorf("upstream saw %d eth_subscribe calls, want 2", subscribes)
+	}
+}
+
+func TestProxyResubscribesOnlyFailedSubscriptions(t *testing.T) {
+	stub, srv := newRPCStub(t, nil)
+	p := Services.NewProxyBackend(Services.ProxyConfig{
+		HTTPURL:          srv.URL,
+		WSURL:            "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws",
+		ResubscribeDelay: 10 * time.Millisecond,
+	})
+	t.Cleanup(p.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var heads [2]<-chan *Types.Block
+	for i := range heads {
+		ch, stop, err := p.SubscribeNewHeads(ctx)
+		if err != nil {
+			t.Fatalf("SubscribeNewHeads: %v", err)
+		}
+		defer stop()
+		heads[i] = ch
+		<-ch
+	}
+
+	// After the drop the first resubscription succeeds and the second is
+	// refused; the retry must only redo the refused one
+	stub.mu.Lock()
+	stub.reject = map[int]bool{4: true}
+	stub.mu.Unlock()
+	stub.drop()
+	for _, ch := range heads {
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a head after reconnect")
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	stub.mu.Lock()
+	subscribes := stub.subscribes
+	stub.mu.Unlock()
+	if subscribes != 5 {
+		t.Errorf("upstream saw %d eth_subscribe calls, want 5 (2, then 2 with one refused, then 1)", subscribes)
+	}
+	for i, ch := range heads {
+		select {
+		case b := <-ch:
+			t.Errorf("subscription %d got a second head %d", i, b.Header.Number)
+		default:
+		}
+	}
+}
+
+func TestProxyCountsDroppedNotifications(t *testing.T) {
+	stub, srv := newRPCStub(t, nil)
+	metrics := Services.NewMetrics()
+	p := Services.NewProxyBackend(Services.ProxyConfig{
+		HTTPURL: srv.URL,
+		WSURL:   "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws",
+		Metrics: metrics,
+	})
+	t.Cleanup(p.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, stop, err := p.SubscribeNewHeads(ctx); err != nil {
+		t.Fatalf("SubscribeNewHeads: %v", err)
+	} else {
+		defer stop()
+	}
+
+	// Nobody reads the subscription, so its buffers fill and the rest drop
+	stub.mu.Lock()
+	conn := stub.conns[0]
+	stub.mu.Unlock()
+	for n := range 500 {
+		stub.notify(conn, "0x101", n)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for metrics.Get("facade_proxy_notifications_dropped_total") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no dropped notifications counted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,382 @@
+package Services
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// ErrNoQuorum is returned when the backends do not produce enough matching answers.
+var ErrNoQuorum = errors.New("quorum not reached")
+
+// DefaultQuorumMethods are the Backend methods cross-checked when
+// QuorumConfig.Methods is empty: state and receipt reads used for settlement.
+var DefaultQuorumMethods = []string{
+	"Balance", "GetCode", "GetStorageAt", "GetTransactionCount", "Call", "TxByHash", "ReceiptByHash",
+}
+
+// QuorumConfig configures a QuorumBackend.
+type QuorumConfig struct {
+	// Backends are queried concurrently; Backends[0] alone serves methods
+	// that are not cross-checked, including writes and subscriptions.
+	Backends []Types.Backend
+	// Quorum is how many backends must return the same answer.
+	// Defaults to a simple majority.
+	Quorum int
+	// Fanout is how many backends are queried up front (K of N). More are
+	// queried only when the first answers can no longer reach the quorum.
+	// Defaults to Quorum.
+	Fanout int
+	// Methods lists the Backend method names to cross-check; empty uses DefaultQuorumMethods
+	Methods []string
+	// Metrics receives agreement counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// QuorumBackend decorates several backends and only returns a cross-checked
+// result once Quorum of them agree on its canonical JSON-RPC encoding. This
+// protects high-value reads from a single faulty or malicious provider.
+type QuorumBackend struct {
+	backends []Types.Backend
+	quorum   int
+	fanout   int
+	methods  map[string]bool
+	metrics  *Metrics
+}
+
+// NewQuorumBackend creates a quorum decorator over cfg.Backends.
+func NewQuorumBackend(cfg QuorumConfig) *QuorumBackend {
+	q := &QuorumBackend{
+		backends: cfg.Backends,
+		quorum:   cfg.Quorum,
+		fanout:   cfg.Fanout,
+		methods:  map[string]bool{},
+		metrics:  cfg.Metrics,
+	}
+	if q.quorum <= 0 {
+		q.quorum = len(cfg.Backends)/2 + 1
+	}
+	if q.quorum > len(cfg.Backends) {
+		q.quorum = len(cfg.Backends)
+	}
+	if q.fanout < q.quorum || q.fanout > len(cfg.Backends) {
+		q.fanout = max(q.quorum, min(q.fanout, len(cfg.Backends)))
+	}
+	if q.metrics == nil {
+		q.metrics = DefaultMetrics
+	}
+	methods := cfg.Methods
+	if len(methods) == 0 {
+		methods = DefaultQuorumMethods
+	}
+	for _, m := range methods {
+		q.methods[m] = true
+	}
+	return q
+}
+
+// canonicalEncoding renders a backend result the way it is sent to clients,
+// so answers are compared on what users would actually observe.
+// //conversions: Uses the marshal* helpers to ignore nil-vs-empty differences
+func canonicalEncoding(v any) string {
+	var out any
+	switch x := v.(type) {
+	case *big.Int:
+		if x == nil {
+			return "null"
+		}
+		return "0x" + x.Text(16)
+	case []byte:
+		return "0x" + hex.EncodeToString(x)
+	case *Types.Block:
+		out = marshalBlock(x, true)
+	case *Types.Transaction:
+		out = marshalTx(x)
+	case *Types.Receipt:
+		out = marshalReceipt(x)
+	case []*Types.Log:
+		out = marshalLogs(x)
+	default:
+		out = x
+	}
+	enc, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(enc)
+}
+
+// quorumCall queries Fanout backends and returns the first answer that
+// reaches the quorum, querying further backends while agreement is still
+// possible. Methods not configured for cross-checking go to the primary
+// backend only.
+func quorumCall[T any](q *QuorumBackend, ctx context.Context, method string, fn func(context.Context, Types.Backend) (T, error)) (T, error) {
+	var zero T
+	if len(q.backends) == 0 {
+		return zero, errors.New("quorum backend: no backends configured")
+	}
+	if !q.methods[method] {
+		return fn(ctx, q.backends[0])
+	}
+	q.metrics.Inc(metricName("facade_quorum_requests_total", "method", method))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type answer struct {
+		idx int
+		v   T
+		err error
+	}
+	answers := make(chan answer, len(q.backends))
+	launched, pending := 0, 0
+	launch := func(n int) {
+		for ; n > 0 && launched < len(q.backends); n-- {
+			go func(i int, be Types.Backend) {
+				v, err := fn(ctx, be)
+				answers <- answer{idx: i, v: v, err: err}
+			}(launched, q.backends[launched])
+			launched++
+			pending++
+		}
+	}
+	launch(q.fanout)
+
+	votes := map[string]int{}
+	voters := map[string][]int{}
+	var lastErr error
+	for pending > 0 {
+		a := <-answers
+		pending--
+		if a.err != nil {
+			lastErr = a.err
+			q.metrics.Inc(metricName("facade_quorum_backend_errors_total", "method", method))
+		} else {
+			key := canonicalEncoding(a.v)
+			votes[key]++
+			voters[key] = append(voters[key], a.idx)
+			if votes[key] >= q.quorum {
+				if len(votes) > 1 {
+					q.reportDisagreement(method, voters)
+				}
+				return a.v, nil
+			}
+		}
+
+		best := 0
+		for _, n := range votes {
+			best = max(best, n)
+		}
+		if best+pending+len(q.backends)-launched < q.quorum {
+			// No answer can reach the quorum any more
+			break
+		}
+		if best+pending < q.quorum {
+			q.metrics.Inc(metricName("facade_quorum_escalations_total", "method", method))
+			launch(q.quorum - best - pending)
+		}
+	}
+
+	q.metrics.Inc(metricName("facade_quorum_failures_total", "method", method))
+	if len(votes) > 1 {
+		q.reportDisagreement(method, voters)
+	}
+	if len(votes) == 0 && lastErr != nil {
+		return zero, lastErr
+	}
+	return zero, fmt.Errorf("%w for %s: %d distinct answers, need %d matching", ErrNoQuorum, method, len(votes), q.quorum)
+}
+
+func (q *QuorumBackend) reportDisagreement(method string, voters map[string][]int) {
+	q.metrics.Inc(metricName("facade_quorum_disagreements_total", "method", method))
+	parts := make([]string, 0, len(voters))
+	for key, idx := range voters {
+		if len(key) > 120 {
+			key = key[:120] + "..."
+		}
+		parts = append(parts, fmt.Sprintf("backends %v -> %s", idx, key))
+	}
+	log.Printf("⚠️ Quorum disagreement on %s: %s", method, strings.Join(parts, "; "))
+}
+
+// Basic blockchain info
+func (q *QuorumBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return quorumCall(q, ctx, "ChainID", func(ctx context.Context, be Types.Backend) (*big.Int, error) { return be.ChainID(ctx) })
+}
+func (q *QuorumBackend) ClientVersion(ctx context.Context) (string, error) {
+	return quorumCall(q, ctx, "ClientVersion", func(ctx context.Context, be Types.Backend) (string, error) { return be.ClientVersion(ctx) })
+}
+func (q *QuorumBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return quorumCall(q, ctx, "BlockNumber", func(ctx context.Context, be Types.Backend) (*big.Int, error) { return be.BlockNumber(ctx) })
+}
+
+// Block operations
+func (q *QuorumBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return quorumCall(q, ctx, "BlockByNumber", func(ctx context.Context, be Types.Backend) (*Types.Block, error) {
+		return be.BlockByNumber(ctx, num, fullTx)
+	})
+}
+func (q *QuorumBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return quorumCall(q, ctx, "BlockByHash", func(ctx context.Context, be Types.Backend) (*Types.Block, error) {
+		return be.BlockByHash(ctx, hash, fullTx)
+	})
+}
+func (q *QuorumBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return quorumCall(q, ctx, "BlockTransactionCountByNumber", func(ctx context.Context, be Types.Backend) (uint64, error) {
+		return be.BlockTransactionCountByNumber(ctx, blockNum)
+	})
+}
+func (q *QuorumBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return quorumCall(q, ctx, "BlockTransactionCountByHash", func(ctx context.Context, be Types.Backend) (uint64, error) {
+		return be.BlockTransactionCountByHash(ctx, blockHash)
+	})
+}
+
+// Account operations
+func (q *QuorumBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return quorumCall(q, ctx, "Balance", func(ctx context.Context, be Types.Backend) (*big.Int, error) {
+		return be.Balance(ctx, addr, block)
+	})
+}
+func (q *QuorumBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return quorumCall(q, ctx, "GetCode", func(ctx context.Context, be Types.Backend) ([]byte, error) {
+		return be.GetCode(ctx, addr, block)
+	})
+}
+func (q *QuorumBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return quorumCall(q, ctx, "GetStorageAt", func(ctx context.Context, be Types.Backend) ([]byte, error) {
+		return be.GetStorageAt(ctx, addr, key, block)
+	})
+}
+func (q *QuorumBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return quorumCall(q, ctx, "GetTransactionCount", func(ctx context.Context, be Types.Backend) (uint64, error) {
+		return be.GetTransactionCount(ctx, addr, block)
+	})
+}
+
+// Transaction operations
+func (q *QuorumBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return quorumCall(q, ctx, "Call", func(ctx context.Context, be Types.Backend) ([]byte, error) {
+		return be.Call(ctx, msg, block)
+	})
+}
+func (q *QuorumBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return quorumCall(q, ctx, "EstimateGas", func(ctx context.Context, be Types.Backend) (uint64, error) {
+		return be.EstimateGas(ctx, msg)
+	})
+}
+func (q *QuorumBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return quorumCall(q, ctx, "GasPrice", func(ctx context.Context, be Types.Backend) (*big.Int, error) { return be.GasPrice(ctx) })
+}
+func (q *QuorumBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	// Writes are never fanned out
+	return q.backends[0].SendRawTx(ctx, rawHex)
+}
+func (q *QuorumBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return quorumCall(q, ctx, "TxByHash", func(ctx context.Context, be Types.Backend) (*Types.Transaction, error) {
+		return be.TxByHash(ctx, hash)
+	})
+}
+func (q *QuorumBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return quorumCall(q, ctx, "TxByBlockNumberAndIndex", func(ctx context.Context, be Types.Backend) (*Types.Transaction, error) {
+		return be.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (q *QuorumBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return quorumCall(q, ctx, "TxByBlockHashAndIndex", func(ctx context.Context, be Types.Backend) (*Types.Transaction, error) {
+		return be.TxByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+func (q *QuorumBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return quorumCall(q, ctx, "ReceiptByHash", func(ctx context.Context, be Types.Backend) (*Types.Receipt, error) {
+		return be.ReceiptByHash(ctx, hash)
+	})
+}
+
+// Log operations
+func (q *QuorumBackend) GetLogs(ctx context.Context, fq Types.FilterQuery) ([]*Types.Log, error) {
+	return quorumCall(q, ctx, "GetLogs", func(ctx context.Context, be Types.Backend) ([]*Types.Log, error) {
+		return be.GetLogs(ctx, fq)
+	})
+}
+
+// Network operations
+func (q *QuorumBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return q.backends[0].PeerCount(ctx)
+}
+func (q *QuorumBackend) Listening(ctx context.Context) (bool, error) {
+	return q.backends[0].Listening(ctx)
+}
+func (q *QuorumBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return q.backends[0].Syncing(ctx)
+}
+
+// Mining operations (for PoW chains)
+func (q *QuorumBackend) Mining(ctx context.Context) (bool, error) {
+	return asMining(q.backends[0]).Mining(ctx)
+}
+func (q *QuorumBackend) Hashrate(ctx context.Context) (uint64, error) {
+	return asMining(q.backends[0]).Hashrate(ctx)
+}
+
+// Uncle operations (for PoW chains)
+func (q *QuorumBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return quorumCall(q, ctx, "UncleCountByBlockNumber", func(ctx context.Context, be Types.Backend) (uint64, error) {
+		return asUncles(be).UncleCountByBlockNumber(ctx, blockNum)
+	})
+}
+func (q *QuorumBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return quorumCall(q, ctx, "UncleCountByBlockHash", func(ctx context.Context, be Types.Backend) (uint64, error) {
+		return asUncles(be).UncleCountByBlockHash(ctx, blockHash)
+	})
+}
+func (q *QuorumBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return quorumCall(q, ctx, "UncleByBlockNumberAndIndex", func(ctx context.Context, be Types.Backend) (*Types.Block, error) {
+		return asUncles(be).UncleByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (q *QuorumBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return quorumCall(q, ctx, "UncleByBlockHashAndIndex", func(ctx context.Context, be Types.Backend) (*Types.Block, error) {
+		return asUncles(be).UncleByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+
+// Streaming (for WS subscriptions) is served by the primary backend
+func (q *QuorumBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return asSubscriptions(q.backends[0]).SubscribeNewHeads(ctx)
+}
+func (q *QuorumBackend) SubscribeLogs(ctx context.Context, fq *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return asSubscriptions(q.backends[0]).SubscribeLogs(ctx, fq)
+}
+func (q *QuorumBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return asSubscriptions(q.backends[0]).SubscribePendingTxs(ctx)
+}
+
+// Tracing results are cross-checked like any other read
+func (q *QuorumBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return quorumCall(q, ctx, "TraceTransaction", func(ctx context.Context, be Types.Backend) (json.RawMessage, error) {
+		return asTracing(be).TraceTransaction(ctx, hash, config)
+	})
+}
+func (q *QuorumBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return quorumCall(q, ctx, "TraceCall", func(ctx context.Context, be Types.Backend) (json.RawMessage, error) {
+		return asTracing(be).TraceCall(ctx, msg, block, config)
+	})
+}
+
+// Supports reports what the primary serves for the methods it answers alone,
+// and what every backend serves for cross-checked ones.
+func (q *QuorumBackend) Supports(c Types.Capability) bool {
+	switch c {
+	case Types.CapMining, Types.CapSubscriptions:
+		return len(q.backends) > 0 && Types.Supports(q.backends[0], c)
+	}
+	return supportedByAll(q.backends, c)
+}
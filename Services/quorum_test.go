@@ -0,0 +1,156 @@
+package Services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// voter answers Balance with its balance after delay, or with err
+type voter struct {
+	Types.Backend
+	balance   int64
+	err       error
+	delay     time.Duration
+	calls     atomic.Int32
+	cancelled atomic.Bool
+}
+
+func (v *voter) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	v.calls.Add(1)
+	select {
+	case <-time.After(v.delay):
+	case <-ctx.Done():
+		v.cancelled.Store(true)
+		return nil, ctx.Err()
+	}
+	if v.err != nil {
+		return nil, v.err
+	}
+	return big.NewInt(v.balance), nil
+}
+
+func (v *voter) ChainID(ctx context.Context) (*big.Int, error) {
+	v.calls.Add(1)
+	return big.NewInt(v.balance), nil
+}
+
+func TestQuorum(t *testing.T) {
+	tests := []struct {
+		name        string
+		voters      []*voter
+		fanout      int
+		want        int64
+		wantErr     error
+		wantCalls   []int32
+		disagreed   uint64
+		escalations uint64
+	}{
+		{
+			name:      "agreement needs only the fanout",
+			voters:    []*voter{{balance: 5}, {balance: 5}, {balance: 5}},
+			want:      5,
+			wantCalls: []int32{1, 1, 0},
+		},
+		{
+			name:        "disagreement escalates to a majority",
+			voters:      []*voter{{balance: 5}, {balance: 6}, {balance: 5}},
+			want:        5,
+			wantCalls:   []int32{1, 1, 1},
+			disagreed:   1,
+			escalations: 1,
+		},
+		{
+			name:        "no majority",
+			voters:      []*voter{{balance: 5}, {balance: 6}, {balance: 7}},
+			wantErr:     Services.ErrNoQuorum,
+			wantCalls:   []int32{1, 1, 1},
+			disagreed:   1,
+			escalations: 1,
+		},
+		{
+			name:        "errors do not vote",
+			voters:      []*voter{{err: errReset}, {balance: 5}, {balance: 5}},
+			want:        5,
+			wantCalls:   []int32{1, 1, 1},
+			escalations: 1,
+		},
+		{
+			name:        "every backend failing returns the error",
+			voters:      []*voter{{err: errReset}, {err: errReset}, {err: errReset}},
+			wantErr:     errReset,
+			wantCalls:   []int32{1, 1, 1},
+			escalations: 1,
+		},
+		{
+			name:      "a slow minority is not waited for",
+			voters:    []*voter{{balance: 5, delay: time.Minute}, {balance: 5}, {balance: 5}},
+			fanout:    3,
+			want:      5,
+			wantCalls: []int32{1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backends := make([]Types.Backend, len(tt.voters))
+			for i, v := range tt.voters {
+				backends[i] = v
+			}
+			metrics := Services.NewMetrics()
+			q := Services.NewQuorumBackend(Services.QuorumConfig{Backends: backends, Fanout: tt.fanout, Metrics: metrics})
+
+			start := time.Now()
+			got, err := q.Balance(context.Background(), make([]byte, 20), nil)
+			if took := time.Since(start); took > 5*time.Second {
+				t.Fatalf("Balance took %v", took)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Balance error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Int64() != tt.want {
+				t.Errorf("Balance = %v, want %d", got, tt.want)
+			}
+			// Escalated calls may start after the answer is in
+			for i, v := range tt.voters {
+				waitFor(t, fmt.Sprintf("backend %d is called %d times", i, tt.wantCalls[i]), func() bool { return v.calls.Load() == tt.wantCalls[i] })
+			}
+			if n := metrics.Get(`facade_quorum_disagreements_total{method="Balance"}`); n != tt.disagreed {
+				t.Errorf("%d disagreements counted, want %d", n, tt.disagreed)
+			}
+			if n := metrics.Get(`facade_quorum_escalations_total{method="Balance"}`); n != tt.escalations {
+				t.Errorf("%d escalations counted, want %d", n, tt.escalations)
+			}
+		})
+	}
+}
+
+func TestQuorumCancelsSlowMinority(t *testing.T) {
+	slow := &voter{balance: 5, delay: time.Minute}
+	q := Services.NewQuorumBackend(Services.QuorumConfig{
+		Backends: []Types.Backend{slow, &voter{balance: 5}, &voter{balance: 5}},
+		Fanout:   3,
+		Metrics:  Services.NewMetrics(),
+	})
+	if _, err := q.Balance(context.Background(), make([]byte, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the slow backend's call is cancelled", slow.cancelled.Load)
+}
+
+func TestQuorumPassesUncheckedMethodsToPrimary(t *testing.T) {
+	primary, other := &voter{balance: 1}, &voter{balance: 2}
+	q := Services.NewQuorumBackend(Services.QuorumConfig{Backends: []Types.Backend{primary, other}, Metrics: Services.NewMetrics()})
+	if id, err := q.ChainID(context.Background()); err != nil || id.Int64() != 1 {
+		t.Errorf("ChainID = %v, %v, want the primary's", id, err)
+	}
+	if other.calls.Load() != 0 {
+		t.Error("unchecked method reached a secondary backend")
+	}
+}
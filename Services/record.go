@@ -0,0 +1,309 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// cassetteEntry is one line of a JSONL cassette. The first line lists the
+// recorded backend's Capabilities. A plain call carries its arguments, result
+// and error. A subscription is a call line with a Sub id followed by event
+// lines (Event, OffsetMs since the subscription started) and, if the stream
+// ended while recording, an End line.
+// //conversions: Values use the Types structs' own JSON encoding so they round-trip exactly
+type cassetteEntry struct {
+	Seq          uint64             `json:"seq"`
+	Method       string             `json:"method"`
+	Args         json.RawMessage    `json:"args,omitempty"`
+	Result       json.RawMessage    `json:"result,omitempty"`
+	Error        *Types.Error       `json:"error,omitempty"`
+	ElapsedMs    int64              `json:"elapsedMs,omitempty"`
+	Sub          uint64             `json:"sub,omitempty"`
+	Event        json.RawMessage    `json:"event,omitempty"`
+	OffsetMs     int64              `json:"offsetMs,omitempty"`
+	End          bool               `json:"end,omitempty"`
+	Capabilities []Types.Capability `json:"capabilities,omitempty"`
+}
+
+// cassetteCapabilities are the optional interfaces a cassette records support for.
+var cassetteCapabilities = []Types.Capability{Types.CapUncles, Types.CapMining, Types.CapSubscriptions, Types.CapTracing}
+
+// encodeArgs renders call arguments identically when recording and replaying.
+func encodeArgs(args ...any) json.RawMessage {
+	if len(args) == 0 {
+		return nil
+	}
+	enc, err := json.Marshal(args)
+	if err != nil {
+		log.Printf("⚠️ Cassette could not encode arguments: %v", err)
+		return nil
+	}
+	return enc
+}
+
+// cassetteError keeps JSON-RPC codes so replayed errors map to the same responses.
+func cassetteError(err error) *Types.Error {
+	if err == nil {
+		return nil
+	}
+	var rpcErr *Types.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	if errors.Is(err, Types.ErrNotSupported) {
+		return &Types.Error{Code: -32601, Message: err.Error()}
+	}
+	return &Types.Error{Message: err.Error()}
+}
+
+// RecordingBackend wraps a backend and writes every call, its arguments,
+// result and error, plus subscription events, to a JSONL cassette that
+// ReplayBackend can serve offline.
+type RecordingBackend struct {
+	inner Types.Backend
+	mu    sync.Mutex
+	enc   *json.Encoder
+	seq   uint64
+	subs  uint64
+	err   error
+}
+
+// NewRecordingBackend records calls to inner into w, one JSON object per line,
+// starting with the capabilities inner supports.
+func NewRecordingBackend(inner Types.Backend, w io.Writer) *RecordingBackend {
+	r := &RecordingBackend{inner: inner, enc: json.NewEncoder(w)}
+	caps := []Types.Capability{}
+	for _, c := range cassetteCapabilities {
+		if Types.Supports(inner, c) {
+			caps = append(caps, c)
+		}
+	}
+	r.write(&cassetteEntry{Method: "Supports", Capabilities: caps})
+	return r
+}
+
+// Err returns the first error encountered while writing the cassette.
+func (r *RecordingBackend) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *RecordingBackend) write(e *cassetteEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.Seq = r.seq
+	if err := r.enc.Encode(e); err != nil && r.err == nil {
+		r.err = err
+		log.Printf("⚠️ Cassette write failed: %v", err)
+	}
+}
+
+// recordCall runs fn and appends the call to the cassette.
+func recordCall[T any](r *RecordingBackend, method string, args []any, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	v, err := fn()
+	e := &cassetteEntry{Method: method, Args: encodeArgs(args...), Error: cassetteError(err), ElapsedMs: time.Since(start).Milliseconds()}
+	if err == nil {
+		if e.Result, err = json.Marshal(v); err != nil {
+			log.Printf("⚠️ Cassette could not encode %s result: %v", method, err)
+			err = nil
+		}
+	}
+	r.write(e)
+	return v, err
+}
+
+// recordSub tees a subscription stream into the cassette.
+func recordSub[T any](r *RecordingBackend, method string, args []any, open func() (<-chan T, func(), error)) (<-chan T, func(), error) {
+	ch, stop, err := open()
+	r.mu.Lock()
+	r.subs++
+	id := r.subs
+	r.mu.Unlock()
+	r.write(&cassetteEntry{Method: method, Args: encodeArgs(args...), Error: cassetteError(err), Sub: id})
+	if err != nil {
+		return ch, stop, err
+	}
+
+	out := make(chan T, cap(ch))
+	stopped := make(chan struct{})
+	var once sync.Once
+	start := time.Now()
+	go func() {
+		defer close(out)
+		for v := range ch {
+			ev, err := json.Marshal(v)
+			if err != nil {
+				log.Printf("⚠️ Cassette could not encode %s event: %v", method, err)
+			}
+			r.write(&cassetteEntry{Method: method, Sub: id, Event: ev, OffsetMs: time.Since(start).Milliseconds()})
+			select {
+			case out <- v:
+			case <-stopped:
+				return
+			}
+		}
+		r.write(&cassetteEntry{Method: method, Sub: id, End: true, OffsetMs: time.Since(start).Milliseconds()})
+	}()
+	return out, func() {
+		once.Do(func() { close(stopped) })
+		stop()
+	}, nil
+}
+
+// Basic blockchain info
+func (r *RecordingBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return recordCall(r, "ChainID", nil, func() (*big.Int, error) { return r.inner.ChainID(ctx) })
+}
+func (r *RecordingBackend) ClientVersion(ctx context.Context) (string, error) {
+	return recordCall(r, "ClientVersion", nil, func() (string, error) { return r.inner.ClientVersion(ctx) })
+}
+func (r *RecordingBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return recordCall(r, "BlockNumber", nil, func() (*big.Int, error) { return r.inner.BlockNumber(ctx) })
+}
+
+// Block operations
+func (r *RecordingBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return recordCall(r, "BlockByNumber", []any{num, fullTx}, func() (*Types.Block, error) { return r.inner.BlockByNumber(ctx, num, fullTx) })
+}
+func (r *RecordingBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return recordCall(r, "BlockByHash", []any{hash, fullTx}, func() (*Types.Block, error) { return r.inner.BlockByHash(ctx, hash, fullTx) })
+}
+func (r *RecordingBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return recordCall(r, "BlockTransactionCountByNumber", []any{blockNum}, func() (uint64, error) {
+		return r.inner.BlockTransactionCountByNumber(ctx, blockNum)
+	})
+}
+func (r *RecordingBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return recordCall(r, "BlockTransactionCountByHash", []any{blockHash}, func() (uint64, error) {
+		return r.inner.BlockTransactionCountByHash(ctx, blockHash)
+	})
+}
+
+// Account operations
+func (r *RecordingBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return recordCall(r, "Balance", []any{addr, block}, func() (*big.Int, error) { return r.inner.Balance(ctx, addr, block) })
+}
+func (r *RecordingBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return recordCall(r, "GetCode", []any{addr, block}, func() ([]byte, error) { return r.inner.GetCode(ctx, addr, block) })
+}
+func (r *RecordingBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return recordCall(r, "GetStorageAt", []any{addr, key, block}, func() ([]byte, error) { return r.inner.GetStorageAt(ctx, addr, key, block) })
+}
+func (r *RecordingBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return recordCall(r, "GetTransactionCount", []any{addr, block}, func() (uint64, error) {
+		return r.inner.GetTransactionCount(ctx, addr, block)
+	})
+}
+
+// Transaction operations
+func (r *RecordingBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return recordCall(r, "Call", []any{msg, block}, func() ([]byte, error) { return r.inner.Call(ctx, msg, block) })
+}
+func (r *RecordingBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return recordCall(r, "EstimateGas", []any{msg}, func() (uint64, error) { return r.inner.EstimateGas(ctx, msg) })
+}
+func (r *RecordingBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return recordCall(r, "GasPrice", nil, func() (*big.Int, error) { return r.inner.GasPrice(ctx) })
+}
+func (r *RecordingBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	return recordCall(r, "SendRawTx", []any{rawHex}, func() ([]byte, error) { return r.inner.SendRawTx(ctx, rawHex) })
+}
+func (r *RecordingBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return recordCall(r, "TxByHash", []any{hash}, func() (*Types.Transaction, error) { return r.inner.TxByHash(ctx, hash) })
+}
+func (r *RecordingBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return recordCall(r, "TxByBlockNumberAndIndex", []any{blockNum, index}, func() (*Types.Transaction, error) {
+		return r.inner.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (r *RecordingBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return recordCall(r, "TxByBlockHashAndIndex", []any{blockHash, index}, func() (*Types.Transaction, error) {
+		return r.inner.TxByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+func (r *RecordingBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return recordCall(r, "ReceiptByHash", []any{hash}, func() (*Types.Receipt, error) { return r.inner.ReceiptByHash(ctx, hash) })
+}
+
+// Log operations
+func (r *RecordingBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	return recordCall(r, "GetLogs", []any{q}, func() ([]*Types.Log, error) { return r.inner.GetLogs(ctx, q) })
+}
+
+// Network operations
+func (r *RecordingBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return recordCall(r, "PeerCount", nil, func() (uint64, error) { return r.inner.PeerCount(ctx) })
+}
+func (r *RecordingBackend) Listening(ctx context.Context) (bool, error) {
+	return recordCall(r, "Listening", nil, func() (bool, error) { return r.inner.Listening(ctx) })
+}
+func (r *RecordingBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return recordCall(r, "Syncing", nil, func() (map[string]any, error) { return r.inner.Syncing(ctx) })
+}
+
+// Mining operations (for PoW chains)
+func (r *RecordingBackend) Mining(ctx context.Context) (bool, error) {
+	return recordCall(r, "Mining", nil, func() (bool, error) { return asMining(r.inner).Mining(ctx) })
+}
+func (r *RecordingBackend) Hashrate(ctx context.Context) (uint64, error) {
+	return recordCall(r, "Hashrate", nil, func() (uint64, error) { return asMining(r.inner).Hashrate(ctx) })
+}
+
+// Uncle operations (for PoW chains)
+func (r *RecordingBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return recordCall(r, "UncleCountByBlockNumber", []any{blockNum}, func() (uint64, error) {
+		return asUncles(r.inner).UncleCountByBlockNumber(ctx, blockNum)
+	})
+}
+func (r *RecordingBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return recordCall(r, "UncleCountByBlockHash", []any{blockHash}, func() (uint64, error) {
+		return asUncles(r.inner).UncleCountByBlockHash(ctx, blockHash)
+	})
+}
+func (r *RecordingBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return recordCall(r, "UncleByBlockNumberAndIndex", []any{blockNum, index}, func() (*Types.Block, error) {
+		return asUncles(r.inner).UncleByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (r *RecordingBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return recordCall(r, "UncleByBlockHashAndIndex", []any{blockHash, index}, func() (*Types.Block, error) {
+		return asUncles(r.inner).UncleByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+
+// Streaming (for WS subscriptions)
+func (r *RecordingBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return recordSub(r, "SubscribeNewHeads", nil, func() (<-chan *Types.Block, func(), error) { return asSubscriptions(r.inner).SubscribeNewHeads(ctx) })
+}
+func (r *RecordingBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return recordSub(r, "SubscribeLogs", []any{q}, func() (<-chan *Types.Log, func(), error) { return asSubscriptions(r.inner).SubscribeLogs(ctx, q) })
+}
+func (r *RecordingBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return recordSub(r, "SubscribePendingTxs", nil, func() (<-chan []byte, func(), error) { return asSubscriptions(r.inner).SubscribePendingTxs(ctx) })
+}
+
+// Tracing
+func (r *RecordingBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return recordCall(r, "TraceTransaction", []any{hash, config}, func() (json.RawMessage, error) {
+		return asTracing(r.inner).TraceTransaction(ctx, hash, config)
+	})
+}
+func (r *RecordingBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return recordCall(r, "TraceCall", []any{msg, block, config}, func() (json.RawMessage, error) {
+		return asTracing(r.inner).TraceCall(ctx, msg, block, config)
+	})
+}
+
+// Supports reports the recorded backend's capabilities.
+func (r *RecordingBackend) Supports(c Types.Capability) bool { return Types.Supports(r.inner, c) }
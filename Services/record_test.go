@@ -0,0 +1,69 @@
+package Services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	be := Services.NewMemoryBackend(big.NewInt(7))
+	ctx := context.Background()
+	var cassette bytes.Buffer
+	rec := Services.NewRecordingBackend(be, &cassette)
+
+	head, err := rec.BlockNumber(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := rec.BlockByNumber(ctx, head, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, err := rec.GetLogs(ctx, Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: head})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, wantErr := rec.TxByHash(ctx, make([]byte, 32))
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var missed []string
+	rb, err := Services.NewReplayBackend(&cassette, Services.ReplayOptions{OnMiss: func(method, args string) { missed = append(missed, method) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := rb.BlockNumber(ctx); err != nil || got.Cmp(head) != 0 {
+		t.Errorf("replayed BlockNumber = %v, %v, want %v", got, err, head)
+	}
+	if got, err := rb.BlockByNumber(ctx, head, true); err != nil || !reflect.DeepEqual(got, block) {
+		t.Errorf("replayed BlockByNumber = %+v, %v, want %+v", got, err, block)
+	}
+	if got, err := rb.GetLogs(ctx, Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: head}); err != nil || !reflect.DeepEqual(got, logs) {
+		t.Errorf("replayed GetLogs = %v, %v, want %v", got, err, logs)
+	}
+	if _, err := rb.TxByHash(ctx, make([]byte, 32)); (err == nil) != (wantErr == nil) {
+		t.Errorf("replayed TxByHash error %v, recorded %v", err, wantErr)
+	}
+
+	// A call with other arguments was never recorded
+	if _, err := rb.BlockByNumber(ctx, head, false); !errors.Is(err, Services.ErrNotRecorded) {
+		t.Errorf("unrecorded call returned %v, want ErrNotRecorded", err)
+	}
+	if len(missed) != 1 || missed[0] != "BlockByNumber" || len(rb.Misses()) != 1 {
+		t.Errorf("misses reported %v and %v, want the one BlockByNumber", missed, rb.Misses())
+	}
+
+	for _, c := range []Types.Capability{Types.CapUncles, Types.CapMining, Types.CapSubscriptions, Types.CapTracing} {
+		if got, want := Types.Supports(rb, c), Types.Supports(be, c); got != want {
+			t.Errorf("replay supports %s = %v, recorded backend %v", c, got, want)
+		}
+	}
+}
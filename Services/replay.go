@@ -0,0 +1,338 @@
+package Services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// ErrNotRecorded is returned by ReplayBackend for calls missing from its cassette.
+var ErrNotRecorded = errors.New("call not recorded in cassette")
+
+// ReplayOptions tunes a ReplayBackend.
+type ReplayOptions struct {
+	// Speed scales subscription timing; 2 replays twice as fast (default 1)
+	Speed float64
+	// Instant delivers subscription events without waiting
+	Instant bool
+	// OnMiss is called for every unrecorded call, e.g. with t.Errorf in tests
+	OnMiss func(method string, args string)
+}
+
+// ReplayBackend serves calls from a cassette written by RecordingBackend.
+// Identical calls are answered in recorded order and the last answer repeats
+// once they run out. Subscriptions replay their events with the recorded
+// timing. Anything not in the cassette fails with ErrNotRecorded. It reports
+// the capabilities the recorded backend had.
+type ReplayBackend struct {
+	opts   ReplayOptions
+	caps   map[Types.Capability]bool
+	mu     sync.Mutex
+	calls  map[string][]*cassetteEntry
+	events map[uint64][]*cassetteEntry
+	ended  map[uint64]bool
+	misses []string
+}
+
+// LoadReplayBackend reads the cassette at path.
+func LoadReplayBackend(path string, opts ReplayOptions) (*ReplayBackend, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplayBackend(f, opts)
+}
+
+// NewReplayBackend reads a JSONL cassette from r.
+func NewReplayBackend(r io.Reader, opts ReplayOptions) (*ReplayBackend, error) {
+	if opts.Speed <= 0 {
+		opts.Speed = 1
+	}
+	rb := &ReplayBackend{
+		opts:   opts,
+		calls:  map[string][]*cassetteEntry{},
+		events: map[uint64][]*cassetteEntry{},
+		ended:  map[uint64]bool{},
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		e := &cassetteEntry{}
+		if err := json.Unmarshal(sc.Bytes(), e); err != nil {
+			return nil, fmt.Errorf("cassette line %d: %w", line, err)
+		}
+		switch {
+		case e.Method == "Supports" && e.Sub == 0 && e.Args == nil:
+			rb.caps = map[Types.Capability]bool{}
+			for _, c := range e.Capabilities {
+				rb.caps[c] = true
+			}
+		case e.End:
+			rb.ended[e.Sub] = true
+		case e.Event != nil:
+			rb.events[e.Sub] = append(rb.events[e.Sub], e)
+		default:
+			key := replayKey(e.Method, e.Args)
+			rb.calls[key] = append(rb.calls[key], e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+// Supports reports the recorded backend's capabilities. Cassettes from before
+// they were recorded claim every optional interface.
+func (rb *ReplayBackend) Supports(c Types.Capability) bool {
+	return rb.caps == nil || rb.caps[c]
+}
+
+// Misses lists every call that was not found in the cassette.
+func (rb *ReplayBackend) Misses() []string {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return append([]string(nil), rb.misses...)
+}
+
+func replayKey(method string, args json.RawMessage) string {
+	return method + "|" + string(args)
+}
+
+// next pops the next recorded answer for a call, keeping the last one.
+func (rb *ReplayBackend) next(method string, args []any) (*cassetteEntry, error) {
+	enc := encodeArgs(args...)
+	key := replayKey(method, enc)
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	queue := rb.calls[key]
+	if len(queue) == 0 {
+		rb.misses = append(rb.misses, key)
+		log.Printf("❌ Replay miss: %s %s", method, enc)
+		if rb.opts.OnMiss != nil {
+			rb.opts.OnMiss(method, string(enc))
+		}
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, method, enc)
+	}
+	e := queue[0]
+	if len(queue) > 1 {
+		rb.calls[key] = queue[1:]
+	}
+	return e, nil
+}
+
+// replayedError rebuilds a recorded error, keeping its JSON-RPC code.
+func replayedError(e *Types.Error) error {
+	if e.Code != 0 {
+		return &Types.Error{Code: e.Code, Message: e.Message, Data: e.Data}
+	}
+	return errors.New(e.Message)
+}
+
+// replayCall answers a call from the cassette.
+func replayCall[T any](rb *ReplayBackend, method string, args ...any) (T, error) {
+	var v T
+	e, err := rb.next(method, args)
+	if err != nil {
+		return v, err
+	}
+	if e.Error != nil {
+		return v, replayedError(e.Error)
+	}
+	if err := json.Unmarshal(e.Result, &v); err != nil {
+		return v, fmt.Errorf("cassette %s result #%d: %w", method, e.Seq, err)
+	}
+	return v, nil
+}
+
+// replaySub replays a recorded subscription stream with its original timing.
+// The channel closes when the recording ended, on stop, or when ctx is done.
+func replaySub[T any](rb *ReplayBackend, ctx context.Context, method string, args ...any) (<-chan T, func(), error) {
+	e, err := rb.next(method, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.Error != nil {
+		return nil, nil, replayedError(e.Error)
+	}
+	rb.mu.Lock()
+	events, ended := rb.events[e.Sub], rb.ended[e.Sub]
+	rb.mu.Unlock()
+
+	out := make(chan T, 1)
+	stopped := make(chan struct{})
+	var once sync.Once
+	go func() {
+		defer close(out)
+		start := time.Now()
+		for _, ev := range events {
+			var v T
+			if err := json.Unmarshal(ev.Event, &v); err != nil {
+				log.Printf("⚠️ Replay could not decode %s event #%d: %v", method, ev.Seq, err)
+				continue
+			}
+			if !rb.opts.Instant {
+				at := time.Duration(float64(ev.OffsetMs) * float64(time.Millisecond) / rb.opts.Speed)
+				select {
+				case <-time.After(time.Until(start.Add(at))):
+				case <-stopped:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case out <- v:
+			case <-stopped:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+		if ended {
+			return
+		}
+		// The stream was still live when recording stopped
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+		}
+	}()
+	return out, func() { once.Do(func() { close(stopped) }) }, nil
+}
+
+// Basic blockchain info
+func (rb *ReplayBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return replayCall[*big.Int](rb, "ChainID")
+}
+func (rb *ReplayBackend) ClientVersion(ctx context.Context) (string, error) {
+	return replayCall[string](rb, "ClientVersion")
+}
+func (rb *ReplayBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return replayCall[*big.Int](rb, "BlockNumber")
+}
+
+// Block operations
+func (rb *ReplayBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return replayCall[*Types.Block](rb, "BlockByNumber", num, fullTx)
+}
+func (rb *ReplayBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return replayCall[*Types.Block](rb, "BlockByHash", hash, fullTx)
+}
+func (rb *ReplayBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return replayCall[uint64](rb, "BlockTransactionCountByNumber", blockNum)
+}
+func (rb *ReplayBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return replayCall[uint64](rb, "BlockTransactionCountByHash", blockHash)
+}
+
+// Account operations
+func (rb *ReplayBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return replayCall[*big.Int](rb, "Balance", addr, block)
+}
+func (rb *ReplayBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return replayCall[[]byte](rb, "GetCode", addr, block)
+}
+func (rb *ReplayBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return replayCall[[]byte](rb, "GetStorageAt", addr, key, block)
+}
+func (rb *ReplayBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return replayCall[uint64](rb, "GetTransactionCount", addr, block)
+}
+
+// Transaction operations
+func (rb *ReplayBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return replayCall[[]byte](rb, "Call", msg, block)
+}
+func (rb *ReplayBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return replayCall[uint64](rb, "EstimateGas", msg)
+}
+func (rb *ReplayBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return replayCall[*big.Int](rb, "GasPrice")
+}
+func (rb *ReplayBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	return replayCall[[]byte](rb, "SendRawTx", rawHex)
+}
+func (rb *ReplayBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return replayCall[*Types.Transaction](rb, "TxByHash", hash)
+}
+func (rb *ReplayBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return replayCall[*Types.Transaction](rb, "TxByBlockNumberAndIndex", blockNum, index)
+}
+func (rb *ReplayBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return replayCall[*Types.Transaction](rb, "TxByBlockHashAndIndex", blockHash, index)
+}
+func (rb *ReplayBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return replayCall[*Types.Receipt](rb, "ReceiptByHash", hash)
+}
+
+// Log operations
+func (rb *ReplayBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	return replayCall[[]*Types.Log](rb, "GetLogs", q)
+}
+
+// Network operations
+func (rb *ReplayBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return replayCall[uint64](rb, "PeerCount")
+}
+func (rb *ReplayBackend) Listening(ctx context.Context) (bool, error) {
+	return replayCall[bool](rb, "Listening")
+}
+func (rb *ReplayBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return replayCall[map[string]any](rb, "Syncing")
+}
+
+// Mining operations (for PoW chains)
+func (rb *ReplayBackend) Mining(ctx context.Context) (bool, error) {
+	return replayCall[bool](rb, "Mining")
+}
+func (rb *ReplayBackend) Hashrate(ctx context.Context) (uint64, error) {
+	return replayCall[uint64](rb, "Hashrate")
+}
+
+// Uncle operations (for PoW chains)
+func (rb *ReplayBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return replayCall[uint64](rb, "UncleCountByBlockNumber", blockNum)
+}
+func (rb *ReplayBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return replayCall[uint64](rb, "UncleCountByBlockHash", blockHash)
+}
+func (rb *ReplayBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return replayCall[*Types.Block](rb, "UncleByBlockNumberAndIndex", blockNum, index)
+}
+func (rb *ReplayBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return replayCall[*Types.Block](rb, "UncleByBlockHashAndIndex", blockHash, index)
+}
+
+// Streaming (for WS subscriptions)
+func (rb *ReplayBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return replaySub[*Types.Block](rb, ctx, "SubscribeNewHeads")
+}
+func (rb *ReplayBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return replaySub[*Types.Log](rb, ctx, "SubscribeLogs", q)
+}
+func (rb *ReplayBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return replaySub[[]byte](rb, ctx, "SubscribePendingTxs")
+}
+
+// Tracing
+func (rb *ReplayBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return replayCall[json.RawMessage](rb, "TraceTransaction", hash, config)
+}
+func (rb *ReplayBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return replayCall[json.RawMessage](rb, "TraceCall", msg, block, config)
+}
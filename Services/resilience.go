@@ -0,0 +1,553 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// ErrCircuitOpen is returned without calling the backend while a method's
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// ResilienceConfig configures a ResilientBackend. Zero values use the
+// defaults noted.
+type ResilienceConfig struct {
+	// MaxAttempts bounds the calls made for one request, retries included
+	// (default 3; 1 disables retries)
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry, doubling up to
+	// MaxBackoff; each wait is jittered between half and all of it
+	// (defaults 100ms and 2s)
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// RetryCodes are the JSON-RPC error codes worth retrying (default
+	// -32005, limit exceeded). Transport errors are always retried.
+	RetryCodes []int
+
+	// BreakerFailures is how many consecutive upstream faults open a
+	// method's breaker (default 5); BreakerCooldown is how long it stays
+	// open before a single probe call is let through (default 10s)
+	BreakerFailures int
+	BreakerCooldown time.Duration
+
+	// HedgePercentile, when set (e.g. 0.95), sends a second attempt once a
+	// call has taken longer than that percentile of the method's recent
+	// latencies and returns whichever answers first
+	HedgePercentile float64
+	// HedgeMinSamples is how many latencies a method needs before it is
+	// hedged (default 20)
+	HedgeMinSamples int
+	// HedgeMethods lists the Backend method names to hedge; empty hedges
+	// every read
+	HedgeMethods []string
+
+	// Metrics receives retry, hedge and breaker counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// hedgeWindow is how many recent latencies per method feed the hedge delay
+const hedgeWindow = 128
+
+// ResilientBackend retries transient failures with jittered exponential
+// backoff, fails fast per method while the backend is unhealthy and can hedge
+// slow reads. Reads are retried freely. SendRawTx is only sent again after
+// a failure whose outcome is unknown and once a lookup shows the
+// transaction has not arrived; node rejections are never retried.
+// //future: May add per-method retry budgets
+type ResilientBackend struct {
+	Types.Backend
+	forwardOptional
+	cfg        ResilienceConfig
+	retryCodes map[int]bool
+	hedge      map[string]bool
+	metrics    *Metrics
+
+	mu      sync.Mutex
+	methods map[string]*methodHealth
+}
+
+// methodHealth is the breaker state and latency window of one method
+type methodHealth struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	latencies [hedgeWindow]time.Duration
+	samples   int
+}
+
+// NewResilientBackend wraps inner with retries, circuit breakers and
+// optional hedging.
+func NewResilientBackend(inner Types.Backend, cfg ResilienceConfig) *ResilientBackend {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 2 * time.Second
+	}
+	if cfg.RetryCodes == nil {
+		cfg.RetryCodes = []int{-32005}
+	}
+	if cfg.BreakerFailures <= 0 {
+		cfg.BreakerFailures = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 10 * time.Second
+	}
+	if cfg.HedgeMinSamples <= 0 {
+		cfg.HedgeMinSamples = 20
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	r := &ResilientBackend{
+		Backend:         inner,
+		forwardOptional: forwardOptional{inner},
+		cfg:             cfg,
+		retryCodes:      map[int]bool{},
+		metrics:         cfg.Metrics,
+		methods:         map[string]*methodHealth{},
+	}
+	for _, code := range cfg.RetryCodes {
+		r.retryCodes[code] = true
+	}
+	if len(cfg.HedgeMethods) > 0 {
+		r.hedge = map[string]bool{}
+		for _, m := range cfg.HedgeMethods {
+			r.hedge[m] = true
+		}
+	}
+	return r
+}
+
+// Unwrap returns the wrapped backend
+func (r *ResilientBackend) Unwrap() Types.Backend { return r.Backend }
+
+// Breakers reports the state of every breaker that has seen a call:
+// "closed", "open" or "half-open"
+func (r *ResilientBackend) Breakers() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]string, len(r.methods))
+	now := time.Now()
+	for name, h := range r.methods {
+		h.mu.Lock()
+		switch {
+		case h.failures < r.cfg.BreakerFailures:
+			out[name] = "closed"
+		case now.Before(h.openUntil):
+			out[name] = "open"
+		default:
+			out[name] = "half-open"
+		}
+		h.mu.Unlock()
+	}
+	return out
+}
+
+func (r *ResilientBackend) health(method string) *methodHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.methods[method]
+	if !ok {
+		h = &methodHealth{}
+		r.methods[method] = h
+	}
+	return h
+}
+
+// allow reports whether a call may go ahead. Once the cooldown has passed, an
+// open breaker lets a single probe through.
+func (r *ResilientBackend) allow(h *methodHealth) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures < r.cfg.BreakerFailures {
+		return true
+	}
+	if time.Now().Before(h.openUntil) || h.probing {
+		return false
+	}
+	h.probing = true
+	return true
+}
+
+// record updates a breaker with a call's outcome. Calls the caller cancelled
+// say nothing about the backend and only release a probe.
+func (r *ResilientBackend) record(ctx context.Context, h *methodHealth, method string, took time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case ctx.Err() != nil:
+		h.probing = false
+	case r.retryable(ctx, err):
+		probe := h.probing
+		h.failures++
+		h.probing = false
+		if h.failures == r.cfg.BreakerFailures || probe {
+			log.Printf("⚡ resilience: %s breaker open after %d failures: %v", method, h.failures, err)
+			r.metrics.Inc(metricName("facade_resilience_breaker_open_total", "method", method))
+		}
+		if h.failures >= r.cfg.BreakerFailures {
+			h.openUntil = time.Now().Add(r.cfg.BreakerCooldown)
+		}
+	default:
+		if h.failures >= r.cfg.BreakerFailures {
+			log.Printf("⚡ resilience: %s breaker closed", method)
+		}
+		h.failures, h.openUntil, h.probing = 0, time.Time{}, false
+		if err == nil {
+			h.latencies[h.samples%hedgeWindow] = took
+			h.samples++
+		}
+	}
+}
+
+// hedgeDelay returns the configured latency percentile of a method, once it
+// has enough samples
+func (r *ResilientBackend) hedgeDelay(h *methodHealth, method string) (time.Duration, bool) {
+	if r.cfg.HedgePercentile <= 0 || (r.hedge != nil && !r.hedge[method]) {
+		return 0, false
+	}
+	h.mu.Lock()
+	n := min(h.samples, hedgeWindow)
+	window := slices.Clone(h.latencies[:n])
+	h.mu.Unlock()
+	if n < r.cfg.HedgeMinSamples {
+		return 0, false
+	}
+	slices.Sort(window)
+	i := min(int(r.cfg.HedgePercentile*float64(n)), n-1)
+	return window[i], true
+}
+
+// retryable reports whether err is a transient upstream fault. As for
+// FailoverBackend, errors other than JSON-RPC errors are transport faults,
+// so the decorator belongs around remote backends; of the JSON-RPC errors
+// only RetryCodes are retried.
+func (r *ResilientBackend) retryable(ctx context.Context, err error) bool {
+	if failoverRetryable(ctx, err) {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	var rpcErr *Types.Error
+	return ctx.Err() == nil && errors.As(err, &rpcErr) && r.retryCodes[rpcErr.Code]
+}
+
+// backoff waits before retry n (from 1), or until ctx is done
+func (r *ResilientBackend) backoff(ctx context.Context, n int) error {
+	d := r.cfg.BaseBackoff << (n - 1)
+	if d > r.cfg.MaxBackoff || d <= 0 {
+		d = r.cfg.MaxBackoff
+	}
+	d = d/2 + rand.N(d/2+1)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// resilientCall runs an idempotent call with retries and the method's
+// breaker, hedging it when hedge is set and the method has a hedge delay.
+func resilientCall[T any](r *ResilientBackend, ctx context.Context, method string, hedge bool, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	h := r.health(method)
+	for attempt := 1; ; attempt++ {
+		if !r.allow(h) {
+			r.metrics.Inc(metricName("facade_resilience_rejected_total", "method", method))
+			return zero, fmt.Errorf("%s: %w", method, ErrCircuitOpen)
+		}
+		start := time.Now()
+		var (
+			v   T
+			err error
+		)
+		if delay, ok := r.hedgeDelay(h, method); hedge && ok {
+			v, err = hedgedCall(r, ctx, method, delay, fn)
+		} else {
+			v, err = fn(ctx)
+		}
+		r.record(ctx, h, method, time.Since(start), err)
+		if !r.retryable(ctx, err) || attempt >= r.cfg.MaxAttempts {
+			return v, err
+		}
+		r.metrics.Inc(metricName("facade_resilience_retries_total", "method", method))
+		if err := r.backoff(ctx, attempt); err != nil {
+			return zero, err
+		}
+	}
+}
+
+// hedgedCall starts fn and, if it has not answered after delay, a second
+// attempt; the first answer that is not a transient fault wins and the other
+// attempt is cancelled.
+func hedgedCall[T any](r *ResilientBackend, ctx context.Context, method string, delay time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	type result struct {
+		v     T
+		err   error
+		hedge bool
+	}
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, 2)
+	launch := func(hedge bool) {
+		go func() {
+			v, err := fn(attemptCtx)
+			results <- result{v, err, hedge}
+		}()
+	}
+	launch(false)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending, hedged := 1, false
+	for {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				pending++
+				r.metrics.Inc(metricName("facade_resilience_hedges_total", "method", method))
+				launch(true)
+			}
+		case res := <-results:
+			pending--
+			if !r.retryable(ctx, res.err) || pending == 0 {
+				if res.hedge && res.err == nil {
+					r.metrics.Inc(metricName("facade_resilience_hedge_wins_total", "method", method))
+				}
+				return res.v, res.err
+			}
+		}
+	}
+}
+
+// Basic blockchain info
+func (r *ResilientBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return resilientCall(r, ctx, "ChainID", true, func(ctx context.Context) (*big.Int, error) { return r.Backend.ChainID(ctx) })
+}
+func (r *ResilientBackend) ClientVersion(ctx context.Context) (string, error) {
+	return resilientCall(r, ctx, "ClientVersion", true, func(ctx context.Context) (string, error) { return r.Backend.ClientVersion(ctx) })
+}
+func (r *ResilientBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return resilientCall(r, ctx, "BlockNumber", true, func(ctx context.Context) (*big.Int, error) { return r.Backend.BlockNumber(ctx) })
+}
+
+// Block operations
+func (r *ResilientBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return resilientCall(r, ctx, "BlockByNumber", true, func(ctx context.Context) (*Types.Block, error) {
+		return r.Backend.BlockByNumber(ctx, num, fullTx)
+	})
+}
+func (r *ResilientBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return resilientCall(r, ctx, "BlockByHash", true, func(ctx context.Context) (*Types.Block, error) {
+		return r.Backend.BlockByHash(ctx, hash, fullTx)
+	})
+}
+func (r *ResilientBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return resilientCall(r, ctx, "BlockTransactionCountByNumber", true, func(ctx context.Context) (uint64, error) {
+		return r.Backend.BlockTransactionCountByNumber(ctx, blockNum)
+	})
+}
+func (r *ResilientBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return resilientCall(r, ctx, "BlockTransactionCountByHash", true, func(ctx context.Context) (uint64, error) {
+		return r.Backend.BlockTransactionCountByHash(ctx, blockHash)
+	})
+}
+
+// Account operations
+func (r *ResilientBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return resilientCall(r, ctx, "Balance", true, func(ctx context.Context) (*big.Int, error) { return r.Backend.Balance(ctx, addr, block) })
+}
+func (r *ResilientBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return resilientCall(r, ctx, "GetCode", true, func(ctx context.Context) ([]byte, error) { return r.Backend.GetCode(ctx, addr, block) })
+}
+func (r *ResilientBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return resilientCall(r, ctx, "GetStorageAt", true, func(ctx context.Context) ([]byte, error) {
+		return r.Backend.GetStorageAt(ctx, addr, key, block)
+	})
+}
+func (r *ResilientBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return resilientCall(r, ctx, "GetTransactionCount", true, func(ctx context.Context) (uint64, error) {
+		return r.Backend.GetTransactionCount(ctx, addr, block)
+	})
+}
+
+// Transaction operations
+func (r *ResilientBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return resilientCall(r, ctx, "Call", true, func(ctx context.Context) ([]byte, error) { return r.Backend.Call(ctx, msg, block) })
+}
+func (r *ResilientBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return resilientCall(r, ctx, "EstimateGas", true, func(ctx context.Context) (uint64, error) { return r.Backend.EstimateGas(ctx, msg) })
+}
+func (r *ResilientBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return resilientCall(r, ctx, "GasPrice", true, func(ctx context.Context) (*big.Int, error) { return r.Backend.GasPrice(ctx) })
+}
+
+// SendRawTx is never hedged. After a transient failure the send may still
+// have reached the node, so the transaction is looked up by hash before it
+// is sent again. A resend answered with "already known", or rejected while
+// the lookup now finds the transaction, counts as success.
+func (r *ResilientBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	var hash []byte
+	if raw, err := decodeHex(rawHex); err == nil && len(raw) > 0 {
+		hash = crypto.Keccak256(raw)
+	}
+	h := r.health("SendRawTx")
+	for attempt := 1; ; attempt++ {
+		if !r.allow(h) {
+			r.metrics.Inc(metricName("facade_resilience_rejected_total", "method", "SendRawTx"))
+			return nil, fmt.Errorf("SendRawTx: %w", ErrCircuitOpen)
+		}
+		start := time.Now()
+		txHash, err := r.Backend.SendRawTx(ctx, rawHex)
+		r.record(ctx, h, "SendRawTx", time.Since(start), err)
+		if err != nil && attempt > 1 && hash != nil {
+			// An earlier attempt may have reached the node after all, in which
+			// case the resend is refused as a duplicate or for its nonce
+			if strings.Contains(strings.ToLower(err.Error()), "already known") ||
+				(!r.retryable(ctx, err) && r.landed(ctx, hash)) {
+				return hash, nil
+			}
+		}
+		if !r.retryable(ctx, err) || hash == nil || attempt >= r.cfg.MaxAttempts {
+			return txHash, err
+		}
+		r.metrics.Inc(metricName("facade_resilience_retries_total", "method", "SendRawTx"))
+		if err := r.backoff(ctx, attempt); err != nil {
+			return nil, err
+		}
+		if r.landed(ctx, hash) {
+			return hash, nil
+		}
+	}
+}
+
+// landed reports whether the node knows the transaction with hash
+func (r *ResilientBackend) landed(ctx context.Context, hash []byte) bool {
+	tx, err := r.Backend.TxByHash(ctx, hash)
+	return err == nil && tx != nil
+}
+func (r *ResilientBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return resilientCall(r, ctx, "TxByHash", true, func(ctx context.Context) (*Types.Transaction, error) { return r.Backend.TxByHash(ctx, hash) })
+}
+func (r *ResilientBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return resilientCall(r, ctx, "TxByBlockNumberAndIndex", true, func(ctx context.Context) (*Types.Transaction, error) {
+		return r.Backend.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (r *ResilientBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return resilientCall(r, ctx, "TxByBlockHashAndIndex", true, func(ctx context.Context) (*Types.Transaction, error) {
+		return r.Backend.TxByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+func (r *ResilientBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return resilientCall(r, ctx, "ReceiptByHash", true, func(ctx context.Context) (*Types.Receipt, error) {
+		return r.Backend.ReceiptByHash(ctx, hash)
+	})
+}
+
+// Log operations
+func (r *ResilientBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	return resilientCall(r, ctx, "GetLogs", true, func(ctx context.Context) ([]*Types.Log, error) { return r.Backend.GetLogs(ctx, q) })
+}
+
+// Network operations
+func (r *ResilientBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return resilientCall(r, ctx, "PeerCount", true, func(ctx context.Context) (uint64, error) { return r.Backend.PeerCount(ctx) })
+}
+func (r *ResilientBackend) Listening(ctx context.Context) (bool, error) {
+	return resilientCall(r, ctx, "Listening", true, func(ctx context.Context) (bool, error) { return r.Backend.Listening(ctx) })
+}
+func (r *ResilientBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return resilientCall(r, ctx, "Syncing", true, func(ctx context.Context) (map[string]any, error) { return r.Backend.Syncing(ctx) })
+}
+
+// Mining operations (for PoW chains)
+func (r *ResilientBackend) Mining(ctx context.Context) (bool, error) {
+	return resilientCall(r, ctx, "Mining", true, func(ctx context.Context) (bool, error) { return r.forwardOptional.Mining(ctx) })
+}
+func (r *ResilientBackend) Hashrate(ctx context.Context) (uint64, error) {
+	return resilientCall(r, ctx, "Hashrate", true, func(ctx context.Context) (uint64, error) { return r.forwardOptional.Hashrate(ctx) })
+}
+
+// Uncle operations (for PoW chains)
+func (r *ResilientBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return resilientCall(r, ctx, "UncleCountByBlockNumber", true, func(ctx context.Context) (uint64, error) {
+		return r.forwardOptional.UncleCountByBlockNumber(ctx, blockNum)
+	})
+}
+func (r *ResilientBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return resilientCall(r, ctx, "UncleCountByBlockHash", true, func(ctx context.Context) (uint64, error) {
+		return r.forwardOptional.UncleCountByBlockHash(ctx, blockHash)
+	})
+}
+func (r *ResilientBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return resilientCall(r, ctx, "UncleByBlockNumberAndIndex", true, func(ctx context.Context) (*Types.Block, error) {
+		return r.forwardOptional.UncleByBlockNumberAndIndex(ctx, blockNum, index)
+	})
+}
+func (r *ResilientBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return resilientCall(r, ctx, "UncleByBlockHashAndIndex", true, func(ctx context.Context) (*Types.Block, error) {
+		return r.forwardOptional.UncleByBlockHashAndIndex(ctx, blockHash, index)
+	})
+}
+
+// stream is an open subscription, so opening one can go through resilientCall
+type stream[T any] struct {
+	ch   <-chan T
+	stop func()
+}
+
+// Streaming (for WS subscriptions)
+// Opening a subscription is retried but not hedged, which would leave a
+// second one open. Streams that end are not reopened.
+func (r *ResilientBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	s, err := resilientCall(r, ctx, "SubscribeNewHeads", false, func(ctx context.Context) (stream[*Types.Block], error) {
+		ch, stop, err := r.forwardOptional.SubscribeNewHeads(ctx)
+		return stream[*Types.Block]{ch, stop}, err
+	})
+	return s.ch, s.stop, err
+}
+func (r *ResilientBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	s, err := resilientCall(r, ctx, "SubscribeLogs", false, func(ctx context.Context) (stream[*Types.Log], error) {
+		ch, stop, err := r.forwardOptional.SubscribeLogs(ctx, q)
+		return stream[*Types.Log]{ch, stop}, err
+	})
+	return s.ch, s.stop, err
+}
+func (r *ResilientBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	s, err := resilientCall(r, ctx, "SubscribePendingTxs", false, func(ctx context.Context) (stream[[]byte], error) {
+		ch, stop, err := r.forwardOptional.SubscribePendingTxs(ctx)
+		return stream[[]byte]{ch, stop}, err
+	})
+	return s.ch, s.stop, err
+}
+
+// Tracing
+func (r *ResilientBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return resilientCall(r, ctx, "TraceTransaction", true, func(ctx context.Context) (json.RawMessage, error) {
+		return r.forwardOptional.TraceTransaction(ctx, hash, config)
+	})
+}
+func (r *ResilientBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return resilientCall(r, ctx, "TraceCall", true, func(ctx context.Context) (json.RawMessage, error) {
+		return r.forwardOptional.TraceCall(ctx, msg, block, config)
+	})
+}
@@ -0,0 +1,309 @@
+package Services_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+var (
+	rawTx   = "0xc0ffee"
+	rawHash = crypto.Keccak256([]byte{0xc0, 0xff, 0xee})
+)
+
+// sendStep scripts one SendRawTx call: accept puts the transaction in the
+// node's pool, err is what the caller sees
+type sendStep struct {
+	accept bool
+	err    error
+}
+
+// scriptedNode is a fake upstream that plays back scripted answers. Calls past
+// the end of a script accept the transaction or answer the lookup honestly.
+type scriptedNode struct {
+	Types.Backend
+	mu      sync.Mutex
+	sends   []sendStep
+	lookups []error
+	pool    bool
+	nSend   int
+	nLookup int
+
+	// blockNumber answers BlockNumber when set
+	blockNumber func(ctx context.Context) (*big.Int, error)
+}
+
+func (s *scriptedNode) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	step := sendStep{accept: true}
+	if s.nSend < len(s.sends) {
+		step = s.sends[s.nSend]
+	}
+	s.nSend++
+	if step.accept {
+		s.pool = true
+	}
+	if step.err != nil {
+		return nil, step.err
+	}
+	return rawHash, nil
+}
+
+func (s *scriptedNode) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if s.nLookup < len(s.lookups) {
+		err = s.lookups[s.nLookup]
+	}
+	s.nLookup++
+	if err != nil || !s.pool {
+		return nil, err
+	}
+	return &Types.Transaction{Hash: hash}, nil
+}
+
+func (s *scriptedNode) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return s.blockNumber(ctx)
+}
+
+func fastRetries(metrics *Services.Metrics) Services.ResilienceConfig {
+	return Services.ResilienceConfig{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Metrics: metrics}
+}
+
+func TestResilientSendRawTx(t *testing.T) {
+	nonceTooLow := &Types.Error{Code: -32000, Message: "nonce too low"}
+	tests := []struct {
+		name       string
+		sends      []sendStep
+		lookups    []error
+		wantErr    error
+		wantSends  int
+		wantLookup int
+	}{
+		{
+			name:       "accepted then transport error",
+			sends:      []sendStep{{accept: true, err: errReset}},
+			wantSends:  1,
+			wantLookup: 1,
+		},
+		{
+			name:       "lost before the node",
+			sends:      []sendStep{{err: errReset}},
+			wantSends:  2,
+			wantLookup: 1,
+		},
+		{
+			name:       "resend already known",
+			sends:      []sendStep{{err: errReset}, {accept: true, err: &Types.Error{Code: -32000, Message: "already known"}}},
+			wantSends:  2,
+			wantLookup: 1,
+		},
+		{
+			name:       "lookup fails and resend is refused for its nonce",
+			sends:      []sendStep{{accept: true, err: errReset}, {err: nonceTooLow}},
+			lookups:    []error{errReset},
+			wantSends:  2,
+			wantLookup: 2,
+		},
+		{
+			name:      "rejection is not retried",
+			sends:     []sendStep{{err: nonceTooLow}},
+			wantErr:   nonceTooLow,
+			wantSends: 1,
+		},
+		{
+			name:       "attempts exhausted",
+			sends:      []sendStep{{err: errReset}, {err: errReset}, {err: errReset}},
+			wantErr:    errReset,
+			wantSends:  3,
+			wantLookup: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &scriptedNode{sends: tt.sends, lookups: tt.lookups}
+			r := Services.NewResilientBackend(node, fastRetries(Services.NewMetrics()))
+			hash, err := r.SendRawTx(context.Background(), rawTx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SendRawTx error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(hash) != string(rawHash) {
+				t.Errorf("SendRawTx = %x, want %x", hash, rawHash)
+			}
+			if node.nSend != tt.wantSends || node.nLookup != tt.wantLookup {
+				t.Errorf("%d sends and %d lookups, want %d and %d", node.nSend, node.nLookup, tt.wantSends, tt.wantLookup)
+			}
+		})
+	}
+}
+
+func TestResilientBreaker(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+		fail  = true
+		gate  chan struct{}
+	)
+	node := &scriptedNode{blockNumber: func(ctx context.Context) (*big.Int, error) {
+		mu.Lock()
+		calls++
+		f, g := fail, gate
+		mu.Unlock()
+		if g != nil {
+			<-g
+		}
+		if f {
+			return nil, errReset
+		}
+		return big.NewInt(1), nil
+	}}
+	metrics := Services.NewMetrics()
+	r := Services.NewResilientBackend(node, Services.ResilienceConfig{
+		MaxAttempts:     1,
+		BreakerFailures: 2,
+		BreakerCooldown: 30 * time.Millisecond,
+		Metrics:         metrics,
+	})
+	ctx := context.Background()
+	state := func() string { return r.Breakers()["BlockNumber"] }
+	called := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+
+	steps := []struct {
+		name      string
+		wait      time.Duration
+		fail      bool
+		wantErr   error
+		wantCalls int
+		wantState string
+	}{
+		{"first failure", 0, true, errReset, 1, "closed"},
+		{"second failure opens", 0, true, errReset, 2, "open"},
+		{"open rejects without calling", 0, true, Services.ErrCircuitOpen, 2, "open"},
+		{"failed probe reopens", 40 * time.Millisecond, true, errReset, 3, "open"},
+		{"still open after the probe", 0, true, Services.ErrCircuitOpen, 3, "open"},
+		{"good probe closes", 40 * time.Millisecond, false, nil, 4, "closed"},
+		{"closed again", 0, false, nil, 5, "closed"},
+	}
+	for _, s := range steps {
+		time.Sleep(s.wait)
+		mu.Lock()
+		fail = s.fail
+		mu.Unlock()
+		if _, err := r.BlockNumber(ctx); !errors.Is(err, s.wantErr) {
+			t.Fatalf("%s: error %v, want %v", s.name, err, s.wantErr)
+		}
+		if n := called(); n != s.wantCalls {
+			t.Fatalf("%s: %d backend calls, want %d", s.name, n, s.wantCalls)
+		}
+		if got := state(); got != s.wantState {
+			t.Fatalf("%s: breaker %s, want %s", s.name, got, s.wantState)
+		}
+	}
+	if n := metrics.Get(`facade_resilience_breaker_open_total{method="BlockNumber"}`); n != 2 {
+		t.Errorf("breaker opened %d times, want 2", n)
+	}
+
+	// Half-open lets exactly one probe through while the rest fail fast
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	for range 2 {
+		r.BlockNumber(ctx)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if got := state(); got != "half-open" {
+		t.Fatalf("breaker %s after the cooldown, want half-open", got)
+	}
+	mu.Lock()
+	fail, gate = false, make(chan struct{})
+	release := gate
+	mu.Unlock()
+	probe := make(chan error)
+	go func() {
+		_, err := r.BlockNumber(ctx)
+		probe <- err
+	}()
+	for called() != 8 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := r.BlockNumber(ctx); !errors.Is(err, Services.ErrCircuitOpen) {
+		t.Errorf("call during the probe: %v, want ErrCircuitOpen", err)
+	}
+	close(release)
+	if err := <-probe; err != nil {
+		t.Errorf("probe: %v", err)
+	}
+	if got := state(); got != "closed" {
+		t.Errorf("breaker %s after a good probe, want closed", got)
+	}
+}
+
+func TestResilientHedge(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		calls     int
+		slow      bool
+		cancelled = make(chan struct{})
+	)
+	node := &scriptedNode{blockNumber: func(ctx context.Context) (*big.Int, error) {
+		mu.Lock()
+		calls++
+		n, s := calls, slow
+		mu.Unlock()
+		if s && n%2 == 1 {
+			// The first attempt hangs until the hedge wins and cancels it
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}
+		// Long enough that the first attempt is under way before the hedge
+		time.Sleep(10 * time.Millisecond)
+		return big.NewInt(int64(n)), nil
+	}}
+	metrics := Services.NewMetrics()
+	r := Services.NewResilientBackend(node, Services.ResilienceConfig{
+		MaxAttempts:     1,
+		HedgePercentile: 0.9,
+		HedgeMinSamples: 5,
+		Metrics:         metrics,
+	})
+	ctx := context.Background()
+	// Enough fast samples to set a hedge delay
+	for range 5 {
+		if _, err := r.BlockNumber(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	calls, slow = 0, true
+	mu.Unlock()
+	n, err := r.BlockNumber(ctx)
+	if err != nil || n.Int64() != 2 {
+		t.Fatalf("hedged BlockNumber = %v, %v, want the hedge's answer 2", n, err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow attempt was not cancelled once the hedge answered")
+	}
+	if n := metrics.Get(`facade_resilience_hedge_wins_total{method="BlockNumber"}`); n != 1 {
+		t.Errorf("%d hedge wins, want 1", n)
+	}
+	if got := r.Breakers()["BlockNumber"]; got != "closed" {
+		t.Errorf("breaker %s after a won hedge", got)
+	}
+}
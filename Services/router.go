@@ -0,0 +1,369 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Route targets used by SubmitRoutes and ArchiveRoutes
+const (
+	RouteRead    = "read"
+	RouteSubmit  = "submit"
+	RouteArchive = "archive"
+)
+
+// StateMethods are the Backend methods that read account state at a block,
+// which full nodes only serve for recent blocks.
+var StateMethods = []string{"Balance", "GetCode", "GetStorageAt", "GetTransactionCount", "Call"}
+
+// RouteRule sends the calls it matches to a named backend.
+type RouteRule struct {
+	// Name labels the rule in metrics; defaults to "<method>-><target>"
+	Name string `json:"name,omitempty"`
+	// Method is a Backend method name such as "SendRawTx"; a trailing *
+	// matches a prefix and an empty Method matches every call
+	Method string `json:"method,omitempty"`
+	// MinDepth, when set, matches only calls at an explicit block at least
+	// this many blocks behind the head; calls at the latest block never match
+	MinDepth uint64 `json:"minDepth,omitempty"`
+	// Target names a backend in RouterConfig.Backends
+	Target string `json:"target"`
+}
+
+// RouterConfig configures a RouterBackend.
+type RouterConfig struct {
+	// Backends are the route targets by name
+	Backends map[string]Types.Backend
+	// Default names the backend serving calls no rule matches (default "read")
+	Default string
+	// Rules are checked in order and the first match wins
+	Rules []RouteRule
+	// HeadTTL bounds how stale the head used for MinDepth may be (default 1s)
+	HeadTTL time.Duration
+	// Metrics receives routing counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// SubmitRoutes sends transaction submission to the "submit" backend, such as
+// a private relay or sequencer.
+func SubmitRoutes() []RouteRule {
+	return []RouteRule{{Name: "submit", Method: "SendRawTx", Target: RouteSubmit}}
+}
+
+// ArchiveRoutes sends StateMethods calls at blocks depth or more behind the
+// head to the "archive" backend. geth full nodes keep the last 128 states.
+func ArchiveRoutes(depth uint64) []RouteRule {
+	rules := make([]RouteRule, 0, len(StateMethods))
+	for _, m := range StateMethods {
+		rules = append(rules, RouteRule{Name: "archive", Method: m, MinDepth: depth, Target: RouteArchive})
+	}
+	return rules
+}
+
+// LoadRouteRules reads a JSON or YAML list of rules from path.
+func LoadRouteRules(path string) ([]RouteRule, error) {
+	rules, err := loadRules[RouteRule](path)
+	if err != nil {
+		return nil, fmt.Errorf("route rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// RouterBackend splits traffic between backends by declarative rules, for
+// example submissions to a relay, reads to a pool of full nodes and
+// historical state queries to an archive node. Calls are not retried on
+// another backend; put a FailoverBackend behind a target for that.
+type RouterBackend struct {
+	backends map[string]Types.Backend
+	def      string
+	rules    []RouteRule
+	headTTL  time.Duration
+	metrics  *Metrics
+
+	flights flightGroup
+
+	mu         sync.Mutex
+	head       uint64
+	headAt     time.Time
+	headErr    error
+	refreshing bool
+}
+
+// NewRouterBackend checks that every rule names a configured backend.
+func NewRouterBackend(cfg RouterConfig) (*RouterBackend, error) {
+	if cfg.Default == "" {
+		cfg.Default = RouteRead
+	}
+	if cfg.HeadTTL <= 0 {
+		cfg.HeadTTL = time.Second
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	if cfg.Backends[cfg.Default] == nil {
+		return nil, fmt.Errorf("router: default backend %q is not configured", cfg.Default)
+	}
+	rules := make([]RouteRule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		if cfg.Backends[rule.Target] == nil {
+			return nil, fmt.Errorf("router: rule %d targets unknown backend %q", i, rule.Target)
+		}
+		if rule.Name == "" {
+			rule.Name = rule.Method + "->" + rule.Target
+		}
+		rules[i] = rule
+		// Publish the routing table as info series
+		info := metricName("facade_route_rule_info", "route", rule.Name, "method", rule.Method, "target", rule.Target, "min_depth", fmt.Sprint(rule.MinDepth))
+		if cfg.Metrics.Get(info) == 0 {
+			cfg.Metrics.Inc(info)
+		}
+	}
+	return &RouterBackend{
+		backends: cfg.Backends,
+		def:      cfg.Default,
+		rules:    rules,
+		headTTL:  cfg.HeadTTL,
+		metrics:  cfg.Metrics,
+		flights:  flightGroup{calls: map[string]*flight{}},
+	}, nil
+}
+
+// Unwrap returns the default backend, so dev and chaos controls behind it
+// stay reachable. Backends only reached through rules are not unwrapped.
+func (r *RouterBackend) Unwrap() Types.Backend { return r.backends[r.def] }
+
+// Rules returns the routing rules in the order they are checked
+func (r *RouterBackend) Rules() []RouteRule {
+	return append([]RouteRule{}, r.rules...)
+}
+
+// route picks the backend for a call to method at block, nil meaning latest
+func (r *RouterBackend) route(ctx context.Context, method string, block *big.Int) Types.Backend {
+	for _, rule := range r.rules {
+		if !matchMethod(rule.Method, method) {
+			continue
+		}
+		if rule.MinDepth > 0 && !r.deeper(ctx, block, rule.MinDepth) {
+			continue
+		}
+		r.metrics.Inc(metricName("facade_route_requests_total", "route", rule.Name, "target", rule.Target, "method", method))
+		return r.backends[rule.Target]
+	}
+	r.metrics.Inc(metricName("facade_route_requests_total", "route", "default", "target", r.def, "method", method))
+	return r.backends[r.def]
+}
+
+// deeper reports whether block is at least depth blocks behind the head.
+// When the head is unknown the call stays on its default route.
+func (r *RouterBackend) deeper(ctx context.Context, block *big.Int, depth uint64) bool {
+	if block == nil || block.Sign() < 0 || !block.IsUint64() {
+		return false
+	}
+	head, err := r.headNumber(ctx)
+	if err != nil {
+		return false
+	}
+	return head >= block.Uint64() && head-block.Uint64() >= depth
+}
+
+// headNumber returns the default backend's head, refreshed at most every
+// HeadTTL. Only one refresh runs at a time; while it does, callers that
+// already have a head use it instead of waiting.
+func (r *RouterBackend) headNumber(ctx context.Context) (uint64, error) {
+	r.mu.Lock()
+	if time.Since(r.headAt) < r.headTTL || (r.refreshing && !r.headAt.IsZero()) {
+		defer r.mu.Unlock()
+		return r.head, r.headErr
+	}
+	r.mu.Unlock()
+	v, _, err := r.flights.do(ctx, "head", r.refreshHead)
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint64), nil
+}
+
+// refreshHead fetches the head without holding r.mu across the call
+func (r *RouterBackend) refreshHead(ctx context.Context) (any, error) {
+	r.mu.Lock()
+	r.refreshing = true
+	r.mu.Unlock()
+	n, err := r.backends[r.def].BlockNumber(ctx)
+	if err == nil && !n.IsUint64() {
+		err = errors.New("router: head out of range")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshing = false
+	if err != nil && ctx.Err() != nil {
+		// Every caller gave up; that says nothing about the backend
+		return nil, err
+	}
+	r.headAt, r.headErr = time.Now(), err
+	if err != nil {
+		return nil, err
+	}
+	r.head = n.Uint64()
+	return r.head, nil
+}
+
+// Basic blockchain info
+func (r *RouterBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return r.route(ctx, "ChainID", nil).ChainID(ctx)
+}
+func (r *RouterBackend) ClientVersion(ctx context.Context) (string, error) {
+	return r.route(ctx, "ClientVersion", nil).ClientVersion(ctx)
+}
+func (r *RouterBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	return r.route(ctx, "BlockNumber", nil).BlockNumber(ctx)
+}
+
+// Block operations
+func (r *RouterBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return r.route(ctx, "BlockByNumber", num).BlockByNumber(ctx, num, fullTx)
+}
+func (r *RouterBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return r.route(ctx, "BlockByHash", nil).BlockByHash(ctx, hash, fullTx)
+}
+func (r *RouterBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return r.route(ctx, "BlockTransactionCountByNumber", blockNum).BlockTransactionCountByNumber(ctx, blockNum)
+}
+func (r *RouterBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return r.route(ctx, "BlockTransactionCountByHash", nil).BlockTransactionCountByHash(ctx, blockHash)
+}
+
+// Account operations
+func (r *RouterBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return r.route(ctx, "Balance", block).Balance(ctx, addr, block)
+}
+func (r *RouterBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return r.route(ctx, "GetCode", block).GetCode(ctx, addr, block)
+}
+func (r *RouterBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return r.route(ctx, "GetStorageAt", block).GetStorageAt(ctx, addr, key, block)
+}
+func (r *RouterBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return r.route(ctx, "GetTransactionCount", block).GetTransactionCount(ctx, addr, block)
+}
+
+// Transaction operations
+func (r *RouterBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return r.route(ctx, "Call", block).Call(ctx, msg, block)
+}
+func (r *RouterBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return r.route(ctx, "EstimateGas", nil).EstimateGas(ctx, msg)
+}
+func (r *RouterBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	return r.route(ctx, "GasPrice", nil).GasPrice(ctx)
+}
+func (r *RouterBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	return r.route(ctx, "SendRawTx", nil).SendRawTx(ctx, rawHex)
+}
+func (r *RouterBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return r.route(ctx, "TxByHash", nil).TxByHash(ctx, hash)
+}
+func (r *RouterBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	return r.route(ctx, "TxByBlockNumberAndIndex", blockNum).TxByBlockNumberAndIndex(ctx, blockNum, index)
+}
+func (r *RouterBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	return r.route(ctx, "TxByBlockHashAndIndex", nil).TxByBlockHashAndIndex(ctx, blockHash, index)
+}
+func (r *RouterBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return r.route(ctx, "ReceiptByHash", nil).ReceiptByHash(ctx, hash)
+}
+
+// Log operations
+func (r *RouterBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	// A range is as deep as its first block
+	return r.route(ctx, "GetLogs", q.FromBlock).GetLogs(ctx, q)
+}
+
+// Network operations
+func (r *RouterBackend) PeerCount(ctx context.Context) (uint64, error) {
+	return r.route(ctx, "PeerCount", nil).PeerCount(ctx)
+}
+func (r *RouterBackend) Listening(ctx context.Context) (bool, error) {
+	return r.route(ctx, "Listening", nil).Listening(ctx)
+}
+func (r *RouterBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return r.route(ctx, "Syncing", nil).Syncing(ctx)
+}
+
+// Mining operations (for PoW chains)
+func (r *RouterBackend) Mining(ctx context.Context) (bool, error) {
+	return asMining(r.route(ctx, "Mining", nil)).Mining(ctx)
+}
+func (r *RouterBackend) Hashrate(ctx context.Context) (uint64, error) {
+	return asMining(r.route(ctx, "Hashrate", nil)).Hashrate(ctx)
+}
+
+// Uncle operations (for PoW chains)
+func (r *RouterBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return asUncles(r.route(ctx, "UncleCountByBlockNumber", blockNum)).UncleCountByBlockNumber(ctx, blockNum)
+}
+func (r *RouterBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return asUncles(r.route(ctx, "UncleCountByBlockHash", nil)).UncleCountByBlockHash(ctx, blockHash)
+}
+func (r *RouterBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return asUncles(r.route(ctx, "UncleByBlockNumberAndIndex", blockNum)).UncleByBlockNumberAndIndex(ctx, blockNum, index)
+}
+func (r *RouterBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return asUncles(r.route(ctx, "UncleByBlockHashAndIndex", nil)).UncleByBlockHashAndIndex(ctx, blockHash, index)
+}
+
+// Streaming (for WS subscriptions)
+func (r *RouterBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	return asSubscriptions(r.route(ctx, "SubscribeNewHeads", nil)).SubscribeNewHeads(ctx)
+}
+func (r *RouterBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	return asSubscriptions(r.route(ctx, "SubscribeLogs", nil)).SubscribeLogs(ctx, q)
+}
+func (r *RouterBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	return asSubscriptions(r.route(ctx, "SubscribePendingTxs", nil)).SubscribePendingTxs(ctx)
+}
+
+// Tracing
+func (r *RouterBackend) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	return asTracing(r.route(ctx, "TraceTransaction", nil)).TraceTransaction(ctx, hash, config)
+}
+func (r *RouterBackend) TraceCall(ctx context.Context, msg Types.CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	return asTracing(r.route(ctx, "TraceCall", block)).TraceCall(ctx, msg, block, config)
+}
+
+// capabilityMethods lists the Backend methods behind each capability
+var capabilityMethods = map[Types.Capability][]string{
+	Types.CapUncles:        {"UncleCountByBlockNumber", "UncleCountByBlockHash", "UncleByBlockNumberAndIndex", "UncleByBlockHashAndIndex"},
+	Types.CapMining:        {"Mining", "Hashrate"},
+	Types.CapSubscriptions: {"SubscribeNewHeads", "SubscribeLogs", "SubscribePendingTxs"},
+	Types.CapTracing:       {"TraceTransaction", "TraceCall"},
+}
+
+// Supports reports a capability when every backend its methods may be routed
+// to serves it.
+func (r *RouterBackend) Supports(c Types.Capability) bool {
+	var targets []Types.Backend
+	for _, method := range capabilityMethods[c] {
+		reachesDefault := true
+		for _, rule := range r.rules {
+			if !matchMethod(rule.Method, method) {
+				continue
+			}
+			targets = append(targets, r.backends[rule.Target])
+			if rule.MinDepth == 0 {
+				reachesDefault = false
+				break
+			}
+		}
+		if reachesDefault {
+			targets = append(targets, r.backends[r.def])
+		}
+	}
+	return supportedByAll(targets, c)
+}
@@ -0,0 +1,134 @@
+package Services_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// gatedHead answers BlockNumber with head once release is closed and counts
+// the calls
+type gatedHead struct {
+	Types.Backend
+	head    int64
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (g *gatedHead) BlockNumber(ctx context.Context) (*big.Int, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.release:
+		return big.NewInt(g.head), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// countingBalance records the calls routed to it
+type countingBalance struct {
+	Types.Backend
+	calls atomic.Int32
+}
+
+func (c *countingBalance) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	c.calls.Add(1)
+	return big.NewInt(0), nil
+}
+
+func TestRouterHeadRefresh(t *testing.T) {
+	mem := Services.NewMemoryBackend(big.NewInt(7))
+	t.Cleanup(mem.(interface{ Close() }).Close)
+	read := &gatedHead{Backend: mem, head: 1000, release: make(chan struct{})}
+	archive := &countingBalance{Backend: mem}
+	r, err := Services.NewRouterBackend(Services.RouterConfig{
+		Backends: map[string]Types.Backend{Services.RouteRead: read, Services.RouteArchive: archive},
+		Rules:    Services.ArchiveRoutes(128),
+		HeadTTL:  time.Hour,
+		Metrics:  Services.NewMetrics(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Callers without a head share one fetch instead of queueing on a lock
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Balance(ctx, make([]byte, 20), big.NewInt(1))
+		}()
+	}
+	for read.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(read.release)
+	wg.Wait()
+	if n := read.calls.Load(); n != 1 {
+		t.Errorf("%d head fetches for concurrent callers, want 1", n)
+	}
+	if n := archive.calls.Load(); n != 8 {
+		t.Errorf("%d of 8 deep calls reached the archive", n)
+	}
+
+	// A caller that gives up while waiting does not poison the cached head
+	stuck := &gatedHead{Backend: mem, head: 1000, release: make(chan struct{})}
+	r, _ = Services.NewRouterBackend(Services.RouterConfig{
+		Backends: map[string]Types.Backend{Services.RouteRead: stuck, Services.RouteArchive: archive},
+		Rules:    Services.ArchiveRoutes(128),
+		HeadTTL:  time.Hour,
+		Metrics:  Services.NewMetrics(),
+	})
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	before := archive.calls.Load()
+	r.Balance(cctx, make([]byte, 20), big.NewInt(1))
+	if archive.calls.Load() != before {
+		t.Error("call routed to the archive without a known head")
+	}
+	close(stuck.release)
+	r.Balance(ctx, make([]byte, 20), big.NewInt(1))
+	if archive.calls.Load() != before+1 {
+		t.Error("head fetch after a cancelled caller was not retried")
+	}
+}
+
+func TestRouterUnwrapsDefault(t *testing.T) {
+	mem := Services.NewMemoryBackendWithConfig(Services.MemoryConfig{})
+	t.Cleanup(mem.(interface{ Close() }).Close)
+	chaos := Services.NewChaosBackend(mem, Services.ChaosConfig{Metrics: Services.NewMetrics()})
+	archive := &countingBalance{Backend: mem}
+	r, err := Services.NewRouterBackend(Services.RouterConfig{
+		Backends: map[string]Types.Backend{Services.RouteRead: chaos, Services.RouteArchive: archive},
+		Rules:    Services.ArchiveRoutes(128),
+		Metrics:  Services.NewMetrics(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Unwrap() != chaos {
+		t.Fatal("Unwrap did not return the default backend")
+	}
+
+	// Chaos and dev controls behind the default backend are served
+	h := Services.NewHandlers(r)
+	for _, req := range []Types.Request{
+		{Method: "chaos_status", Params: []any{}},
+		{Method: "anvil_setBalance", Params: []any{devTarget, "0x64"}},
+	} {
+		req.Jsonrpc, req.ID = "2.0", 1
+		resp, err := h.Handle(context.Background(), req)
+		if err != nil || resp.Error != nil {
+			t.Errorf("%s through the router: %v, %+v", req.Method, err, resp.Error)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// DefaultShadowMethods are the JSON-RPC methods mirrored when
+// ShadowConfig.Methods is empty: reads whose answers should match between
+// backends at the same chain state. Writes, dev controls and answers that
+// drift by themselves (head, gas price, peers, client version) are left out.
+var DefaultShadowMethods = []string{
+	"eth_chainId", "net_version",
+	"eth_getBlockByNumber", "eth_getBlockByHash",
+	"eth_getBlockTransactionCountByNumber", "eth_getBlockTransactionCountByHash",
+	"eth_getBalance", "eth_getCode", "eth_getStorageAt", "eth_getTransactionCount",
+	"eth_call", "eth_estimateGas",
+	"eth_getTransactionByHash", "eth_getTransactionByBlockNumberAndIndex", "eth_getTransactionByBlockHashAndIndex",
+	"eth_getTransactionReceipt", "eth_getLogs",
+	"eth_getUncleCountByBlockNumber", "eth_getUncleCountByBlockHash",
+	"eth_getUncleByBlockNumberAndIndex", "eth_getUncleByBlockHashAndIndex",
+	"debug_traceTransaction", "debug_traceCall",
+}
+
+// maxShadowDiffs bounds the field differences kept per mismatch
+const maxShadowDiffs = 50
+
+// ShadowConfig configures a Shadow.
+type ShadowConfig struct {
+	// Backend is the secondary backend the traffic is mirrored to
+	Backend Types.Backend
+	// SampleRate is the fraction of eligible requests mirrored (default 1)
+	SampleRate float64
+	// Methods lists the JSON-RPC methods to mirror; empty uses DefaultShadowMethods
+	Methods []string
+	// Output receives mismatches as JSON lines; nil logs them instead
+	Output io.Writer
+	// Workers bounds concurrent shadow calls (default 4) and QueueSize the
+	// requests waiting for one (default 1000); requests beyond it are dropped
+	Workers   int
+	QueueSize int
+	// Timeout bounds each shadow call (default 10s)
+	Timeout time.Duration
+	// Metrics receives mirrored, mismatch and drop counters; nil uses DefaultMetrics
+	Metrics *Metrics
+}
+
+// ShadowDiff is one differing field, addressed by a path such as
+// "result.transactions[0].gas". Missing fields are null.
+type ShadowDiff struct {
+	Path    string `json:"path"`
+	Primary any    `json:"primary"`
+	Shadow  any    `json:"shadow"`
+}
+
+// ShadowReport describes a request whose answers differed.
+type ShadowReport struct {
+	Time             time.Time    `json:"time"`
+	Method           string       `json:"method"`
+	Params           []any        `json:"params"`
+	Diffs            []ShadowDiff `json:"diffs"`
+	PrimaryLatencyMs int64        `json:"primaryLatencyMs"`
+	ShadowLatencyMs  int64        `json:"shadowLatencyMs"`
+}
+
+// Shadow mirrors a sample of the requests served by Handlers to a secondary
+// backend in the background and reports where the answers differ, to vet a
+// new backend against live traffic. Clients only ever get the primary's
+// answer and never wait for the shadow. Requests at "latest" are resolved by
+// each backend on its own, so backends at different heads may differ there.
+type Shadow struct {
+	h       *Handlers
+	cfg     ShadowConfig
+	methods map[string]bool
+	metrics *Metrics
+	jobs    chan shadowJob
+	wg      sync.WaitGroup
+	outMu   sync.Mutex
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+type shadowJob struct {
+	req     Types.Request
+	resp    Types.Response
+	primary time.Duration
+}
+
+// NewShadow starts the shadow workers. Call Close to stop them.
+func NewShadow(cfg ShadowConfig) *Shadow {
+	if cfg.SampleRate <= 0 {
+		cfg.SampleRate = 1
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = DefaultShadowMethods
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = DefaultMetrics
+	}
+	// The shadow's answers are compared, not served, so they stay out of
+	// the client traffic log
+	h := NewHandlers(cfg.Backend)
+	h.quiet = true
+	s := &Shadow{
+		h:       h,
+		cfg:     cfg,
+		methods: map[string]bool{},
+		metrics: cfg.Metrics,
+		jobs:    make(chan shadowJob, cfg.QueueSize),
+	}
+	for _, m := range cfg.Methods {
+		s.methods[m] = true
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s
+}
+
+// Close stops accepting requests and waits for the queued ones to finish.
+func (s *Shadow) Close() {
+	s.closeMu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.jobs)
+	}
+	s.closeMu.Unlock()
+	s.wg.Wait()
+}
+
+// Mirror queues a served request for comparison if it is sampled. It never
+// blocks: when the queue is full the request is dropped.
+func (s *Shadow) Mirror(req Types.Request, resp Types.Response, took time.Duration) {
+	if !s.methods[req.Method] || (s.cfg.SampleRate < 1 && rand.Float64() >= s.cfg.SampleRate) {
+		return
+	}
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.jobs <- shadowJob{req: req, resp: resp, primary: took}:
+	default:
+		s.metrics.Inc(metricName("facade_shadow_dropped_total", "method", req.Method))
+	}
+}
+
+func (s *Shadow) worker() {
+	defer s.wg.Done()
+	for job := range s.jobs {
+		s.compare(job)
+	}
+}
+
+func (s *Shadow) compare(job shadowJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	resp, _ := s.h.handle(ctx, job.req)
+	took := time.Since(start)
+	s.metrics.Inc(metricName("facade_shadow_requests_total", "method", job.req.Method))
+
+	var diffs []ShadowDiff
+	diffJSON("", canonicalResponse(job.resp), canonicalResponse(resp), &diffs)
+	if len(diffs) == 0 {
+		return
+	}
+	s.metrics.Inc(metricName("facade_shadow_mismatches_total", "method", job.req.Method))
+	s.report(ShadowReport{
+		Time:             time.Now().UTC(),
+		Method:           job.req.Method,
+		Params:           job.req.Params,
+		Diffs:            diffs,
+		PrimaryLatencyMs: job.primary.Milliseconds(),
+		ShadowLatencyMs:  took.Milliseconds(),
+	})
+}
+
+func (s *Shadow) report(r ShadowReport) {
+	if s.cfg.Output == nil {
+		parts := make([]string, 0, min(len(r.Diffs), 3))
+		for _, d := range r.Diffs[:min(len(r.Diffs), 3)] {
+			parts = append(parts, fmt.Sprintf("%s: %v != %v", d.Path, d.Primary, d.Shadow))
+		}
+		params, _ := json.Marshal(r.Params)
+		log.Printf("🔀 Shadow mismatch on %s %s: %d diffs (%s)", r.Method, params, len(r.Diffs), strings.Join(parts, "; "))
+		return
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("⚠️ shadow: encode report: %v", err)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if _, err := s.cfg.Output.Write(append(line, '\n')); err != nil {
+		log.Printf("⚠️ shadow: write report: %v", err)
+	}
+}
+
+// canonicalResponse renders the result and error of a response as generic
+// JSON values, the form clients see
+func canonicalResponse(resp Types.Response) any {
+	enc, err := json.Marshal(map[string]any{"result": resp.Result, "error": resp.Error})
+	if err != nil {
+		return fmt.Sprintf("%#v", resp)
+	}
+	var out any
+	if err := json.Unmarshal(enc, &out); err != nil {
+		return string(enc)
+	}
+	return out
+}
+
+// diffJSON appends the paths where two decoded JSON values differ
+func diffJSON(path string, a, b any, out *[]ShadowDiff) {
+	if len(*out) >= maxShadowDiffs {
+		return
+	}
+	am, aObj := a.(map[string]any)
+	bm, bObj := b.(map[string]any)
+	if aObj && bObj {
+		keys := make([]string, 0, len(am)+len(bm))
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, ok := am[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffJSON(p, am[k], bm[k], out)
+		}
+		return
+	}
+	as, aArr := a.([]any)
+	bs, bArr := b.([]any)
+	if aArr && bArr {
+		for i := 0; i < max(len(as), len(bs)); i++ {
+			var av, bv any
+			if i < len(as) {
+				av = as[i]
+			}
+			if i < len(bs) {
+				bv = bs[i]
+			}
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), av, bv, out)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, ShadowDiff{Path: path, Primary: a, Shadow: b})
+	}
+}
@@ -0,0 +1,173 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+func TestShadowDiffPaths(t *testing.T) {
+	decode := func(s string) any {
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	for _, c := range []struct {
+		name, a, b string
+		want       []ShadowDiff
+	}{
+		{"equal", `{"result":{"a":[1,2]}}`, `{"result":{"a":[1,2]}}`, nil},
+		{"scalar", `{"result":"0x7"}`, `{"result":"0x8"}`, []ShadowDiff{{"result", "0x7", "0x8"}}},
+		{"nested", `{"result":{"transactions":[{"gas":"0x1"},{"gas":"0x2"}]}}`, `{"result":{"transactions":[{"gas":"0x1"},{"gas":"0x3"}]}}`,
+			[]ShadowDiff{{"result.transactions[1].gas", "0x2", "0x3"}}},
+		{"missing field", `{"result":{"a":1,"b":2}}`, `{"result":{"a":1}}`, []ShadowDiff{{"result.b", 2.0, nil}}},
+		{"extra element", `{"result":[1]}`, `{"result":[1,2]}`, []ShadowDiff{{"result[1]", nil, 2.0}}},
+		{"type change", `{"result":{"a":1},"error":null}`, `{"result":null,"error":{"code":-32000}}`,
+			[]ShadowDiff{{"error", nil, map[string]any{"code": -32000.0}}, {"result", map[string]any{"a": 1.0}, nil}}},
+	} {
+		var got []ShadowDiff
+		diffJSON("", decode(c.a), decode(c.b), &got)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: diffs %+v, want %+v", c.name, got, c.want)
+		}
+	}
+
+	// Long lists are cut at maxShadowDiffs
+	a, b := make([]any, 2*maxShadowDiffs), make([]any, 2*maxShadowDiffs)
+	for i := range a {
+		a[i], b[i] = float64(i), float64(-i-1)
+	}
+	var got []ShadowDiff
+	diffJSON("result", a, b, &got)
+	if len(got) != maxShadowDiffs || got[0].Path != "result[0]" {
+		t.Errorf("%d diffs starting at %q, want %d from result[0]", len(got), got[0].Path, maxShadowDiffs)
+	}
+}
+
+// shadowPair serves chain 7 to clients and shadows it to chain 8
+func shadowPair(t *testing.T, cfg ShadowConfig) (*Handlers, *Shadow) {
+	primary := NewMemoryBackend(big.NewInt(7))
+	t.Cleanup(primary.(interface{ Close() }).Close)
+	if cfg.Backend == nil {
+		secondary := NewMemoryBackend(big.NewInt(8))
+		t.Cleanup(secondary.(interface{ Close() }).Close)
+		cfg.Backend = secondary
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = NewMetrics()
+	}
+	s := NewShadow(cfg)
+	h := NewHandlers(primary)
+	h.SetShadow(s)
+	return h, s
+}
+
+func serve(t *testing.T, h *Handlers, method string, params ...any) {
+	t.Helper()
+	if params == nil {
+		params = []any{}
+	}
+	if _, err := h.Handle(context.Background(), Types.Request{Jsonrpc: "2.0", ID: 1, Method: method, Params: params}); err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+}
+
+func TestShadowOutputLines(t *testing.T) {
+	var out bytes.Buffer
+	h, s := shadowPair(t, ShadowConfig{Output: &out})
+	serve(t, h, "eth_chainId")
+	serve(t, h, "eth_getBalance", "0x00000000000000000000000000000000000000aa", "0x0")
+	serve(t, h, "web3_clientVersion") // not mirrored
+	s.Close()
+
+	// Only the differing chain id is reported, one JSON object per line
+	var reports []ShadowReport
+	lines := bufio.NewScanner(&out)
+	for lines.Scan() {
+		var r ShadowReport
+		if err := json.Unmarshal(lines.Bytes(), &r); err != nil {
+			t.Fatalf("report line %q: %v", lines.Text(), err)
+		}
+		reports = append(reports, r)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("%d reports, want 1:\n%s", len(reports), out.String())
+	}
+	r := reports[0]
+	want := []ShadowDiff{{Path: "result", Primary: "0x7", Shadow: "0x8"}}
+	if r.Method != "eth_chainId" || !reflect.DeepEqual(r.Diffs, want) || r.Time.IsZero() {
+		t.Errorf("report %+v, want eth_chainId with diffs %+v", r, want)
+	}
+	if n := s.metrics.Get(metricName("facade_shadow_requests_total", "method", "eth_getBalance")); n != 1 {
+		t.Errorf("%d balance requests mirrored, want 1", n)
+	}
+	if n := s.metrics.Get(metricName("facade_shadow_requests_total", "method", "web3_clientVersion")); n != 0 {
+		t.Errorf("unlisted method mirrored %d times", n)
+	}
+
+	// The shadow's own calls stay out of the client traffic log
+	if !s.h.quiet || h.quiet {
+		t.Errorf("shadow handlers quiet=%v, client handlers quiet=%v", s.h.quiet, h.quiet)
+	}
+}
+
+func TestShadowSampleRate(t *testing.T) {
+	h, s := shadowPair(t, ShadowConfig{SampleRate: 0.25, Output: &bytes.Buffer{}})
+	for range 400 {
+		serve(t, h, "eth_chainId")
+	}
+	s.Close()
+	if n := s.metrics.Get(metricName("facade_shadow_requests_total", "method", "eth_chainId")); n < 50 || n > 150 {
+		t.Errorf("%d of 400 requests mirrored at a sample rate of 0.25", n)
+	}
+}
+
+// gatedChainID blocks the shadow's chain id calls until release is closed
+type gatedChainID struct {
+	Types.Backend
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (g *gatedChainID) ChainID(ctx context.Context) (*big.Int, error) {
+	g.once.Do(func() { close(g.started) })
+	<-g.release
+	return g.Backend.ChainID(ctx)
+}
+
+func TestShadowDropsWhenQueueFull(t *testing.T) {
+	secondary := NewMemoryBackend(big.NewInt(7))
+	t.Cleanup(secondary.(interface{ Close() }).Close)
+	gated := &gatedChainID{Backend: secondary, started: make(chan struct{}), release: make(chan struct{})}
+	h, s := shadowPair(t, ShadowConfig{Backend: gated, Workers: 1, QueueSize: 2, Output: &bytes.Buffer{}})
+
+	// One request holds the worker, two wait in the queue, the rest are dropped
+	start := time.Now()
+	serve(t, h, "eth_chainId")
+	<-gated.started
+	for range 5 {
+		serve(t, h, "eth_chainId")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("clients waited %s on a stuck shadow", d)
+	}
+	close(gated.release)
+	s.Close()
+	if n := s.metrics.Get(metricName("facade_shadow_dropped_total", "method", "eth_chainId")); n != 3 {
+		t.Errorf("%d requests dropped, want 3", n)
+	}
+	if n := s.metrics.Get(metricName("facade_shadow_requests_total", "method", "eth_chainId")); n != 3 {
+		t.Errorf("%d requests mirrored, want 3", n)
+	}
+}
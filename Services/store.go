@@ -0,0 +1,715 @@
+package Services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/LogIndex"
+	"github.com/jupitermetalabs/geth-facade/Types"
+	bolt "go.etcd.io/bbolt"
+)
+
+// The store keeps a chain in a single bbolt file. Every bucket is keyed so
+// that related entries sort together:
+//
+//	meta      "chainId", "head"            -> big-endian values
+//	blocks    number(8)                    -> block JSON
+//	hashes    block hash                   -> number(8)
+//	txs       tx hash                      -> number(8) index(4)
+//	receipts  tx hash                      -> receipt JSON
+//	logs      number(8) logIndex(8)        -> log JSON
+//	accounts  address number(8)            -> account JSON, from that block on
+//	storage   address slot(32) number(8)   -> value(32), from that block on
+//	changes   number(8) bucket(1) key      -> nil, what a block's state writes touched
+//	blooms    number(8)                    -> logs bloom(256), loaded into the log index on open
+//
+// Account and storage entries are snapshots: a read as of block n seeks the
+// latest entry at or below n.
+var (
+	bucketMeta     = []byte("meta")
+	bucketBlocks   = []byte("blocks")
+	bucketHashes   = []byte("hashes")
+	bucketTxs      = []byte("txs")
+	bucketReceipts = []byte("receipts")
+	bucketLogs     = []byte("logs")
+	bucketAccounts = []byte("accounts")
+	bucketStorage  = []byte("storage")
+	bucketChanges  = []byte("changes")
+	bucketBlooms   = []byte("blooms")
+
+	storeBuckets = [][]byte{bucketMeta, bucketBlocks, bucketHashes, bucketTxs, bucketReceipts, bucketLogs, bucketAccounts, bucketStorage, bucketChanges, bucketBlooms}
+
+	keyChainID = []byte("chainId")
+	keyHead    = []byte("head")
+)
+
+// Change kinds recorded in the changes bucket
+const (
+	changeAccount byte = 'a'
+	changeStorage byte = 's'
+)
+
+// StoreConfig configures a persistent store backend.
+type StoreConfig struct {
+	// Path of the database file, created if missing
+	Path string
+	// ChainID is recorded on first open and checked on later ones; nil uses the recorded one
+	ChainID *big.Int
+}
+
+// StoreAccount is an account's state as of a block.
+type StoreAccount struct {
+	Balance *big.Int `json:"balance"`
+	Nonce   uint64   `json:"nonce"`
+	Code    []byte   `json:"code,omitempty"`
+}
+
+// StoreBackend serves a chain persisted in an embedded bbolt database, so the
+// facade keeps its data across restarts. Blocks, receipts and state are
+// written through InsertBlock, SetAccount and SetStorage, by an indexer or by
+// the private chain itself; Rewind drops blocks for reorgs.
+// //future: Execute calls once state is kept as a trie
+type StoreBackend struct {
+	db      *bolt.DB
+	chainID *big.Int
+	index   *LogIndex.Index
+
+	heads   feed[*Types.Block]
+	logFeed feed[*Types.Log]
+}
+
+// OpenStoreBackend opens or creates the database at cfg.Path.
+func OpenStoreBackend(cfg StoreConfig) (*StoreBackend, error) {
+	db, err := bolt.Open(cfg.Path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open store %s: %w", cfg.Path, err)
+	}
+	s := &StoreBackend{db: db, index: LogIndex.New(0)}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range storeBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(bucketMeta)
+		stored := meta.Get(keyChainID)
+		switch {
+		case stored == nil && cfg.ChainID == nil:
+			return errors.New("store has no chain id and none was configured")
+		case stored == nil:
+			s.chainID = new(big.Int).Set(cfg.ChainID)
+			return meta.Put(keyChainID, cfg.ChainID.Bytes())
+		default:
+			s.chainID = new(big.Int).SetBytes(stored)
+			if cfg.ChainID != nil && cfg.ChainID.Cmp(s.chainID) != 0 {
+				return fmt.Errorf("store holds chain %s, not %s", s.chainID, cfg.ChainID)
+			}
+			return nil
+		}
+	})
+	if err == nil {
+		err = s.loadIndex()
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadIndex builds the log index from the stored blooms, filling in those of
+// blocks written before the store kept them.
+func (s *StoreBackend) loadIndex() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		blooms := tx.Bucket(bucketBlooms)
+		c := tx.Bucket(bucketBlocks).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			bloom := blooms.Get(k)
+			if bloom == nil {
+				var b Types.Block
+				if err := json.Unmarshal(v, &b); err != nil {
+					return err
+				}
+				var err error
+				if bloom, err = storeBloom(tx, &b); err != nil {
+					return err
+				}
+				if err := blooms.Put(k, bloom); err != nil {
+					return err
+				}
+			}
+			if err := s.index.Add(binary.BigEndian.Uint64(k), bloom); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// storeBloom returns a stored block's logs bloom, computed from its logs when
+// the header carries none
+func storeBloom(tx *bolt.Tx, b *Types.Block) ([]byte, error) {
+	if len(b.Header.LogsBloom) == LogIndex.BloomLength {
+		return b.Header.LogsBloom, nil
+	}
+	prefix := u64Key(b.Header.Number)
+	var logs []*Types.Log
+	c := tx.Bucket(bucketLogs).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		l := new(Types.Log)
+		if err := json.Unmarshal(v, l); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return LogIndex.Bloom(logs), nil
+}
+
+// Close closes the database.
+func (s *StoreBackend) Close() error { return s.db.Close() }
+
+func u64Key(n uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, n)
+	return k
+}
+
+func joinKey(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// Head returns the number of the latest stored block, and false if the store is empty.
+func (s *StoreBackend) Head() (uint64, bool) {
+	var head uint64
+	var ok bool
+	s.db.View(func(tx *bolt.Tx) error {
+		head, ok = storeHead(tx)
+		return nil
+	})
+	return head, ok
+}
+
+// Range returns the first and latest stored block numbers, and false if the store is empty.
+func (s *StoreBackend) Range() (first, head uint64, ok bool) {
+	s.db.View(func(tx *bolt.Tx) error {
+		if head, ok = storeHead(tx); ok {
+			k, _ := tx.Bucket(bucketBlocks).Cursor().First()
+			first = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return first, head, ok
+}
+
+// Reset empties the store of blocks and state, keeping its chain id.
+func (s *StoreBackend) Reset() error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range storeBuckets[1:] {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(bucketMeta).Delete(keyHead)
+	})
+	if err == nil {
+		s.index.Reset()
+	}
+	return err
+}
+
+func storeHead(tx *bolt.Tx) (uint64, bool) {
+	v := tx.Bucket(bucketMeta).Get(keyHead)
+	if v == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(v), true
+}
+
+// InsertBlock appends a block on top of the head with its receipts, whose
+// logs are indexed by block. The block must extend the head by number and
+// parent hash; use Rewind first to replace blocks.
+func (s *StoreBackend) InsertBlock(b *Types.Block, receipts []*Types.Receipt) error {
+	if b == nil || b.Header == nil {
+		return errors.New("store: block has no header")
+	}
+	n := b.Header.Number
+	var logs []*Types.Log
+	var bloom []byte
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if head, ok := storeHead(tx); ok {
+			if n != head+1 {
+				return fmt.Errorf("store: block %d does not follow head %d", n, head)
+			}
+			parent, err := storeBlock(tx, head)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(parent.Header.Hash, b.Header.ParentHash) {
+				return fmt.Errorf("store: block %d parent 0x%x is not head 0x%x", n, b.Header.ParentHash, parent.Header.Hash)
+			}
+		}
+		enc, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketBlocks).Put(u64Key(n), enc); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketHashes).Put(b.Header.Hash, u64Key(n)); err != nil {
+			return err
+		}
+		for i, t := range b.Transactions {
+			loc := binary.BigEndian.AppendUint32(u64Key(n), uint32(i))
+			if err := tx.Bucket(bucketTxs).Put(t.Hash, loc); err != nil {
+				return err
+			}
+		}
+		for _, r := range receipts {
+			enc, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketReceipts).Put(r.TxHash, enc); err != nil {
+				return err
+			}
+			for _, l := range r.Logs {
+				enc, err := json.Marshal(l)
+				if err != nil {
+					return err
+				}
+				if err := tx.Bucket(bucketLogs).Put(joinKey(u64Key(n), u64Key(l.LogIndex)), enc); err != nil {
+					return err
+				}
+				logs = append(logs, l)
+			}
+		}
+		bloom = b.Header.LogsBloom
+		if len(bloom) != LogIndex.BloomLength {
+			bloom = LogIndex.Bloom(logs)
+		}
+		if err := tx.Bucket(bucketBlooms).Put(u64Key(n), bloom); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketMeta).Put(keyHead, u64Key(n))
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.index.Add(n, bloom); err != nil {
+		// Queries still find the block, as the index treats it as unindexed
+		log.Printf("⚠️ Store log index: %v", err)
+	}
+	s.heads.send(b)
+	for _, l := range logs {
+		s.logFeed.send(l)
+	}
+	return nil
+}
+
+// Rewind drops every block above head together with its transactions,
+// receipts, logs and state writes. Rewinding below the first stored block
+// empties the chain but keeps the chain id.
+func (s *StoreBackend) Rewind(head uint64) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		current, ok := storeHead(tx)
+		if !ok || head >= current {
+			return nil
+		}
+		blocks := tx.Bucket(bucketBlocks)
+		c := blocks.Cursor()
+		var dropped [][]byte
+		for k, v := c.Seek(u64Key(head + 1)); k != nil; k, v = c.Next() {
+			var b Types.Block
+			if err := json.Unmarshal(v, &b); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketHashes).Delete(b.Header.Hash); err != nil {
+				return err
+			}
+			for _, t := range b.Transactions {
+				if err := tx.Bucket(bucketTxs).Delete(t.Hash); err != nil {
+					return err
+				}
+				if err := tx.Bucket(bucketReceipts).Delete(t.Hash); err != nil {
+					return err
+				}
+			}
+			dropped = append(dropped, append([]byte(nil), k...))
+		}
+		for _, k := range dropped {
+			if err := blocks.Delete(k); err != nil {
+				return err
+			}
+		}
+		if err := deleteFrom(tx.Bucket(bucketLogs), u64Key(head+1)); err != nil {
+			return err
+		}
+		if err := s.dropChanges(tx, head+1); err != nil {
+			return err
+		}
+		if err := deleteFrom(tx.Bucket(bucketBlooms), u64Key(head+1)); err != nil {
+			return err
+		}
+		if first, _ := blocks.Cursor().First(); first == nil {
+			return tx.Bucket(bucketMeta).Delete(keyHead)
+		}
+		return tx.Bucket(bucketMeta).Put(keyHead, u64Key(head))
+	})
+	if err == nil {
+		s.index.Truncate(head)
+	}
+	return err
+}
+
+// deleteFrom deletes every key at or after from
+func deleteFrom(b *bolt.Bucket, from []byte) error {
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := c.Seek(from); k != nil; k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropChanges undoes the state writes recorded for blocks from on
+func (s *StoreBackend) dropChanges(tx *bolt.Tx, from uint64) error {
+	changes := tx.Bucket(bucketChanges)
+	c := changes.Cursor()
+	for k, _ := c.Seek(u64Key(from)); k != nil; k, _ = c.Next() {
+		n, kind, key := k[:8], k[8], k[9:]
+		var err error
+		switch kind {
+		case changeAccount:
+			err = tx.Bucket(bucketAccounts).Delete(joinKey(key, n))
+		case changeStorage:
+			err = tx.Bucket(bucketStorage).Delete(joinKey(key, n))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return deleteFrom(changes, u64Key(from))
+}
+
+// SetAccount records addr's state from block on.
+func (s *StoreBackend) SetAccount(block uint64, addr []byte, acct StoreAccount) error {
+	enc, err := json.Marshal(acct)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		n := u64Key(block)
+		if err := tx.Bucket(bucketAccounts).Put(joinKey(addr, n), enc); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketChanges).Put(joinKey(n, []byte{changeAccount}, addr), nil)
+	})
+}
+
+// SetStorage records a storage slot of addr from block on.
+func (s *StoreBackend) SetStorage(block uint64, addr, slot, value []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		n := u64Key(block)
+		key := joinKey(addr, leftPad32(slot))
+		if err := tx.Bucket(bucketStorage).Put(joinKey(key, n), leftPad32(value)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketChanges).Put(joinKey(n, []byte{changeStorage}, key), nil)
+	})
+}
+
+// snapshotAt finds the latest entry under prefix written at or below block
+func snapshotAt(b *bolt.Bucket, prefix []byte, block uint64) []byte {
+	c := b.Cursor()
+	k, v := c.Seek(joinKey(prefix, u64Key(block+1)))
+	if k == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Prev()
+	}
+	if k == nil || len(k) != len(prefix)+8 || !bytes.HasPrefix(k, prefix) {
+		return nil
+	}
+	return v
+}
+
+func storeBlock(tx *bolt.Tx, n uint64) (*Types.Block, error) {
+	v := tx.Bucket(bucketBlocks).Get(u64Key(n))
+	if v == nil {
+		return nil, nil
+	}
+	var b Types.Block
+	if err := json.Unmarshal(v, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// resolve maps a requested block number to a stored one, nil meaning the head
+func (s *StoreBackend) resolve(tx *bolt.Tx, num *big.Int) uint64 {
+	if num == nil || num.Sign() < 0 || !num.IsUint64() {
+		head, _ := storeHead(tx)
+		return head
+	}
+	return num.Uint64()
+}
+
+func (s *StoreBackend) account(addr []byte, block *big.Int) (*StoreAccount, error) {
+	var acct *StoreAccount
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := snapshotAt(tx.Bucket(bucketAccounts), addr, s.resolve(tx, block))
+		if v == nil {
+			return nil
+		}
+		acct = new(StoreAccount)
+		return json.Unmarshal(v, acct)
+	})
+	return acct, err
+}
+
+func (s *StoreBackend) blockBy(num *big.Int, hash []byte) (*Types.Block, error) {
+	var b *Types.Block
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		if hash != nil {
+			v := tx.Bucket(bucketHashes).Get(hash)
+			if v == nil {
+				return nil
+			}
+			b, err = storeBlock(tx, binary.BigEndian.Uint64(v))
+			return err
+		}
+		if _, ok := storeHead(tx); !ok {
+			return nil
+		}
+		b, err = storeBlock(tx, s.resolve(tx, num))
+		return err
+	})
+	return b, err
+}
+
+// Basic blockchain info
+func (s *StoreBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(s.chainID), nil
+}
+func (s *StoreBackend) ClientVersion(ctx context.Context) (string, error) {
+	return "store-backend/0.1.0", nil
+}
+func (s *StoreBackend) BlockNumber(ctx context.Context) (*big.Int, error) {
+	head, _ := s.Head()
+	return new(big.Int).SetUint64(head), nil
+}
+
+// Block operations
+func (s *StoreBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return s.blockBy(num, nil)
+}
+func (s *StoreBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Types.Block, error) {
+	return s.blockBy(nil, hash)
+}
+func (s *StoreBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	b, err := s.blockBy(blockNum, nil)
+	if b == nil {
+		return 0, err
+	}
+	return uint64(len(b.Transactions)), nil
+}
+func (s *StoreBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	b, err := s.blockBy(nil, blockHash)
+	if b == nil {
+		return 0, err
+	}
+	return uint64(len(b.Transactions)), nil
+}
+
+// Account operations
+func (s *StoreBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	acct, err := s.account(addr, block)
+	if acct == nil || acct.Balance == nil {
+		return big.NewInt(0), err
+	}
+	return acct.Balance, nil
+}
+func (s *StoreBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	acct, err := s.account(addr, block)
+	if acct == nil || acct.Code == nil {
+		return []byte{}, err
+	}
+	return acct.Code, nil
+}
+func (s *StoreBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	out := make([]byte, 32)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := snapshotAt(tx.Bucket(bucketStorage), joinKey(addr, leftPad32(key)), s.resolve(tx, block)); v != nil {
+			copy(out, v)
+		}
+		return nil
+	})
+	return out, err
+}
+func (s *StoreBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	acct, err := s.account(addr, block)
+	if acct == nil {
+		return 0, err
+	}
+	return acct.Nonce, nil
+}
+
+// Transaction operations
+func (s *StoreBackend) Call(ctx context.Context, msg Types.CallMsg, block *big.Int) ([]byte, error) {
+	return nil, errors.New("store backend does not execute calls")
+}
+func (s *StoreBackend) EstimateGas(ctx context.Context, msg Types.CallMsg) (uint64, error) {
+	return 0, errors.New("store backend does not execute calls")
+}
+func (s *StoreBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	// The head's base fee is the best price the store knows of
+	b, err := s.blockBy(nil, nil)
+	if b == nil || len(b.Header.BaseFee) == 0 {
+		return big.NewInt(0), err
+	}
+	return new(big.Int).SetBytes(b.Header.BaseFee), nil
+}
+func (s *StoreBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	return nil, errors.New("store backend is read-only")
+}
+func (s *StoreBackend) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	var out *Types.Transaction
+	err := s.db.View(func(tx *bolt.Tx) error {
+		loc := tx.Bucket(bucketTxs).Get(hash)
+		if loc == nil {
+			return nil
+		}
+		b, err := storeBlock(tx, binary.BigEndian.Uint64(loc[:8]))
+		if err != nil {
+			return err
+		}
+		out = txAt(b, uint64(binary.BigEndian.Uint32(loc[8:])))
+		return nil
+	})
+	return out, err
+}
+func (s *StoreBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Transaction, error) {
+	b, err := s.blockBy(blockNum, nil)
+	return txAt(b, index), err
+}
+func (s *StoreBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Transaction, error) {
+	b, err := s.blockBy(nil, blockHash)
+	return txAt(b, index), err
+}
+func (s *StoreBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	var out *Types.Receipt
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketReceipts).Get(hash)
+		if v == nil {
+			return nil
+		}
+		out = new(Types.Receipt)
+		return json.Unmarshal(v, out)
+	})
+	return out, err
+}
+
+// Log operations
+func (s *StoreBackend) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	out := []*Types.Log{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		head, ok := storeHead(tx)
+		if !ok {
+			return nil
+		}
+		from, to := filterRange(&q, head)
+		if len(q.BlockHash) > 0 {
+			v := tx.Bucket(bucketHashes).Get(q.BlockHash)
+			if v == nil {
+				return fmt.Errorf("unknown block 0x%x", q.BlockHash)
+			}
+			from = binary.BigEndian.Uint64(v)
+			to = from
+		}
+		c := tx.Bucket(bucketLogs).Cursor()
+		for _, n := range s.index.Match(&q, from, min(to, head)) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			prefix := u64Key(n)
+			for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				l := new(Types.Log)
+				if err := json.Unmarshal(v, l); err != nil {
+					return err
+				}
+				if logMatches(&q, l) {
+					out = append(out, l)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Network operations
+func (s *StoreBackend) PeerCount(ctx context.Context) (uint64, error) { return 0, nil }
+func (s *StoreBackend) Listening(ctx context.Context) (bool, error)   { return true, nil }
+func (s *StoreBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return map[string]any{"syncing": false}, nil
+}
+
+// Mining operations (for PoW chains)
+func (s *StoreBackend) Mining(ctx context.Context) (bool, error)     { return false, nil }
+func (s *StoreBackend) Hashrate(ctx context.Context) (uint64, error) { return 0, nil }
+
+// Uncle operations (for PoW chains)
+// //future: Only uncle hashes are stored, so uncle bodies are not served
+func (s *StoreBackend) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	b, err := s.blockBy(blockNum, nil)
+	if b == nil {
+		return 0, err
+	}
+	return uint64(len(b.Ommers)), nil
+}
+func (s *StoreBackend) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	b, err := s.blockBy(nil, blockHash)
+	if b == nil {
+		return 0, err
+	}
+	return uint64(len(b.Ommers)), nil
+}
+func (s *StoreBackend) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Types.Block, error) {
+	return nil, nil
+}
+func (s *StoreBackend) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Types.Block, error) {
+	return nil, nil
+}
+
+// Subscriptions deliver blocks and logs as they are inserted
+func (s *StoreBackend) SubscribeNewHeads(ctx context.Context) (<-chan *Types.Block, func(), error) {
+	ch, stop := s.heads.subscribe(ctx, nil)
+	return ch, stop, nil
+}
+func (s *StoreBackend) SubscribeLogs(ctx context.Context, q *Types.FilterQuery) (<-chan *Types.Log, func(), error) {
+	ch, stop := s.logFeed.subscribe(ctx, func(l *Types.Log) bool { return logMatches(q, l) })
+	return ch, stop, nil
+}
+func (s *StoreBackend) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	ch, stop := idleSubscription[[]byte](ctx)
+	return ch, stop, nil
+}
@@ -0,0 +1,250 @@
+package Services_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+func openStore(t *testing.T, path string, chainID *big.Int) *Services.StoreBackend {
+	t.Helper()
+	s, err := Services.OpenStoreBackend(Services.StoreConfig{Path: path, ChainID: chainID})
+	if err != nil {
+		t.Fatalf("OpenStoreBackend: %v", err)
+	}
+	return s
+}
+
+// copyBlocks inserts blocks from..to of src, with their receipts, into s
+func copyBlocks(t *testing.T, src Types.Backend, s *Services.StoreBackend, from, to uint64) {
+	t.Helper()
+	ctx := context.Background()
+	for n := from; n <= to; n++ {
+		b, err := src.BlockByNumber(ctx, new(big.Int).SetUint64(n), true)
+		if err != nil || b == nil {
+			t.Fatalf("block %d: %v", n, err)
+		}
+		var receipts []*Types.Receipt
+		for _, tx := range b.Transactions {
+			r, err := src.ReceiptByHash(ctx, tx.Hash)
+			if err != nil || r == nil {
+				t.Fatalf("receipt 0x%x: %v", tx.Hash, err)
+			}
+			receipts = append(receipts, r)
+		}
+		if err := s.InsertBlock(b, receipts); err != nil {
+			t.Fatalf("InsertBlock(%d): %v", n, err)
+		}
+	}
+}
+
+func head(t *testing.T, be Types.Backend) uint64 {
+	t.Helper()
+	n, err := be.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n.Uint64()
+}
+
+// logKeys names logs by block and index
+func logKeys(t *testing.T, be Types.Backend, q Types.FilterQuery) [][2]uint64 {
+	t.Helper()
+	logs, err := be.GetLogs(context.Background(), q)
+	if err != nil {
+		t.Fatalf("GetLogs: %v", err)
+	}
+	out := [][2]uint64{}
+	for _, l := range logs {
+		out = append(out, [2]uint64{l.BlockNumber, l.LogIndex})
+	}
+	return out
+}
+
+func sameKeys(a, b [][2]uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	storeAddr = bytes.Repeat([]byte{0xaa}, 20)
+	storeSlot = []byte{1}
+)
+
+func TestStoreSurvivesReopen(t *testing.T) {
+	src := seededMemory(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "chain.db")
+	s := openStore(t, path, big.NewInt(7))
+	top := head(t, src)
+	copyBlocks(t, src, s, 0, top)
+	for _, w := range []struct {
+		block   uint64
+		balance int64
+	}{{1, 100}, {3, 50}} {
+		if err := s.SetAccount(w.block, storeAddr, Services.StoreAccount{Balance: big.NewInt(w.balance), Nonce: w.block}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetStorage(2, storeAddr, storeSlot, []byte{0x2a}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A different chain id is refused; none takes the recorded one
+	if _, err := Services.OpenStoreBackend(Services.StoreConfig{Path: path, ChainID: big.NewInt(8)}); err == nil {
+		t.Fatal("store reopened for another chain")
+	}
+	s = openStore(t, path, nil)
+	t.Cleanup(func() { s.Close() })
+	if id, err := s.ChainID(ctx); err != nil || id.Int64() != 7 {
+		t.Errorf("ChainID after reopen = %v, %v", id, err)
+	}
+	if got := head(t, s); got != top {
+		t.Fatalf("head after reopen = %d, want %d", got, top)
+	}
+
+	for n := uint64(0); n <= top; n++ {
+		want, _ := src.BlockByNumber(ctx, new(big.Int).SetUint64(n), true)
+		got, err := s.BlockByHash(ctx, want.Header.Hash, true)
+		if err != nil || got == nil || got.Header.Number != n || len(got.Transactions) != len(want.Transactions) {
+			t.Fatalf("block %d by hash after reopen: %v, %v", n, got, err)
+		}
+		for _, tx := range want.Transactions {
+			r, err := s.ReceiptByHash(ctx, tx.Hash)
+			if err != nil || r == nil || r.BlockNumber != n || !bytes.Equal(r.TxHash, tx.Hash) {
+				t.Errorf("receipt 0x%x after reopen: %+v, %v", tx.Hash, r, err)
+			}
+		}
+	}
+
+	// Logs are found through the log index rebuilt from the stored blooms
+	all := Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: new(big.Int).SetUint64(top)}
+	want := logKeys(t, src, all)
+	if len(want) == 0 {
+		t.Fatal("seeded chain has no logs")
+	}
+	if got := logKeys(t, s, all); !sameKeys(got, want) {
+		t.Errorf("GetLogs after reopen = %v, want %v", got, want)
+	}
+	logs, _ := src.GetLogs(ctx, all)
+	byTopic := Types.FilterQuery{FromBlock: all.FromBlock, ToBlock: all.ToBlock, Topics: [][]byte{nil, logs[len(logs)-1].Topics[1]}}
+	if got, want := logKeys(t, s, byTopic), logKeys(t, src, byTopic); len(want) != 1 || !sameKeys(got, want) {
+		t.Errorf("GetLogs by topic after reopen = %v, want %v", got, want)
+	}
+
+	// State reads find the latest snapshot at or below the block
+	for _, c := range []struct {
+		block   int64
+		balance int64
+		slot    byte
+	}{{0, 0, 0}, {1, 100, 0}, {2, 100, 0x2a}, {3, 50, 0x2a}, {int64(top), 50, 0x2a}} {
+		bal, err := s.Balance(ctx, storeAddr, big.NewInt(c.block))
+		if err != nil || bal.Int64() != c.balance {
+			t.Errorf("balance at %d = %v, %v, want %d", c.block, bal, err, c.balance)
+		}
+		v, err := s.GetStorageAt(ctx, storeAddr, storeSlot, big.NewInt(c.block))
+		if err != nil || new(big.Int).SetBytes(v).Int64() != int64(c.slot) {
+			t.Errorf("slot at %d = 0x%x, %v, want 0x%x", c.block, v, err, c.slot)
+		}
+	}
+}
+
+func TestStoreRewind(t *testing.T) {
+	src := seededMemory(t)
+	ctx := context.Background()
+	s := openStore(t, filepath.Join(t.TempDir(), "chain.db"), big.NewInt(7))
+	t.Cleanup(func() { s.Close() })
+	top := head(t, src)
+	copyBlocks(t, src, s, 0, top)
+	keep := top - 2
+	if err := s.SetAccount(keep, storeAddr, Services.StoreAccount{Balance: big.NewInt(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAccount(keep+1, storeAddr, Services.StoreAccount{Balance: big.NewInt(2)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetStorage(keep+1, storeAddr, storeSlot, []byte{0x2a}); err != nil {
+		t.Fatal(err)
+	}
+	dropped, _ := src.BlockByNumber(ctx, new(big.Int).SetUint64(keep+1), true)
+	if len(dropped.Transactions) == 0 {
+		t.Fatal("seeded chain has no transaction above the rewind point")
+	}
+
+	if err := s.Rewind(keep); err != nil {
+		t.Fatal(err)
+	}
+	if got := head(t, s); got != keep {
+		t.Fatalf("head after rewind = %d, want %d", got, keep)
+	}
+	if b, _ := s.BlockByNumber(ctx, new(big.Int).SetUint64(keep+1), false); b != nil {
+		t.Error("block above the rewind point still served by number")
+	}
+	if b, _ := s.BlockByHash(ctx, dropped.Header.Hash, false); b != nil {
+		t.Error("block above the rewind point still served by hash")
+	}
+	tx := dropped.Transactions[0].Hash
+	if got, _ := s.TxByHash(ctx, tx); got != nil {
+		t.Error("dropped transaction still served")
+	}
+	if got, _ := s.ReceiptByHash(ctx, tx); got != nil {
+		t.Error("dropped receipt still served")
+	}
+
+	// Logs and state writes above the rewind point go with their blocks
+	all := Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: new(big.Int).SetUint64(top)}
+	kept := Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: new(big.Int).SetUint64(keep)}
+	if got, want := logKeys(t, s, all), logKeys(t, src, kept); !sameKeys(got, want) {
+		t.Errorf("GetLogs after rewind = %v, want %v", got, want)
+	}
+	if bal, err := s.Balance(ctx, storeAddr, nil); err != nil || bal.Int64() != 1 {
+		t.Errorf("balance after rewind = %v, %v, want the snapshot at %d", bal, err, keep)
+	}
+	if v, err := s.GetStorageAt(ctx, storeAddr, storeSlot, nil); err != nil || new(big.Int).SetBytes(v).Sign() != 0 {
+		t.Errorf("slot after rewind = 0x%x, %v, want zero", v, err)
+	}
+
+	// The chain grows again from the rewind point, its logs indexed afresh
+	copyBlocks(t, src, s, keep+1, top)
+	if got, want := logKeys(t, s, all), logKeys(t, src, all); !sameKeys(got, want) {
+		t.Errorf("GetLogs after reinserting = %v, want %v", got, want)
+	}
+
+	// Rewinding below the first stored block empties the chain but keeps
+	// its id, so mirroring can start over anywhere
+	if err := s.Rewind(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	copyBlocks(t, src, s, 2, top)
+	if err := s.Rewind(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Head(); ok {
+		t.Error("store still has a head after rewinding below its first block")
+	}
+	if id, err := s.ChainID(ctx); err != nil || id.Int64() != 7 {
+		t.Errorf("ChainID after emptying = %v, %v", id, err)
+	}
+	copyBlocks(t, src, s, keep, top)
+	if first, last, ok := s.Range(); !ok || first != keep || last != top {
+		t.Errorf("Range after refilling = %d, %d, %v, want %d, %d", first, last, ok, keep, top)
+	}
+}
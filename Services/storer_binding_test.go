@@ -0,0 +1,150 @@
+// Code generated via abigen V2 - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package Services_test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = bytes.Equal
+	_ = errors.New
+	_ = big.NewInt
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = abi.ConvertType
+)
+
+// StorerMetaData contains all meta data concerning the Storer contract.
+var StorerMetaData = bind.MetaData{
+	ABI: "[{\"type\":\"function\",\"name\":\"get\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"store\",\"inputs\":[{\"name\":\"x\",\"type\":\"uint256\"}],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"event\",\"name\":\"Stored\",\"inputs\":[{\"name\":\"x\",\"type\":\"uint256\",\"indexed\":true}],\"anonymous\":false}]",
+	ID:  "Storer",
+	Bin: "0x6053600c60003960536000f360003560e01c80636d4ce63c14601d57636057361d14602857600080fd5b602a60005260206000f35b6004357fc6d8c0af6d21f291e7c359603aa97e0ed500f04db6e983b9fce75a91c6b8da6b60006000a200",
+}
+
+// Storer is an auto generated Go binding around an Ethereum contract.
+type Storer struct {
+	abi abi.ABI
+}
+
+// NewStorer creates a new instance of Storer.
+func NewStorer() *Storer {
+	parsed, err := StorerMetaData.ParseABI()
+	if err != nil {
+		panic(errors.New("invalid ABI: " + err.Error()))
+	}
+	return &Storer{abi: *parsed}
+}
+
+// Instance creates a wrapper for a deployed contract instance at the given address.
+// Use this to create the instance object passed to abigen v2 library functions Call, Transact, etc.
+func (c *Storer) Instance(backend bind.ContractBackend, addr common.Address) *bind.BoundContract {
+	return bind.NewBoundContract(addr, c.abi, backend, backend, backend)
+}
+
+// PackGet is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x6d4ce63c.  This method will panic if any
+// invalid/nil inputs are passed.
+//
+// Solidity: function get() view returns(uint256)
+func (storer *Storer) PackGet() []byte {
+	enc, err := storer.abi.Pack("get")
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// TryPackGet is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x6d4ce63c.  This method will return an error
+// if any inputs are invalid/nil.
+//
+// Solidity: function get() view returns(uint256)
+func (storer *Storer) TryPackGet() ([]byte, error) {
+	return storer.abi.Pack("get")
+}
+
+// UnpackGet is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0x6d4ce63c.
+//
+// Solidity: function get() view returns(uint256)
+func (storer *Storer) UnpackGet(data []byte) (*big.Int, error) {
+	out, err := storer.abi.Unpack("get", data)
+	if err != nil {
+		return new(big.Int), err
+	}
+	out0 := abi.ConvertType(out[0], new(big.Int)).(*big.Int)
+	return out0, nil
+}
+
+// PackStore is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x6057361d.  This method will panic if any
+// invalid/nil inputs are passed.
+//
+// Solidity: function store(uint256 x) returns()
+func (storer *Storer) PackStore(x *big.Int) []byte {
+	enc, err := storer.abi.Pack("store", x)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// TryPackStore is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x6057361d.  This method will return an error
+// if any inputs are invalid/nil.
+//
+// Solidity: function store(uint256 x) returns()
+func (storer *Storer) TryPackStore(x *big.Int) ([]byte, error) {
+	return storer.abi.Pack("store", x)
+}
+
+// StorerStored represents a Stored event raised by the Storer contract.
+type StorerStored struct {
+	X   *big.Int
+	Raw *types.Log // Blockchain specific contextual infos
+}
+
+const StorerStoredEventName = "Stored"
+
+// ContractEventName returns the user-defined event name.
+func (StorerStored) ContractEventName() string {
+	return StorerStoredEventName
+}
+
+// UnpackStoredEvent is the Go binding that unpacks the event data emitted
+// by contract.
+//
+// Solidity: event Stored(uint256 indexed x)
+func (storer *Storer) UnpackStoredEvent(log *types.Log) (*StorerStored, error) {
+	event := "Stored"
+	if len(log.Topics) == 0 || log.Topics[0] != storer.abi.Events[event].ID {
+		return nil, errors.New("event signature mismatch")
+	}
+	out := new(StorerStored)
+	if len(log.Data) > 0 {
+		if err := storer.abi.UnpackIntoInterface(out, event, log.Data); err != nil {
+			return nil, err
+		}
+	}
+	var indexed abi.Arguments
+	for _, arg := range storer.abi.Events[event].Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if err := abi.ParseTopics(out, indexed, log.Topics[1:]); err != nil {
+		return nil, err
+	}
+	out.Raw = log
+	return out, nil
+}
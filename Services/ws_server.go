@@ -72,6 +72,10 @@ func (s *WSServer) handleWS(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if req.Method == "eth_subscribe" {
+			if resp, ok := s.h.admit(req); !ok {
+				_ = conn.WriteJSON(resp)
+				continue
+			}
 			// params: [subscriptionType, (optional) filter]
 			if len(req.Params) < 1 {
 				_ = conn.WriteJSON(Types.RespErr(req.ID, -32602, "missing subscription type"))
@@ -82,38 +86,45 @@ func (s *WSServer) handleWS(w http.ResponseWriter, r *http.Request) {
 
 			switch typ {
 			case "newHeads":
-				ch, stop, err := s.be.SubscribeNewHeads(ctx)
+				ch, stop, err := asSubscriptions(s.be).SubscribeNewHeads(ctx)
 				if err != nil {
-					_ = conn.WriteJSON(Types.RespErr(req.ID, -32000, err.Error()))
+					resp, _ := finish(req, nil, err)
+					_ = conn.WriteJSON(resp)
 					continue
 				}
-				storeSub(subs, mu, sid, stop)
+				storeSub(subs, &mu, sid, stop)
 				_ = conn.WriteJSON(Types.RespOK(req.ID, sid))
 				go forwardBlocks(conn, sid, ch)
 
 			case "logs":
 				var q Types.FilterQuery
-				if len(req.Params) > 1 {
-					if qq, err := toFilterQuery(req.Params[1]); err == nil {
-						q = *qq
+				if len(req.Params) > 1 && req.Params[1] != nil {
+					qq, err := toFilterQuery(req.Params[1])
+					if err != nil {
+						resp, _ := invalidParams(req, err.Error())
+						_ = conn.WriteJSON(resp)
+						continue
 					}
+					q = *qq
 				}
-				ch, stop, err := s.be.SubscribeLogs(ctx, &q)
+				ch, stop, err := asSubscriptions(s.be).SubscribeLogs(ctx, &q)
 				if err != nil {
-					_ = conn.WriteJSON(Types.RespErr(req.ID, -32000, err.Error()))
+					resp, _ := finish(req, nil, err)
+					_ = conn.WriteJSON(resp)
 					continue
 				}
-				storeSub(subs, mu, sid, stop)
+				storeSub(subs, &mu, sid, stop)
 				_ = conn.WriteJSON(Types.RespOK(req.ID, sid))
 				go forwardLogs(conn, sid, ch)
 
 			case "newPendingTransactions":
-				ch, stop, err := s.be.SubscribePendingTxs(ctx)
+				ch, stop, err := asSubscriptions(s.be).SubscribePendingTxs(ctx)
 				if err != nil {
-					_ = conn.WriteJSON(Types.RespErr(req.ID, -32000, err.Error()))
+					resp, _ := finish(req, nil, err)
+					_ = conn.WriteJSON(resp)
 					continue
 				}
-				storeSub(subs, mu, sid, stop)
+				storeSub(subs, &mu, sid, stop)
 				_ = conn.WriteJSON(Types.RespOK(req.ID, sid))
 				go forwardPending(conn, sid, ch)
 
@@ -146,7 +157,7 @@ func (s *WSServer) handleWS(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func storeSub(m map[string]*sub, mu sync.Mutex, id string, stop func()) {
+func storeSub(m map[string]*sub, mu *sync.Mutex, id string, stop func()) {
 	mu.Lock()
 	defer mu.Unlock()
 	m[id] = &sub{id: id, stop: stop}
@@ -0,0 +1,164 @@
+package Types
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// AddressLength is the size of an account address in bytes
+	AddressLength = 20
+	// HashLength is the size of a keccak256 hash in bytes
+	HashLength = 32
+)
+
+// ErrBadChecksum is returned for mixed-case addresses that fail the EIP-55
+// checksum
+var ErrBadChecksum = errors.New("address checksum mismatch")
+
+// Address is a 20-byte account address. It encodes to JSON as a lowercase
+// 0x-prefixed hex string, as geth does; Hex gives the EIP-55 checksummed form.
+// //conversions: Backend keeps []byte addresses; use Bytes and AddressFromBytes at the boundary
+type Address [AddressLength]byte
+
+// Hash is a 32-byte keccak256 hash, encoded as 0x-prefixed hex
+type Hash [HashLength]byte
+
+// BytesToAddress returns the last 20 bytes of b, left-padding shorter input,
+// like geth's common.BytesToAddress.
+func BytesToAddress(b []byte) Address {
+	var a Address
+	if len(b) > AddressLength {
+		b = b[len(b)-AddressLength:]
+	}
+	copy(a[AddressLength-len(b):], b)
+	return a
+}
+
+// BytesToHash returns the last 32 bytes of b, left-padding shorter input.
+func BytesToHash(b []byte) Hash {
+	var h Hash
+	if len(b) > HashLength {
+		b = b[len(b)-HashLength:]
+	}
+	copy(h[HashLength-len(b):], b)
+	return h
+}
+
+// AddressFromBytes converts b, which must be exactly 20 bytes.
+func AddressFromBytes(b []byte) (Address, error) {
+	if len(b) != AddressLength {
+		return Address{}, fmt.Errorf("address has length %d, want %d", len(b), AddressLength)
+	}
+	return Address(b), nil
+}
+
+// HashFromBytes converts b, which must be exactly 32 bytes.
+func HashFromBytes(b []byte) (Hash, error) {
+	if len(b) != HashLength {
+		return Hash{}, fmt.Errorf("hash has length %d, want %d", len(b), HashLength)
+	}
+	return Hash(b), nil
+}
+
+// ParseAddress decodes a 0x-prefixed hex address. All-lowercase and
+// all-uppercase input is accepted as is; mixed case must carry a valid EIP-55
+// checksum.
+func ParseAddress(s string) (Address, error) {
+	var a Address
+	if err := decodeFixed(a[:], s, "address"); err != nil {
+		return Address{}, err
+	}
+	digits := s[2:]
+	if strings.ToLower(digits) != digits && strings.ToUpper(digits) != digits && a.Hex()[2:] != digits {
+		return Address{}, ErrBadChecksum
+	}
+	return a, nil
+}
+
+// ParseHash decodes a 0x-prefixed hex hash.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if err := decodeFixed(h[:], s, "hash"); err != nil {
+		return Hash{}, err
+	}
+	return h, nil
+}
+
+// decodeFixed decodes 0x-prefixed hex of exactly len(dst) bytes into dst
+func decodeFixed(dst []byte, s, what string) error {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return fmt.Errorf("%s %q lacks 0x prefix", what, s)
+	}
+	if len(s)-2 != 2*len(dst) {
+		return fmt.Errorf("%s has %d hex digits, want %d", what, len(s)-2, 2*len(dst))
+	}
+	if _, err := hex.Decode(dst, []byte(s[2:])); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", what, s, err)
+	}
+	return nil
+}
+
+// Bytes returns the address as a byte slice
+func (a Address) Bytes() []byte { return a[:] }
+
+// IsZero reports whether a is the zero address
+func (a Address) IsZero() bool { return a == Address{} }
+
+// Hex returns the EIP-55 checksummed form of a
+func (a Address) Hex() string {
+	buf := []byte(hex.EncodeToString(a[:]))
+	sum := crypto.Keccak256(buf)
+	for i, c := range buf {
+		// Letters whose hash nibble is 8 or more are uppercased
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0xf
+		}
+		if c >= 'a' && nibble >= 8 {
+			buf[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(buf)
+}
+
+func (a Address) String() string { return a.Hex() }
+
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte("0x" + hex.EncodeToString(a[:])), nil
+}
+
+func (a *Address) UnmarshalText(text []byte) error {
+	parsed, err := ParseAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Bytes returns the hash as a byte slice
+func (h Hash) Bytes() []byte { return h[:] }
+
+// IsZero reports whether h is the zero hash
+func (h Hash) IsZero() bool { return h == Hash{} }
+
+// Hex returns h as 0x-prefixed hex
+func (h Hash) Hex() string { return "0x" + hex.EncodeToString(h[:]) }
+
+func (h Hash) String() string { return h.Hex() }
+
+func (h Hash) MarshalText() ([]byte, error) { return []byte(h.Hex()), nil }
+
+func (h *Hash) UnmarshalText(text []byte) error {
+	parsed, err := ParseHash(string(text))
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
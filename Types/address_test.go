@@ -0,0 +1,96 @@
+package Types_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// checksummed is the first EIP-55 test vector
+const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+func TestParseAddress(t *testing.T) {
+	for _, s := range []string{checksummed, strings.ToLower(checksummed), "0x" + strings.ToUpper(checksummed[2:])} {
+		a, err := Types.ParseAddress(s)
+		if err != nil {
+			t.Errorf("ParseAddress(%s): %v", s, err)
+			continue
+		}
+		if a.Hex() != checksummed {
+			t.Errorf("ParseAddress(%s).Hex() = %s, want %s", s, a.Hex(), checksummed)
+		}
+	}
+
+	// One letter of the checksummed form in the wrong case
+	bad := strings.Replace(checksummed, "aA", "aa", 1)
+	if _, err := Types.ParseAddress(bad); !errors.Is(err, Types.ErrBadChecksum) {
+		t.Errorf("ParseAddress(%s) = %v, want ErrBadChecksum", bad, err)
+	}
+
+	for _, s := range []string{
+		checksummed[:len(checksummed)-2],
+		checksummed + "00",
+		"0x",
+		checksummed[2:],
+		"0x" + strings.Repeat("zz", 20),
+	} {
+		if _, err := Types.ParseAddress(s); err == nil {
+			t.Errorf("ParseAddress(%s) accepted", s)
+		}
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	s := "0x" + strings.Repeat("ab", 32)
+	h, err := Types.ParseHash(s)
+	if err != nil || h.Hex() != s {
+		t.Errorf("ParseHash(%s) = %s, %v", s, h, err)
+	}
+	for _, s := range []string{"0x" + strings.Repeat("ab", 31), "0x" + strings.Repeat("ab", 33), strings.Repeat("ab", 32)} {
+		if _, err := Types.ParseHash(s); err == nil {
+			t.Errorf("ParseHash(%s) accepted", s)
+		}
+	}
+}
+
+func TestAddressHashJSON(t *testing.T) {
+	a, _ := Types.ParseAddress(checksummed)
+	h := Types.BytesToHash([]byte{1, 2, 3})
+	in := struct {
+		Addr  Types.Address  `json:"addr"`
+		Hash  Types.Hash     `json:"hash"`
+		Maybe *Types.Address `json:"maybe"`
+	}{Addr: a, Hash: h, Maybe: &a}
+
+	enc, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Addresses encode in lowercase, as geth does
+	want := `{"addr":"` + strings.ToLower(checksummed) + `","hash":"0x` + strings.Repeat("00", 29) + `010203","maybe":"` + strings.ToLower(checksummed) + `"}`
+	if string(enc) != want {
+		t.Errorf("Marshal = %s, want %s", enc, want)
+	}
+
+	out := in
+	out.Addr, out.Hash, out.Maybe = Types.Address{}, Types.Hash{}, nil
+	if err := json.Unmarshal(enc, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Addr != a || out.Hash != h || out.Maybe == nil || *out.Maybe != a {
+		t.Errorf("Unmarshal = %+v, want %+v", out, in)
+	}
+
+	for _, bad := range []string{
+		`{"addr":"0x1234"}`,
+		`{"addr":"` + strings.Replace(checksummed, "aA", "aa", 1) + `"}`,
+		`{"hash":"0x0102"}`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &out); err == nil {
+			t.Errorf("Unmarshal(%s) accepted", bad)
+		}
+	}
+}
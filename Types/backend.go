@@ -2,6 +2,7 @@ package Types
 
 import (
 	"context"
+	"encoding/json"
 	"math/big"
 )
 
@@ -157,19 +158,112 @@ type Backend interface {
 	PeerCount(ctx context.Context) (uint64, error)
 	Listening(ctx context.Context) (bool, error)
 	Syncing(ctx context.Context) (map[string]any, error)
+}
 
-	// Mining operations (for PoW chains)
-	Mining(ctx context.Context) (bool, error)
-	Hashrate(ctx context.Context) (uint64, error)
-
-	// Uncle operations (for PoW chains)
+// UncleBackend serves ommers, for chains that have them (eth_getUncle*).
+type UncleBackend interface {
 	UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error)
 	UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error)
 	UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Block, error)
 	UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Block, error)
+}
+
+// MiningBackend reports mining status (eth_mining, eth_hashrate).
+type MiningBackend interface {
+	Mining(ctx context.Context) (bool, error)
+	Hashrate(ctx context.Context) (uint64, error)
+}
 
-	// Streaming (for WS subscriptions)
+// SubscriptionBackend streams chain events for WebSocket eth_subscribe.
+type SubscriptionBackend interface {
 	SubscribeNewHeads(ctx context.Context) (<-chan *Block, func(), error)
 	SubscribeLogs(ctx context.Context, q *FilterQuery) (<-chan *Log, func(), error)
 	SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error)
 }
+
+// TracingBackend serves debug_traceTransaction and debug_traceCall. The
+// config and result are passed through in geth's JSON shapes.
+type TracingBackend interface {
+	TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error)
+	TraceCall(ctx context.Context, msg CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error)
+}
+
+// Capability names an optional backend interface.
+type Capability string
+
+const (
+	CapUncles        Capability = "uncles"
+	CapMining        Capability = "mining"
+	CapSubscriptions Capability = "subscriptions"
+	CapTracing       Capability = "tracing"
+)
+
+// CapabilityReporter is implemented by backends that wrap others. They carry
+// every optional method but only serve a capability when what they wrap does.
+type CapabilityReporter interface {
+	Supports(c Capability) bool
+}
+
+// Supports reports whether be serves the optional interface named by c:
+// through its own report if it is a CapabilityReporter, else by implementing it.
+func Supports(be Backend, c Capability) bool { return supports(be, c) }
+
+// supports is Supports for any backend value, so adapters over other backend
+// versions answer the same way
+func supports(be any, c Capability) bool {
+	if r, ok := be.(CapabilityReporter); ok {
+		return r.Supports(c)
+	}
+	var ok bool
+	switch c {
+	case CapUncles:
+		_, ok = be.(UncleBackend)
+	case CapMining:
+		_, ok = be.(MiningBackend)
+	case CapSubscriptions:
+		_, ok = be.(SubscriptionBackend)
+	case CapTracing:
+		_, ok = be.(TracingBackend)
+	}
+	return ok
+}
+
+// DevControl is implemented by development backends that let tests manipulate
+// the chain, as with Hardhat's and Anvil's evm_* and anvil_* methods. Handlers
+// serves those namespaces only when the backend implements it.
+type DevControl interface {
+	// Mining and time
+	Mine(ctx context.Context, blocks uint64, timestamp uint64) error
+	IncreaseTime(ctx context.Context, seconds uint64) (int64, error)
+	SetNextBlockTimestamp(ctx context.Context, timestamp uint64) error
+
+	// Snapshots; Revert drops the snapshot and every later one
+	Snapshot(ctx context.Context) (uint64, error)
+	Revert(ctx context.Context, id uint64) (bool, error)
+
+	// State overrides, visible from the latest block on
+	SetBalance(ctx context.Context, addr []byte, balance *big.Int) error
+	SetCode(ctx context.Context, addr []byte, code []byte) error
+	SetStorageAt(ctx context.Context, addr []byte, slot []byte, value []byte) error
+	SetNonce(ctx context.Context, addr []byte, nonce uint64) error
+
+	// Accounts; SendTransaction signs for dev accounts and impersonated ones
+	Accounts(ctx context.Context) ([][]byte, error)
+	ImpersonateAccount(ctx context.Context, addr []byte) error
+	StopImpersonatingAccount(ctx context.Context, addr []byte) error
+	SendTransaction(ctx context.Context, msg CallMsg) ([]byte, error)
+
+	// Reorgs; Reorg replaces the last depth blocks with as many new ones
+	// holding txs. Subscribers see the orphaned logs as removed, then the new
+	// branch's heads and logs.
+	Reorg(ctx context.Context, depth uint64, txs []ReorgTx) error
+}
+
+// ReorgTx places a transaction in the branch mined by DevControl.Reorg: a
+// signed raw transaction, or a message signed as by SendTransaction. Offset
+// picks the new block, 0 being the first one after the fork point.
+type ReorgTx struct {
+	Raw    []byte
+	Msg    *CallMsg
+	Offset uint64
+}
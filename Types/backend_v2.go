@@ -0,0 +1,465 @@
+package Types
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// BackendV2 is the core Backend interface with typed addresses and hashes in
+// place of []byte and hex strings, so length errors are caught where values
+// are parsed rather than deep inside a backend.
+//
+// Blocks, transactions, receipts and logs are returned as BlockV2 and the
+// other typed structs in block_v2.go. The optional interfaces keep their
+// version 1 signatures.
+//
+// The facade serves version 1; wrap a BackendV2 with FromV2 to serve it, and
+// wrap an existing []byte backend with ToV2 to call it through this
+// interface. Optional capabilities (UncleBackend and friends) are shared by
+// both versions.
+type BackendV2 interface {
+	// Basic blockchain info
+	ChainID(ctx context.Context) (*big.Int, error)
+	ClientVersion(ctx context.Context) (string, error)
+	BlockNumber(ctx context.Context) (*big.Int, error)
+
+	// Block operations
+	BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*BlockV2, error)
+	BlockByHash(ctx context.Context, hash Hash, fullTx bool) (*BlockV2, error)
+	BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error)
+	BlockTransactionCountByHash(ctx context.Context, blockHash Hash) (uint64, error)
+
+	// Account operations
+	Balance(ctx context.Context, addr Address, block *big.Int) (*big.Int, error)
+	GetCode(ctx context.Context, addr Address, block *big.Int) ([]byte, error)
+	GetStorageAt(ctx context.Context, addr Address, key Hash, block *big.Int) (Hash, error)
+	GetTransactionCount(ctx context.Context, addr Address, block *big.Int) (uint64, error)
+
+	// Transaction operations
+	Call(ctx context.Context, msg CallMsgV2, block *big.Int) ([]byte, error)
+	EstimateGas(ctx context.Context, msg CallMsgV2) (uint64, error)
+	GasPrice(ctx context.Context) (*big.Int, error)
+	SendRawTx(ctx context.Context, raw []byte) (Hash, error)
+	TxByHash(ctx context.Context, hash Hash) (*TransactionV2, error)
+	TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*TransactionV2, error)
+	TxByBlockHashAndIndex(ctx context.Context, blockHash Hash, index uint64) (*TransactionV2, error)
+	ReceiptByHash(ctx context.Context, hash Hash) (*ReceiptV2, error)
+
+	// Log operations
+	GetLogs(ctx context.Context, q FilterQueryV2) ([]*LogV2, error)
+
+	// Network operations
+	PeerCount(ctx context.Context) (uint64, error)
+	Listening(ctx context.Context) (bool, error)
+	Syncing(ctx context.Context) (map[string]any, error)
+}
+
+// CallMsgV2 is CallMsg with typed addresses. A nil To creates a contract.
+type CallMsgV2 struct {
+	From          Address
+	To            *Address
+	Data          []byte
+	Value         *big.Int
+	Gas, GasPrice *big.Int
+}
+
+// FilterQueryV2 is FilterQuery with typed addresses and topics. A nil topic
+// is a wildcard for its position.
+type FilterQueryV2 struct {
+	FromBlock, ToBlock *big.Int
+	Addresses          []Address
+	Topics             []*Hash
+	BlockHash          *Hash
+}
+
+// ToV2 adapts a version 1 backend to BackendV2. Optional interfaces are still
+// reached on the original backend.
+func ToV2(be Backend) BackendV2 { return v2Adapter{be} }
+
+// FromV2 adapts a BackendV2 to the version 1 Backend the facade serves.
+// Addresses and hashes of the wrong length are rejected as invalid params
+// (-32602) before reaching the wrapped backend. Optional interfaces implemented by the wrapped backend are
+// passed through.
+func FromV2(be BackendV2) Backend { return v1Adapter{be} }
+
+// CallMsgToV2 parses the hex addresses of a version 1 call message.
+func CallMsgToV2(msg CallMsg) (CallMsgV2, error) {
+	out := CallMsgV2{Data: msg.Data, Value: msg.Value, Gas: msg.Gas, GasPrice: msg.GasPrice}
+	if msg.From != "" {
+		from, err := ParseAddress(msg.From)
+		if err != nil {
+			return CallMsgV2{}, fmt.Errorf("from: %w", err)
+		}
+		out.From = from
+	}
+	if msg.To != "" {
+		to, err := ParseAddress(msg.To)
+		if err != nil {
+			return CallMsgV2{}, fmt.Errorf("to: %w", err)
+		}
+		out.To = &to
+	}
+	return out, nil
+}
+
+// V1 returns msg as a version 1 call message. A zero From is left empty.
+func (msg CallMsgV2) V1() CallMsg {
+	out := CallMsg{Data: msg.Data, Value: msg.Value, Gas: msg.Gas, GasPrice: msg.GasPrice}
+	if !msg.From.IsZero() {
+		out.From = msg.From.Hex()
+	}
+	if msg.To != nil {
+		out.To = msg.To.Hex()
+	}
+	return out
+}
+
+// FilterQueryToV2 checks the lengths of a version 1 query's addresses and
+// topics.
+func FilterQueryToV2(q FilterQuery) (FilterQueryV2, error) {
+	out := FilterQueryV2{FromBlock: q.FromBlock, ToBlock: q.ToBlock}
+	for _, b := range q.Addresses {
+		a, err := AddressFromBytes(b)
+		if err != nil {
+			return FilterQueryV2{}, err
+		}
+		out.Addresses = append(out.Addresses, a)
+	}
+	for _, b := range q.Topics {
+		if len(b) == 0 {
+			out.Topics = append(out.Topics, nil)
+			continue
+		}
+		t, err := HashFromBytes(b)
+		if err != nil {
+			return FilterQueryV2{}, fmt.Errorf("topic: %w", err)
+		}
+		out.Topics = append(out.Topics, &t)
+	}
+	if len(q.BlockHash) > 0 {
+		h, err := HashFromBytes(q.BlockHash)
+		if err != nil {
+			return FilterQueryV2{}, fmt.Errorf("block hash: %w", err)
+		}
+		out.BlockHash = &h
+	}
+	return out, nil
+}
+
+// V1 returns q as a version 1 query
+func (q FilterQueryV2) V1() FilterQuery {
+	out := FilterQuery{FromBlock: q.FromBlock, ToBlock: q.ToBlock}
+	for _, a := range q.Addresses {
+		out.Addresses = append(out.Addresses, a.Bytes())
+	}
+	for _, t := range q.Topics {
+		if t == nil {
+			out.Topics = append(out.Topics, nil)
+			continue
+		}
+		out.Topics = append(out.Topics, t.Bytes())
+	}
+	if q.BlockHash != nil {
+		out.BlockHash = q.BlockHash.Bytes()
+	}
+	return out
+}
+
+// v2Adapter serves BackendV2 from a version 1 backend. Results the backend
+// returns with hashes or addresses of the wrong length are errors.
+type v2Adapter struct {
+	Backend
+}
+
+func (a v2Adapter) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*BlockV2, error) {
+	b, err := a.Backend.BlockByNumber(ctx, num, fullTx)
+	if err != nil {
+		return nil, err
+	}
+	return BlockToV2(b)
+}
+func (a v2Adapter) BlockByHash(ctx context.Context, hash Hash, fullTx bool) (*BlockV2, error) {
+	b, err := a.Backend.BlockByHash(ctx, hash.Bytes(), fullTx)
+	if err != nil {
+		return nil, err
+	}
+	return BlockToV2(b)
+}
+func (a v2Adapter) BlockTransactionCountByHash(ctx context.Context, blockHash Hash) (uint64, error) {
+	return a.Backend.BlockTransactionCountByHash(ctx, blockHash.Bytes())
+}
+func (a v2Adapter) Balance(ctx context.Context, addr Address, block *big.Int) (*big.Int, error) {
+	return a.Backend.Balance(ctx, addr.Bytes(), block)
+}
+func (a v2Adapter) GetCode(ctx context.Context, addr Address, block *big.Int) ([]byte, error) {
+	return a.Backend.GetCode(ctx, addr.Bytes(), block)
+}
+func (a v2Adapter) GetStorageAt(ctx context.Context, addr Address, key Hash, block *big.Int) (Hash, error) {
+	word, err := a.Backend.GetStorageAt(ctx, addr.Bytes(), key.Bytes(), block)
+	if err != nil {
+		return Hash{}, err
+	}
+	if len(word) > HashLength {
+		return Hash{}, fmt.Errorf("storage word has length %d, want at most %d", len(word), HashLength)
+	}
+	return BytesToHash(word), nil
+}
+func (a v2Adapter) GetTransactionCount(ctx context.Context, addr Address, block *big.Int) (uint64, error) {
+	return a.Backend.GetTransactionCount(ctx, addr.Bytes(), block)
+}
+func (a v2Adapter) Call(ctx context.Context, msg CallMsgV2, block *big.Int) ([]byte, error) {
+	return a.Backend.Call(ctx, msg.V1(), block)
+}
+func (a v2Adapter) EstimateGas(ctx context.Context, msg CallMsgV2) (uint64, error) {
+	return a.Backend.EstimateGas(ctx, msg.V1())
+}
+func (a v2Adapter) SendRawTx(ctx context.Context, raw []byte) (Hash, error) {
+	hash, err := a.Backend.SendRawTx(ctx, "0x"+hex.EncodeToString(raw))
+	if err != nil {
+		return Hash{}, err
+	}
+	return HashFromBytes(hash)
+}
+func (a v2Adapter) TxByHash(ctx context.Context, hash Hash) (*TransactionV2, error) {
+	tx, err := a.Backend.TxByHash(ctx, hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return TransactionToV2(tx)
+}
+func (a v2Adapter) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*TransactionV2, error) {
+	tx, err := a.Backend.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	if err != nil {
+		return nil, err
+	}
+	return TransactionToV2(tx)
+}
+func (a v2Adapter) TxByBlockHashAndIndex(ctx context.Context, blockHash Hash, index uint64) (*TransactionV2, error) {
+	tx, err := a.Backend.TxByBlockHashAndIndex(ctx, blockHash.Bytes(), index)
+	if err != nil {
+		return nil, err
+	}
+	return TransactionToV2(tx)
+}
+func (a v2Adapter) ReceiptByHash(ctx context.Context, hash Hash) (*ReceiptV2, error) {
+	r, err := a.Backend.ReceiptByHash(ctx, hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return ReceiptToV2(r)
+}
+func (a v2Adapter) GetLogs(ctx context.Context, q FilterQueryV2) ([]*LogV2, error) {
+	logs, err := a.Backend.GetLogs(ctx, q.V1())
+	if err != nil {
+		return nil, err
+	}
+	return LogsToV2(logs)
+}
+
+// v1Adapter serves version 1 from a BackendV2. Parameters of the wrong length
+// are rejected as JSON-RPC invalid params.
+type v1Adapter struct {
+	BackendV2
+}
+
+// invalidParams reports a parameter that failed validation as -32602
+func invalidParams(err error) error {
+	return &Error{Code: -32602, Message: err.Error()}
+}
+
+func (a v1Adapter) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Block, error) {
+	b, err := a.BackendV2.BlockByNumber(ctx, num, fullTx)
+	return b.V1(), err
+}
+func (a v1Adapter) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Block, error) {
+	h, err := HashFromBytes(hash)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	b, err := a.BackendV2.BlockByHash(ctx, h, fullTx)
+	return b.V1(), err
+}
+func (a v1Adapter) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	h, err := HashFromBytes(blockHash)
+	if err != nil {
+		return 0, invalidParams(err)
+	}
+	return a.BackendV2.BlockTransactionCountByHash(ctx, h)
+}
+func (a v1Adapter) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	ad, err := AddressFromBytes(addr)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	return a.BackendV2.Balance(ctx, ad, block)
+}
+func (a v1Adapter) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	ad, err := AddressFromBytes(addr)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	return a.BackendV2.GetCode(ctx, ad, block)
+}
+func (a v1Adapter) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	ad, err := AddressFromBytes(addr)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	if len(key) > HashLength {
+		return nil, invalidParams(fmt.Errorf("storage key has length %d, want at most %d", len(key), HashLength))
+	}
+	word, err := a.BackendV2.GetStorageAt(ctx, ad, BytesToHash(key), block)
+	if err != nil {
+		return nil, err
+	}
+	return word.Bytes(), nil
+}
+func (a v1Adapter) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	ad, err := AddressFromBytes(addr)
+	if err != nil {
+		return 0, invalidParams(err)
+	}
+	return a.BackendV2.GetTransactionCount(ctx, ad, block)
+}
+func (a v1Adapter) Call(ctx context.Context, msg CallMsg, block *big.Int) ([]byte, error) {
+	m, err := CallMsgToV2(msg)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	return a.BackendV2.Call(ctx, m, block)
+}
+func (a v1Adapter) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	m, err := CallMsgToV2(msg)
+	if err != nil {
+		return 0, invalidParams(err)
+	}
+	return a.BackendV2.EstimateGas(ctx, m)
+}
+func (a v1Adapter) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(rawHex, "0x"))
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	hash, err := a.BackendV2.SendRawTx(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Bytes(), nil
+}
+func (a v1Adapter) TxByHash(ctx context.Context, hash []byte) (*Transaction, error) {
+	h, err := HashFromBytes(hash)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	tx, err := a.BackendV2.TxByHash(ctx, h)
+	return tx.V1(), err
+}
+func (a v1Adapter) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Transaction, error) {
+	tx, err := a.BackendV2.TxByBlockNumberAndIndex(ctx, blockNum, index)
+	return tx.V1(), err
+}
+func (a v1Adapter) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Transaction, error) {
+	h, err := HashFromBytes(blockHash)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	tx, err := a.BackendV2.TxByBlockHashAndIndex(ctx, h, index)
+	return tx.V1(), err
+}
+func (a v1Adapter) ReceiptByHash(ctx context.Context, hash []byte) (*Receipt, error) {
+	h, err := HashFromBytes(hash)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	r, err := a.BackendV2.ReceiptByHash(ctx, h)
+	return r.V1(), err
+}
+func (a v1Adapter) GetLogs(ctx context.Context, q FilterQuery) ([]*Log, error) {
+	qv2, err := FilterQueryToV2(q)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	logs, err := a.BackendV2.GetLogs(ctx, qv2)
+	return LogsV1(logs), err
+}
+
+// Supports reports the optional interfaces of the wrapped backend
+func (a v1Adapter) Supports(c Capability) bool { return supports(a.BackendV2, c) }
+
+// Uncle operations (for PoW chains)
+func (a v1Adapter) UncleCountByBlockNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	if u, ok := a.BackendV2.(UncleBackend); ok {
+		return u.UncleCountByBlockNumber(ctx, blockNum)
+	}
+	return 0, ErrNotSupported
+}
+func (a v1Adapter) UncleCountByBlockHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	if u, ok := a.BackendV2.(UncleBackend); ok {
+		return u.UncleCountByBlockHash(ctx, blockHash)
+	}
+	return 0, ErrNotSupported
+}
+func (a v1Adapter) UncleByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Block, error) {
+	if u, ok := a.BackendV2.(UncleBackend); ok {
+		return u.UncleByBlockNumberAndIndex(ctx, blockNum, index)
+	}
+	return nil, ErrNotSupported
+}
+func (a v1Adapter) UncleByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Block, error) {
+	if u, ok := a.BackendV2.(UncleBackend); ok {
+		return u.UncleByBlockHashAndIndex(ctx, blockHash, index)
+	}
+	return nil, ErrNotSupported
+}
+
+// Mining operations (for PoW chains)
+func (a v1Adapter) Mining(ctx context.Context) (bool, error) {
+	if m, ok := a.BackendV2.(MiningBackend); ok {
+		return m.Mining(ctx)
+	}
+	return false, ErrNotSupported
+}
+func (a v1Adapter) Hashrate(ctx context.Context) (uint64, error) {
+	if m, ok := a.BackendV2.(MiningBackend); ok {
+		return m.Hashrate(ctx)
+	}
+	return 0, ErrNotSupported
+}
+
+// Streaming (for WS subscriptions)
+func (a v1Adapter) SubscribeNewHeads(ctx context.Context) (<-chan *Block, func(), error) {
+	if s, ok := a.BackendV2.(SubscriptionBackend); ok {
+		return s.SubscribeNewHeads(ctx)
+	}
+	return nil, nil, ErrNotSupported
+}
+func (a v1Adapter) SubscribeLogs(ctx context.Context, q *FilterQuery) (<-chan *Log, func(), error) {
+	if s, ok := a.BackendV2.(SubscriptionBackend); ok {
+		return s.SubscribeLogs(ctx, q)
+	}
+	return nil, nil, ErrNotSupported
+}
+func (a v1Adapter) SubscribePendingTxs(ctx context.Context) (<-chan []byte, func(), error) {
+	if s, ok := a.BackendV2.(SubscriptionBackend); ok {
+		return s.SubscribePendingTxs(ctx)
+	}
+	return nil, nil, ErrNotSupported
+}
+
+// Tracing
+func (a v1Adapter) TraceTransaction(ctx context.Context, hash []byte, config map[string]any) (json.RawMessage, error) {
+	if t, ok := a.BackendV2.(TracingBackend); ok {
+		return t.TraceTransaction(ctx, hash, config)
+	}
+	return nil, ErrNotSupported
+}
+func (a v1Adapter) TraceCall(ctx context.Context, msg CallMsg, block *big.Int, config map[string]any) (json.RawMessage, error) {
+	if t, ok := a.BackendV2.(TracingBackend); ok {
+		return t.TraceCall(ctx, msg, block, config)
+	}
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,133 @@
+package Types_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// v1Chain serves one block, its transaction, receipt and log
+type v1Chain struct {
+	Types.BaseBackend
+	block   *Types.Block
+	receipt *Types.Receipt
+}
+
+func (c v1Chain) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Types.Block, error) {
+	return c.block, nil
+}
+func (c v1Chain) TxByHash(ctx context.Context, hash []byte) (*Types.Transaction, error) {
+	return c.block.Transactions[0], nil
+}
+func (c v1Chain) ReceiptByHash(ctx context.Context, hash []byte) (*Types.Receipt, error) {
+	return c.receipt, nil
+}
+func (c v1Chain) GetLogs(ctx context.Context, q Types.FilterQuery) ([]*Types.Log, error) {
+	return c.receipt.Logs, nil
+}
+func (c v1Chain) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func filled(n int, b byte) []byte { return bytes.Repeat([]byte{b}, n) }
+
+func newV1Chain() v1Chain {
+	log := &Types.Log{Address: filled(20, 0xc0), Topics: [][]byte{filled(32, 0x70)}, Data: []byte{1}, BlockNumber: 1, BlockHash: filled(32, 0xb1), TxHash: filled(32, 0x71)}
+	tx := &Types.Transaction{
+		Hash:       filled(32, 0x71),
+		From:       filled(20, 0xf0),
+		Input:      []byte{0x60},
+		Value:      []byte{1},
+		GasPrice:   []byte{2},
+		AccessList: &Types.AccessList{AccessTuples: []*Types.AccessTuple{{Address: filled(20, 0xa0), StorageKeys: [][]byte{filled(32, 0x5)}}}},
+	}
+	block := &Types.Block{
+		Header: &Types.BlockHeader{
+			ParentHash:          filled(32, 0xb0),
+			StateRoot:           filled(32, 0x51),
+			ReceiptsRoot:        filled(32, 0x52),
+			LogsBloom:           filled(256, 0),
+			Miner:               filled(20, 0xee),
+			Number:              1,
+			MixHashOrPrevRandao: filled(32, 0x53),
+			BaseFee:             []byte{7},
+			Hash:                filled(32, 0xb1),
+		},
+		Transactions:    []*Types.Transaction{tx},
+		WithdrawalsRoot: filled(32, 0x54),
+		Withdrawals:     []*Types.Withdrawal{{Index: 1, Address: filled(20, 0xdd), Amount: 2}},
+	}
+	receipt := &Types.Receipt{TxHash: tx.Hash, Status: 1, Logs: []*Types.Log{log}, ContractAddress: filled(20, 0xcc), BlockHash: block.Header.Hash, BlockNumber: 1}
+	return v1Chain{block: block, receipt: receipt}
+}
+
+func TestV2RoundTrip(t *testing.T) {
+	chain := newV1Chain()
+	be := Types.FromV2(Types.ToV2(chain))
+	ctx := context.Background()
+
+	if got, err := be.BlockByNumber(ctx, nil, true); err != nil || !reflect.DeepEqual(got, chain.block) {
+		t.Errorf("BlockByNumber = %+v, %v, want %+v", got, err, chain.block)
+	}
+	if got, err := be.TxByHash(ctx, chain.receipt.TxHash); err != nil || !reflect.DeepEqual(got, chain.block.Transactions[0]) {
+		t.Errorf("TxByHash = %+v, %v", got, err)
+	}
+	if got, err := be.ReceiptByHash(ctx, chain.receipt.TxHash); err != nil || !reflect.DeepEqual(got, chain.receipt) {
+		t.Errorf("ReceiptByHash = %+v, %v, want %+v", got, err, chain.receipt)
+	}
+	if got, err := be.GetLogs(ctx, Types.FilterQuery{}); err != nil || !reflect.DeepEqual(got, chain.receipt.Logs) {
+		t.Errorf("GetLogs = %+v, %v", got, err)
+	}
+
+	// A contract creation has no To
+	creation, err := Types.TransactionToV2(&Types.Transaction{Hash: filled(32, 1), From: filled(20, 2)})
+	if err != nil || creation.To != nil {
+		t.Errorf("creation converted to %+v, %v", creation, err)
+	}
+}
+
+func TestV2RejectsMalformedResults(t *testing.T) {
+	chain := newV1Chain()
+	chain.block.Header.Miner = filled(19, 0xee)
+	chain.receipt.Logs[0].Topics = [][]byte{filled(31, 0x70)}
+	be := Types.ToV2(chain)
+	ctx := context.Background()
+
+	if _, err := be.BlockByNumber(ctx, nil, false); err == nil {
+		t.Error("block with a 19-byte miner accepted")
+	}
+	if _, err := be.ReceiptByHash(ctx, Types.Hash{}); err == nil {
+		t.Error("receipt with a 31-byte topic accepted")
+	}
+	if _, err := be.GetLogs(ctx, Types.FilterQueryV2{}); err == nil {
+		t.Error("log with a 31-byte topic accepted")
+	}
+}
+
+func TestV1AdapterInvalidParams(t *testing.T) {
+	be := Types.FromV2(Types.ToV2(newV1Chain()))
+	ctx := context.Background()
+	short := filled(19, 1)
+	for name, call := range map[string]func() error{
+		"Balance":       func() error { _, err := be.Balance(ctx, short, nil); return err },
+		"BlockByHash":   func() error { _, err := be.BlockByHash(ctx, short, false); return err },
+		"ReceiptByHash": func() error { _, err := be.ReceiptByHash(ctx, short); return err },
+		"GetStorageAt":  func() error { _, err := be.GetStorageAt(ctx, filled(20, 1), filled(33, 1), nil); return err },
+		"Call":          func() error { _, err := be.Call(ctx, Types.CallMsg{To: "0x1234"}, nil); return err },
+		"GetLogs":       func() error { _, err := be.GetLogs(ctx, Types.FilterQuery{Addresses: [][]byte{short}}); return err },
+		"SendRawTx":     func() error { _, err := be.SendRawTx(ctx, "0xzz"); return err },
+	} {
+		var rpcErr *Types.Error
+		if err := call(); !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+			t.Errorf("%s with a malformed parameter returned %v, want -32602", name, err)
+		}
+	}
+	if bal, err := be.Balance(ctx, filled(20, 1), nil); err != nil || bal.Int64() != 1 {
+		t.Errorf("Balance = %v, %v", bal, err)
+	}
+}
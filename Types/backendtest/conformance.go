@@ -0,0 +1,653 @@
+// Package backendtest is a conformance suite for Types.Backend
+// implementations. RunConformance exercises every interface method against a
+// seeded chain and checks the invariants the facade's handlers rely on.
+package backendtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jupitermetalabs/geth-facade/Types"
+)
+
+// Factory returns a fresh backend for one subtest. Its chain must already be
+// seeded with at least one block past genesis, transactions from at least one
+// account, and at least one receipt with logs. The factory registers any
+// cleanup with t.Cleanup.
+//
+// Subtests for optional capabilities (mining, uncles, subscriptions) are
+// skipped unless Types.Supports reports them. Delivery of subscription events
+// and reorgs are only checked when the backend also implements
+// Types.DevControl, which the suite uses to mine, send and reorg.
+type Factory func(t *testing.T) Types.Backend
+
+// timeout bounds every wait in the suite
+const timeout = 5 * time.Second
+
+// RunConformance runs the conformance suite against backends from factory.
+func RunConformance(t *testing.T, factory Factory) {
+	t.Run("ChainInfo", func(t *testing.T) { testChainInfo(t, factory(t)) })
+	t.Run("Blocks", func(t *testing.T) { testBlocks(t, factory(t)) })
+	t.Run("Transactions", func(t *testing.T) { testTransactions(t, factory(t)) })
+	t.Run("Accounts", func(t *testing.T) { testAccounts(t, factory(t)) })
+	t.Run("Execution", func(t *testing.T) { testExecution(t, factory(t)) })
+	t.Run("Logs", func(t *testing.T) { testLogs(t, factory(t)) })
+	t.Run("Network", func(t *testing.T) { testNetwork(t, factory(t)) })
+	t.Run("Mining", func(t *testing.T) { testMining(t, factory(t)) })
+	t.Run("Uncles", func(t *testing.T) { testUncles(t, factory(t)) })
+	t.Run("SubscriptionDelivery", func(t *testing.T) { testSubscriptionDelivery(t, factory(t)) })
+	t.Run("Reorg", func(t *testing.T) { testReorg(t, factory(t)) })
+	t.Run("SubscriptionCleanup", func(t *testing.T) { testSubscriptionCleanup(t, factory(t)) })
+	t.Run("Cancellation", func(t *testing.T) { testCancellation(t, factory(t)) })
+}
+
+// chain is the seeded chain as read back from the backend
+type chain struct {
+	head     uint64
+	blocks   []*Types.Block
+	receipts map[string]*Types.Receipt // by tx hash
+	logs     []*Types.Log              // every log, in chain order
+}
+
+// load reads every block and receipt of the seeded chain
+func load(t *testing.T, be Types.Backend) *chain {
+	t.Helper()
+	ctx := context.Background()
+	num, err := be.BlockNumber(ctx)
+	if err != nil || num == nil {
+		t.Fatalf("BlockNumber: %v, %v", num, err)
+	}
+	c := &chain{head: num.Uint64(), receipts: map[string]*Types.Receipt{}}
+	if c.head == 0 {
+		t.Fatal("seeded chain has no blocks past genesis")
+	}
+	for n := uint64(0); n <= c.head; n++ {
+		b, err := be.BlockByNumber(ctx, new(big.Int).SetUint64(n), true)
+		if err != nil || b == nil || b.Header == nil {
+			t.Fatalf("BlockByNumber(%d): %v, %v", n, b, err)
+		}
+		c.blocks = append(c.blocks, b)
+		for _, tx := range b.Transactions {
+			r, err := be.ReceiptByHash(ctx, tx.Hash)
+			if err != nil || r == nil {
+				t.Fatalf("ReceiptByHash(%x): %v, %v", tx.Hash, r, err)
+			}
+			c.receipts[string(tx.Hash)] = r
+			c.logs = append(c.logs, r.Logs...)
+		}
+	}
+	if len(c.receipts) == 0 {
+		t.Fatal("seeded chain has no transactions")
+	}
+	if len(c.logs) == 0 {
+		t.Fatal("seeded chain has no logs")
+	}
+	return c
+}
+
+func hexAddr(b []byte) string { return "0x" + hex.EncodeToString(b) }
+
+func bigU(n uint64) *big.Int { return new(big.Int).SetUint64(n) }
+
+// missing reports whether a lookup found nothing: a nil value or an error
+func missing[T any](v *T, err error) bool { return v == nil || err != nil }
+
+func testChainInfo(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	id, err := be.ChainID(ctx)
+	if err != nil || id == nil || id.Sign() <= 0 {
+		t.Errorf("ChainID: %v, %v", id, err)
+	}
+	if v, err := be.ClientVersion(ctx); err != nil || v == "" {
+		t.Errorf("ClientVersion: %q, %v", v, err)
+	}
+	if n, err := be.BlockNumber(ctx); err != nil || n == nil || n.Sign() <= 0 {
+		t.Errorf("BlockNumber: %v, %v", n, err)
+	}
+}
+
+func testBlocks(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	c := load(t, be)
+	for n, b := range c.blocks {
+		h := b.Header
+		if h.Number != uint64(n) {
+			t.Errorf("block %d: header number %d", n, h.Number)
+		}
+		if len(h.Hash) != 32 {
+			t.Errorf("block %d: hash 0x%x is not 32 bytes", n, h.Hash)
+		}
+		if n > 0 && !bytes.Equal(h.ParentHash, c.blocks[n-1].Header.Hash) {
+			t.Errorf("block %d: parent 0x%x, want 0x%x", n, h.ParentHash, c.blocks[n-1].Header.Hash)
+		}
+		for _, full := range []bool{false, true} {
+			byNum, err := be.BlockByNumber(ctx, bigU(uint64(n)), full)
+			if err != nil {
+				t.Fatalf("BlockByNumber(%d, %v): %v", n, full, err)
+			}
+			byHash, err := be.BlockByHash(ctx, h.Hash, full)
+			if err != nil {
+				t.Fatalf("BlockByHash(0x%x, %v): %v", h.Hash, full, err)
+			}
+			if !reflect.DeepEqual(byNum, byHash) {
+				t.Errorf("block %d: BlockByHash(BlockByNumber(n).Hash) differs from BlockByNumber(n) (fullTx %v)", n, full)
+			}
+		}
+		count, err := be.BlockTransactionCountByNumber(ctx, bigU(uint64(n)))
+		if err != nil || count != uint64(len(b.Transactions)) {
+			t.Errorf("BlockTransactionCountByNumber(%d) = %d, %v; want %d", n, count, err, len(b.Transactions))
+		}
+		count, err = be.BlockTransactionCountByHash(ctx, h.Hash)
+		if err != nil || count != uint64(len(b.Transactions)) {
+			t.Errorf("BlockTransactionCountByHash(0x%x) = %d, %v; want %d", h.Hash, count, err, len(b.Transactions))
+		}
+	}
+
+	latest, err := be.BlockByNumber(ctx, nil, false)
+	if err != nil || latest == nil || !bytes.Equal(latest.Header.Hash, c.blocks[c.head].Header.Hash) {
+		t.Errorf("BlockByNumber(latest) is not block %d", c.head)
+	}
+	if !missing(be.BlockByNumber(ctx, bigU(c.head+1000), false)) {
+		t.Error("BlockByNumber beyond the head returned a block")
+	}
+	if !missing(be.BlockByHash(ctx, bytes.Repeat([]byte{0xee}, 32), false)) {
+		t.Error("BlockByHash of an unknown hash returned a block")
+	}
+}
+
+func testTransactions(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	c := load(t, be)
+	for n, b := range c.blocks {
+		var cumulative uint64
+		var logIndex uint64
+		for i, tx := range b.Transactions {
+			byHash, err := be.TxByHash(ctx, tx.Hash)
+			if err != nil || byHash == nil || !bytes.Equal(byHash.Hash, tx.Hash) || !bytes.Equal(byHash.From, tx.From) {
+				t.Errorf("TxByHash(0x%x) = %v, %v", tx.Hash, byHash, err)
+			}
+			byNum, err := be.TxByBlockNumberAndIndex(ctx, bigU(uint64(n)), uint64(i))
+			if err != nil || byNum == nil || !bytes.Equal(byNum.Hash, tx.Hash) {
+				t.Errorf("TxByBlockNumberAndIndex(%d, %d) is not 0x%x", n, i, tx.Hash)
+			}
+			byBlockHash, err := be.TxByBlockHashAndIndex(ctx, b.Header.Hash, uint64(i))
+			if err != nil || byBlockHash == nil || !bytes.Equal(byBlockHash.Hash, tx.Hash) {
+				t.Errorf("TxByBlockHashAndIndex(0x%x, %d) is not 0x%x", b.Header.Hash, i, tx.Hash)
+			}
+
+			r := c.receipts[string(tx.Hash)]
+			if !bytes.Equal(r.TxHash, tx.Hash) || r.BlockNumber != uint64(n) || r.TransactionIndex != uint64(i) || !bytes.Equal(r.BlockHash, b.Header.Hash) {
+				t.Errorf("receipt of 0x%x does not place it at block %d index %d", tx.Hash, n, i)
+			}
+			if r.CumulativeGasUsed < cumulative || r.CumulativeGasUsed-cumulative != r.GasUsed {
+				t.Errorf("receipt of 0x%x: cumulative gas %d after %d with %d used", tx.Hash, r.CumulativeGasUsed, cumulative, r.GasUsed)
+			}
+			cumulative = r.CumulativeGasUsed
+			if len(tx.To) == 0 && r.Status == 1 {
+				code, err := be.GetCode(ctx, r.ContractAddress, nil)
+				if len(r.ContractAddress) == 0 || err != nil || len(code) == 0 {
+					t.Errorf("creation 0x%x: contract 0x%x has no code (%v)", tx.Hash, r.ContractAddress, err)
+				}
+			}
+			for _, l := range r.Logs {
+				if l.BlockNumber != uint64(n) || !bytes.Equal(l.BlockHash, b.Header.Hash) || !bytes.Equal(l.TxHash, tx.Hash) || l.TxIndex != uint64(i) {
+					t.Errorf("log %d of 0x%x is not placed in its transaction", l.LogIndex, tx.Hash)
+				}
+				if l.LogIndex != logIndex {
+					t.Errorf("block %d: log index %d, want %d", n, l.LogIndex, logIndex)
+				}
+				logIndex++
+			}
+		}
+		if !missing(be.TxByBlockNumberAndIndex(ctx, bigU(uint64(n)), uint64(len(b.Transactions)))) {
+			t.Errorf("TxByBlockNumberAndIndex(%d) past the last transaction returned one", n)
+		}
+	}
+	unknown := bytes.Repeat([]byte{0xee}, 32)
+	if !missing(be.TxByHash(ctx, unknown)) {
+		t.Error("TxByHash of an unknown hash returned a transaction")
+	}
+	if !missing(be.ReceiptByHash(ctx, unknown)) {
+		t.Error("ReceiptByHash of an unknown hash returned a receipt")
+	}
+}
+
+func testAccounts(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	c := load(t, be)
+	nonces := map[string]uint64{}
+	for _, b := range c.blocks {
+		for _, tx := range b.Transactions {
+			nonces[string(tx.From)] = max(nonces[string(tx.From)], tx.Nonce+1)
+		}
+	}
+	for from, next := range nonces {
+		addr := []byte(from)
+		if n, err := be.GetTransactionCount(ctx, addr, nil); err != nil || n < next {
+			t.Errorf("GetTransactionCount(0x%x) = %d, %v; want at least %d", addr, n, err, next)
+		}
+		if n, err := be.GetTransactionCount(ctx, addr, big.NewInt(0)); err != nil || n != 0 {
+			t.Errorf("GetTransactionCount(0x%x, genesis) = %d, %v; want 0", addr, n, err)
+		}
+		if bal, err := be.Balance(ctx, addr, nil); err != nil || bal == nil {
+			t.Errorf("Balance(0x%x) = %v, %v", addr, bal, err)
+		}
+		if code, err := be.GetCode(ctx, addr, nil); err != nil || len(code) != 0 {
+			t.Errorf("GetCode(0x%x) of a sender = 0x%x, %v; want empty", addr, code, err)
+		}
+		if v, err := be.GetStorageAt(ctx, addr, make([]byte, 32), nil); err != nil || len(bytes.Trim(v, "\x00")) != 0 {
+			t.Errorf("GetStorageAt(0x%x, 0) of a sender = 0x%x, %v; want zero", addr, v, err)
+		}
+	}
+}
+
+func testExecution(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	c := load(t, be)
+	if p, err := be.GasPrice(ctx); err != nil || p == nil || p.Sign() < 0 {
+		t.Errorf("GasPrice: %v, %v", p, err)
+	}
+	var sender []byte
+	for _, b := range c.blocks {
+		if len(b.Transactions) > 0 && sender == nil {
+			sender = b.Transactions[0].From
+		}
+	}
+	transfer := Types.CallMsg{From: hexAddr(sender), To: hexAddr(bytes.Repeat([]byte{0x11}, 20)), Value: big.NewInt(1)}
+	if gas, err := be.EstimateGas(ctx, transfer); err != nil || gas < 21000 {
+		t.Errorf("EstimateGas(transfer) = %d, %v; want at least 21000", gas, err)
+	}
+	if out, err := be.Call(ctx, Types.CallMsg{From: transfer.From, To: transfer.To}, nil); err != nil || len(out) != 0 {
+		t.Errorf("Call to an account without code = 0x%x, %v; want empty", out, err)
+	}
+	for _, raw := range []string{"0x", "0x00", "0xzz"} {
+		if _, err := be.SendRawTx(ctx, raw); err == nil {
+			t.Errorf("SendRawTx(%q) accepted a malformed transaction", raw)
+		}
+	}
+}
+
+// matches is the reference filter: any listed address, and each non-empty
+// topic position equal
+func matches(q *Types.FilterQuery, l *Types.Log) bool {
+	if len(q.Addresses) > 0 {
+		found := false
+		for _, a := range q.Addresses {
+			found = found || bytes.Equal(a, l.Address)
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, topic := range q.Topics {
+		if len(topic) == 0 {
+			continue
+		}
+		if i >= len(l.Topics) || !bytes.Equal(topic, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func testLogs(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	c := load(t, be)
+	first := c.logs[0]
+	all := Types.FilterQuery{FromBlock: big.NewInt(0), ToBlock: bigU(c.head)}
+
+	queries := map[string]Types.FilterQuery{
+		"everything": all,
+		"address":    {FromBlock: all.FromBlock, ToBlock: all.ToBlock, Addresses: [][]byte{first.Address}},
+		"addresses":  {FromBlock: all.FromBlock, ToBlock: all.ToBlock, Addresses: [][]byte{bytes.Repeat([]byte{0xee}, 20), first.Address}},
+		"unknown":    {FromBlock: all.FromBlock, ToBlock: all.ToBlock, Addresses: [][]byte{bytes.Repeat([]byte{0xee}, 20)}},
+		"one block":  {FromBlock: bigU(first.BlockNumber), ToBlock: bigU(first.BlockNumber)},
+		"block hash": {BlockHash: first.BlockHash},
+		"latest":     {},
+	}
+	if len(first.Topics) > 0 {
+		queries["topic"] = Types.FilterQuery{FromBlock: all.FromBlock, ToBlock: all.ToBlock, Topics: [][]byte{first.Topics[0]}}
+	}
+	if len(first.Topics) > 1 {
+		queries["wildcard"] = Types.FilterQuery{FromBlock: all.FromBlock, ToBlock: all.ToBlock, Topics: [][]byte{nil, first.Topics[1]}}
+	}
+	for name, q := range queries {
+		var want []*Types.Log
+		for _, l := range c.logs {
+			// Without bounds a query covers the latest block
+			inRange := l.BlockNumber == c.head
+			switch {
+			case len(q.BlockHash) > 0:
+				inRange = bytes.Equal(l.BlockHash, q.BlockHash)
+			case q.FromBlock != nil:
+				inRange = l.BlockNumber >= q.FromBlock.Uint64() && l.BlockNumber <= q.ToBlock.Uint64()
+			}
+			if inRange && matches(&q, l) {
+				want = append(want, l)
+			}
+		}
+		got, err := be.GetLogs(ctx, q)
+		if err != nil {
+			t.Errorf("GetLogs(%s): %v", name, err)
+			continue
+		}
+		if len(got) != len(want) {
+			t.Errorf("GetLogs(%s) returned %d logs, want %d", name, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if got[i].BlockNumber != want[i].BlockNumber || got[i].LogIndex != want[i].LogIndex {
+				t.Errorf("GetLogs(%s)[%d] is log %d/%d, want %d/%d", name, i, got[i].BlockNumber, got[i].LogIndex, want[i].BlockNumber, want[i].LogIndex)
+			}
+		}
+	}
+}
+
+func testNetwork(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	if _, err := be.PeerCount(ctx); err != nil {
+		t.Errorf("PeerCount: %v", err)
+	}
+	if _, err := be.Listening(ctx); err != nil {
+		t.Errorf("Listening: %v", err)
+	}
+	if s, err := be.Syncing(ctx); err != nil || s == nil {
+		t.Errorf("Syncing: %v, %v", s, err)
+	}
+}
+
+func testMining(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	m := capable[Types.MiningBackend](t, be, Types.CapMining)
+	if _, err := m.Mining(ctx); err != nil {
+		t.Errorf("Mining: %v", err)
+	}
+	if _, err := m.Hashrate(ctx); err != nil {
+		t.Errorf("Hashrate: %v", err)
+	}
+}
+
+func testUncles(t *testing.T, be Types.Backend) {
+	ctx := context.Background()
+	u := capable[Types.UncleBackend](t, be, Types.CapUncles)
+	c := load(t, be)
+	for n, b := range c.blocks {
+		want := uint64(len(b.Ommers))
+		if count, err := u.UncleCountByBlockNumber(ctx, bigU(uint64(n))); err != nil || count != want {
+			t.Errorf("UncleCountByBlockNumber(%d) = %d, %v; want %d", n, count, err, want)
+		}
+		if count, err := u.UncleCountByBlockHash(ctx, b.Header.Hash); err != nil || count != want {
+			t.Errorf("UncleCountByBlockHash(0x%x) = %d, %v; want %d", b.Header.Hash, count, err, want)
+		}
+		if !missing(u.UncleByBlockNumberAndIndex(ctx, bigU(uint64(n)), want)) {
+			t.Errorf("UncleByBlockNumberAndIndex(%d) past the last uncle returned one", n)
+		}
+		if !missing(u.UncleByBlockHashAndIndex(ctx, b.Header.Hash, want)) {
+			t.Errorf("UncleByBlockHashAndIndex(0x%x) past the last uncle returned one", b.Header.Hash)
+		}
+	}
+}
+
+// receive waits for the next value on ch
+func receive[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatalf("%s: channel closed", what)
+		}
+		return v
+	case <-time.After(timeout):
+		t.Fatalf("%s: nothing delivered within %v", what, timeout)
+	}
+	var zero T
+	return zero
+}
+
+// closes waits for ch to be closed, discarding values still buffered
+func closes[T any](t *testing.T, ch <-chan T, what string) {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Errorf("%s: channel still open after %v", what, timeout)
+			return
+		}
+	}
+}
+
+func testSubscriptionDelivery(t *testing.T, be Types.Backend) {
+	sub := capable[Types.SubscriptionBackend](t, be, Types.CapSubscriptions)
+	ctl, ok := be.(Types.DevControl)
+	if !ok {
+		t.Skip("backend does not implement Types.DevControl")
+	}
+	ctx := context.Background()
+	c := load(t, be)
+
+	// Replay a transaction that logged, so the same emitter logs again
+	var emitter *Types.Transaction
+	for _, b := range c.blocks {
+		for _, tx := range b.Transactions {
+			if r := c.receipts[string(tx.Hash)]; len(r.Logs) > 0 && len(tx.To) > 0 && emitter == nil {
+				emitter = tx
+			}
+		}
+	}
+	if emitter == nil {
+		t.Fatal("seeded chain has no call to a contract that logs")
+	}
+
+	heads, stopHeads, err := sub.SubscribeNewHeads(ctx)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads: %v", err)
+	}
+	defer stopHeads()
+	logs, stopLogs, err := sub.SubscribeLogs(ctx, &Types.FilterQuery{Addresses: [][]byte{emitter.To}})
+	if err != nil {
+		t.Fatalf("SubscribeLogs: %v", err)
+	}
+	defer stopLogs()
+	pending, stopPending, err := sub.SubscribePendingTxs(ctx)
+	if err != nil {
+		t.Fatalf("SubscribePendingTxs: %v", err)
+	}
+	defer stopPending()
+
+	hash, err := ctl.SendTransaction(ctx, Types.CallMsg{From: hexAddr(emitter.From), To: hexAddr(emitter.To), Data: emitter.Input})
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	if got := receive(t, pending, "pending transactions"); !bytes.Equal(got, hash) {
+		t.Errorf("pending transaction 0x%x, want 0x%x", got, hash)
+	}
+	if err := ctl.Mine(ctx, 1, 0); err != nil {
+		t.Fatalf("Mine: %v", err)
+	}
+	r, err := be.ReceiptByHash(ctx, hash)
+	if err != nil || r == nil {
+		t.Fatalf("ReceiptByHash(0x%x) after mining: %v, %v", hash, r, err)
+	}
+	if head := receive(t, heads, "new heads"); head == nil || head.Header.Number != c.head+1 {
+		t.Errorf("first new head is not block %d", c.head+1)
+	}
+	if l := receive(t, logs, "logs"); l == nil || !bytes.Equal(l.Address, emitter.To) || !bytes.Equal(l.TxHash, hash) {
+		t.Errorf("delivered log %+v is not from 0x%x", l, hash)
+	}
+}
+
+func testReorg(t *testing.T, be Types.Backend) {
+	sub := capable[Types.SubscriptionBackend](t, be, Types.CapSubscriptions)
+	ctl, ok := be.(Types.DevControl)
+	if !ok {
+		t.Skip("backend does not implement Types.DevControl")
+	}
+	ctx := context.Background()
+	c := load(t, be)
+
+	// Fork just below the last block with logs and replay its last logging
+	// transaction at the start of the new branch
+	last := c.logs[len(c.logs)-1]
+	depth := c.head - last.BlockNumber + 1
+	fork := c.head - depth
+	var orphaned []*Types.Log
+	for _, l := range c.logs {
+		if l.BlockNumber > fork {
+			orphaned = append(orphaned, l)
+		}
+	}
+	var replay *Types.Transaction
+	for _, tx := range c.blocks[last.BlockNumber].Transactions {
+		if bytes.Equal(tx.Hash, last.TxHash) {
+			replay = tx
+		}
+	}
+
+	heads, stopHeads, err := sub.SubscribeNewHeads(ctx)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads: %v", err)
+	}
+	defer stopHeads()
+	logs, stopLogs, err := sub.SubscribeLogs(ctx, &Types.FilterQuery{})
+	if err != nil {
+		t.Fatalf("SubscribeLogs: %v", err)
+	}
+	defer stopLogs()
+
+	msg := Types.CallMsg{From: hexAddr(replay.From), To: hexAddr(replay.To), Data: replay.Input}
+	if err := ctl.Reorg(ctx, depth, []Types.ReorgTx{{Msg: &msg}}); err != nil {
+		t.Fatalf("Reorg(%d): %v", depth, err)
+	}
+
+	// Orphaned logs come first, flagged as removed
+	for _, want := range orphaned {
+		got := receive(t, logs, "removed logs")
+		if got == nil || !got.Removed || !bytes.Equal(got.BlockHash, want.BlockHash) || got.LogIndex != want.LogIndex {
+			t.Errorf("removed log %+v, want block 0x%x index %d removed", got, want.BlockHash, want.LogIndex)
+		}
+	}
+	// Then the new branch, block by block, at the same height
+	branch := map[uint64][]byte{}
+	for n := fork + 1; n <= c.head; n++ {
+		h := receive(t, heads, "new branch heads")
+		if h == nil || h.Header.Number != n {
+			t.Fatalf("new branch head %+v is not block %d", h, n)
+		}
+		if bytes.Equal(h.Header.Hash, c.blocks[n].Header.Hash) {
+			t.Errorf("block %d was not replaced", n)
+		}
+		branch[n] = h.Header.Hash
+	}
+	if l := receive(t, logs, "new branch logs"); l == nil || l.Removed || l.BlockNumber != fork+1 || !bytes.Equal(l.BlockHash, branch[fork+1]) {
+		t.Errorf("new branch log %+v is not in block %d", l, fork+1)
+	}
+
+	// Queries follow the new branch
+	if b, err := be.BlockByNumber(ctx, bigU(c.head), false); err != nil || b == nil || !bytes.Equal(b.Header.Hash, branch[c.head]) {
+		t.Errorf("BlockByNumber(%d) after reorg is not the new branch: %v", c.head, err)
+	}
+	if b, err := be.BlockByHash(ctx, c.blocks[c.head].Header.Hash, false); !missing(b, err) {
+		t.Errorf("orphaned block 0x%x is still served", c.blocks[c.head].Header.Hash)
+	}
+	if r, err := be.ReceiptByHash(ctx, last.TxHash); !missing(r, err) && !bytes.Equal(r.BlockHash, branch[r.BlockNumber]) {
+		t.Errorf("receipt of 0x%x is still in orphaned block 0x%x", last.TxHash, r.BlockHash)
+	}
+	got, err := be.GetLogs(ctx, Types.FilterQuery{FromBlock: bigU(fork + 1), ToBlock: bigU(c.head)})
+	if err != nil {
+		t.Fatalf("GetLogs after reorg: %v", err)
+	}
+	for _, l := range got {
+		if !bytes.Equal(l.BlockHash, branch[l.BlockNumber]) {
+			t.Errorf("GetLogs returned orphaned log in block 0x%x", l.BlockHash)
+		}
+	}
+}
+
+func testSubscriptionCleanup(t *testing.T, be Types.Backend) {
+	sub := capable[Types.SubscriptionBackend](t, be, Types.CapSubscriptions)
+	ctx := context.Background()
+	heads, stopHeads, err := sub.SubscribeNewHeads(ctx)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads: %v", err)
+	}
+	logs, stopLogs, err := sub.SubscribeLogs(ctx, &Types.FilterQuery{})
+	if err != nil {
+		t.Fatalf("SubscribeLogs: %v", err)
+	}
+	pending, stopPending, err := sub.SubscribePendingTxs(ctx)
+	if err != nil {
+		t.Fatalf("SubscribePendingTxs: %v", err)
+	}
+	for _, stop := range []func(){stopHeads, stopLogs, stopPending} {
+		stop()
+		// A second stop must be harmless
+		stop()
+	}
+	closes(t, heads, "new heads after stop")
+	closes(t, logs, "logs after stop")
+	closes(t, pending, "pending transactions after stop")
+}
+
+func testCancellation(t *testing.T, be Types.Backend) {
+	load(t, be)
+	ctx, cancel := context.WithCancel(context.Background())
+	if sub, ok := be.(Types.SubscriptionBackend); ok && Types.Supports(be, Types.CapSubscriptions) {
+		heads, stopHeads, err := sub.SubscribeNewHeads(ctx)
+		if err != nil {
+			t.Fatalf("SubscribeNewHeads: %v", err)
+		}
+		defer stopHeads()
+		logs, stopLogs, err := sub.SubscribeLogs(ctx, &Types.FilterQuery{})
+		if err != nil {
+			t.Fatalf("SubscribeLogs: %v", err)
+		}
+		defer stopLogs()
+		pending, stopPending, err := sub.SubscribePendingTxs(ctx)
+		if err != nil {
+			t.Fatalf("SubscribePendingTxs: %v", err)
+		}
+		defer stopPending()
+		cancel()
+		closes(t, heads, "new heads after cancel")
+		closes(t, logs, "logs after cancel")
+		closes(t, pending, "pending transactions after cancel")
+	}
+	cancel()
+
+	// Calls with a cancelled context must return, with or without an error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		be.BlockNumber(ctx)
+		be.BlockByNumber(ctx, nil, true)
+		be.GetLogs(ctx, Types.FilterQuery{FromBlock: big.NewInt(0)})
+		be.EstimateGas(ctx, Types.CallMsg{To: hexAddr(bytes.Repeat([]byte{0x11}, 20))})
+		be.Call(ctx, Types.CallMsg{To: hexAddr(bytes.Repeat([]byte{0x11}, 20))}, nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Error("calls with a cancelled context did not return")
+	}
+}
+
+// capable returns be as the optional interface T, skipping the test unless be
+// serves capability c
+func capable[T any](t *testing.T, be Types.Backend, c Types.Capability) T {
+	t.Helper()
+	impl, ok := be.(T)
+	if !ok || !Types.Supports(be, c) {
+		t.Skipf("backend does not support %s", c)
+	}
+	return impl
+}
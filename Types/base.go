@@ -0,0 +1,95 @@
+package Types
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+// ErrNotSupported is returned for methods a backend does not serve. Handlers
+// answer it with -32601, as for an unknown method.
+var ErrNotSupported = errors.New("method not supported by this backend")
+
+// BaseBackend implements every Backend method by returning ErrNotSupported.
+// Embed it and override the methods your chain serves:
+//
+//	type MyBackend struct {
+//		Types.BaseBackend
+//	}
+//
+//	func (b *MyBackend) ChainID(ctx context.Context) (*big.Int, error) { ... }
+//
+// Optional interfaces such as UncleBackend are not covered: a backend serves
+// them by implementing them.
+type BaseBackend struct{}
+
+var _ Backend = BaseBackend{}
+
+// Basic blockchain info
+func (BaseBackend) ChainID(ctx context.Context) (*big.Int, error)     { return nil, ErrNotSupported }
+func (BaseBackend) ClientVersion(ctx context.Context) (string, error) { return "", ErrNotSupported }
+func (BaseBackend) BlockNumber(ctx context.Context) (*big.Int, error) { return nil, ErrNotSupported }
+
+// Block operations
+func (BaseBackend) BlockByNumber(ctx context.Context, num *big.Int, fullTx bool) (*Block, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) BlockByHash(ctx context.Context, hash []byte, fullTx bool) (*Block, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) BlockTransactionCountByNumber(ctx context.Context, blockNum *big.Int) (uint64, error) {
+	return 0, ErrNotSupported
+}
+func (BaseBackend) BlockTransactionCountByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+// Account operations
+func (BaseBackend) Balance(ctx context.Context, addr []byte, block *big.Int) (*big.Int, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) GetCode(ctx context.Context, addr []byte, block *big.Int) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) GetStorageAt(ctx context.Context, addr []byte, key []byte, block *big.Int) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) GetTransactionCount(ctx context.Context, addr []byte, block *big.Int) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+// Transaction operations
+func (BaseBackend) Call(ctx context.Context, msg CallMsg, block *big.Int) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	return 0, ErrNotSupported
+}
+func (BaseBackend) GasPrice(ctx context.Context) (*big.Int, error) { return nil, ErrNotSupported }
+func (BaseBackend) SendRawTx(ctx context.Context, rawHex string) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) TxByHash(ctx context.Context, hash []byte) (*Transaction, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) TxByBlockNumberAndIndex(ctx context.Context, blockNum *big.Int, index uint64) (*Transaction, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) TxByBlockHashAndIndex(ctx context.Context, blockHash []byte, index uint64) (*Transaction, error) {
+	return nil, ErrNotSupported
+}
+func (BaseBackend) ReceiptByHash(ctx context.Context, hash []byte) (*Receipt, error) {
+	return nil, ErrNotSupported
+}
+
+// Log operations
+func (BaseBackend) GetLogs(ctx context.Context, q FilterQuery) ([]*Log, error) {
+	return nil, ErrNotSupported
+}
+
+// Network operations
+func (BaseBackend) PeerCount(ctx context.Context) (uint64, error) { return 0, ErrNotSupported }
+func (BaseBackend) Listening(ctx context.Context) (bool, error)   { return false, ErrNotSupported }
+func (BaseBackend) Syncing(ctx context.Context) (map[string]any, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,480 @@
+package Types
+
+import "fmt"
+
+// BlockV2 is Block with typed hashes and addresses. Quantities, bloom and
+// extra data stay as in version 1.
+type BlockV2 struct {
+	Header          *BlockHeaderV2
+	Transactions    []*TransactionV2
+	Ommers          []Hash
+	WithdrawalsRoot *Hash // nil before Shanghai
+	Withdrawals     []*WithdrawalV2
+	BlobGasUsed     []byte
+	ExcessBlobGas   []byte
+}
+
+// BlockHeaderV2 is BlockHeader with typed hashes and miner
+type BlockHeaderV2 struct {
+	ParentHash          Hash
+	StateRoot           Hash
+	ReceiptsRoot        Hash
+	LogsBloom           []byte
+	Miner               Address
+	Number              uint64
+	GasLimit            uint64
+	GasUsed             uint64
+	Timestamp           uint64
+	MixHashOrPrevRandao Hash
+	BaseFee             []byte
+	BlobGasUsedField    uint64
+	ExcessBlobGasField  uint64
+	ExtraData           []byte
+	Hash                Hash
+}
+
+// TransactionV2 is Transaction with typed hashes and addresses. A nil To
+// creates a contract.
+type TransactionV2 struct {
+	Hash                 Hash
+	From                 Address
+	To                   *Address
+	Input                []byte
+	Nonce                uint64
+	Value                []byte
+	Gas                  uint64
+	GasPrice             []byte
+	Type                 uint32
+	R                    []byte
+	S                    []byte
+	V                    uint32
+	AccessList           []AccessTupleV2
+	MaxFeePerGas         []byte
+	MaxPriorityFeePerGas []byte
+	MaxFeePerBlobGas     []byte
+	BlobVersionedHashes  []Hash
+}
+
+// AccessTupleV2 is AccessTuple with a typed address and storage keys
+type AccessTupleV2 struct {
+	Address     Address
+	StorageKeys []Hash
+}
+
+// WithdrawalV2 is Withdrawal with a typed address
+type WithdrawalV2 struct {
+	Index          uint64
+	ValidatorIndex uint64
+	Address        Address
+	Amount         uint64
+}
+
+// ReceiptV2 is Receipt with typed hashes and addresses. ContractAddress is
+// nil unless the transaction created a contract.
+type ReceiptV2 struct {
+	TxHash            Hash
+	Status            uint64
+	CumulativeGasUsed uint64
+	GasUsed           uint64
+	Logs              []*LogV2
+	ContractAddress   *Address
+	Type              uint32
+	BlockHash         Hash
+	BlockNumber       uint64
+	TransactionIndex  uint64
+}
+
+// LogV2 is Log with typed address, topics and hashes
+type LogV2 struct {
+	Address     Address
+	Topics      []Hash
+	Data        []byte
+	BlockNumber uint64
+	BlockHash   Hash
+	TxIndex     uint64
+	TxHash      Hash
+	LogIndex    uint64
+	Removed     bool
+}
+
+// hashField converts a version 1 hash, which may be left empty
+func hashField(b []byte, what string) (Hash, error) {
+	if len(b) == 0 {
+		return Hash{}, nil
+	}
+	h, err := HashFromBytes(b)
+	if err != nil {
+		return Hash{}, fmt.Errorf("%s: %w", what, err)
+	}
+	return h, nil
+}
+
+// addressField converts a version 1 address, which may be left empty
+func addressField(b []byte, what string) (Address, error) {
+	if len(b) == 0 {
+		return Address{}, nil
+	}
+	a, err := AddressFromBytes(b)
+	if err != nil {
+		return Address{}, fmt.Errorf("%s: %w", what, err)
+	}
+	return a, nil
+}
+
+func hashList(bs [][]byte, what string) ([]Hash, error) {
+	if bs == nil {
+		return nil, nil
+	}
+	out := make([]Hash, len(bs))
+	for i, b := range bs {
+		h, err := HashFromBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", what, i, err)
+		}
+		out[i] = h
+	}
+	return out, nil
+}
+
+func hashBytes(hs []Hash) [][]byte {
+	if hs == nil {
+		return nil
+	}
+	out := make([][]byte, len(hs))
+	for i, h := range hs {
+		out[i] = h.Bytes()
+	}
+	return out
+}
+
+// BlockToV2 checks the lengths of a version 1 block's hashes and addresses.
+// A nil block converts to nil.
+func BlockToV2(b *Block) (*BlockV2, error) {
+	if b == nil {
+		return nil, nil
+	}
+	out := &BlockV2{BlobGasUsed: b.BlobGasUsed, ExcessBlobGas: b.ExcessBlobGas}
+	var err error
+	if out.Header, err = headerToV2(b.Header); err != nil {
+		return nil, err
+	}
+	for _, tx := range b.Transactions {
+		t, err := TransactionToV2(tx)
+		if err != nil {
+			return nil, err
+		}
+		out.Transactions = append(out.Transactions, t)
+	}
+	if out.Ommers, err = hashList(b.Ommers, "ommer"); err != nil {
+		return nil, err
+	}
+	if len(b.WithdrawalsRoot) > 0 {
+		root, err := HashFromBytes(b.WithdrawalsRoot)
+		if err != nil {
+			return nil, fmt.Errorf("withdrawals root: %w", err)
+		}
+		out.WithdrawalsRoot = &root
+	}
+	for _, w := range b.Withdrawals {
+		if w == nil {
+			continue
+		}
+		a, err := AddressFromBytes(w.Address)
+		if err != nil {
+			return nil, fmt.Errorf("withdrawal %d: %w", w.Index, err)
+		}
+		out.Withdrawals = append(out.Withdrawals, &WithdrawalV2{Index: w.Index, ValidatorIndex: w.ValidatorIndex, Address: a, Amount: w.Amount})
+	}
+	return out, nil
+}
+
+func headerToV2(h *BlockHeader) (*BlockHeaderV2, error) {
+	if h == nil {
+		return nil, nil
+	}
+	out := &BlockHeaderV2{
+		LogsBloom:          h.LogsBloom,
+		Number:             h.Number,
+		GasLimit:           h.GasLimit,
+		GasUsed:            h.GasUsed,
+		Timestamp:          h.Timestamp,
+		BaseFee:            h.BaseFee,
+		BlobGasUsedField:   h.BlobGasUsedField,
+		ExcessBlobGasField: h.ExcessBlobGasField,
+		ExtraData:          h.ExtraData,
+	}
+	for _, f := range []struct {
+		dst  *Hash
+		src  []byte
+		what string
+	}{
+		{&out.ParentHash, h.ParentHash, "parent hash"},
+		{&out.StateRoot, h.StateRoot, "state root"},
+		{&out.ReceiptsRoot, h.ReceiptsRoot, "receipts root"},
+		{&out.MixHashOrPrevRandao, h.MixHashOrPrevRandao, "mix hash"},
+		{&out.Hash, h.Hash, "block hash"},
+	} {
+		v, err := hashField(f.src, f.what)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = v
+	}
+	var err error
+	if out.Miner, err = addressField(h.Miner, "miner"); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// V1 returns b as a version 1 block
+func (b *BlockV2) V1() *Block {
+	if b == nil {
+		return nil
+	}
+	out := &Block{Ommers: hashBytes(b.Ommers), BlobGasUsed: b.BlobGasUsed, ExcessBlobGas: b.ExcessBlobGas}
+	if h := b.Header; h != nil {
+		out.Header = &BlockHeader{
+			ParentHash:          h.ParentHash.Bytes(),
+			StateRoot:           h.StateRoot.Bytes(),
+			ReceiptsRoot:        h.ReceiptsRoot.Bytes(),
+			LogsBloom:           h.LogsBloom,
+			Miner:               h.Miner.Bytes(),
+			Number:              h.Number,
+			GasLimit:            h.GasLimit,
+			GasUsed:             h.GasUsed,
+			Timestamp:           h.Timestamp,
+			MixHashOrPrevRandao: h.MixHashOrPrevRandao.Bytes(),
+			BaseFee:             h.BaseFee,
+			BlobGasUsedField:    h.BlobGasUsedField,
+			ExcessBlobGasField:  h.ExcessBlobGasField,
+			ExtraData:           h.ExtraData,
+			Hash:                h.Hash.Bytes(),
+		}
+	}
+	for _, tx := range b.Transactions {
+		out.Transactions = append(out.Transactions, tx.V1())
+	}
+	if b.WithdrawalsRoot != nil {
+		out.WithdrawalsRoot = b.WithdrawalsRoot.Bytes()
+	}
+	for _, w := range b.Withdrawals {
+		out.Withdrawals = append(out.Withdrawals, &Withdrawal{Index: w.Index, ValidatorIndex: w.ValidatorIndex, Address: w.Address.Bytes(), Amount: w.Amount})
+	}
+	return out
+}
+
+// TransactionToV2 checks the lengths of a version 1 transaction's hashes and
+// addresses. A nil transaction converts to nil.
+func TransactionToV2(tx *Transaction) (*TransactionV2, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	out := &TransactionV2{
+		Input:                tx.Input,
+		Nonce:                tx.Nonce,
+		Value:                tx.Value,
+		Gas:                  tx.Gas,
+		GasPrice:             tx.GasPrice,
+		Type:                 tx.Type,
+		R:                    tx.R,
+		S:                    tx.S,
+		V:                    tx.V,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		MaxFeePerBlobGas:     tx.MaxFeePerBlobGas,
+	}
+	var err error
+	if out.Hash, err = hashField(tx.Hash, "transaction hash"); err != nil {
+		return nil, err
+	}
+	if out.From, err = addressField(tx.From, "from"); err != nil {
+		return nil, err
+	}
+	if len(tx.To) > 0 {
+		to, err := AddressFromBytes(tx.To)
+		if err != nil {
+			return nil, fmt.Errorf("to: %w", err)
+		}
+		out.To = &to
+	}
+	if tx.AccessList != nil {
+		for _, at := range tx.AccessList.AccessTuples {
+			if at == nil {
+				continue
+			}
+			a, err := AddressFromBytes(at.Address)
+			if err != nil {
+				return nil, fmt.Errorf("access list: %w", err)
+			}
+			keys, err := hashList(at.StorageKeys, "access list storage key")
+			if err != nil {
+				return nil, err
+			}
+			out.AccessList = append(out.AccessList, AccessTupleV2{Address: a, StorageKeys: keys})
+		}
+	}
+	if out.BlobVersionedHashes, err = hashList(tx.BlobVersionedHashes, "blob versioned hash"); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// V1 returns tx as a version 1 transaction
+func (tx *TransactionV2) V1() *Transaction {
+	if tx == nil {
+		return nil
+	}
+	out := &Transaction{
+		Hash:                 tx.Hash.Bytes(),
+		From:                 tx.From.Bytes(),
+		Input:                tx.Input,
+		Nonce:                tx.Nonce,
+		Value:                tx.Value,
+		Gas:                  tx.Gas,
+		GasPrice:             tx.GasPrice,
+		Type:                 tx.Type,
+		R:                    tx.R,
+		S:                    tx.S,
+		V:                    tx.V,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		MaxFeePerBlobGas:     tx.MaxFeePerBlobGas,
+		BlobVersionedHashes:  hashBytes(tx.BlobVersionedHashes),
+	}
+	if tx.To != nil {
+		out.To = tx.To.Bytes()
+	}
+	if tx.AccessList != nil {
+		out.AccessList = &AccessList{}
+		for _, at := range tx.AccessList {
+			out.AccessList.AccessTuples = append(out.AccessList.AccessTuples, &AccessTuple{Address: at.Address.Bytes(), StorageKeys: hashBytes(at.StorageKeys)})
+		}
+	}
+	return out
+}
+
+// ReceiptToV2 checks the lengths of a version 1 receipt's hashes and
+// addresses, its logs' included. A nil receipt converts to nil.
+func ReceiptToV2(r *Receipt) (*ReceiptV2, error) {
+	if r == nil {
+		return nil, nil
+	}
+	out := &ReceiptV2{
+		Status:            r.Status,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		GasUsed:           r.GasUsed,
+		Type:              r.Type,
+		BlockNumber:       r.BlockNumber,
+		TransactionIndex:  r.TransactionIndex,
+	}
+	var err error
+	if out.TxHash, err = hashField(r.TxHash, "transaction hash"); err != nil {
+		return nil, err
+	}
+	if out.BlockHash, err = hashField(r.BlockHash, "block hash"); err != nil {
+		return nil, err
+	}
+	if len(r.ContractAddress) > 0 {
+		a, err := AddressFromBytes(r.ContractAddress)
+		if err != nil {
+			return nil, fmt.Errorf("contract address: %w", err)
+		}
+		out.ContractAddress = &a
+	}
+	if out.Logs, err = LogsToV2(r.Logs); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// V1 returns r as a version 1 receipt
+func (r *ReceiptV2) V1() *Receipt {
+	if r == nil {
+		return nil
+	}
+	out := &Receipt{
+		TxHash:            r.TxHash.Bytes(),
+		Status:            r.Status,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		GasUsed:           r.GasUsed,
+		Logs:              LogsV1(r.Logs),
+		Type:              r.Type,
+		BlockHash:         r.BlockHash.Bytes(),
+		BlockNumber:       r.BlockNumber,
+		TransactionIndex:  r.TransactionIndex,
+	}
+	if r.ContractAddress != nil {
+		out.ContractAddress = r.ContractAddress.Bytes()
+	}
+	return out
+}
+
+// LogToV2 checks the lengths of a version 1 log's address, topics and
+// hashes. A nil log converts to nil.
+func LogToV2(l *Log) (*LogV2, error) {
+	if l == nil {
+		return nil, nil
+	}
+	out := &LogV2{Data: l.Data, BlockNumber: l.BlockNumber, TxIndex: l.TxIndex, LogIndex: l.LogIndex, Removed: l.Removed}
+	var err error
+	if out.Address, err = AddressFromBytes(l.Address); err != nil {
+		return nil, fmt.Errorf("log address: %w", err)
+	}
+	if out.Topics, err = hashList(l.Topics, "topic"); err != nil {
+		return nil, err
+	}
+	if out.BlockHash, err = hashField(l.BlockHash, "block hash"); err != nil {
+		return nil, err
+	}
+	if out.TxHash, err = hashField(l.TxHash, "transaction hash"); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogsToV2 converts logs with LogToV2
+func LogsToV2(logs []*Log) ([]*LogV2, error) {
+	if logs == nil {
+		return nil, nil
+	}
+	out := make([]*LogV2, 0, len(logs))
+	for _, l := range logs {
+		lv2, err := LogToV2(l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, lv2)
+	}
+	return out, nil
+}
+
+// V1 returns l as a version 1 log
+func (l *LogV2) V1() *Log {
+	if l == nil {
+		return nil
+	}
+	return &Log{
+		Address:     l.Address.Bytes(),
+		Topics:      hashBytes(l.Topics),
+		Data:        l.Data,
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash.Bytes(),
+		TxIndex:     l.TxIndex,
+		TxHash:      l.TxHash.Bytes(),
+		LogIndex:    l.LogIndex,
+		Removed:     l.Removed,
+	}
+}
+
+// LogsV1 returns logs as version 1 logs
+func LogsV1(logs []*LogV2) []*Log {
+	if logs == nil {
+		return nil
+	}
+	out := make([]*Log, len(logs))
+	for i, l := range logs {
+		out[i] = l.V1()
+	}
+	return out
+}
@@ -15,8 +15,14 @@ type Response struct {
 type Error struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Data carries extra error detail, such as hex revert data for code 3
+	Data any `json:"data,omitempty"`
 }
 
+// Error implements the error interface so backends can return JSON-RPC
+// errors whose code is preserved in the response.
+func (e *Error) Error() string { return e.Message }
+
 func RespOK(id any, v any) Response  { return Response{Jsonrpc: "2.0", Result: v, ID: id} }
 func RespErr(id any, code int, msg string) Response {
 	return Response{Jsonrpc: "2.0", Error: &Error{Code: code, Message: msg}, ID: id}
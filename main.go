@@ -4,9 +4,12 @@ import (
 	"flag"
 	"log"
 	"math/big"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/jupitermetalabs/geth-facade/Services"
+	"github.com/jupitermetalabs/geth-facade/Types"
 )
 
 func main() {
@@ -14,8 +17,31 @@ func main() {
 	chainIDFlag := flag.String("chainid", "11155111", "Chain ID in hex (e.g. 0xaa36a7) or decimal (e.g. 11155111)")
 	httpAddrFlag := flag.String("http", ":8545", "HTTP listen address (e.g. :8545 or 0.0.0.0:8545)")
 	wsAddrFlag := flag.String("ws", ":8546", "WebSocket listen address (e.g. :8546 or 0.0.0.0:8546)")
+	upstreamFlag := flag.String("upstream", "", "Comma-separated upstream JSON-RPC HTTP URLs; when set, requests are proxied instead of served from memory")
+	upstreamWSFlag := flag.String("upstream-ws", "", "Comma-separated upstream WebSocket URLs for subscriptions, matching -upstream by position")
+	fixtureFlag := flag.String("fixture", "", "Serve a fixed chain from a JSON or YAML fixture file instead of the mock memory backend")
+	storeFlag := flag.String("store", "", "Serve the chain persisted in this store file instead of the mock memory backend; with -upstream, mirror the upstream into it")
+	indexFromFlag := flag.Uint64("index-from", 0, "Block to start mirroring from when -store and -upstream are both set")
+	blockTimeFlag := flag.Duration("block-time", 6*time.Second, "Memory dev chain block interval; 0 mines a block for every transaction (automine)")
+	retriesFlag := flag.Int("retries", 0, "Retry transient upstream failures this many times with backoff, behind per-method circuit breakers; 0 disables")
+	hedgeFlag := flag.Float64("hedge", 0, "Send a second upstream read once a call is slower than this latency percentile (e.g. 0.95); 0 disables")
+	submitFlag := flag.String("submit", "", "JSON-RPC HTTP URL that receives eth_sendRawTransaction instead of the read backend (e.g. a private relay)")
+	archiveFlag := flag.String("archive", "", "Archive node JSON-RPC HTTP URL for state queries at least -archive-depth blocks old")
+	archiveDepthFlag := flag.Uint64("archive-depth", 128, "How many blocks behind the head a state query must be to go to -archive")
+	routesFlag := flag.String("routes", "", "JSON or YAML routing rules file replacing the -submit and -archive defaults; targets are read, submit and archive")
+	shadowFlag := flag.String("shadow", "", "Mirror a sample of read traffic to this JSON-RPC HTTP URL and report differing answers")
+	shadowSampleFlag := flag.Float64("shadow-sample", 1, "Fraction of eligible requests mirrored to -shadow")
+	shadowOutFlag := flag.String("shadow-out", "", "Append -shadow mismatches to this JSONL file instead of logging them")
+	chaosFlag := flag.String("chaos", "", "Inject faults described by this JSON or YAML rules file")
+	chaosAdminFlag := flag.Bool("chaos-admin", false, "Let clients toggle and edit the -chaos rules at runtime with the chaos_* methods; only for endpoints you do not share")
+	chainsFlag := flag.String("chains", "", "Serve several chains from a JSON or YAML chains file at /chain/<id> and /<name>, with chain metadata at /chains and metrics at /metrics")
 	flag.Parse()
 
+	if *chainsFlag != "" {
+		serveChains(*chainsFlag, *httpAddrFlag, *wsAddrFlag, *blockTimeFlag)
+		return
+	}
+
 	// Parse chain id
 	var chainID = new(big.Int)
 	if strings.HasPrefix(*chainIDFlag, "0x") || strings.HasPrefix(*chainIDFlag, "0X") {
@@ -24,11 +50,124 @@ func main() {
 		chainID.SetString(*chainIDFlag, 10)
 	}
 
-	// Create server configuration with memory backend (for testing/development)
+	// Use the memory dev chain (for testing/development) unless a fixture or upstream is given
+	var backend Types.Backend
+	if *fixtureFlag == "" && *upstreamFlag == "" && *storeFlag == "" {
+		backend = Services.NewMemoryBackendWithConfig(Services.MemoryConfig{ChainID: chainID, BlockTime: *blockTimeFlag})
+		if *blockTimeFlag == 0 {
+			log.Printf("Memory dev chain automining every transaction")
+		} else {
+			log.Printf("Memory dev chain mining every %s", *blockTimeFlag)
+		}
+	}
+	if *fixtureFlag != "" {
+		if *storeFlag != "" || *upstreamFlag != "" {
+			log.Fatal("Fixture error: -fixture cannot be combined with -store or -upstream")
+		}
+		fb, err := Services.LoadFixtureBackend(*fixtureFlag)
+		if err != nil {
+			log.Fatal("Fixture error: ", err)
+		}
+		backend = fb
+		log.Printf("Serving chain fixture %s", *fixtureFlag)
+	}
+	var store *Services.StoreBackend
+	if *storeFlag != "" {
+		sb, err := Services.OpenStoreBackend(Services.StoreConfig{Path: *storeFlag, ChainID: chainID})
+		if err != nil {
+			log.Fatal("Store error: ", err)
+		}
+		defer sb.Close()
+		store, backend = sb, sb
+		log.Printf("Serving chain store %s", *storeFlag)
+	}
+	if *upstreamFlag != "" {
+		httpURLs := strings.Split(*upstreamFlag, ",")
+		wsURLs := strings.Split(*upstreamWSFlag, ",")
+		var upstreams []Services.Upstream
+		for i, u := range httpURLs {
+			cfg := Services.ProxyConfig{HTTPURL: strings.TrimSpace(u)}
+			if i < len(wsURLs) {
+				cfg.WSURL = strings.TrimSpace(wsURLs[i])
+			}
+			var be Types.Backend = Services.NewProxyBackend(cfg)
+			if *retriesFlag > 0 || *hedgeFlag > 0 {
+				be = Services.NewResilientBackend(be, Services.ResilienceConfig{MaxAttempts: *retriesFlag + 1, HedgePercentile: *hedgeFlag})
+			}
+			upstreams = append(upstreams, Services.Upstream{Name: cfg.HTTPURL, Backend: be, Priority: i})
+		}
+		if len(upstreams) == 1 {
+			backend = upstreams[0].Backend
+		} else {
+			backend = Services.NewFailoverBackend(Services.FailoverConfig{Upstreams: upstreams, Strategy: Services.StrategyPriority})
+		}
+		log.Printf("Proxying to upstream(s) %s", *upstreamFlag)
+		if store != nil {
+			ix := Services.NewIndexer(Services.IndexerConfig{Upstream: backend, Store: store, StartBlock: *indexFromFlag})
+			defer ix.Close()
+			backend = ix
+			log.Printf("Mirroring upstream into %s from block %d", *storeFlag, *indexFromFlag)
+		}
+	}
+
+	if *submitFlag != "" || *archiveFlag != "" || *routesFlag != "" {
+		cfg := Services.RouterConfig{Backends: map[string]Types.Backend{Services.RouteRead: backend}}
+		if *submitFlag != "" {
+			cfg.Backends[Services.RouteSubmit] = Services.NewProxyBackend(Services.ProxyConfig{HTTPURL: *submitFlag})
+			cfg.Rules = append(cfg.Rules, Services.SubmitRoutes()...)
+		}
+		if *archiveFlag != "" {
+			cfg.Backends[Services.RouteArchive] = Services.NewProxyBackend(Services.ProxyConfig{HTTPURL: *archiveFlag})
+			cfg.Rules = append(cfg.Rules, Services.ArchiveRoutes(*archiveDepthFlag)...)
+		}
+		if *routesFlag != "" {
+			rules, err := Services.LoadRouteRules(*routesFlag)
+			if err != nil {
+				log.Fatal("Routes error: ", err)
+			}
+			cfg.Rules = rules
+		}
+		router, err := Services.NewRouterBackend(cfg)
+		if err != nil {
+			log.Fatal("Routes error: ", err)
+		}
+		backend = router
+		log.Printf("Routing with %d rules", len(cfg.Rules))
+	}
+	if *chaosFlag != "" {
+		rules, err := Services.LoadChaosRules(*chaosFlag)
+		if err != nil {
+			log.Fatal("Chaos error: ", err)
+		}
+		backend = Services.NewChaosBackend(backend, Services.ChaosConfig{Rules: rules, Enabled: true})
+		log.Printf("Injecting faults from %s (%d rules)", *chaosFlag, len(rules))
+	} else if *chaosAdminFlag {
+		log.Fatal("Chaos error: -chaos-admin needs -chaos")
+	}
+
+	// Create server configuration
 	config := Services.Config{
-		Backend:  Services.NewMemoryBackend(chainID),
-		HTTPAddr: *httpAddrFlag,
-		WSAddr:   *wsAddrFlag,
+		Backend:    backend,
+		HTTPAddr:   *httpAddrFlag,
+		WSAddr:     *wsAddrFlag,
+		ChaosAdmin: *chaosAdminFlag,
+	}
+	if *shadowFlag != "" {
+		shadowCfg := Services.ShadowConfig{
+			Backend:    Services.NewProxyBackend(Services.ProxyConfig{HTTPURL: *shadowFlag}),
+			SampleRate: *shadowSampleFlag,
+		}
+		if *shadowOutFlag != "" {
+			f, err := os.OpenFile(*shadowOutFlag, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				log.Fatal("Shadow error: ", err)
+			}
+			defer f.Close()
+			shadowCfg.Output = f
+		}
+		config.Shadow = Services.NewShadow(shadowCfg)
+		defer config.Shadow.Close()
+		log.Printf("Mirroring %.0f%% of reads to %s", *shadowSampleFlag*100, *shadowFlag)
 	}
 
 	// Create and start server
@@ -43,3 +182,42 @@ func main() {
 		log.Fatal("Server error:", err)
 	}
 }
+
+// serveChains runs a multi-chain server for the chains listed in path. Chains
+// without an upstream get their own memory dev chain.
+func serveChains(path, httpAddr, wsAddr string, blockTime time.Duration) {
+	specs, err := Services.LoadChainSpecs(path)
+	if err != nil {
+		log.Fatal("Chains error: ", err)
+	}
+	var chains []Services.ChainConfig
+	for _, spec := range specs {
+		c := Services.ChainConfig{Name: spec.Name, Limits: spec.ChainLimits}
+		if spec.ChainID != 0 {
+			c.ChainID = new(big.Int).SetUint64(spec.ChainID)
+		}
+		if spec.Upstream != "" {
+			c.Backend = Services.NewProxyBackend(Services.ProxyConfig{HTTPURL: spec.Upstream, WSURL: spec.UpstreamWS})
+			log.Printf("Chain %s proxying to %s", spec.Name, spec.Upstream)
+		} else {
+			if c.ChainID == nil {
+				log.Fatalf("Chains error: chain %q needs a chainId or an upstream", spec.Name)
+			}
+			c.Backend = Services.NewMemoryBackendWithConfig(Services.MemoryConfig{ChainID: c.ChainID, BlockTime: blockTime})
+			log.Printf("Chain %s served from a memory dev chain", spec.Name)
+		}
+		chains = append(chains, c)
+	}
+
+	server, err := Services.NewMultiChainServer(Services.MultiChainConfig{Chains: chains, HTTPAddr: httpAddr, WSAddr: wsAddr})
+	if err != nil {
+		log.Fatal("Chains error: ", err)
+	}
+	log.Printf("Starting JMDT Geth Facade server for %d chains...", len(chains))
+	for _, c := range server.Chains() {
+		log.Printf("Chain %s (%s) at %s", c.Name, c.ChainID, strings.Join(c.Paths, ", "))
+	}
+	if err := server.Start(); err != nil {
+		log.Fatal("Server error:", err)
+	}
+}